@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"online-trail/pkg/game"
+)
+
+// How bots play: the chance on a turn of stopping at a fort that is
+// available and of hunting when food isn't short yet, the bullets a hunt
+// needs and the food below which a bot always hunts, and the chance of
+// saying something in chat.
+const (
+	botFortChance  = 0.25
+	botHuntChance  = 0.3
+	botHuntBullets = 50
+	botHuntFood    = 100
+	botChatChance  = 0.05
+)
+
+var botChatter = []string{
+	"Howdy from the trail!",
+	"Anyone else low on food?",
+	"The oxen are holding up.",
+	"Watch out for the river crossings.",
+	"Good luck out there, pioneers.",
+}
+
+// botStats counts what every bot in the run did.
+type botStats struct {
+	actions    atomic.Int64
+	broadcasts atomic.Int64
+	reconnects atomic.Int64
+}
+
+// botConfig is how a run of bots connects and how fast each one plays.
+type botConfig struct {
+	wsURL string
+	room  string
+	name  string
+	count int
+	pace  time.Duration
+
+	// retries caps each bot's reconnect attempts (0 = keep trying)
+	retries int
+}
+
+// runBots connects cfg.count bots to the server and plays until ctx is
+// done, then prints what they did.
+func runBots(ctx context.Context, cfg botConfig) {
+	stats := &botStats{}
+	runID := fmt.Sprintf("%04x", rand.Intn(0x10000))
+
+	var wg sync.WaitGroup
+	for i := 1; i <= cfg.count; i++ {
+		b := &bot{
+			cfg:   cfg,
+			stats: stats,
+			name:  fmt.Sprintf("%s-%s-%d", cfg.name, runID, i),
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.run(ctx)
+		}()
+		// Stagger the joins so the server isn't handed every handshake at once
+		select {
+		case <-ctx.Done():
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	wg.Wait()
+
+	fmt.Printf("\nBots: %d\n", cfg.count)
+	fmt.Printf("Actions sent: %d\n", stats.actions.Load())
+	fmt.Printf("Broadcasts received: %d\n", stats.broadcasts.Load())
+	fmt.Printf("Reconnects: %d\n", stats.reconnects.Load())
+}
+
+// botWagon is the part of a player's state a bot plays from.
+type botWagon struct {
+	TurnPhase     game.TurnPhase `json:"turn_phase"`
+	HuntWord      string         `json:"hunt_word"`
+	Food          float64        `json:"food"`
+	Bullets       float64        `json:"bullets"`
+	Cash          float64        `json:"cash"`
+	FortAvailable bool           `json:"fort_available"`
+	Alive         bool           `json:"alive"`
+	GameOver      bool           `json:"game_over"`
+}
+
+// bot is one automatic player with its own websocket connection. It keeps
+// its session cookie so a dropped connection resumes the same wagon.
+type bot struct {
+	cfg    botConfig
+	stats  *botStats
+	name   string
+	cookie string
+
+	mu     sync.Mutex
+	id     string
+	wagon  *botWagon
+	traded bool
+}
+
+// run keeps the bot connected and playing until ctx is done, backing off
+// when the server can't be reached.
+func (b *bot) run(ctx context.Context) {
+	status := func(format string, args ...interface{}) {
+		log.Printf("%s: "+format, append([]interface{}{b.name}, args...)...)
+	}
+	var conn *websocket.Conn
+	dial := func() (err error) {
+		conn, err = b.dial(ctx)
+		return err
+	}
+
+	if err := dial(); err != nil {
+		status("%v", err)
+		if err := redial(ctx, b.cfg.retries, status, dial); err != nil {
+			status("%v", err)
+			return
+		}
+	}
+	for {
+		b.play(ctx, conn)
+		if ctx.Err() != nil {
+			return
+		}
+		if err := redial(ctx, b.cfg.retries, status, dial); err != nil {
+			if ctx.Err() == nil {
+				status("%v", err)
+			}
+			return
+		}
+		b.stats.reconnects.Add(1)
+	}
+}
+
+// dial opens the bot's connection, resuming its session if it has one.
+func (b *bot) dial(ctx context.Context) (*websocket.Conn, error) {
+	u, err := url.Parse(b.cfg.wsURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("name", b.name)
+	if b.cfg.room != "" {
+		q.Set("room", b.cfg.room)
+	}
+	u.RawQuery = q.Encode()
+
+	header := http.Header{}
+	if b.cookie != "" {
+		header.Set("Cookie", "session_id="+b.cookie)
+	}
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range resp.Cookies() {
+		if c.Name == "session_id" {
+			b.cookie = c.Value
+		}
+	}
+	return conn, nil
+}
+
+// play reads the server's broadcasts and takes a move every pace until the
+// connection drops or ctx is done.
+func (b *bot) play(ctx context.Context, conn *websocket.Conn) {
+	defer conn.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.read(conn)
+	}()
+
+	for {
+		jitter := time.Duration(rand.Int63n(int64(b.cfg.pace)/2 + 1))
+		select {
+		case <-ctx.Done():
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		case <-done:
+			return
+		case <-time.After(b.cfg.pace + jitter):
+		}
+		if err := b.move(conn); err != nil {
+			return
+		}
+	}
+}
+
+// read keeps the bot's view of its wagon current.
+func (b *bot) read(conn *websocket.Conn) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		b.stats.broadcasts.Add(1)
+
+		var msg struct {
+			Type     string          `json:"type"`
+			ClientID string          `json:"client_id"`
+			Data     json.RawMessage `json:"data"`
+		}
+		if json.Unmarshal(data, &msg) != nil {
+			continue
+		}
+		switch msg.Type {
+		case "your_id":
+			b.mu.Lock()
+			b.id = msg.ClientID
+			b.mu.Unlock()
+		case "state":
+			var state struct {
+				PlayerStates map[string]botWagon `json:"player_states"`
+			}
+			if json.Unmarshal(msg.Data, &state) != nil {
+				continue
+			}
+			b.mu.Lock()
+			if w, ok := state.PlayerStates[b.id]; ok {
+				b.wagon = &w
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+// move plays the bot's wagon one step: whatever its phase asks for, and
+// otherwise mostly carrying on down the trail. Each state is played from
+// once, so a move isn't repeated before the server has answered it.
+func (b *bot) move(conn *websocket.Conn) error {
+	b.mu.Lock()
+	w := b.wagon
+	b.wagon = nil
+	b.mu.Unlock()
+	if w == nil {
+		return nil
+	}
+
+	if rand.Float64() < botChatChance {
+		if err := b.send(conn, map[string]interface{}{
+			"type":    "chat",
+			"message": botChatter[rand.Intn(len(botChatter))],
+		}); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case !w.Alive || w.GameOver:
+		return b.send(conn, map[string]interface{}{"type": "action", "action": "start"})
+
+	case w.TurnPhase == game.PhaseHunting:
+		// The server sends the word when it appears; take a human-ish time
+		// to type it
+		if w.HuntWord == "" {
+			return nil
+		}
+		reaction := 200 + rand.Intn(1500)
+		time.Sleep(time.Duration(reaction) * time.Millisecond)
+		return b.send(conn, map[string]interface{}{"type": "hunt_shoot", "word": w.HuntWord, "time": reaction})
+
+	case w.TurnPhase == game.PhaseRiders:
+		return b.send(conn, map[string]interface{}{"type": "rider_tactic", "tactic": 3})
+
+	case w.TurnPhase == game.PhaseFort:
+		if !b.traded && w.Cash >= 10 {
+			b.traded = true
+			return b.send(conn, map[string]interface{}{"type": "fort_buy", "item": "food", "qty": 1 + rand.Intn(3)})
+		}
+		b.traded = false
+		return b.send(conn, map[string]interface{}{"type": "fort_leave", "confirm": true})
+
+	case w.FortAvailable && rand.Float64() < botFortChance:
+		return b.send(conn, map[string]interface{}{"type": "fort_enter"})
+
+	case w.Bullets >= botHuntBullets && (w.Food < botHuntFood || rand.Float64() < botHuntChance):
+		return b.send(conn, map[string]interface{}{"type": "action", "action": "hunt", "confirm": true})
+	}
+	return b.send(conn, map[string]interface{}{"type": "action", "action": "continue", "confirm": true})
+}
+
+// send writes one message and counts it.
+func (b *bot) send(conn *websocket.Conn, msg map[string]interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		return err
+	}
+	b.stats.actions.Add(1)
+	return nil
+}
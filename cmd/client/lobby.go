@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errLobbyQuit is returned when the player leaves the lobby without picking
+// a room.
+var errLobbyQuit = errors.New("left the lobby")
+
+// lobbyEntry is one room as /api/lobbies lists it.
+type lobbyEntry struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	RoomType    string `json:"room_type"`
+	PlayerCount int    `json:"player_count"`
+	MaxPlayers  int    `json:"max_players"`
+	HasPassword bool   `json:"has_password"`
+	Status      string `json:"status"`
+	Joinable    bool   `json:"joinable"`
+}
+
+var lobbyHTTP = &http.Client{Timeout: 10 * time.Second}
+
+// webBase turns the websocket address into the base of the web API.
+func webBase(wsURL string) (string, error) {
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+	u.Path, u.RawQuery = "", ""
+	return u.String(), nil
+}
+
+// chooseRoom shows the lobby until the player picks a room, jumps to the
+// open trail or creates a room of their own, and sets link's room and the
+// password to join it with. Creating a room signs name in, so link also
+// gets the session the websocket resumes into it with.
+func chooseRoom(input *bufio.Reader, base, name string, link *webLink) error {
+	lobbies, err := fetchLobbies(base)
+	if err != nil {
+		return err
+	}
+	for {
+		printLobbies(lobbies)
+		fmt.Print("\n> ")
+		line, err := input.ReadString('\n')
+		if err != nil {
+			return errLobbyQuit
+		}
+		choice := strings.ToLower(strings.TrimSpace(line))
+
+		switch choice {
+		case "q":
+			return errLobbyQuit
+		case "r", "":
+			if lobbies, err = fetchLobbies(base); err != nil {
+				return err
+			}
+			continue
+		case "t":
+			for _, l := range lobbies {
+				if l.RoomType == "continuous" {
+					link.room = l.ID
+					return nil
+				}
+			}
+			fmt.Println("There's no open trail on this server.")
+			continue
+		case "n":
+			if link.cookie == "" {
+				if link.cookie, err = signIn(base, name); err != nil {
+					fmt.Println("Couldn't sign in:", err)
+					continue
+				}
+			}
+			id, err := createRoom(input, base, link.cookie)
+			if err != nil {
+				fmt.Println("Couldn't create the room:", err)
+				continue
+			}
+			link.room = id
+			return nil
+		}
+
+		n, err := strconv.Atoi(choice)
+		if err != nil || n < 1 || n > len(lobbies) {
+			fmt.Printf("Pick a room from 1 to %d.\n", len(lobbies))
+			continue
+		}
+		l := lobbies[n-1]
+		if !l.Joinable {
+			fmt.Printf("%s has no open seat.\n", l.Name)
+			continue
+		}
+		link.room, link.password = l.ID, ""
+		if l.HasPassword {
+			fmt.Print("Password: ")
+			line, _ := input.ReadString('\n')
+			link.password = strings.TrimSpace(line)
+		}
+		return nil
+	}
+}
+
+// fetchLobbies lists the server's rooms, the open trail first.
+func fetchLobbies(base string) ([]lobbyEntry, error) {
+	resp, err := lobbyHTTP.Get(base + "/api/lobbies")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listing rooms: %s", resp.Status)
+	}
+	var lobbies []lobbyEntry
+	if err := json.NewDecoder(resp.Body).Decode(&lobbies); err != nil {
+		return nil, err
+	}
+	return lobbies, nil
+}
+
+func printLobbies(lobbies []lobbyEntry) {
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println("ROOMS")
+	fmt.Println(strings.Repeat("-", 50))
+	if len(lobbies) == 0 {
+		fmt.Println("  (none yet)")
+	}
+	for i, l := range lobbies {
+		seats := fmt.Sprintf("%d/%d", l.PlayerCount, l.MaxPlayers)
+		if l.MaxPlayers == 0 {
+			seats = fmt.Sprintf("%d", l.PlayerCount)
+		}
+		lock := ""
+		if l.HasPassword {
+			lock = "  [password]"
+		}
+		fmt.Printf("  %2d  %-20s %-7s %-9s%s\n", i+1, l.Name, seats, l.Status, lock)
+	}
+	fmt.Println(strings.Repeat("=", 50))
+	fmt.Println("(number) join  (t) the open trail  (r) refresh  (n) new room  (q) quit")
+}
+
+// serverError is the plain-text reason the web API gave for refusing a
+// request, or its status if it gave none.
+func serverError(resp *http.Response) error {
+	reason, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if msg := strings.TrimSpace(string(reason)); msg != "" {
+		return errors.New(msg)
+	}
+	return errors.New(resp.Status)
+}
+
+// signIn starts a session for name, which the server wants before it makes
+// a room, and returns its cookie.
+func signIn(base, name string) (string, error) {
+	body, err := json.Marshal(map[string]string{"name": name})
+	if err != nil {
+		return "", err
+	}
+	resp, err := lobbyHTTP.Post(base+"/api/session", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", serverError(resp)
+	}
+	for _, ck := range resp.Cookies() {
+		if ck.Name == "session_id" {
+			return ck.Value, nil
+		}
+	}
+	return "", errors.New("the server didn't start a session")
+}
+
+// createRoom asks for a name and size and opens a new party room for the
+// session in cookie.
+func createRoom(input *bufio.Reader, base, cookie string) (string, error) {
+	fmt.Print("Room name: ")
+	line, _ := input.ReadString('\n')
+	name := strings.TrimSpace(line)
+	fmt.Print("Max players: ")
+	line, _ = input.ReadString('\n')
+	maxPlayers, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || maxPlayers < 1 || maxPlayers > 8 {
+		return "", errors.New("max players must be a number from 1 to 8")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"name":        name,
+		"max_players": maxPlayers,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, base+"/api/lobbies/create", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: cookie})
+	resp, err := lobbyHTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", serverError(resp)
+	}
+	var created struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", err
+	}
+	fmt.Printf("Created %s.\n", created.Name)
+	return created.ID, nil
+}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"net"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"online-trail/pkg/game"
@@ -16,50 +21,148 @@ import (
 )
 
 type Client struct {
+	addr     string
+	retries  int
+	connMu   sync.Mutex // guards conn and encoder across reconnects
 	conn     net.Conn
+	web      *webLink // set when playing on the web server instead
 	encoder  *json.Encoder
 	decoder  *json.Decoder
 	game     *game.GameState
 	playerID string
 	name     string
 	input    *bufio.Reader
+
+	// ownerID is who owns the wagon on the TCP server and starts it;
+	// startSent is set once we, as owner, have asked to start
+	ownerID   string
+	startSent bool
+
+	// huntIssued is when the hunt we last shot at was issued, so one hunt
+	// isn't shot at twice while the state catches up
+	huntIssued time.Time
+
+	// fortPrices is the price list the server sent with the last state
+	fortPrices map[string]game.FortItem
+
+	// redraw asks for the next state to be shown as soon as it arrives,
+	// after a reconnect
+	redraw bool
 }
 
-func NewClient(addr, name string) (*Client, error) {
-	conn, err := net.Dial("tcp", addr)
-	if err != nil {
+// NewClient connects to the server and joins as name. A dropped connection
+// is retried up to retries times (0 = forever).
+func NewClient(addr, name string, retries int) (*Client, error) {
+	c := &Client{
+		addr:    addr,
+		retries: retries,
+		game:    game.NewGameState(),
+		name:    name,
+		input:   bufio.NewReader(os.Stdin),
+	}
+	if err := c.connect(); err != nil {
 		return nil, err
 	}
+	return c, nil
+}
 
+// NewWebClient is NewClient for the web server's websocket.
+func NewWebClient(web *webLink, name string, retries int, input *bufio.Reader) (*Client, error) {
 	c := &Client{
-		conn:    conn,
-		encoder: json.NewEncoder(conn),
-		decoder: json.NewDecoder(conn),
+		web:     web,
+		retries: retries,
 		game:    game.NewGameState(),
 		name:    name,
-		input:   bufio.NewReader(os.Stdin),
+		input:   input,
 	}
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
 
-	if err := c.encoder.Encode(network.Message{
+// connect dials the server and joins under the client's name, replacing
+// any earlier connection.
+func (c *Client) connect() error {
+	if c.web != nil {
+		return c.connectWeb()
+	}
+	conn, err := net.Dial("tcp", c.addr)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(conn)
+	if err := encoder.Encode(network.Message{
 		Type:    network.MsgJoin,
-		Payload: []byte(fmt.Sprintf(`{"name":%q}`, name)),
+		Payload: []byte(fmt.Sprintf(`{"name":%q}`, c.name)),
 	}); err != nil {
 		conn.Close()
-		return nil, err
+		return err
 	}
 
-	return c, nil
+	c.connMu.Lock()
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	c.conn = conn
+	c.encoder = encoder
+	c.decoder = json.NewDecoder(conn)
+	c.connMu.Unlock()
+	return nil
+}
+
+// send writes a message on the current connection. A failed write is left
+// for the reader to notice and reconnect.
+func (c *Client) send(msg network.Message) {
+	if c.web != nil {
+		c.sendWeb(msg)
+		return
+	}
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	c.encoder.Encode(msg)
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if c.web != nil {
+		return c.web.conn.Close()
+	}
+	return c.conn.Close()
+}
+
+// reconnect redials after the connection drops and rejoins under the same
+// name, without asking for anything again. The state the server sends on
+// joining is shown straight away.
+func (c *Client) reconnect() error {
+	fmt.Println("\nLost the connection to the server.")
+	status := func(format string, args ...interface{}) {
+		fmt.Printf(format+"\n", args...)
+	}
+	if err := redial(context.Background(), c.retries, status, c.connect); err != nil {
+		return err
+	}
+	fmt.Println("Reconnected!")
+	c.redraw = true
+	return nil
 }
 
 func (c *Client) Run() error {
 	fmt.Println("Connected to Online Trail server!")
 	fmt.Println("Waiting for game to start...")
 
-	go c.readFromServer()
+	if c.web != nil {
+		go c.readFromWeb()
+		go c.pingWeb()
+	} else {
+		go c.readFromServer()
+	}
 
 	for {
 		if c.game.TurnPhase == game.PhaseGameOver {
-			c.printGameOver()
+			printGameOver(c.game)
 			break
 		}
 
@@ -68,6 +171,15 @@ func (c *Client) Run() error {
 			continue
 		}
 
+		if c.web == nil && c.game.TurnPhase == game.PhaseStart {
+			if c.ownerID == c.playerID && !c.startSent {
+				c.startJourney()
+				continue
+			}
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
 		currentPlayer := c.game.GetCurrentPlayer()
 		if currentPlayer == nil {
 			time.Sleep(100 * time.Millisecond)
@@ -78,21 +190,53 @@ func (c *Client) Run() error {
 			continue
 		}
 
-		c.printGameState()
+		if c.game.TurnPhase == game.PhaseHunting {
+			if c.game.HuntStartedAt.Equal(c.huntIssued) {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			c.huntIssued = c.game.HuntStartedAt
+			c.hunt()
+			continue
+		}
+
+		if c.game.TurnPhase == game.PhaseRiders {
+			c.riders()
+			continue
+		}
+
+		if c.game.TurnPhase == game.PhaseFort {
+			printGameState(c.game)
+			c.fort()
+			continue
+		}
+
+		printGameState(c.game)
 		c.handleTurn()
 	}
 
 	return nil
 }
 
+// readFromServer plays the server's messages as they come. The server pings
+// every network.PingInterval, so a connection silent for longer than it
+// takes to miss network.MissedPongs of them is taken as dropped.
 func (c *Client) readFromServer() {
+	silence := network.MissedPongs*network.PingInterval + network.PingInterval/2
 	for {
 		var msg network.Message
+		c.conn.SetReadDeadline(time.Now().Add(silence))
 		if err := c.decoder.Decode(&msg); err != nil {
-			break
+			if err := c.reconnect(); err != nil {
+				log.Fatal(err)
+			}
+			continue
 		}
 
 		switch msg.Type {
+		case network.MsgPing:
+			c.send(network.Message{Type: network.MsgPong})
+
 		case network.MsgJoin:
 			var payload struct {
 				ID string `json:"id"`
@@ -102,7 +246,7 @@ func (c *Client) readFromServer() {
 			fmt.Println("Your player ID:", c.playerID)
 
 		case network.MsgGameState:
-			json.Unmarshal(msg.Payload, c.game)
+			c.applyState(msg.Payload)
 			if c.game.TurnPhase == game.PhaseGameOver {
 				return
 			}
@@ -115,6 +259,34 @@ func (c *Client) readFromServer() {
 			json.Unmarshal(msg.Payload, &payload)
 			fmt.Printf("[%s]: %s\n", payload.Sender, payload.Message)
 
+		case network.MsgOwner:
+			var payload network.OwnerPayload
+			json.Unmarshal(msg.Payload, &payload)
+			c.ownerID = payload.ID
+			if payload.ID == c.playerID {
+				fmt.Println("You own the wagon: you start the journey.")
+			} else {
+				fmt.Printf("%s owns the wagon and will start the journey.\n", payload.Name)
+			}
+
+		case network.MsgEvent:
+			var payload network.EventPayload
+			json.Unmarshal(msg.Payload, &payload)
+			if payload.Result != "" {
+				fmt.Print("\n" + payload.Result)
+			}
+
+		case network.MsgError:
+			var payload network.ErrorPayload
+			json.Unmarshal(msg.Payload, &payload)
+			if payload.Request == string(network.MsgJoin) {
+				log.Fatalf("Couldn't join: %s", payload.Message)
+			}
+			if payload.Request == string(network.MsgStart) {
+				c.startSent = false
+			}
+			fmt.Println("Error:", payload.Message)
+
 		case network.MsgPlayerList:
 			var players []network.Player
 			json.Unmarshal(msg.Payload, &players)
@@ -126,13 +298,45 @@ func (c *Client) readFromServer() {
 	}
 }
 
-func (c *Client) printGameState() {
+// applyState takes in a game state from the server, along with the extras
+// sent beside it while the wagon is at a fort or meeting riders.
+func (c *Client) applyState(data []byte) {
+	json.Unmarshal(data, c.game)
+	var extra struct {
+		FortPrices   map[string]game.FortItem `json:"fort_prices"`
+		RiderHostile *bool                    `json:"rider_hostile"`
+		RiderCount   *int                     `json:"rider_count"`
+	}
+	json.Unmarshal(data, &extra)
+	c.fortPrices = extra.FortPrices
+	if c.redraw {
+		c.redraw = false
+		printGameState(c.game)
+	}
+	if extra.RiderHostile != nil {
+		c.game.PendingRiderHostile = *extra.RiderHostile
+	}
+	if extra.RiderCount != nil {
+		c.game.PendingRiderCount = *extra.RiderCount
+	}
+}
+
+// startJourney waits for the owner to say everyone is here, then asks the
+// server to start.
+func (c *Client) startJourney() {
+	fmt.Print("\nPress Enter to start the journey once everyone has joined: ")
+	c.input.ReadString('\n')
+	c.startSent = true
+	c.send(network.Message{Type: network.MsgStart})
+}
+
+func printGameState(g *game.GameState) {
 	fmt.Println("\n" + strings.Repeat("=", 50))
-	fmt.Printf("Turn: %d | Mileage: %.0f\n", c.game.TurnNumber, c.game.Mileage)
+	fmt.Printf("Turn: %d | Mileage: %.0f\n", g.TurnNumber, g.Mileage)
 	fmt.Println("-" + strings.Repeat("-", 49))
 	fmt.Printf("FOOD: %.0f  BULLETS: %.0f  CLOTHING: %.0f\n",
-		c.game.Food, c.game.Bullets, c.game.Clothing)
-	fmt.Printf("MISC: %.0f  CASH: $%.0f\n", c.game.MiscSupplies, c.game.Cash)
+		g.Food, g.Bullets, g.Clothing)
+	fmt.Printf("MISC: %.0f  CASH: $%.0f\n", g.MiscSupplies, g.Cash)
 	fmt.Println(strings.Repeat("=", 50))
 }
 
@@ -145,7 +349,10 @@ func (c *Client) handleTurn() {
 	fmt.Println("\nYour turn! Choose an action:")
 
 	hasFort := c.game.TurnNumber > 0 && c.game.TurnNumber%2 == 1
-	_ = hasFort
+	if c.web != nil {
+		// The web server says when a fort is in reach
+		hasFort = c.game.FortAvailable
+	}
 
 	if hasFort {
 		fmt.Println("  (1) Stop at the next fort")
@@ -179,7 +386,7 @@ func (c *Client) handleTurn() {
 		action = "continue"
 	}
 
-	c.encoder.Encode(network.Message{
+	c.send(network.Message{
 		Type: network.MsgAction,
 		Payload: []byte(fmt.Sprintf(`{"player_id":%q,"action":%q,"value":""}`,
 			c.playerID, action)),
@@ -188,12 +395,238 @@ func (c *Client) handleTurn() {
 	time.Sleep(200 * time.Millisecond)
 }
 
-func (c *Client) printGameOver() {
+// hunt plays the hunting mini-game against the hunt the server issued and
+// sends the shot.
+func (c *Client) hunt() {
+	typed, reactionMs := shoot(c.input, c.huntWord())
+
+	payload, err := json.Marshal(network.HuntShootPayload{
+		PlayerID: c.playerID,
+		Word:     typed,
+		Time:     reactionMs,
+	})
+	if err != nil {
+		return
+	}
+	c.send(network.Message{
+		Type:    network.MsgHuntShoot,
+		Payload: payload,
+	})
+
+	time.Sleep(200 * time.Millisecond)
+}
+
+// shoot shows word after delay and times the shot from then until it is
+// typed. Like the server, it scores a wrong word or a shot before the word
+// appeared as a miss.
+func shoot(input *bufio.Reader, reveal <-chan string) (string, int) {
+	fmt.Println("\nYou spot game! Type the word as soon as it appears and press Enter...")
+	lines := make(chan string, 1)
+	go func() {
+		line, _ := input.ReadString('\n')
+		lines <- strings.TrimSpace(line)
+	}()
+
+	reactionMs := game.HuntMissMs
+	typed := ""
+	select {
+	case typed = <-lines:
+		fmt.Println("You fired too soon and spooked the game!")
+	case word, ok := <-reveal:
+		if !ok {
+			fmt.Println("The game got away before you could shoot.")
+			return "", reactionMs
+		}
+		fmt.Printf("\n>>> %s <<<\n> ", word)
+		shown := time.Now()
+		typed = <-lines
+		if strings.EqualFold(typed, word) {
+			reactionMs = int(time.Since(shown).Milliseconds())
+			fmt.Printf("BANG! (%d ms)\n", reactionMs)
+		} else {
+			fmt.Printf("You typed %q, not %s. The shot goes wide.\n", typed, word)
+		}
+	}
+	return typed, reactionMs
+}
+
+// huntWord delivers the hunt's word once the server shows it, or closes if
+// the hunt ends first.
+func (c *Client) huntWord() <-chan string {
+	reveal := make(chan string, 1)
+	go func() {
+		defer close(reveal)
+		deadline := time.Now().Add(game.HuntTimeLimit)
+		for time.Now().Before(deadline) && c.game.TurnPhase == game.PhaseHunting {
+			if word := c.game.HuntWord; word != "" {
+				reveal <- word
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	return reveal
+}
+
+// afterDelay delivers word after delay, for a hunt played locally.
+func afterDelay(word string, delay time.Duration) <-chan string {
+	reveal := make(chan string, 1)
+	time.AfterFunc(delay, func() { reveal <- word })
+	return reveal
+}
+
+// riders asks how to meet the riders ahead and sends the tactic.
+func (c *Client) riders() {
+	tactic := chooseTactic(c.input, c.game.PendingRiderHostile, c.game.PendingRiderCount)
+
+	payload, err := json.Marshal(network.RiderTacticPayload{
+		PlayerID: c.playerID,
+		Tactic:   tactic,
+	})
+	if err != nil {
+		return
+	}
+	c.send(network.Message{
+		Type:    network.MsgRiderTactic,
+		Payload: payload,
+	})
+
+	time.Sleep(200 * time.Millisecond)
+}
+
+// chooseTactic reads a rider tactic. Anything but 1-4 means carry on, as the
+// server would take it.
+func chooseTactic(input *bufio.Reader, hostile bool, count int) int {
+	if hostile {
+		fmt.Printf("\nRIDERS AHEAD! %d hostile riders approaching!\n", count)
+	} else {
+		fmt.Printf("\nRIDERS AHEAD. %d riders, they don't look hostile.\n", count)
+	}
+	fmt.Println("  (1) Run")
+	fmt.Println("  (2) Attack")
+	fmt.Println("  (3) Continue")
+	fmt.Println("  (4) Circle the wagons")
+	fmt.Print("\n> ")
+
+	line, _ := input.ReadString('\n')
+	tactic, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || tactic < 1 || tactic > 4 {
+		tactic = 3
+	}
+	return tactic
+}
+
+// fort shows the trading post and sends the order typed, if it is a valid
+// one.
+func (c *Client) fort() {
+	prices := c.fortPrices
+	if len(prices) == 0 {
+		prices = game.GetFortPrices()
+	}
+	order, ok := readFortOrder(c.input, c.game, prices)
+	if !ok {
+		return
+	}
+
+	var msg network.Message
+	switch order.verb {
+	case "leave":
+		payload, err := json.Marshal(network.ActionPayload{PlayerID: c.playerID, Action: "fort_leave"})
+		if err != nil {
+			return
+		}
+		msg = network.Message{Type: network.MsgFortLeave, Payload: payload}
+	default:
+		payload, err := json.Marshal(network.FortTradePayload{
+			PlayerID: c.playerID,
+			Item:     order.item,
+			Qty:      order.qty,
+		})
+		if err != nil {
+			return
+		}
+		msgType := network.MsgFortBuy
+		if order.verb == "sell" {
+			msgType = network.MsgFortSell
+		}
+		msg = network.Message{Type: msgType, Payload: payload}
+	}
+
+	c.send(msg)
+	time.Sleep(200 * time.Millisecond)
+}
+
+// fortOrder is one command at the trading post: buy or sell qty bundles of
+// item, or leave.
+type fortOrder struct {
+	verb string
+	item string
+	qty  int
+}
+
+// readFortOrder shows the trading post's prices and the wagon's goods, then
+// reads one command: "buy <item> <qty>", "sell <item> <qty>" or "leave".
+// Orders are checked here so a typo doesn't cost a round trip; ok is false
+// if the command was no good.
+func readFortOrder(input *bufio.Reader, g *game.GameState, prices map[string]game.FortItem) (order fortOrder, ok bool) {
+	items := make([]string, 0, len(prices))
+	for item := range prices {
+		items = append(items, item)
+	}
+	sort.Strings(items)
+
+	fmt.Println("\nYou're at the fort. Prices per bundle (sell for half):")
+	for _, item := range items {
+		fi := prices[item]
+		fmt.Printf("  %-9s $%-4.0f %s\n", item, fi.Price, fi.Label)
+	}
+	fmt.Printf("\nCash: $%.0f | Food: %.0f  Bullets: %.0f  Clothing: %.0f  Misc: %.0f  Oxen: %.0f\n",
+		g.Cash, g.Food, g.Bullets, g.Clothing, g.MiscSupplies, g.OxenCost)
+	fmt.Println("Commands: buy <item> <qty>, sell <item> <qty>, leave")
+	fmt.Print("\n> ")
+
+	line, _ := input.ReadString('\n')
+	fields := strings.Fields(strings.ToLower(line))
+	if len(fields) == 0 {
+		return fortOrder{}, false
+	}
+
+	switch fields[0] {
+	case "leave":
+		return fortOrder{verb: "leave"}, true
+
+	case "buy", "sell":
+		if len(fields) != 3 {
+			fmt.Printf("Usage: %s <item> <qty>\n", fields[0])
+			return fortOrder{}, false
+		}
+		item := fields[1]
+		if _, ok := prices[item]; !ok {
+			fmt.Printf("Unknown item %q. Choose %s.\n", item, strings.Join(items, ", "))
+			return fortOrder{}, false
+		}
+		qty, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			fmt.Printf("%q isn't a number.\n", fields[2])
+			return fortOrder{}, false
+		}
+		if err := game.CheckFortQty(qty); err != nil {
+			fmt.Printf("Can't %s: %v.\n", fields[0], err)
+			return fortOrder{}, false
+		}
+		return fortOrder{verb: fields[0], item: item, qty: int(qty)}, true
+	}
+
+	fmt.Printf("Unknown command %q.\n", fields[0])
+	return fortOrder{}, false
+}
+
+func printGameOver(g *game.GameState) {
 	fmt.Println("\n" + strings.Repeat("*", 50))
-	if c.game.Win {
+	if g.Win {
 		fmt.Println("       CONGRATULATIONS!")
 		fmt.Println("   YOU MADE IT TO ONLINE CITY!")
-		fmt.Printf("   Arrival date: %s\n", c.game.FinalDate)
+		fmt.Printf("   Arrival date: %s\n", g.FinalDate)
 	} else {
 		fmt.Println("         GAME OVER")
 		fmt.Println("     Your party did not survive...")
@@ -201,23 +634,97 @@ func (c *Client) printGameOver() {
 	fmt.Println(strings.Repeat("*", 50))
 
 	fmt.Println("\nFINAL INVENTORY:")
-	fmt.Printf("  Food: %.0f\n", c.game.Food)
-	fmt.Printf("  Bullets: %.0f\n", c.game.Bullets)
-	fmt.Printf("  Clothing: %.0f\n", c.game.Clothing)
-	fmt.Printf("  Misc Supplies: %.0f\n", c.game.MiscSupplies)
-	fmt.Printf("  Cash: $%.0f\n", c.game.Cash)
+	fmt.Printf("  Food: %.0f\n", g.Food)
+	fmt.Printf("  Bullets: %.0f\n", g.Bullets)
+	fmt.Printf("  Clothing: %.0f\n", g.Clothing)
+	fmt.Printf("  Misc Supplies: %.0f\n", g.MiscSupplies)
+	fmt.Printf("  Cash: $%.0f\n", g.Cash)
 }
 
 func main() {
 	addr := flag.String("addr", "localhost:5555", "Server address")
 	name := flag.String("name", "Player", "Your name")
+	solo := flag.Bool("solo", false, "Play offline on your own, without a server")
+	botMode := flag.Bool("bot", false, "Play automatically over websocket, for load testing")
+	botCount := flag.Int("count", 1, "Bots to run with -bot")
+	botPace := flag.Duration("pace", 2*time.Second, "Time between a bot's moves")
+	botDuration := flag.Duration("duration", 0, "How long bots play before reporting (0 = until interrupted)")
+	wsURL := flag.String("ws", "ws://localhost:8080/ws", "Websocket address bots connect to")
+	room := flag.String("room", "", "Room to join with -web or -bot (default: the lobby with -web, the server's default room with -bot)")
+	web := flag.Bool("web", false, "Play on the web server at -ws instead of the TCP server at -addr")
+	retries := flag.Int("retries", 0, "Reconnect attempts after a dropped connection before giving up (0 = keep trying)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintln(flag.CommandLine.Output(), "\nChat commands:")
+		for _, cmd := range network.ChatCommands {
+			fmt.Fprintf(flag.CommandLine.Output(), "  %-26s %s\n", cmd.Usage, cmd.Help)
+		}
+	}
 	flag.Parse()
 
-	c, err := NewClient(*addr, *name)
+	if *botMode {
+		if *botCount < 1 || *botPace <= 0 {
+			log.Fatal("-count must be at least 1 and -pace positive")
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if *botDuration > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, *botDuration)
+			defer cancel()
+		}
+		runBots(ctx, botConfig{
+			wsURL: *wsURL,
+			room:  *room,
+			name:  *name,
+			count: *botCount,
+			pace:  *botPace,
+
+			retries: *retries,
+		})
+		return
+	}
+
+	if *solo {
+		if err := runSolo(*name); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *web {
+		input := bufio.NewReader(os.Stdin)
+		link := &webLink{wsURL: *wsURL, room: *room}
+		if link.room == "" {
+			base, err := webBase(*wsURL)
+			if err != nil {
+				log.Fatal(err)
+			}
+			err = chooseRoom(input, base, *name, link)
+			if err == errLobbyQuit {
+				return
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		c, err := NewWebClient(link, *name, *retries, input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer c.Close()
+		if err := c.Run(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	c, err := NewClient(*addr, *name, *retries)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer c.conn.Close()
+	defer c.Close()
 
 	if err := c.Run(); err != nil {
 		log.Fatal(err)
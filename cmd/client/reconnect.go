@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Reconnect backoff: the first retry waits reconnectMinDelay and each
+// failed one doubles the wait, up to reconnectMaxDelay.
+const (
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = 30 * time.Second
+)
+
+// redial calls dial until it succeeds, backing off between attempts and
+// reporting each one through status. maxRetries of 0 keeps trying until ctx
+// is done.
+func redial(ctx context.Context, maxRetries int, status func(format string, args ...interface{}), dial func() error) error {
+	delay := reconnectMinDelay
+	for attempt := 1; maxRetries == 0 || attempt <= maxRetries; attempt++ {
+		status("Reconnecting… (attempt %d, in %s)", attempt, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		err := dial()
+		if err == nil {
+			return nil
+		}
+		status("Reconnect failed: %v", err)
+		delay = min(2*delay, reconnectMaxDelay)
+	}
+	return fmt.Errorf("gave up after %d reconnect attempts", maxRetries)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// soloFortInterval is how often a trading post turns up on a solo run, the
+// same as on the server.
+const soloFortInterval = 3
+
+// soloGame is an offline run of the trail: one human party driving a local
+// game with no server involved.
+type soloGame struct {
+	g      *game.GameState
+	player *game.Player
+	input  *bufio.Reader
+}
+
+// runSolo plays a whole journey on this machine, from outfitting the wagon
+// to the end of the trail or the party.
+func runSolo(name string) error {
+	s := &soloGame{
+		g:     game.NewGameState(),
+		input: bufio.NewReader(os.Stdin),
+	}
+	s.player = s.g.AddPlayer(name, game.PlayerTypeHuman)
+
+	fmt.Print(s.g.StartGame())
+	fmt.Print("> ")
+	if answer, _ := s.input.ReadString('\n'); strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y") {
+		fmt.Print(s.g.ShowInstructions())
+	}
+
+	s.outfit()
+	for !s.g.GameOver && s.player.Alive {
+		printGameState(s.g)
+		s.turn()
+		if s.g.GameOver || !s.player.Alive {
+			break
+		}
+		s.g.NextTurn()
+		if s.g.TurnNumber%soloFortInterval == 0 {
+			s.g.FortAvailable = true
+		}
+	}
+
+	printGameOver(s.g)
+	s.printSummary()
+	return nil
+}
+
+// outfit loads the wagon with the standard supplies and opens the trading
+// post in Independence so the rest of the purse can be spent before setting
+// out.
+func (s *soloGame) outfit() {
+	oxen, food, bullets, clothing, misc, cash := s.g.InitialPurchase(s.player)
+	s.g.OxenCost = oxen
+	s.g.Food = food
+	s.g.Bullets = bullets
+	s.g.Clothing = clothing
+	s.g.MiscSupplies = misc
+	s.g.Cash = cash
+	s.g.TurnNumber = 1
+
+	fmt.Println("\nYour wagon is loaded with the basics. Spend what you like before you leave Independence.")
+	s.g.EnterFort()
+	s.trade()
+}
+
+// turn plays one turn from the main menu through any hunt, fort or riders
+// it leads to.
+func (s *soloGame) turn() {
+	fmt.Println("\nChoose an action:")
+	options := []string{"continue", "hunt"}
+	if s.g.FortAvailable {
+		options = []string{"fort", "hunt", "continue"}
+		fmt.Println("  (1) Stop at the fort")
+		fmt.Println("  (2) Hunt")
+		fmt.Println("  (3) Continue")
+	} else {
+		fmt.Println("  (1) Continue")
+		fmt.Println("  (2) Hunt")
+	}
+	fmt.Print("\n> ")
+
+	line, _ := s.input.ReadString('\n')
+	action := "continue"
+	switch n := strings.TrimSpace(line); {
+	case n == "1":
+		action = options[0]
+	case n == "2":
+		action = options[1]
+	}
+
+	if action == "fort" {
+		fmt.Print(s.g.HandleFort(s.player).String())
+		s.trade()
+		s.g.FortAvailable = false
+		return
+	}
+
+	fmt.Print(s.g.ProcessTurn(s.player, action).String())
+	switch s.g.TurnPhase {
+	case game.PhaseHunting:
+		delay := time.Duration(s.g.HuntDelayMs) * time.Millisecond
+		word, ms := shoot(s.input, afterDelay(s.g.HuntWord, delay))
+		fmt.Print(s.g.HandleHuntShoot(s.player, word, ms, 0).String())
+	case game.PhaseRiders:
+		tactic := chooseTactic(s.input, s.g.PendingRiderHostile, s.g.PendingRiderCount)
+		fmt.Print(s.g.HandleRiderTactic(s.player, tactic).String())
+	}
+}
+
+// trade runs the trading post until the player leaves it.
+func (s *soloGame) trade() {
+	prices := game.GetFortPrices()
+	for s.g.TurnPhase == game.PhaseFort {
+		order, ok := readFortOrder(s.input, s.g, prices)
+		if !ok {
+			continue
+		}
+		switch order.verb {
+		case "buy":
+			fmt.Print(s.g.HandleFortBuy(order.item, order.qty).String())
+		case "sell":
+			fmt.Print(s.g.HandleFortSell(order.item, order.qty).String())
+		case "leave":
+			fmt.Print(s.g.HandleFortLeave().String())
+		}
+	}
+}
+
+// printSummary shows how the party fared, as the server would report it at
+// game over.
+func (s *soloGame) printSummary() {
+	summary := s.g.Summary()
+	fmt.Printf("\nMiles traveled: %.0f in %d turns\n", summary.Mileage, summary.Turns)
+	for _, party := range summary.Parties {
+		fmt.Printf("%s: %d of %d survived, score %d\n",
+			party.Name, party.Survivors, len(party.Members), party.Score)
+		for _, m := range party.Members {
+			if !m.Alive {
+				fmt.Printf("  %s - died of %s on turn %d\n", m.Name, m.DiedOf, m.DiedOnTurn)
+			}
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"online-trail/pkg/game"
+	"online-trail/pkg/network"
+)
+
+// webLink is a connection to the web server's websocket, for a client
+// playing there instead of on the TCP server. It keeps the session cookie
+// so a reconnect resumes the same player.
+type webLink struct {
+	wsURL    string
+	room     string
+	password string
+	cookie   string
+	conn     *websocket.Conn
+
+	// confirm is the move the server last asked to have confirmed; sending
+	// it again goes ahead
+	confirm string
+
+	// rtt is the last measured round trip to the server, in milliseconds
+	rtt int64
+}
+
+// webDialer dials the websocket, offering permessage-deflate so the
+// server can compress large states.
+var webDialer = &websocket.Dialer{
+	Proxy:             http.ProxyFromEnvironment,
+	HandshakeTimeout:  45 * time.Second,
+	EnableCompression: true,
+}
+
+// webProtocolVersion is the version of the web server's protocol the
+// client speaks.
+const webProtocolVersion = "2.0"
+
+// webPingInterval is how often the client measures its round trip. The
+// server allows for it when timing hunting shots.
+const webPingInterval = 10 * time.Second
+
+// connectWeb dials the websocket for the client's room, replacing any
+// earlier connection.
+func (c *Client) connectWeb() error {
+	u, err := url.Parse(c.web.wsURL)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("name", c.name)
+	q.Set("v", webProtocolVersion)
+	if c.web.room != "" {
+		q.Set("room", c.web.room)
+	}
+	if c.web.password != "" {
+		q.Set("password", c.web.password)
+	}
+	u.RawQuery = q.Encode()
+
+	header := http.Header{}
+	if c.web.cookie != "" {
+		header.Set("Cookie", "session_id="+c.web.cookie)
+	}
+	conn, resp, err := webDialer.Dial(u.String(), header)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("%v (%s)", err, resp.Status)
+		}
+		return err
+	}
+
+	c.connMu.Lock()
+	for _, ck := range resp.Cookies() {
+		if ck.Name == "session_id" {
+			c.web.cookie = ck.Value
+		}
+	}
+	if c.web.conn != nil {
+		c.web.conn.Close()
+	}
+	c.web.conn = conn
+	c.connMu.Unlock()
+	return nil
+}
+
+// pingWeb pings the web server every webPingInterval, reporting the last
+// round trip measured.
+func (c *Client) pingWeb() {
+	for {
+		c.send(network.Message{Type: network.MsgPing})
+		time.Sleep(webPingInterval)
+	}
+}
+
+// readFromWeb is readFromServer for the websocket. The server's state holds
+// every wagon in rooms where each player has their own; the client plays
+// from its own.
+func (c *Client) readFromWeb() {
+	for {
+		_, data, err := c.web.conn.ReadMessage()
+		if err != nil {
+			if err := c.reconnect(); err != nil {
+				log.Fatal(err)
+			}
+			continue
+		}
+
+		var msg struct {
+			Type     string          `json:"type"`
+			ClientID string          `json:"client_id"`
+			Message  string          `json:"message"`
+			Request  json.RawMessage `json:"request"`
+			Data     json.RawMessage `json:"data"`
+			T        int64           `json:"t"`
+		}
+		if json.Unmarshal(data, &msg) != nil {
+			continue
+		}
+
+		switch msg.Type {
+		case "your_id":
+			c.playerID = msg.ClientID
+			fmt.Println("Your player ID:", c.playerID)
+
+		case "state":
+			c.applyWebState(msg.Data)
+
+		case "pong":
+			c.connMu.Lock()
+			c.web.rtt = time.Now().UnixMilli() - msg.T
+			c.connMu.Unlock()
+
+		case "event":
+			var payload network.EventPayload
+			json.Unmarshal(msg.Data, &payload)
+			switch {
+			case payload.Result == "":
+			case payload.Player == c.name:
+				fmt.Print("\n" + payload.Result)
+			default:
+				fmt.Printf("\n[%s] %s", payload.Player, payload.Result)
+			}
+
+		case "chat":
+			var payload struct {
+				Player  string `json:"player"`
+				Message string `json:"message"`
+			}
+			json.Unmarshal(msg.Data, &payload)
+			fmt.Printf("[%s]: %s\n", payload.Player, payload.Message)
+
+		case "error":
+			if string(msg.Request) == `"join"` {
+				log.Fatalf("Couldn't join: %s", msg.Message)
+			}
+			fmt.Println("Error:", msg.Message)
+
+		case "upgrade_required":
+			log.Fatalf("The server no longer speaks this client's protocol: %s", msg.Message)
+
+		case "warning":
+			fmt.Printf("\nWarning: %s\nMake the same move again to go ahead.\n", msg.Message)
+			var req map[string]interface{}
+			if json.Unmarshal(msg.Request, &req) == nil {
+				c.connMu.Lock()
+				c.web.confirm = moveKey(req)
+				c.connMu.Unlock()
+			}
+		}
+	}
+}
+
+// applyWebState takes in a websocket state message. The players list and
+// whose turn it is come from the room; the supplies and phase from the
+// client's own wagon where it has one.
+func (c *Client) applyWebState(data []byte) {
+	var room struct {
+		CurrentPlayerID string                     `json:"current_player_id"`
+		PlayerStates    map[string]json.RawMessage `json:"player_states"`
+	}
+	if json.Unmarshal(data, &room) != nil {
+		return
+	}
+	wasHunting := c.game.TurnPhase == game.PhaseHunting
+
+	json.Unmarshal(data, c.game)
+	current := room.CurrentPlayerID
+	if room.PlayerStates != nil {
+		own, ok := room.PlayerStates[c.playerID]
+		if !ok {
+			return
+		}
+		c.applyState(own)
+		current = c.playerID
+	} else {
+		c.applyState(data)
+	}
+	for i, p := range c.game.Players {
+		if p.ID == current {
+			c.game.CurrentPlayerIdx = i
+		}
+	}
+
+	// The websocket doesn't say when a hunt was issued; a new hunt is one
+	// that wasn't on the last state
+	if c.game.TurnPhase == game.PhaseHunting && !wasHunting {
+		c.game.HuntStartedAt = time.Now()
+	}
+}
+
+// sendWeb writes a message in the websocket's own form.
+func (c *Client) sendWeb(msg network.Message) {
+	out := map[string]interface{}{"type": string(msg.Type)}
+	switch msg.Type {
+	case network.MsgAction:
+		var p network.ActionPayload
+		json.Unmarshal(msg.Payload, &p)
+		out["action"] = p.Action
+		if p.Action == "fort" {
+			out = map[string]interface{}{"type": "fort_enter"}
+		}
+	case network.MsgHuntShoot:
+		var p network.HuntShootPayload
+		json.Unmarshal(msg.Payload, &p)
+		out["word"] = p.Word
+		out["time"] = p.Time
+	case network.MsgFortBuy, network.MsgFortSell:
+		var p network.FortTradePayload
+		json.Unmarshal(msg.Payload, &p)
+		out["item"] = p.Item
+		out["qty"] = p.Qty
+	case network.MsgRiderTactic:
+		var p network.RiderTacticPayload
+		json.Unmarshal(msg.Payload, &p)
+		out["tactic"] = p.Tactic
+	case network.MsgChat:
+		var p network.ChatPayload
+		json.Unmarshal(msg.Payload, &p)
+		out["message"] = p.Message
+	case network.MsgPing:
+		out["t"] = time.Now().UnixMilli()
+	}
+
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if key := moveKey(out); key != "" && key == c.web.confirm {
+		out["confirm"] = true
+		c.web.confirm = ""
+	}
+	if msg.Type == network.MsgPing && c.web.rtt > 0 {
+		out["rtt"] = c.web.rtt
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return
+	}
+	c.web.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// moveKey names a move for matching a confirmation to its retry.
+func moveKey(msg map[string]interface{}) string {
+	t, _ := msg["type"].(string)
+	a, _ := msg["action"].(string)
+	return t + "/" + a
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strings"
+)
+
+// Ban keys for GameRoom.bannedClients. A kick bans both the session and the
+// name so the player can't rejoin by reconnecting or by starting a new session.
+func sessionBanKey(sessionID string) string { return "session:" + sessionID }
+func nameBanKey(name string) string         { return "name:" + strings.ToLower(name) }
+
+// ban keeps a kicked player out of the room until it resets or the owner
+// unbans them.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) ban(sessionID, name string) {
+	if sessionID != "" {
+		r.bannedClients[sessionBanKey(sessionID)] = name
+	}
+	r.bannedClients[nameBanKey(name)] = name
+}
+
+// isBanned reports whether the session or name was kicked from the room.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) isBanned(sessionID, name string) bool {
+	if sessionID != "" {
+		if _, ok := r.bannedClients[sessionBanKey(sessionID)]; ok {
+			return true
+		}
+	}
+	_, ok := r.bannedClients[nameBanKey(name)]
+	return ok
+}
+
+// IsClientKicked reports whether a connecting player was kicked from the room.
+func (s *Server) IsClientKicked(roomID, sessionID, name string) bool {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return false
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	return room.isBanned(sessionID, name)
+}
+
+// UnbanPlayer lifts a kick ban on the named player. Only the owner may unban.
+func (s *Server) UnbanPlayer(roomID, requesterID, name string) bool {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return false
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.ownerID != requesterID {
+		return false
+	}
+	found := false
+	for key, banned := range room.bannedClients {
+		if strings.EqualFold(banned, name) {
+			delete(room.bannedClients, key)
+			found = true
+		}
+	}
+	if found {
+		log.Printf("Player %s unbanned from room %s by owner", name, roomID)
+	}
+	return found
+}
+
+// ListBans returns the names of players kicked from the room. The second
+// result is false if the requester isn't the owner.
+func (s *Server) ListBans(roomID, requesterID string) ([]string, bool) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return nil, false
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+
+	if room.ownerID != requesterID {
+		return nil, false
+	}
+	names := make([]string, 0)
+	for key, name := range room.bannedClients {
+		if strings.HasPrefix(key, "name:") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, true
+}
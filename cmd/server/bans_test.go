@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestKickBansUntilUnban(t *testing.T) {
+	s := newTestServer(t)
+	room := newTestRoom(t, s)
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann", SessionID: "session-ann"}, room.id)
+	s.AddClient(&Client{ID: "client-bea", Name: "Bea", SessionID: "session-bea"}, room.id)
+	s.AddClient(&Client{ID: "client-cy", Name: "Cy", SessionID: "session-cy"}, room.id)
+	room.mu.Lock()
+	room.ownerID = "client-ann"
+	room.mu.Unlock()
+
+	if s.KickClient(room.id, "client-cy", "client-bea") || s.KickClient(room.id, "client-bea", "client-ann") {
+		t.Fatal("a player who isn't the owner kicked someone")
+	}
+	if !s.KickClient(room.id, "client-ann", "client-bea") {
+		t.Fatal("the owner couldn't kick Bea")
+	}
+	if inRoom(room, "client-bea") {
+		t.Error("Bea is still in the room")
+	}
+	// Neither a new session nor the old one under another name gets back in
+	for _, tc := range []struct{ session, name string }{
+		{"session-bea", "Beatrice"},
+		{"session-new", "BEA"},
+		{"", "bea"},
+	} {
+		if !s.IsClientKicked(room.id, tc.session, tc.name) {
+			t.Errorf("%s as %s got past the ban", tc.session, tc.name)
+		}
+	}
+	if s.IsClientKicked(room.id, "session-cy", "Cy") {
+		t.Error("Cy is banned")
+	}
+
+	if _, ok := s.ListBans(room.id, "client-cy"); ok {
+		t.Error("a player who isn't the owner listed the bans")
+	}
+	if bans, ok := s.ListBans(room.id, "client-ann"); !ok || len(bans) != 1 || bans[0] != "Bea" {
+		t.Errorf("bans = %v, %v; want Bea", bans, ok)
+	}
+
+	if s.UnbanPlayer(room.id, "client-cy", "Bea") {
+		t.Error("a player who isn't the owner unbanned Bea")
+	}
+	if s.UnbanPlayer(room.id, "client-ann", "Gus") {
+		t.Error("unbanned a player who was never kicked")
+	}
+	if !s.UnbanPlayer(room.id, "client-ann", "bea") {
+		t.Fatal("the owner couldn't unban Bea")
+	}
+	if s.IsClientKicked(room.id, "session-bea", "Bea") {
+		t.Error("Bea is still banned after the unban")
+	}
+}
+
+func TestResetLiftsBans(t *testing.T) {
+	s := newTestServer(t)
+	room := newTestRoom(t, s)
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann"}, room.id)
+	s.AddClient(&Client{ID: "client-bea", Name: "Bea", SessionID: "session-bea"}, room.id)
+	room.mu.Lock()
+	room.ownerID = "client-ann"
+	room.mu.Unlock()
+	s.KickClient(room.id, "client-ann", "client-bea")
+
+	room.mu.Lock()
+	room.game.GameOver = true
+	room.mu.Unlock()
+	if !s.ResetGame(room.id) {
+		t.Fatal("the finished game didn't reset")
+	}
+	if s.IsClientKicked(room.id, "session-bea", "Bea") {
+		t.Error("Bea is still banned after the reset")
+	}
+}
+
+func TestKickedPlayerIsTurnedAway(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	ann := ts.dial(t, "name=Ann&room="+room.id)
+	bea := ts.dial(t, "name=Bea&room="+room.id)
+	if !ts.KickClient(room.id, ann.clientID, bea.clientID) {
+		t.Fatal("Ann couldn't kick Bea")
+	}
+
+	resp, err := http.Get(ts.url + "/ws?v=2&preflight=1&name=Bea&room=" + room.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Bea rejoining: %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestKickLeavesTheOtherRoomsConnected(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	other := newTestRoom(t, ts.Server)
+	ann := ts.dial(t, "name=Ann&room="+room.id)
+	ts.AddClient(&Client{ID: "client-bea", Name: "Bea"}, room.id)
+	ts.AddClient(&Client{ID: "client-bea", Name: "Bea"}, other.id)
+	here := registerConn(t, ts.hub, "client-bea", "Bea", room.id)
+	elsewhere := registerConn(t, ts.hub, "client-bea", "Bea", other.id)
+
+	ann.send(map[string]interface{}{"type": "kick", "target_id": "client-bea"})
+	ann.sync()
+	if inRoom(room, "client-bea") || !closed(here.conn) {
+		t.Error("Bea is still connected to the room they were kicked from")
+	}
+	if !inRoom(other, "client-bea") || closed(elsewhere.conn) {
+		t.Error("kicking Bea cut them off from another room")
+	}
+}
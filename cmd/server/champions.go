@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// Champion is a place in the hall of fame: a player who reached the end of
+// a continuous trail.
+type Champion struct {
+	PlayerName  string  `json:"player_name"`
+	RoomID      string  `json:"room_id"`
+	RoomName    string  `json:"room_name"`
+	Score       int     `json:"score"`
+	Survivors   int     `json:"survivors"`
+	Miles       float64 `json:"miles"`
+	TurnCount   int     `json:"turn_count"`
+	ArrivalDate string  `json:"arrival_date"`
+	Occupation  string  `json:"occupation,omitempty"`
+	Date        string  `json:"date"`                // RFC3339
+	ClientID    string  `json:"client_id,omitempty"` // kept off the public hall of fame
+}
+
+// Announcement is the champion event the room is sent.
+func (c Champion) Announcement() string {
+	return fmt.Sprintf("%s has reached Online City and joins the hall of fame! Score %d, %d survivors, arrived %s after %d turns.",
+		c.PlayerName, c.Score, c.Survivors, c.ArrivalDate, c.TurnCount)
+}
+
+// seasonLength is how long a continuous trail runs on after its first
+// champion before a seasonal reset.
+const seasonLength = 24 * time.Hour
+
+// AddChampion adds a champion to the hall of fame.
+func (lb *Leaderboard) AddChampion(c Champion) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.champions = append(lb.champions, c)
+	lb.saveChampions()
+}
+
+// Champions returns the hall of fame, newest first.
+func (lb *Leaderboard) Champions() []Champion {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+	result := make([]Champion, 0, len(lb.champions))
+	for i := len(lb.champions) - 1; i >= 0; i-- {
+		c := lb.champions[i]
+		c.ClientID = ""
+		result = append(result, c)
+	}
+	return result
+}
+
+func (lb *Leaderboard) loadChampions() {
+	data, err := os.ReadFile(lb.hallFilePath)
+	if err != nil {
+		return
+	}
+	var champions []Champion
+	if err := json.Unmarshal(data, &champions); err != nil {
+		log.Printf("Failed to parse hall of fame: %v", err)
+		return
+	}
+	lb.champions = champions
+	log.Printf("Hall of fame loaded %d champions from %s", len(champions), lb.hallFilePath)
+}
+
+func (lb *Leaderboard) saveChampions() {
+	data, err := json.MarshalIndent(lb.champions, "", "  ")
+	if err != nil {
+		log.Printf("Failed to marshal hall of fame: %v", err)
+		return
+	}
+	if err := os.WriteFile(lb.hallFilePath, data, 0644); err != nil {
+		log.Printf("Failed to save hall of fame to %s: %v", lb.hallFilePath, err)
+	}
+}
+
+// crownChampion puts a continuous player who reached the end of the trail
+// in the hall of fame and sends them back east with a fresh wagon. The
+// rest of the room plays on; with seasonal resets on, the first champion
+// starts the countdown to a new season.
+// NOTE: caller must hold room.mu.
+func (s *Server) crownChampion(room *GameRoom, g *game.GameState, p *game.Player) {
+	champ := Champion{
+		PlayerName:  p.Name,
+		RoomID:      room.id,
+		RoomName:    room.name,
+		Score:       g.ComputeScore(p),
+		Survivors:   g.CountSurvivors(p),
+		Miles:       g.Mileage,
+		TurnCount:   g.TurnNumber,
+		ArrivalDate: g.FinalDate,
+		Occupation:  string(p.Occupation),
+		Date:        s.clock.Now().Format(time.RFC3339),
+		ClientID:    p.ID,
+	}
+	s.leaderboard.AddChampion(champ)
+	if room.gameOver != nil {
+		room.gameOver.Champion = &champ
+	}
+	log.Printf("Continuous room %s: %s crowned champion", room.id, p.Name)
+
+	s.freshWagon(room, p.ID)
+	if s.seasonalReset && room.seasonEndsAt.IsZero() {
+		s.scheduleSeasonReset(room, s.clock.Now().Add(seasonLength))
+	}
+}
+
+// freshWagon replaces a continuous player's game with a new journey from
+// the start of the trail, keeping their occupation, raid choice and
+// journal.
+// NOTE: caller must hold room.mu.
+func (s *Server) freshWagon(room *GameRoom, clientID string) {
+	old, p := s.getPlayerGame(room, clientID)
+	if old == nil || p == nil {
+		return
+	}
+	fresh := game.NewGameState()
+	player := fresh.AddPlayer(p.Name, game.PlayerTypeHuman)
+	player.ID = clientID
+	player.Occupation = p.Occupation
+	player.Peaceful = p.Peaceful
+	outfitWagon(fresh, player)
+	fresh.EventLog = old.EventLog
+
+	room.playerGames[clientID] = fresh
+	if c, ok := room.clients[clientID]; ok {
+		c.Player = player
+	}
+}
+
+// scheduleSeasonReset sets the continuous trail to be reset at at.
+// NOTE: caller must hold room.mu.
+func (s *Server) scheduleSeasonReset(room *GameRoom, at time.Time) {
+	room.seasonEndsAt = at
+	roomID := room.id
+	room.seasonTimer = s.clock.AfterFunc(at.Sub(s.clock.Now()), func() {
+		s.resetSeason(roomID)
+	})
+	log.Printf("Continuous room %s: new season at %s", roomID, at.Format(time.RFC3339))
+}
+
+// resumeSeason restarts the countdown to a seasonal reset that was due when
+// the server last stopped. A reset overdue by then happens at once; with
+// seasonal resets turned off since, it is called off.
+func (s *Server) resumeSeason(room *GameRoom) {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if room.seasonEndsAt.IsZero() || room.seasonTimer != nil {
+		return
+	}
+	if !s.seasonalReset {
+		room.seasonEndsAt = time.Time{}
+		return
+	}
+	s.scheduleSeasonReset(room, room.seasonEndsAt)
+}
+
+// resetSeason starts a new season on a continuous trail: every wagon goes
+// back to the start with fresh supplies, and the trail loses its wrecks,
+// notes and pending raids before it is seeded again.
+func (s *Server) resetSeason(roomID string) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return
+	}
+	room.mu.Lock()
+	if room.closed {
+		room.mu.Unlock()
+		return
+	}
+	room.seasonEndsAt = time.Time{}
+	room.seasonTimer = nil
+
+	for clientID := range room.playerGames {
+		s.freshWagon(room, clientID)
+	}
+	for defenderID, raid := range room.raids {
+		raid.timer.Stop()
+		delete(room.raids, defenderID)
+	}
+	room.raidCooldowns = make(map[string]time.Time)
+	room.game.TrailNotes = nil
+	room.game.LootSites = generateNPCLootSites(s.npcSeed, room.game.Rand)
+	s.saveGameStateLocked(room)
+	room.mu.Unlock()
+
+	log.Printf("Continuous room %s: a new season begins", roomID)
+	if s.hub != nil {
+		s.hub.BroadcastEventTo(roomID, "System", "season",
+			"A new season begins! Every wagon is back at the start of the trail with fresh supplies.")
+		s.hub.FlushStateTo(roomID)
+	}
+}
+
+// handleChampions serves GET /api/champions, the hall of fame newest first.
+func (s *Server) handleChampions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	json.NewEncoder(w).Encode(s.leaderboard.Champions())
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"online-trail/pkg/network"
+)
+
+// chatCommandHelp renders the supported chat commands, shown by /help.
+func chatCommandHelp() string {
+	lines := make([]string, len(network.ChatCommands))
+	for i, cmd := range network.ChatCommands {
+		lines[i] = cmd.Usage + " - " + cmd.Help
+	}
+	return "Commands:\n" + strings.Join(lines, "\n")
+}
+
+// handleChatCommand intercepts a chat message starting with "/". Replies
+// meant for the sender alone are sent privately; unknown commands are never
+// broadcast.
+func (c *wsClient) handleChatCommand(roomID, message string) {
+	fields := strings.Fields(message)
+	if len(fields) == 0 {
+		return
+	}
+	cmd := strings.ToLower(fields[0])
+	args := strings.TrimSpace(strings.TrimPrefix(message, fields[0]))
+
+	switch cmd {
+	case "/help":
+		c.hub.SendChatToClient(c.clientID, chatCommandHelp())
+
+	case "/status":
+		c.hub.SendChatToClient(c.clientID, c.hub.server.PlayerStatus(c.clientID, roomID))
+
+	case "/players":
+		names := c.hub.server.RoomRoster(roomID)
+		if len(names) == 0 {
+			c.hub.SendChatToClient(c.clientID, "Nobody is here.")
+			return
+		}
+		c.hub.SendChatToClient(c.clientID, fmt.Sprintf("%d in room: %s", len(names), strings.Join(names, ", ")))
+
+	case "/me":
+		if args == "" {
+			c.hub.SendChatToClient(c.clientID, "Usage: /me <action>")
+			return
+		}
+		args, ok := c.prepareChat(roomID, args)
+		if !ok {
+			return
+		}
+		c.hub.BroadcastEmoteTo(roomID, c.playerName, fmt.Sprintf("* %s %s", c.playerName, args))
+
+	case "/w", "/whisper":
+		parts := strings.SplitN(args, " ", 2)
+		if len(parts) < 2 || strings.TrimSpace(parts[1]) == "" {
+			c.hub.SendChatToClient(c.clientID, "Usage: /w <name> <message>")
+			return
+		}
+		c.whisper(roomID, parts[0], strings.TrimSpace(parts[1]))
+
+	case "/register":
+		parts := strings.Fields(args)
+		if len(parts) < 1 || len(parts) > 2 {
+			c.hub.SendChatToClient(c.clientID, "Usage: /register <pin>, or /register <old pin> <new pin>")
+			return
+		}
+		parts = append(parts, "")
+		c.registerName(parts[0], parts[1])
+
+	case "/hints":
+		if strings.ToLower(args) != "off" {
+			c.hub.SendChatToClient(c.clientID, "Usage: /hints off")
+			return
+		}
+		c.hub.server.leaderboard.DisableHints(c.playerName)
+		c.hub.SendChatToClient(c.clientID, "Trail tips turned off.")
+
+	case "/roll":
+		if _, ok := c.prepareChat(roomID, ""); !ok {
+			return
+		}
+		sides := 100
+		if args != "" {
+			n, err := strconv.Atoi(args)
+			if err != nil || n < 2 || n > 1000 {
+				c.hub.SendChatToClient(c.clientID, "Usage: /roll [sides] (2-1000)")
+				return
+			}
+			sides = n
+		}
+		c.hub.BroadcastEmoteTo(roomID, c.playerName,
+			fmt.Sprintf("* %s rolls a d%d: %d", c.playerName, sides, rand.Intn(sides)+1))
+
+	default:
+		c.hub.SendChatToClient(c.clientID, fmt.Sprintf("Unknown command %s. Type /help for a list.", fields[0]))
+	}
+}
+
+// Owner-issued room mutes default to five minutes and are capped at a day.
+const (
+	defaultRoomMute = 5 * time.Minute
+	maxRoomMute     = 24 * time.Hour
+)
+
+// formatMuteDuration renders a mute duration for chat, rounded up to a minute.
+func formatMuteDuration(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	if minutes <= 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+// RoomMute silences a target's chat for everyone in the room. Only the room
+// owner may do this. Mutes are keyed by client ID, which a resumed session
+// keeps, so reconnecting doesn't clear them. Returns the target's name.
+func (s *Server) RoomMute(roomID, requesterID, targetID string, duration time.Duration) (string, bool) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return "", false
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.ownerID != requesterID || requesterID == targetID {
+		return "", false
+	}
+	target, ok := room.clients[targetID]
+	if !ok {
+		return "", false
+	}
+	if duration > maxRoomMute {
+		duration = maxRoomMute
+	}
+	room.chatMutes[targetID] = s.clock.Now().Add(duration)
+	log.Printf("Player %s muted in room %s for %v", target.Name, roomID, duration)
+	return target.Name, true
+}
+
+// RoomMutedUntil reports whether a client is muted in a room and until when.
+func (s *Server) RoomMutedUntil(roomID, clientID string) (time.Time, bool) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return time.Time{}, false
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	until, ok := room.chatMutes[clientID]
+	if !ok || !s.clock.Now().Before(until) {
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// SetChatMode changes how the room treats chat. Only the owner may change it.
+func (s *Server) SetChatMode(roomID, requesterID string, mode ChatMode) error {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return errors.New("room not found")
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if room.ownerID == "" || room.ownerID != requesterID {
+		return ownerOnly("only the lobby owner can change room settings")
+	}
+	room.chatMode = mode
+	return nil
+}
+
+// ChatText applies the room's chat mode to a message, returning the text to
+// send or an error if chat is turned off.
+func (s *Server) ChatText(roomID, message string) (string, error) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return "", errors.New("room not found")
+	}
+	room.mu.RLock()
+	mode := room.chatMode
+	room.mu.RUnlock()
+
+	switch mode {
+	case ChatDisabled:
+		return "", errors.New("chat is turned off in this room")
+	case ChatFiltered:
+		return s.chatFilter.Filter(message), nil
+	}
+	return message, nil
+}
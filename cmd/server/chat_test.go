@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"online-trail/pkg/network"
+)
+
+// chatReply sends a chat message and returns the next chat the client gets.
+func chatReply(c *testConn, message string) string {
+	c.t.Helper()
+	c.send(map[string]interface{}{"type": "chat", "message": message})
+	data, _ := c.await("chat")["data"].(map[string]interface{})
+	reply, _ := data["message"].(string)
+	return reply
+}
+
+func TestEveryListedChatCommandIsHandled(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	ann := ts.dial(t, "name=Ann&room="+room.id)
+
+	help := chatReply(ann, "/help")
+	for i, cmd := range network.ChatCommands {
+		if !strings.Contains(help, cmd.Usage+" - "+cmd.Help) {
+			t.Errorf("/help doesn't list %s:\n%s", cmd.Usage, help)
+		}
+		// A player each, so chat's rate limit doesn't get in the way, on
+		// the open trail, which has room for them all
+		c := ts.dial(t, fmt.Sprintf("name=Player%d&room=%s", i, ts.defaultRoomID))
+		name := strings.Fields(cmd.Usage)[0]
+		if reply := chatReply(c, name); strings.HasPrefix(reply, "Unknown command") {
+			t.Errorf("%s is listed but unknown: %s", name, reply)
+		}
+	}
+	if reply := chatReply(ann, "/dance"); !strings.HasPrefix(reply, "Unknown command") {
+		t.Errorf("/dance: %q, want it unknown", reply)
+	}
+}
+
+func TestRoomMuteClampsLongDurations(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	ann := ts.dial(t, "name=Ann&room="+room.id)
+	bea := ts.dial(t, "name=Bea&room="+room.id)
+
+	// A thousand centuries' worth of seconds overflows a time.Duration
+	for _, secs := range []float64{3e12, 1e300} {
+		ann.send(map[string]interface{}{"type": "room_mute", "target_id": bea.clientID, "duration": secs})
+		ann.sync()
+		until, muted := ts.RoomMutedUntil(room.id, bea.clientID)
+		if !muted {
+			t.Fatalf("a %g-second mute didn't mute Bea", secs)
+		}
+		if left := time.Until(until); left < maxRoomMute-time.Minute || left > maxRoomMute {
+			t.Errorf("a %g-second mute lasts %v, want the longest, %v", secs, left, maxRoomMute)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ChatMode controls what a room does with player chat.
+type ChatMode string
+
+const (
+	ChatOpen     ChatMode = "open"
+	ChatFiltered ChatMode = "filtered"
+	ChatDisabled ChatMode = "disabled"
+)
+
+// parseChatMode validates a chat mode sent by a client.
+func parseChatMode(s string) (ChatMode, bool) {
+	switch m := ChatMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case ChatOpen, ChatFiltered, ChatDisabled:
+		return m, true
+	}
+	return "", false
+}
+
+// chatFilterFile is the word list read from the data path. One word per
+// line; a trailing * also matches longer words, and # starts a comment.
+const chatFilterFile = "chat_filter.txt"
+
+// chatFilterCheckInterval is how often the word list file is checked for
+// changes.
+const chatFilterCheckInterval = 10 * time.Second
+
+// leetReplacer undoes the common digit and symbol stand-ins for letters.
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s",
+)
+
+// ChatFilter masks listed words in chat. The word list is reloaded when its
+// file changes.
+type ChatFilter struct {
+	path      string
+	exact     map[string]bool
+	prefixes  []string
+	modTime   time.Time
+	lastCheck time.Time
+	mu        sync.Mutex
+}
+
+func NewChatFilter(path string) *ChatFilter {
+	f := &ChatFilter{path: path, exact: make(map[string]bool)}
+	f.reloadIfChanged(time.Now())
+	return f
+}
+
+// reloadIfChanged rereads the word list if the file's modification time
+// moved since the last load. A missing file leaves the list empty.
+// NOTE: caller must hold f.mu, or be the constructor.
+func (f *ChatFilter) reloadIfChanged(now time.Time) {
+	f.lastCheck = now
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Chat filter: %v", err)
+		}
+		if !f.modTime.IsZero() {
+			f.exact = make(map[string]bool)
+			f.prefixes = nil
+			f.modTime = time.Time{}
+		}
+		return
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return
+	}
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		log.Printf("Chat filter: %v", err)
+		return
+	}
+	defer file.Close()
+
+	exact := make(map[string]bool)
+	var prefixes []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasSuffix(line, "*") {
+			if word := normalizeChatWord(strings.TrimSuffix(line, "*")); word != "" {
+				prefixes = append(prefixes, word)
+			}
+			continue
+		}
+		if word := normalizeChatWord(line); word != "" {
+			exact[word] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Chat filter: %v", err)
+		return
+	}
+	f.exact = exact
+	f.prefixes = prefixes
+	f.modTime = info.ModTime()
+	log.Printf("Chat filter loaded %d words from %s", len(exact)+len(prefixes), f.path)
+}
+
+// Filter returns message with every listed word replaced by asterisks.
+func (f *ChatFilter) Filter(message string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if now := time.Now(); now.Sub(f.lastCheck) >= chatFilterCheckInterval {
+		f.reloadIfChanged(now)
+	}
+	if len(f.exact) == 0 && len(f.prefixes) == 0 {
+		return message
+	}
+
+	var out strings.Builder
+	start := -1
+	flush := func(end int) {
+		word := message[start:end]
+		if f.blocked(normalizeChatWord(word)) {
+			out.WriteString(strings.Repeat("*", utf8.RuneCountInString(word)))
+		} else {
+			out.WriteString(word)
+		}
+		start = -1
+	}
+	for i, r := range message {
+		if isChatWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			flush(i)
+		}
+		out.WriteRune(r)
+	}
+	if start >= 0 {
+		flush(len(message))
+	}
+	return out.String()
+}
+
+// blocked reports whether a normalized word is on the list.
+// NOTE: caller must hold f.mu.
+func (f *ChatFilter) blocked(word string) bool {
+	if f.exact[word] {
+		return true
+	}
+	for _, p := range f.prefixes {
+		if strings.HasPrefix(word, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// isChatWordRune reports whether r can be part of a word, counting the
+// symbols that stand in for letters.
+func isChatWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '@' || r == '$'
+}
+
+// normalizeChatWord case-folds a word and undoes leetspeak so that variants
+// of a listed word compare equal.
+func normalizeChatWord(word string) string {
+	folded := strings.Map(func(r rune) rune {
+		// Fold to the smallest rune in the case orbit so that e.g. K, k and
+		// the Kelvin sign all compare equal
+		lowest := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < lowest {
+				lowest = f
+			}
+		}
+		return lowest
+	}, word)
+	return leetReplacer.Replace(strings.ToLower(folded))
+}
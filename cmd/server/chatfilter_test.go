@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeWordList writes a chat filter word list with the given modification
+// time.
+func writeWordList(t *testing.T, path, list string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(list), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChatFilterMasksListedWords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), chatFilterFile)
+	writeWordList(t, path, "# trail words\ndarn\nheck*\nkook\n\n", time.Now())
+	f := NewChatFilter(path)
+
+	for _, tc := range []struct{ in, want string }{
+		{"darn it", "**** it"},
+		{"DARN, the oxen!", "****, the oxen!"},
+		{"d4rn", "****"},
+		{"what the heckin wagon", "what the ****** wagon"},
+		{"darned", "darned"}, // only listed with * do longer words match
+		{"Good morning", "Good morning"},
+		{"\u212Aook", "****"}, // with the Kelvin sign for K
+	} {
+		if got := f.Filter(tc.in); got != tc.want {
+			t.Errorf("Filter(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestChatFilterReloadsItsList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), chatFilterFile)
+	f := NewChatFilter(path)
+	if got := f.Filter("darn"); got != "darn" {
+		t.Fatalf("with no word list, Filter = %q", got)
+	}
+
+	// The file is only checked every so often
+	recheck := func() {
+		f.mu.Lock()
+		f.lastCheck = time.Now().Add(-chatFilterCheckInterval)
+		f.mu.Unlock()
+	}
+	writeWordList(t, path, "darn\n", time.Now().Add(-time.Hour))
+	recheck()
+	if got := f.Filter("darn"); got != "****" {
+		t.Errorf("after adding the list, Filter = %q", got)
+	}
+	writeWordList(t, path, "heck\n", time.Now())
+	recheck()
+	if got := f.Filter("darn heck"); got != "darn ****" {
+		t.Errorf("after changing the list, Filter = %q", got)
+	}
+	os.Remove(path)
+	recheck()
+	if got := f.Filter("heck"); got != "heck" {
+		t.Errorf("after removing the list, Filter = %q", got)
+	}
+}
+
+func TestRoomChatModes(t *testing.T) {
+	s := newTestServer(t)
+	writeWordList(t, filepath.Join(s.dataPath, chatFilterFile), "darn\n", time.Now())
+	s.chatFilter = NewChatFilter(filepath.Join(s.dataPath, chatFilterFile))
+	room := newTestRoom(t, s)
+	room.mu.Lock()
+	room.ownerID = "client-ann"
+	room.mu.Unlock()
+
+	if open := s.GetRoom(s.defaultRoomID); open.chatMode != ChatFiltered {
+		t.Errorf("the open trail's chat is %s, want filtered", open.chatMode)
+	}
+	if got, err := s.ChatText(room.id, "darn"); err != nil || got != "darn" {
+		t.Errorf("open chat = %q, %v", got, err)
+	}
+	if err := s.SetChatMode(room.id, "client-bea", ChatDisabled); err == nil {
+		t.Error("a player who isn't the owner changed the chat mode")
+	}
+
+	if err := s.SetChatMode(room.id, "client-ann", ChatFiltered); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.ChatText(room.id, "darn"); err != nil || got != "****" {
+		t.Errorf("filtered chat = %q, %v", got, err)
+	}
+	if err := s.SetChatMode(room.id, "client-ann", ChatDisabled); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ChatText(room.id, "hello"); err == nil {
+		t.Error("a message was sent with chat turned off")
+	}
+
+	for in, want := range map[string]ChatMode{" Open ": ChatOpen, "FILTERED": ChatFiltered, "disabled": ChatDisabled, "loud": ""} {
+		if got, ok := parseChatMode(in); got != want || ok != (want != "") {
+			t.Errorf("parseChatMode(%q) = %q, %v", in, got, ok)
+		}
+	}
+}
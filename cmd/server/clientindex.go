@@ -0,0 +1,20 @@
+package main
+
+// indexClient records that a client joined a room, for FindRoomForClient.
+func (s *Server) indexClient(clientID, roomID string) {
+	s.clientRoomsMu.Lock()
+	defer s.clientRoomsMu.Unlock()
+	s.clientRooms[clientID] = roomID
+}
+
+// dropClient removes a client from a room and from the client index, unless
+// the index already points at a room the client joined since.
+// NOTE: caller must hold room.mu.
+func (s *Server) dropClient(room *GameRoom, clientID string) {
+	delete(room.clients, clientID)
+	s.clientRoomsMu.Lock()
+	defer s.clientRoomsMu.Unlock()
+	if s.clientRooms[clientID] == room.id {
+		delete(s.clientRooms, clientID)
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// addTestRooms adds n empty party rooms to the server directly, bypassing
+// the limits on how many rooms players may make.
+func addTestRooms(s *Server, n int) []*GameRoom {
+	rooms := make([]*GameRoom, n)
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+	for i := range rooms {
+		rooms[i] = NewGameRoom(fmt.Sprintf("room-%d", i), fmt.Sprintf("Room %d", i), RoomTypeScheduled, s.clock)
+		s.rooms[rooms[i].id] = rooms[i]
+	}
+	return rooms
+}
+
+// findRoomByScan is how FindRoomForClient worked before the index: look
+// through every room for the client. It is kept to benchmark against.
+func findRoomByScan(s *Server, clientID string) *GameRoom {
+	s.roomsMu.RLock()
+	defer s.roomsMu.RUnlock()
+	for _, room := range s.rooms {
+		room.mu.RLock()
+		_, ok := room.clients[clientID]
+		room.mu.RUnlock()
+		if ok {
+			return room
+		}
+	}
+	return nil
+}
+
+func TestClientIndexFollowsMoves(t *testing.T) {
+	s := newTestServer(t)
+	rooms := addTestRooms(s, 2)
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann"}, rooms[0].id)
+	if got := s.FindRoomForClient("client-ann"); got != rooms[0] {
+		t.Fatalf("FindRoomForClient = %v, want room 0", got)
+	}
+
+	// Joining the next room before the old one lets go
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann"}, rooms[1].id)
+	s.RemoveClient("client-ann", rooms[0].id)
+	if got := s.FindRoomForClient("client-ann"); got != rooms[1] {
+		t.Fatalf("after moving, FindRoomForClient = %v, want room 1", got)
+	}
+
+	s.RemoveClient("client-ann", rooms[1].id)
+	if got := s.FindRoomForClient("client-ann"); got != nil {
+		t.Errorf("after leaving, FindRoomForClient = %v, want nil", got)
+	}
+	s.clientRoomsMu.RLock()
+	defer s.clientRoomsMu.RUnlock()
+	if len(s.clientRooms) != 0 {
+		t.Errorf("index still holds %v", s.clientRooms)
+	}
+}
+
+// Run with -race.
+func TestClientIndexConcurrentJoinsAndLeaves(t *testing.T) {
+	s := newTestServer(t)
+	rooms := addTestRooms(s, 8)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				id := fmt.Sprintf("client-%d-%d", g, i)
+				from, to := rooms[(g+i)%len(rooms)], rooms[(g+i+1)%len(rooms)]
+				s.AddClient(&Client{ID: id, Name: id}, from.id)
+				if got := s.FindRoomForClient(id); got != from {
+					errs <- fmt.Errorf("%s joined %s but is found in %v", id, from.id, got)
+					return
+				}
+				s.AddClient(&Client{ID: id, Name: id}, to.id)
+				s.RemoveClient(id, from.id)
+				if got := s.FindRoomForClient(id); got != to {
+					errs <- fmt.Errorf("%s moved to %s but is found in %v", id, to.id, got)
+					return
+				}
+				if i%2 == 0 {
+					s.RemoveClient(id, to.id)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// The index agrees with the rooms
+	s.clientRoomsMu.RLock()
+	defer s.clientRoomsMu.RUnlock()
+	in := 0
+	for _, room := range rooms {
+		room.mu.RLock()
+		for id := range room.clients {
+			in++
+			if s.clientRooms[id] != room.id {
+				t.Errorf("%s is in %s but indexed in %q", id, room.id, s.clientRooms[id])
+			}
+		}
+		room.mu.RUnlock()
+	}
+	if len(s.clientRooms) != in || in != 16*25 {
+		t.Errorf("index holds %d clients, rooms %d; want %d", len(s.clientRooms), in, 16*25)
+	}
+}
+
+// Run with -race.
+func TestHubIndexConcurrentLookups(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	ts.AddClient(&Client{ID: "client-host", Name: "Host"}, room.id)
+
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func(id string) {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				ts.hub.SendToClient(id, []byte(`{"type":"ping"}`))
+				ts.hub.DisconnectClientInRoom(id, "no-such-room")
+				runtime.Gosched()
+			}
+		}(fmt.Sprintf("client-%d", i))
+	}
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 4; i++ {
+			id := fmt.Sprintf("client-%d", i)
+			c := registerConn(t, ts.hub, id, id, room.id)
+			unregisterConn(ts.hub, c)
+		}
+	}
+	close(stop)
+	readers.Wait()
+
+	ts.hub.mu.RLock()
+	defer ts.hub.mu.RUnlock()
+	if len(ts.hub.byID) != 0 || len(ts.hub.active) != 0 {
+		t.Errorf("hub still indexes %d clients and %d seats", len(ts.hub.byID), len(ts.hub.active))
+	}
+}
+
+// closed reports whether the server end of a connection has been closed.
+func closed(conn *websocket.Conn) bool {
+	return conn.WriteMessage(websocket.PingMessage, nil) != nil
+}
+
+func TestDisconnectClientClosesEveryConnection(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	other := newTestRoom(t, ts.Server)
+	ts.AddClient(&Client{ID: "client-host", Name: "Host"}, room.id)
+	// A reconnect whose old socket hasn't closed yet, and a tab in another
+	// room
+	old := registerConn(t, ts.hub, "client-x", "Ann", room.id)
+	latest := registerConn(t, ts.hub, "client-x", "Ann", room.id)
+	elsewhere := registerConn(t, ts.hub, "client-x", "Ann", other.id)
+	bystander := registerConn(t, ts.hub, "client-y", "Bea", room.id)
+
+	ts.hub.DisconnectClientInRoom("client-x", room.id)
+	if !closed(old.conn) || !closed(latest.conn) {
+		t.Error("DisconnectClientInRoom left one of the client's connections to the room open")
+	}
+	if closed(elsewhere.conn) {
+		t.Error("DisconnectClientInRoom closed the client's connection to another room")
+	}
+
+	ts.hub.DisconnectClient("client-x")
+	if !closed(elsewhere.conn) {
+		t.Error("DisconnectClient left a connection open")
+	}
+	if closed(bystander.conn) {
+		t.Error("DisconnectClient closed another client's connection")
+	}
+}
+
+// benchClients fills 100 rooms with 1,000 clients.
+func benchClients(b *testing.B) (*Server, []string) {
+	cfg := defaultConfig()
+	cfg.DataPath = b.TempDir()
+	if err := cfg.validate(); err != nil {
+		b.Fatal(err)
+	}
+	s := NewServer(&cfg)
+	rooms := addTestRooms(s, 100)
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("client-%d", i)
+		s.AddClient(&Client{ID: ids[i], Name: ids[i]}, rooms[i%len(rooms)].id)
+	}
+	return s, ids
+}
+
+func BenchmarkFindRoomForClient(b *testing.B) {
+	s, ids := benchClients(b)
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if findRoomByScan(s, ids[i%len(ids)]) == nil {
+				b.Fatal("not found")
+			}
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if s.FindRoomForClient(ids[i%len(ids)]) == nil {
+				b.Fatal("not found")
+			}
+		}
+	})
+}
+
+func BenchmarkHubClientLookup(b *testing.B) {
+	h := &Hub{clients: make(map[*websocket.Conn]*wsClient), byID: make(map[string]*wsClient)}
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("client-%d", i)
+		c := &wsClient{clientID: ids[i], conn: new(websocket.Conn)}
+		h.clients[c.conn] = c
+		h.byID[ids[i]] = c
+	}
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			id := ids[i%len(ids)]
+			var found *wsClient
+			h.mu.RLock()
+			for _, c := range h.clients {
+				if c.clientID == id {
+					found = c
+					break
+				}
+			}
+			h.mu.RUnlock()
+			if found == nil {
+				b.Fatal("not found")
+			}
+		}
+	})
+	b.Run("index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			h.mu.RLock()
+			found := h.byID[ids[i%len(ids)]]
+			h.mu.RUnlock()
+			if found == nil {
+				b.Fatal("not found")
+			}
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package main
+
+import "time"
+
+// Clock is where the server gets the time and schedules work. Turn clocks,
+// room cleanup and loot decay go through it rather than the time package,
+// so their timing can be driven by something other than the wall clock.
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a pending call scheduled by a Clock's AfterFunc.
+type Timer interface {
+	Stop() bool
+}
+
+// Ticker delivers ticks on C until stopped.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// realClock is the wall clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct{ t *time.Ticker }
+
+func (r realTicker) C() <-chan time.Time { return r.t.C }
+
+func (r realTicker) Stop() { r.t.Stop() }
+
+// every calls f every d on the server's clock, for as long as the server
+// runs.
+func (s *Server) every(d time.Duration, f func()) {
+	ticker := s.clock.NewTicker(d)
+	go func() {
+		defer ticker.Stop()
+		for range ticker.C() {
+			f()
+		}
+	}()
+}
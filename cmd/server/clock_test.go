@@ -0,0 +1,455 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// fakeClock is a Clock that stands still until Advance moves it, firing the
+// timers that fall due on the way.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	tickers []*fakeTicker
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+type fakeTimer struct {
+	clock *fakeClock
+	at    time.Time
+	f     func()
+	done  bool // fired or stopped
+}
+
+type fakeTicker struct {
+	clock *fakeClock
+	c     chan time.Time
+	every time.Duration
+	next  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{clock: c, c: make(chan time.Time, 1), every: d, next: c.now.Add(d)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	pending := !t.done
+	t.done = true
+	return pending
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	for i, other := range t.clock.tickers {
+		if other == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			break
+		}
+	}
+}
+
+// Advance moves the clock on by d. Timers due by then run in the order
+// they fall due, each with the clock at its time, and may schedule more.
+// Tickers get at most one pending tick, as time.Ticker drops slow ones.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	c.mu.Unlock()
+	for {
+		c.mu.Lock()
+		sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].at.Before(c.timers[j].at) })
+		var next *fakeTimer
+		for _, t := range c.timers {
+			if !t.done && !t.at.After(end) {
+				next = t
+				break
+			}
+		}
+		if next == nil {
+			c.now = end
+			c.tick()
+			c.mu.Unlock()
+			return
+		}
+		if next.at.After(c.now) {
+			c.now = next.at
+		}
+		next.done = true
+		c.tick()
+		c.mu.Unlock()
+		next.f()
+	}
+}
+
+// tick delivers the ticks due by now.
+// NOTE: caller must hold c.mu.
+func (c *fakeClock) tick() {
+	for _, t := range c.tickers {
+		for !t.next.After(c.now) {
+			select {
+			case t.c <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.every)
+		}
+	}
+}
+
+// newFakeClockServer is newTestServer on a fake clock.
+func newFakeClockServer(t *testing.T) (*Server, *fakeClock) {
+	t.Helper()
+	s := newTestServer(t)
+	clock := newFakeClock()
+	s.clock = clock
+	s.sessionManager.clock = clock
+	for _, room := range s.rooms {
+		room.clock = clock
+	}
+	return s, clock
+}
+
+// startPartyGame seats Ann then Bea in a fresh party room and starts it,
+// so it is Ann's turn.
+func startPartyGame(t *testing.T, s *Server) *GameRoom {
+	t.Helper()
+	room := newTestRoom(t, s)
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann"}, room.id)
+	s.AddClient(&Client{ID: "client-bea", Name: "Bea"}, room.id)
+	room.mu.Lock()
+	room.ownerID = "client-ann"
+	room.mu.Unlock()
+	if err := s.StartGame(room.id, "client-ann", true); err != nil {
+		t.Fatal(err)
+	}
+	return room
+}
+
+func TestTurnTimesOut(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	start := clock.Now()
+	room := startPartyGame(t, s)
+
+	clock.Advance(s.turnTime - time.Second)
+	room.mu.RLock()
+	current := room.game.GetCurrentPlayer().ID
+	room.mu.RUnlock()
+	if current != "client-ann" {
+		t.Fatalf("turn passed to %s before the clock ran out", current)
+	}
+
+	clock.Advance(2 * time.Second)
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if cp := room.game.GetCurrentPlayer(); cp == nil || cp.ID != "client-bea" {
+		t.Errorf("after the turn clock ran out the turn is %v's, want Bea's", cp)
+	}
+	if room.timeouts["client-ann"] != 1 {
+		t.Errorf("Ann's timeouts = %d, want 1", room.timeouts["client-ann"])
+	}
+	// Bea's clock starts when Ann's runs out
+	if want := start.Add(2 * s.turnTime); !room.turnDeadline.Equal(want) {
+		t.Errorf("Bea's turn ends at %v, want %v", room.turnDeadline, want)
+	}
+}
+
+func TestClosedRoomIgnoresLateTimers(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	room := startPartyGame(t, s)
+
+	s.roomsMu.Lock()
+	s.closeRoom(room)
+	delete(s.rooms, room.id)
+	s.roomsMu.Unlock()
+
+	room.mu.RLock()
+	armed := room.turnTimer != nil || room.warnTimer != nil || !room.turnDeadline.IsZero()
+	room.mu.RUnlock()
+	if armed {
+		t.Error("closing the room left its turn clock running")
+	}
+
+	// A timeout already waiting on the lock when the room closed, and
+	// anything trying to restart the clock, find it closed
+	s.handleTurnTimeout(room, "client-ann")
+	room.mu.Lock()
+	s.StartTurnTimer(room, "client-ann")
+	rearmed := room.turnTimer != nil
+	room.mu.Unlock()
+	clock.Advance(2 * s.turnTime)
+
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if rearmed {
+		t.Error("a closed room's turn clock started again")
+	}
+	if cp := room.game.GetCurrentPlayer(); cp == nil || cp.ID != "client-ann" || room.timeouts["client-ann"] != 0 {
+		t.Errorf("a late timeout played on: Ann's timeouts = %d", room.timeouts["client-ann"])
+	}
+}
+
+func TestClosedRoomTurnsAwayJoins(t *testing.T) {
+	s := newTestServer(t)
+	room := newTestRoom(t, s)
+	// A join that looked the room up just before cleanup closed it
+	s.roomsMu.Lock()
+	s.closeRoom(room)
+	s.roomsMu.Unlock()
+
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann"}, room.id)
+	if inRoom(room, "client-ann") {
+		t.Error("a player joined a closed room")
+	}
+	if r := s.FindRoomForClient("client-ann"); r != nil {
+		t.Errorf("the closed room's player is indexed in %s", r.id)
+	}
+}
+
+func TestLootDecaysOverThreeDays(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	room := s.GetRoom(s.defaultRoomID)
+	wagon := game.NewGameState()
+	wagon.Food, wagon.Bullets = 400, 200
+	site := lootSiteFromGame("client-ann", "Ann", wagon, s.clock.Now())
+	room.mu.Lock()
+	room.game.LootSites = append(room.game.LootSites, site)
+	room.mu.Unlock()
+
+	// The decay job runs daily; how often only smooths the decay
+	for day := 0; day < 3; day++ {
+		clock.Advance(24 * time.Hour)
+		s.deteriorateLootSites()
+	}
+
+	want := site
+	want.Decay(site.DateCreated.Add(72 * time.Hour))
+	room.mu.RLock()
+	got := room.game.LootSites[len(room.game.LootSites)-1]
+	room.mu.RUnlock()
+	if !got.LastDecayedAt.Equal(clock.Now()) {
+		t.Errorf("site last decayed at %v, want %v", got.LastDecayedAt, clock.Now())
+	}
+	if got.Food >= site.Food || got.Bullets >= site.Bullets {
+		t.Fatalf("three days left the site unchanged: %+v", got)
+	}
+	if diff := got.Food - want.Food; diff > 0.001 || diff < -0.001 {
+		t.Errorf("food after three days = %.3f, want %.3f", got.Food, want.Food)
+	}
+}
+
+func TestLootDecayCarriesOverRestarts(t *testing.T) {
+	cfg := testConfig(t)
+	clock := newFakeClock()
+	// restart starts a new server on the same data, as a redeploy does
+	restart := func() (*Server, *GameRoom) {
+		s := startServer(t, cfg)
+		s.clock = clock
+		return s, s.GetRoom(s.defaultRoomID)
+	}
+
+	s, room := restart()
+	wagon := game.NewGameState()
+	wagon.Food, wagon.Bullets = 100, 50
+	site := lootSiteFromGame("client-ann", "Ann", wagon, clock.Now())
+	site.LastDecayedAt = clock.Now()
+	legacy := site
+	legacy.ID, legacy.LastDecayedAt = "legacy-site", time.Time{}
+	room.mu.Lock()
+	room.game.LootSites = append(room.game.LootSites, site, legacy)
+	room.mu.Unlock()
+	s.saveGameState(room)
+
+	// Down for three days, then one sweep catches up
+	clock.Advance(72 * time.Hour)
+	s, room = restart()
+	s.deteriorateLootSites()
+	want := site
+	want.Decay(clock.Now())
+	find := func(id string) game.LootSite {
+		room.mu.RLock()
+		defer room.mu.RUnlock()
+		for _, l := range room.game.LootSites {
+			if l.ID == id {
+				return l
+			}
+		}
+		t.Fatalf("site %s wasn't loaded", id)
+		return game.LootSite{}
+	}
+	got := find(site.ID)
+	if diff := got.Food - want.Food; diff > 0.001 || diff < -0.001 || got.Food >= site.Food {
+		t.Fatalf("food after three days down = %.3f, want %.3f", got.Food, want.Food)
+	}
+	// A site saved before sites kept their own clock starts it now
+	if old := find(legacy.ID); old.Food != legacy.Food || !old.LastDecayedAt.Equal(clock.Now()) {
+		t.Errorf("legacy site = %.1f food, last decayed %v; want it untouched, starting now", old.Food, old.LastDecayedAt)
+	}
+	s.saveGameState(room)
+
+	// Restarting straight away decays nothing twice
+	s, room = restart()
+	s.deteriorateLootSites()
+	if again := find(site.ID); again.Food != got.Food {
+		t.Errorf("a second sweep after a restart took food to %.3f from %.3f", again.Food, got.Food)
+	}
+}
+
+func TestWaitingRoomExpiresAfterADay(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	room := newTestRoom(t, s)
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann"}, room.id)
+	room.mu.Lock()
+	room.ownerID = "client-ann"
+	room.mu.Unlock()
+
+	clock.Advance(23 * time.Hour)
+	s.CleanupStaleRooms()
+	if s.GetRoom(room.id) == nil {
+		t.Fatal("waiting room cleaned up before 24 hours")
+	}
+	clock.Advance(2 * time.Hour)
+	s.CleanupStaleRooms()
+	if s.GetRoom(room.id) != nil {
+		t.Error("waiting room still open after 25 hours")
+	}
+}
+
+func TestUnclaimedRoomExpires(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	room := newTestRoom(t, s)
+
+	clock.Advance(unclaimedRoomTTL - time.Second)
+	s.CleanupStaleRooms()
+	if s.GetRoom(room.id) == nil {
+		t.Fatal("unclaimed room cleaned up before its creator had time to connect")
+	}
+	clock.Advance(2 * time.Second)
+	s.CleanupStaleRooms()
+	if s.GetRoom(room.id) != nil {
+		t.Error("unclaimed room still open after unclaimedRoomTTL")
+	}
+}
+
+func TestUnclaimedRoomWithPlayersStays(t *testing.T) {
+	// Someone is in the room, though nobody owns it, as when its only
+	// joiner so far is watching
+	s, clock := newFakeClockServer(t)
+	room := newTestRoom(t, s)
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann", Spectator: true}, room.id)
+	room.mu.Lock()
+	room.ownerID = ""
+	room.mu.Unlock()
+
+	clock.Advance(unclaimedRoomTTL + time.Minute)
+	s.CleanupStaleRooms()
+	if s.GetRoom(room.id) == nil {
+		t.Error("an unclaimed room with someone in it was closed")
+	}
+}
+
+func TestFortVisitTimesOut(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	room := startPartyGame(t, s)
+	room.mu.Lock()
+	room.game.FortAvailable = true
+	room.mu.Unlock()
+	if r := s.HandleFortEnter("client-ann", room.id); r.Refusal() != nil {
+		t.Fatalf("enter fort: %s", r)
+	}
+
+	clock.Advance(fortVisitLimit - time.Second)
+	room.mu.RLock()
+	phase := room.game.TurnPhase
+	room.mu.RUnlock()
+	if phase != game.PhaseFort {
+		t.Fatalf("walked out of the fort early, phase %v", phase)
+	}
+	clock.Advance(2 * time.Second)
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if room.game.TurnPhase == game.PhaseFort {
+		t.Error("still in the fort after the visit limit")
+	}
+}
+
+func TestDroppedPlayerIsRemovedAfterTheGrace(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	room := startPartyGame(t, s)
+	if !s.RemoveClient("client-bea", room.id) {
+		t.Fatal("Bea's seat wasn't held when she dropped")
+	}
+
+	held := func() bool {
+		room.mu.RLock()
+		defer room.mu.RUnlock()
+		return room.hasSeat("client-bea")
+	}
+	clock.Advance(reconnectGrace - time.Second)
+	if !held() || !inRoom(room, "client-bea") {
+		t.Fatal("Bea lost her seat before the grace ran out")
+	}
+	clock.Advance(2 * time.Second)
+	if held() || inRoom(room, "client-bea") {
+		t.Error("Bea still holds her seat after the grace ran out")
+	}
+}
+
+func TestSessionsAreSweptOnceTheirCookieExpires(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	sm := s.sessionManager
+	gone := sm.CreateSession("Ann", "client-ann", "")
+	here := sm.CreateSession("Bea", "client-bea", "")
+	sm.RestoreSession(hashSessionID("cookie-cy"), "Cy", "client-cy", "room-1")
+
+	clock.Advance(time.Hour)
+	sm.RemoveClient("client-ann")
+	clock.Advance(sessionTTL - time.Minute)
+	if n := sm.Sweep(); n != 1 {
+		t.Errorf("swept %d sessions, want only Cy's unclaimed one", n)
+	}
+	if sm.GetSession(gone) == nil {
+		t.Fatal("Ann's session was swept before her cookie expired")
+	}
+
+	clock.Advance(2 * time.Minute)
+	if n := sm.Sweep(); n != 1 {
+		t.Errorf("swept %d sessions, want Ann's", n)
+	}
+	if sm.GetSession(gone) != nil {
+		t.Error("Ann's session outlived her cookie")
+	}
+	// A connected player's session stays however long they play
+	if sm.GetSession(here) == nil {
+		t.Error("Bea's session was swept while she was connected")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"log"
+	"time"
+)
+
+// Compression. Browsers offer permessage-deflate and the upgrader takes it
+// up; writePump compresses only messages of compressMinBytes or more, since
+// small ones don't shrink enough to pay for it. A client without the
+// extension can ask for ?gzip=1 and is sent large states gzipped in a
+// "state_gz" message instead. Either way the compressed write is under the
+// usual write deadline, so a client too slow to take it is dropped as any
+// slow client is.
+const (
+	compressMinBytes     = 2 << 10
+	stateSizeLogInterval = time.Minute
+)
+
+// gzipState gzips a state's JSON and base64-encodes it for a state_gz
+// message's data_b64. It returns "" if the state couldn't be compressed.
+func gzipState(stateJSON []byte) string {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(stateJSON); err != nil {
+		return ""
+	}
+	if err := zw.Close(); err != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// logStateSize logs how large a state is and how large it is compressed, at
+// most once every stateSizeLogInterval. gzJSON is the state_gz message if
+// one was built for this broadcast.
+func (h *Hub) logStateSize(roomID string, msgJSON, stateJSON, gzJSON []byte) {
+	if len(msgJSON) < compressMinBytes {
+		return
+	}
+	h.sizeLogMu.Lock()
+	due := time.Since(h.sizeLoggedAt) >= stateSizeLogInterval
+	if due {
+		h.sizeLoggedAt = time.Now()
+	}
+	h.sizeLogMu.Unlock()
+	if !due {
+		return
+	}
+
+	if gzJSON == nil {
+		gzJSON = stateMessage(stateJSON, gzipState(stateJSON), 0)
+	}
+	log.Printf("State for room %s: %d bytes, %d as state_gz (%.0f%%)",
+		roomID, len(msgJSON), len(gzJSON), 100*float64(len(gzJSON))/float64(len(msgJSON)))
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is everything an operator can tune. Each setting starts at its
+// default, then is read from the JSON file named by -config or CONFIG_FILE,
+// then from its environment variable (the setting's key in upper case),
+// then from its flag, each overriding the one before.
+type Config struct {
+	HTTPPort       string `json:"http_port"`
+	HTTPSPort      string `json:"https_port"`
+	TLSCert        string `json:"tls_cert"`
+	TLSKey         string `json:"tls_key"`
+	AutocertDomain string `json:"autocert_domain"`
+	TCPAddr        string `json:"tcp_addr"`
+
+	DataPath   string `json:"data_path"`
+	StaticDir  string `json:"static_dir"`
+	AdminToken string `json:"admin_token"`
+	SaveSecret string `json:"save_secret"`
+
+	PersistentRooms  string `json:"persistent_rooms"`
+	ContinuousShards int    `json:"continuous_shards"`
+	DefaultRoom      string `json:"default_room"`
+	SeasonalReset    bool   `json:"seasonal_reset"`
+	NPCSites         int    `json:"npc_sites"`
+	NPCGraves        int    `json:"npc_graves"`
+
+	MaxRooms          int `json:"max_rooms"`
+	MaxScheduledRooms int `json:"max_scheduled_rooms"`
+	MaxPartyRooms     int `json:"max_party_rooms"`
+	MaxContinuousRuns int `json:"max_continuous_runs"`
+
+	RoomCreateLimit  int      `json:"room_create_limit"`
+	RoomCreateWindow Duration `json:"room_create_window"`
+
+	TurnTime            Duration `json:"turn_time"`
+	FortInterval        int      `json:"fort_interval"`
+	LootDecayInterval   Duration `json:"loot_decay_interval"`
+	RoomCleanupInterval Duration `json:"room_cleanup_interval"`
+	OwnerIdleTimeout    Duration `json:"owner_idle_timeout"`
+
+	AllowedOrigins  string `json:"allowed_origins"`
+	DevAnyOrigin    bool   `json:"dev_any_origin"`
+	MaxMessageBytes int64  `json:"max_message_bytes"`
+
+	// Parsed by validate
+	rooms   []PersistentRoomConfig
+	origins *originPolicy
+}
+
+// defaultConfig is the configuration with nothing set.
+func defaultConfig() Config {
+	return Config{
+		HTTPPort:         "8080",
+		HTTPSPort:        "443",
+		DataPath:         "./data",
+		PersistentRooms:  legacyRoomID + "=The Open Trail",
+		ContinuousShards: 1,
+		NPCSites:         defaultNPCSeed.Sites,
+		NPCGraves:        defaultNPCSeed.Graves,
+
+		MaxScheduledRooms: 100,
+		MaxPartyRooms:     2,
+		MaxContinuousRuns: 1,
+
+		RoomCreateLimit:  5,
+		RoomCreateWindow: Duration(10 * time.Minute),
+
+		TurnTime:            Duration(20 * time.Second),
+		FortInterval:        3,
+		LootDecayInterval:   Duration(24 * time.Hour),
+		RoomCleanupInterval: Duration(5 * time.Minute),
+		OwnerIdleTimeout:    Duration(5 * time.Minute),
+
+		MaxMessageBytes: defaultMaxMessageBytes,
+	}
+}
+
+// Duration is a time.Duration written like "20s" or "24h".
+type Duration time.Duration
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+func (d *Duration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("durations are written like \"20s\": %v", err)
+	}
+	return d.Set(s)
+}
+
+// setting is one Config field as it appears in each layer.
+type setting struct {
+	key    string // in the config file; upper-cased, the environment variable
+	flag   string // "" if it can't be set by flag
+	usage  string
+	secret bool
+	field  func(c *Config) interface{}
+}
+
+var settings = []setting{
+	{"http_port", "http", "HTTP server port (redirects to HTTPS when TLS is on)", false, func(c *Config) interface{} { return &c.HTTPPort }},
+	{"https_port", "https", "HTTPS server port, when TLS is on", false, func(c *Config) interface{} { return &c.HTTPSPort }},
+	{"tls_cert", "tls-cert", "TLS certificate file", false, func(c *Config) interface{} { return &c.TLSCert }},
+	{"tls_key", "tls-key", "TLS key file", false, func(c *Config) interface{} { return &c.TLSKey }},
+	{"autocert_domain", "autocert-domain", "Domain to fetch a Let's Encrypt certificate for", false, func(c *Config) interface{} { return &c.AutocertDomain }},
+	{"tcp_addr", "tcp", "Address to serve the terminal client's TCP game on, e.g. :5555 (off if empty)", false, func(c *Config) interface{} { return &c.TCPAddr }},
+	{"data_path", "data", "Directory for saved games, the leaderboard and certificates", false, func(c *Config) interface{} { return &c.DataPath }},
+	{"static_dir", "static-dir", "Serve the web client from this directory instead of the built-in copy", false, func(c *Config) interface{} { return &c.StaticDir }},
+	{"admin_token", "", "", true, func(c *Config) interface{} { return &c.AdminToken }},
+	{"save_secret", "", "", true, func(c *Config) interface{} { return &c.SaveSecret }},
+	{"persistent_rooms", "persistent-rooms", `Persistent continuous rooms as comma-separated id=name pairs ("none" disables the open trail)`, false, func(c *Config) interface{} { return &c.PersistentRooms }},
+	{"continuous_shards", "continuous-shards", "How many shards to split the open trail into; new players go to the emptiest", false, func(c *Config) interface{} { return &c.ContinuousShards }},
+	{"default_room", "default-room", "Room players join when none is given (default: first persistent room)", false, func(c *Config) interface{} { return &c.DefaultRoom }},
+	{"seasonal_reset", "seasonal-reset", "Reset a continuous trail for a new season a day after someone wins it", false, func(c *Config) interface{} { return &c.SeasonalReset }},
+	{"npc_sites", "npc-sites", "NPC wagons to seed on a fresh open trail", false, func(c *Config) interface{} { return &c.NPCSites }},
+	{"npc_graves", "npc-graves", "NPC graves to seed on a fresh open trail", false, func(c *Config) interface{} { return &c.NPCGraves }},
+	{"max_rooms", "max-rooms", "Max rooms players may create (0 = unlimited)", false, func(c *Config) interface{} { return &c.MaxRooms }},
+	{"max_scheduled_rooms", "max-scheduled-rooms", "Max party rooms open at once (0 = unlimited)", false, func(c *Config) interface{} { return &c.MaxScheduledRooms }},
+	{"room_create_limit", "room-create-limit", "Rooms one address may create per room_create_window (0 = unlimited)", false, func(c *Config) interface{} { return &c.RoomCreateLimit }},
+	{"room_create_window", "room-create-window", "Window room_create_limit counts over", false, func(c *Config) interface{} { return &c.RoomCreateWindow }},
+	{"max_party_rooms", "max-party-rooms", "Max party rooms one player may be seated in at once (0 = unlimited)", false, func(c *Config) interface{} { return &c.MaxPartyRooms }},
+	{"max_continuous_runs", "max-continuous-runs", "Max continuous wagons one player may hold at once (0 = unlimited)", false, func(c *Config) interface{} { return &c.MaxContinuousRuns }},
+	{"turn_time", "turn-time", "Time a player has for each turn in a scheduled game", false, func(c *Config) interface{} { return &c.TurnTime }},
+	{"fort_interval", "fort-interval", "A fort appears every this many turns, unless a room sets its own", false, func(c *Config) interface{} { return &c.FortInterval }},
+	{"loot_decay_interval", "loot-decay-interval", "How often abandoned wagons' supplies decay", false, func(c *Config) interface{} { return &c.LootDecayInterval }},
+	{"room_cleanup_interval", "room-cleanup-interval", "How often empty rooms are closed", false, func(c *Config) interface{} { return &c.RoomCleanupInterval }},
+	{"owner_idle_timeout", "owner-idle-timeout", "Hand a waiting room to another player when its owner has been idle this long (0 = never)", false, func(c *Config) interface{} { return &c.OwnerIdleTimeout }},
+	{"allowed_origins", "allowed-origins", "Comma-separated origins, besides this server's own, whose pages may open websockets and call the API", false, func(c *Config) interface{} { return &c.AllowedOrigins }},
+	{"dev_any_origin", "dev-any-origin", "Let pages from any origin open websockets and call the API (development only)", false, func(c *Config) interface{} { return &c.DevAnyOrigin }},
+	{"max_message_bytes", "max-message-bytes", "Largest websocket message a client may send; bigger ones close the connection", false, func(c *Config) interface{} { return &c.MaxMessageBytes }},
+}
+
+// LoadConfig builds the configuration from the config file, environment
+// and command line args, and validates it.
+func LoadConfig(args []string) (*Config, error) {
+	cfg := defaultConfig()
+
+	// Flags are parsed first, to find the config file, but applied last
+	fromFlags := defaultConfig()
+	fs := flag.NewFlagSet("server", flag.ExitOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "JSON config file (or set CONFIG_FILE)")
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		usage := s.usage + " (or set " + strings.ToUpper(s.key) + ")"
+		switch p := s.field(&fromFlags).(type) {
+		case *string:
+			fs.StringVar(p, s.flag, *p, usage)
+		case *int:
+			fs.IntVar(p, s.flag, *p, usage)
+		case *int64:
+			fs.Int64Var(p, s.flag, *p, usage)
+		case *bool:
+			fs.BoolVar(p, s.flag, *p, usage)
+		case *Duration:
+			fs.Var(p, s.flag, usage)
+		}
+	}
+	fs.Parse(args)
+
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("%s: %v", *configFile, err)
+		}
+	}
+
+	for _, s := range settings {
+		env := strings.ToUpper(s.key)
+		if v := os.Getenv(env); v != "" {
+			if err := setValue(s.field(&cfg), v); err != nil {
+				return nil, fmt.Errorf("%s: %v", env, err)
+			}
+		}
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, s := range settings {
+		if set[s.flag] {
+			setValue(s.field(&cfg), formatValue(s.field(&fromFlags)))
+		}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func setValue(field interface{}, v string) error {
+	var err error
+	switch p := field.(type) {
+	case *string:
+		*p = v
+	case *int:
+		*p, err = strconv.Atoi(v)
+	case *int64:
+		*p, err = strconv.ParseInt(v, 10, 64)
+	case *bool:
+		*p, err = strconv.ParseBool(v)
+	case *Duration:
+		err = p.Set(v)
+	}
+	return err
+}
+
+func formatValue(field interface{}) string {
+	switch p := field.(type) {
+	case *string:
+		return *p
+	case *int:
+		return strconv.Itoa(*p)
+	case *int64:
+		return strconv.FormatInt(*p, 10)
+	case *bool:
+		return strconv.FormatBool(*p)
+	case *Duration:
+		return p.String()
+	}
+	return ""
+}
+
+// validate checks the settings fit together, and parses the ones that need
+// it.
+func (c *Config) validate() error {
+	for _, port := range []struct{ key, value string }{{"http_port", c.HTTPPort}, {"https_port", c.HTTPSPort}} {
+		if n, err := strconv.Atoi(port.value); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("%s: %q is not a port number", port.key, port.value)
+		}
+	}
+	if err := c.tlsSettings().validate(); err != nil {
+		return err
+	}
+	if c.DataPath == "" {
+		return errors.New("data_path must be set")
+	}
+
+	switch {
+	case c.TurnTime < Duration(time.Second):
+		return fmt.Errorf("turn_time: %v is too short to play in", c.TurnTime)
+	case c.FortInterval < 1:
+		return errors.New("fort_interval must be at least 1")
+	case c.LootDecayInterval <= 0:
+		return errors.New("loot_decay_interval must be positive")
+	case c.RoomCleanupInterval <= 0:
+		return errors.New("room_cleanup_interval must be positive")
+	case c.OwnerIdleTimeout < 0:
+		return errors.New("owner_idle_timeout can't be negative")
+	case c.MaxMessageBytes < 1024:
+		return errors.New("max_message_bytes must be at least 1024")
+	case c.MaxRooms < 0 || c.MaxScheduledRooms < 0 || c.MaxPartyRooms < 0 || c.MaxContinuousRuns < 0 || c.RoomCreateLimit < 0:
+		return errors.New("room limits can't be negative")
+	case c.RoomCreateWindow <= 0:
+		return errors.New("room_create_window must be positive")
+	case c.NPCSites < 0 || c.NPCGraves < 0:
+		return errors.New("npc_sites and npc_graves can't be negative")
+	case c.ContinuousShards < 1 || c.ContinuousShards > maxContinuousShards:
+		return fmt.Errorf("continuous_shards must be between 1 and %d", maxContinuousShards)
+	}
+
+	rooms, err := parsePersistentRooms(c.PersistentRooms)
+	if err != nil {
+		return fmt.Errorf("persistent_rooms: %v", err)
+	}
+	if rooms, err = addShards(rooms, c.ContinuousShards); err != nil {
+		return fmt.Errorf("continuous_shards: %v", err)
+	}
+	c.rooms = rooms
+	if c.DefaultRoom != "" {
+		found := false
+		for _, room := range rooms {
+			found = found || room.ID == c.DefaultRoom
+		}
+		if !found {
+			return fmt.Errorf("default_room: %q is not a persistent room", c.DefaultRoom)
+		}
+	}
+
+	origins, err := parseAllowedOrigins(c.AllowedOrigins)
+	if err != nil {
+		return fmt.Errorf("allowed_origins: %v", err)
+	}
+	origins.any = c.DevAnyOrigin
+	c.origins = origins
+	return nil
+}
+
+func (c *Config) tlsSettings() tlsSettings {
+	return tlsSettings{
+		certFile:       c.TLSCert,
+		keyFile:        c.TLSKey,
+		autocertDomain: c.AutocertDomain,
+		httpsPort:      c.HTTPSPort,
+	}
+}
+
+// logEffective logs every setting as it ended up, with secrets hidden.
+func (c *Config) logEffective() {
+	log.Println("Configuration:")
+	for _, s := range settings {
+		value := formatValue(s.field(c))
+		if s.secret {
+			value = "(unset)"
+			if formatValue(s.field(c)) != "" {
+				value = "(set)"
+			}
+		}
+		log.Printf("  %-22s %s", s.key, value)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"online-trail/pkg/game"
+)
+
+// fortTurns advances a fresh wagon through turns and returns the turns that
+// had a fort.
+func fortTurns(s *Server, room *GameRoom, g *game.GameState, turns int) []int {
+	var forts []int
+	for g.TurnNumber < turns {
+		s.advancePlayerTurn(room, g)
+		if g.FortAvailable {
+			forts = append(forts, g.TurnNumber)
+		}
+	}
+	return forts
+}
+
+// wagon returns a one-party game on the given turn.
+func wagon(name string, turn int) *game.GameState {
+	g := game.NewGameState()
+	g.AddPlayer(name, game.PlayerTypeHuman)
+	g.TurnNumber = turn
+	return g
+}
+
+func TestEachWagonGetsFortsOnSchedule(t *testing.T) {
+	s := newTestServer(t)
+	room := s.GetRoom(s.defaultRoomID)
+	ann, bea := wagon("Ann", 1), wagon("Bea", 5)
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if got := fortTurns(s, room, ann, 10); !equalInts(got, []int{3, 6, 9}) {
+		t.Errorf("Ann's forts on turns %v, want 3, 6 and 9", got)
+	}
+	// A wagon that set off later keeps the same cadence
+	if got := fortTurns(s, room, bea, 13); !equalInts(got, []int{6, 9, 12}) {
+		t.Errorf("Bea's forts on turns %v, want 6, 9 and 12", got)
+	}
+	if ann.FortAvailable {
+		t.Error("Ann's fort was still there the turn after")
+	}
+
+	room.rules.FortInterval = 5
+	cy := wagon("Cy", 1)
+	if got := fortTurns(s, room, cy, 11); !equalInts(got, []int{5, 10}) {
+		t.Errorf("with the room's own interval, forts on turns %v, want 5 and 10", got)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// fortVisitLimit caps one fort visit. Entering a fort stops the turn clock,
+// so a player still inside when this runs out is walked out as if they had
+// left, and the game goes on.
+const fortVisitLimit = 2 * time.Minute
+
+const (
+	alreadyInFortMsg = "You're already inside the fort.\n"
+	notInFortMsg     = "You're not at a fort.\n"
+)
+
+// startFortTimer starts clientID's fort visit clock.
+// NOTE: caller must hold room.mu.
+func (s *Server) startFortTimer(room *GameRoom, clientID string) {
+	s.stopFortTimer(room, clientID)
+	var t Timer
+	t = s.clock.AfterFunc(fortVisitLimit, func() {
+		s.handleFortTimeout(room, clientID, t)
+	})
+	room.fortTimers[clientID] = t
+}
+
+// stopFortTimer stops clientID's fort visit clock, if running.
+// NOTE: caller must hold room.mu.
+func (s *Server) stopFortTimer(room *GameRoom, clientID string) {
+	if t, ok := room.fortTimers[clientID]; ok {
+		t.Stop()
+		delete(room.fortTimers, clientID)
+	}
+}
+
+// handleFortTimeout makes a player who stayed in the fort past
+// fortVisitLimit leave it. A paused room keeps them there; its turn clock
+// takes over when it resumes.
+func (s *Server) handleFortTimeout(room *GameRoom, clientID string, t Timer) {
+	room.mu.Lock()
+	if room.fortTimers[clientID] != t {
+		// Left already, or a later visit started its own clock
+		room.mu.Unlock()
+		return
+	}
+	delete(room.fortTimers, clientID)
+	g := room.game
+	if room.perPlayerGames() {
+		g = room.playerGames[clientID]
+	}
+	inFort := g != nil && g.TurnPhase == game.PhaseFort && !room.closed && !room.paused
+	room.mu.Unlock()
+	if !inFort {
+		return
+	}
+
+	result := s.HandleFortLeave(clientID, room.id)
+	if result.Refusal() != nil {
+		return
+	}
+	s.QueueNotice(clientID, Notice{
+		Kind:      "fort_timeout",
+		RoomID:    room.id,
+		Message:   "You stayed at the fort too long, so your party moved on.",
+		CreatedAt: s.clock.Now(),
+	})
+	if s.hub != nil {
+		s.hub.BroadcastTrailNewsTo(room.id)
+		s.hub.BroadcastGameOverTo(room.id)
+		s.hub.BroadcastStateTo(room.id)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+
+	"online-trail/pkg/game"
+)
+
+// GameOverSummary is the game_over message sent when a game ends: the
+// leaderboard mode it was recorded under, the summary of every wagon that
+// finished and, when a continuous player won, their hall of fame place.
+type GameOverSummary struct {
+	RoomID   string             `json:"room_id"`
+	Mode     string             `json:"mode"`
+	Wagons   []game.GameSummary `json:"wagons"`
+	Champion *Champion          `json:"champion,omitempty"`
+}
+
+// recordGameOver puts the players' runs in a finished wagon on the
+// leaderboard, keeps them among the journeys if the wagon won, and adds the
+// wagon to the summary waiting for the next BroadcastGameOverTo.
+// NOTE: caller must hold room.mu.
+func (s *Server) recordGameOver(room *GameRoom, g *game.GameState, mode string, players []*game.Player) {
+	wagon := g.Summary()
+	for _, p := range players {
+		placement := s.leaderboard.AddEntry(p.ID, p.Name, g.Win, g.Mileage, g.TurnNumber,
+			g.ComputeScore(p), g.CountSurvivors(p), mode, string(p.Occupation))
+		if g.Win && s.journeys != nil {
+			s.journeys.Add(newJourneyRecord(room, g, p, mode, s.clock.Now()))
+		}
+		for i := range wagon.Parties {
+			if wagon.Parties[i].PlayerID == p.ID {
+				wagon.Parties[i].Placement = placement
+			}
+		}
+	}
+	if room.gameOver == nil {
+		room.gameOver = &GameOverSummary{RoomID: room.id, Mode: mode}
+	}
+	room.gameOver.Wagons = append(room.gameOver.Wagons, wagon)
+}
+
+// clientPlayers returns the players of the clients in the room, the runs a
+// finished party game records.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) clientPlayers() []*game.Player {
+	players := make([]*game.Player, 0, len(r.clients))
+	for _, cl := range r.clients {
+		if cl.Player != nil {
+			players = append(players, cl.Player)
+		}
+	}
+	return players
+}
+
+// TakeGameOver returns the summary of a game that just ended in the room,
+// if there is one, and clears it.
+func (s *Server) TakeGameOver(roomID string) *GameOverSummary {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return nil
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	summary := room.gameOver
+	room.gameOver = nil
+	return summary
+}
+
+// BroadcastGameOverTo sends the room the structured summary of a game that
+// just ended there, and the champion event if someone won the open trail.
+// It does nothing if no game ended.
+func (h *Hub) BroadcastGameOverTo(roomID string) {
+	summary := h.server.TakeGameOver(roomID)
+	if summary == nil {
+		return
+	}
+	msgJSON, err := json.Marshal(map[string]interface{}{
+		"type": "game_over",
+		"data": summary,
+	})
+	if err != nil {
+		return
+	}
+	h.sendToRoom(roomID, msgJSON)
+	if summary.Champion != nil {
+		h.BroadcastEventTo(roomID, "System", "champion", summary.Champion.Announcement())
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"online-trail/pkg/game"
+)
+
+func TestRestoredGameResumesDraws(t *testing.T) {
+	played := game.NewGameStateWithSeed(99)
+	for i := 0; i < 40; i++ {
+		played.Rand.Intn(100)
+	}
+	restored := restoreGame(persistPlayerGame("p1", "Ann", played))
+	for i := 0; i < 10; i++ {
+		if got, want := restored.Rand.Int63(), played.Rand.Int63(); got != want {
+			t.Fatalf("draw %d after restoring: got %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestLegacySaveGetsFreshSeed(t *testing.T) {
+	// A save with a seed but no draw count would replay the game's
+	// sequence from its start.
+	restored := restoreGame(PersistedGameState{Seed: 99})
+	if restored.Seed == 99 {
+		t.Error("restored legacy save kept its seed, replaying its draws")
+	}
+}
+
+func TestUpgradesSurviveASave(t *testing.T) {
+	played := game.NewGameStateWithSeed(1)
+	played.AddPlayer("Ann", game.PlayerTypeHuman).ID = "p1"
+	played.HasStrongAxle, played.HasWaterBarrel, played.HasRifleScope, played.HasWagonBed = true, true, true, true
+
+	data, err := json.Marshal(persistPlayerGame("p1", "Ann", played))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved PersistedGameState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	restored := restorePlayerGame("p1", "Ann", saved)
+	for _, item := range []string{game.UpgradeAxle, game.UpgradeBarrel, game.UpgradeScope, game.UpgradeWagonBed} {
+		if !restored.HasUpgrade(item) {
+			t.Errorf("the %s was lost in the save", item)
+		}
+	}
+	if len(restored.AvailableUpgrades()) != 0 {
+		t.Errorf("a restored wagon can buy %v again", restored.AvailableUpgrades())
+	}
+}
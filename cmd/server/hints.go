@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"online-trail/pkg/game"
+)
+
+// Hint is a one-time contextual tip shown to a new player.
+type Hint struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// hintContext is the slice of a player's game that hint rules look at.
+type hintContext struct {
+	Playing       bool // the room's game is under way, not waiting to start
+	Phase         game.TurnPhase
+	FortAvailable bool
+	Food          float64
+	Mileage       float64
+}
+
+type hintRule struct {
+	Hint
+	When func(ctx hintContext) bool
+}
+
+// hintRules are evaluated after each action; each fires at most once per player.
+var hintRules = []hintRule{
+	{Hint{"first_fort", "Forts charge more, but it beats starving - buy food now."},
+		func(ctx hintContext) bool { return ctx.FortAvailable || ctx.Phase == game.PhaseFort }},
+	{Hint{"first_riders", "Riders ahead! Hostile ones cost bullets either way - circling the wagons is safest when you're low on ammo."},
+		func(ctx hintContext) bool { return ctx.Phase == game.PhaseRiders }},
+	{Hint{"first_low_food", "Food is running low. Hunt if you have 50+ bullets, or stop at the next fort."},
+		func(ctx hintContext) bool { return ctx.Playing && ctx.Food < game.LowFoodThreshold }},
+	{Hint{"first_mountain", "You've reached the mountains. Warm clothing keeps blizzards from making your party sick."},
+		func(ctx hintContext) bool { return ctx.Mileage > game.MountainThreshold }},
+}
+
+// statsFor returns the stats record for a player, creating an empty one.
+// NOTE: caller must hold lb.mu.
+func (lb *Leaderboard) statsFor(name string) *PlayerStats {
+	key := statsKey(name)
+	ps, ok := lb.stats[key]
+	if !ok {
+		ps = &PlayerStats{PlayerName: name}
+		lb.stats[key] = ps
+	}
+	return ps
+}
+
+// TakeHints returns the hints that fire for the given context and have not
+// been shown to the player yet, marking them seen. Players with a win on
+// record or who disabled hints never get any.
+func (lb *Leaderboard) TakeHints(name string, ctx hintContext) []Hint {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if ps, ok := lb.stats[statsKey(name)]; ok && (ps.Wins > 0 || ps.HintsDisabled) {
+		return nil
+	}
+
+	var hints []Hint
+	for _, rule := range hintRules {
+		if !rule.When(ctx) {
+			continue
+		}
+		ps := lb.statsFor(name)
+		if ps.HintsSeen[rule.ID] {
+			continue
+		}
+		if ps.HintsSeen == nil {
+			ps.HintsSeen = make(map[string]bool)
+		}
+		ps.HintsSeen[rule.ID] = true
+		hints = append(hints, rule.Hint)
+	}
+	if len(hints) > 0 {
+		lb.saveStats()
+	}
+	return hints
+}
+
+// DisableHints turns off tutorial hints for a player.
+func (lb *Leaderboard) DisableHints(name string) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.statsFor(name).HintsDisabled = true
+	lb.saveStats()
+}
+
+// PlayerHints evaluates the hint rules against a client's current game and
+// returns any hints that should be shown now.
+func (s *Server) PlayerHints(clientID, roomID string) []Hint {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return nil
+	}
+	room.mu.RLock()
+	c, ok := room.clients[clientID]
+	g := room.game
+	if room.perPlayerGames() {
+		g, _ = s.getPlayerGame(room, clientID)
+	}
+	if !ok || g == nil || c.Player == nil {
+		room.mu.RUnlock()
+		return nil
+	}
+	name := c.Name
+	ctx := hintContext{
+		Playing:       room.status == StatusPlaying,
+		Phase:         g.TurnPhase,
+		FortAvailable: g.FortAvailable,
+		Food:          g.Food,
+		Mileage:       g.Mileage,
+	}
+	// Only the player whose turn it is sees shared-game situations
+	if room.roomType == RoomTypeScheduled {
+		if cp := g.GetCurrentPlayer(); cp == nil || cp.ID != clientID {
+			ctx.Phase = game.PhaseMainMenu
+			ctx.FortAvailable = false
+		}
+	}
+	room.mu.RUnlock()
+
+	return s.leaderboard.TakeHints(name, ctx)
+}
+
+// sendHints privately delivers any newly triggered hints to the client.
+func (c *wsClient) sendHints(roomID string) {
+	hints := c.hub.server.PlayerHints(c.clientID, roomID)
+	if len(hints) == 0 {
+		return
+	}
+	lines := make([]string, len(hints))
+	for i, h := range hints {
+		lines[i] = h.Text
+	}
+	msgJSON, err := json.Marshal(map[string]interface{}{
+		"type": "event",
+		"data": map[string]interface{}{
+			"player": "Trail Guide",
+			"action": "hint",
+			"result": strings.Join(lines, "\n") + "\n(Type /hints off in chat to turn tips off.)",
+			"hints":  hints,
+		},
+	})
+	if err == nil {
+		c.hub.SendToClient(c.clientID, msgJSON)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"online-trail/pkg/game"
+)
+
+func TestHintRules(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  hintContext
+		want string
+	}{
+		{"nothing yet", hintContext{Playing: true, Food: 500}, ""},
+		{"fort", hintContext{Playing: true, Food: 500, FortAvailable: true}, "first_fort"},
+		{"riders", hintContext{Playing: true, Food: 500, Phase: game.PhaseRiders}, "first_riders"},
+		{"low food", hintContext{Playing: true, Food: 5}, "first_low_food"},
+		{"empty larder before the start", hintContext{Food: 0}, ""},
+		{"mountains", hintContext{Playing: true, Food: 500, Mileage: game.MountainThreshold + 1}, "first_mountain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lb := NewLeaderboard(t.TempDir())
+			got := ""
+			for _, h := range lb.TakeHints("Ann", tt.ctx) {
+				got += h.ID
+			}
+			if got != tt.want {
+				t.Errorf("hints = %q, want %q", got, tt.want)
+			}
+			if again := lb.TakeHints("Ann", tt.ctx); len(again) != 0 {
+				t.Errorf("hint %s shown twice", again[0].ID)
+			}
+		})
+	}
+}
+
+func TestNoLowFoodHintInWaitingRoom(t *testing.T) {
+	s := newTestServer(t)
+	room := newTestRoom(t, s)
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann"}, room.id)
+
+	room.mu.Lock()
+	if room.status != StatusWaiting || room.game.Food >= game.LowFoodThreshold {
+		room.mu.Unlock()
+		t.Fatalf("want a waiting room with an empty larder; status %v, food %.0f", room.status, room.game.Food)
+	}
+	room.clients["client-ann"].Player = room.game.AddPlayer("Ann", game.PlayerTypeHuman)
+	room.clients["client-ann"].Player.ID = "client-ann"
+	room.mu.Unlock()
+
+	for _, h := range s.PlayerHints("client-ann", room.id) {
+		if h.ID == "first_low_food" {
+			t.Error("low food hint shown before the game started")
+		}
+	}
+}
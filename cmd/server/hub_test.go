@@ -0,0 +1,261 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// registerConn registers a new connection for the client with the hub, as
+// serveWs does, and waits until the hub has taken it in.
+func registerConn(t *testing.T, h *Hub, clientID, name, roomID string) *wsClient {
+	t.Helper()
+	client := &wsClient{
+		hub:        h,
+		conn:       serverConn(t),
+		send:       make(chan []byte, 256),
+		clientID:   clientID,
+		playerName: name,
+		roomID:     roomID,
+		mutedNames: make(map[string]bool),
+		registered: make(chan struct{}),
+	}
+	h.register <- client
+	<-client.registered
+	return client
+}
+
+// unregisterConn hands the connection's close to the hub and waits until it
+// has been dealt with.
+func unregisterConn(h *Hub, client *wsClient) {
+	h.unregister <- client.conn
+	// Run takes the next message only once it is done with this one, and
+	// ignores a connection it doesn't know
+	h.unregister <- nil
+}
+
+// inRoom reports whether the client is among the room's clients.
+func inRoom(room *GameRoom, clientID string) bool {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	_, ok := room.clients[clientID]
+	return ok
+}
+
+func TestStaleUnregisterKeepsReconnectedClient(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	first := registerConn(t, ts.hub, "client-x", "Ann", room.id)
+	second := registerConn(t, ts.hub, "client-x", "Ann", room.id)
+
+	// The old socket's close is noticed after the reconnect
+	unregisterConn(ts.hub, first)
+	if !inRoom(room, "client-x") {
+		t.Fatal("the old connection's close removed the reconnected client")
+	}
+	ts.hub.mu.RLock()
+	latest := ts.hub.byID["client-x"]
+	ts.hub.mu.RUnlock()
+	if latest != second {
+		t.Error("the client isn't indexed by its remaining connection")
+	}
+	if r := ts.FindRoomForClient("client-x"); r != room {
+		t.Errorf("FindRoomForClient = %v, want the room", r)
+	}
+
+	unregisterConn(ts.hub, second)
+	if inRoom(room, "client-x") {
+		t.Error("the latest connection's close left the client in the room")
+	}
+	ts.hub.mu.RLock()
+	_, indexed := ts.hub.byID["client-x"]
+	ts.hub.mu.RUnlock()
+	if indexed {
+		t.Error("the client is still indexed with no connection")
+	}
+}
+
+func TestLatestUnregisterFirstKeepsNothing(t *testing.T) {
+	// The other order: the new socket drops before the old one's close is
+	// noticed. Neither close may panic or leave the client behind.
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	first := registerConn(t, ts.hub, "client-x", "Ann", room.id)
+	second := registerConn(t, ts.hub, "client-x", "Ann", room.id)
+
+	unregisterConn(ts.hub, second)
+	unregisterConn(ts.hub, first)
+	if inRoom(room, "client-x") {
+		t.Error("the client is still in the room with no connection")
+	}
+	ts.hub.mu.RLock()
+	defer ts.hub.mu.RUnlock()
+	if len(ts.hub.clients) != 0 || len(ts.hub.active) != 0 || len(ts.hub.byID) != 0 {
+		t.Errorf("hub still holds %d connections, %d seats, %d IDs",
+			len(ts.hub.clients), len(ts.hub.active), len(ts.hub.byID))
+	}
+}
+
+func TestDuplicateUnregisterIsIgnored(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	// Someone else stays, so the room isn't cleaned up in between
+	ts.AddClient(&Client{ID: "client-host", Name: "Bea"}, room.id)
+	first := registerConn(t, ts.hub, "client-x", "Ann", room.id)
+	unregisterConn(ts.hub, first)
+	// Both the read and write pumps unregister on their way out; closing
+	// send again would panic the hub
+	unregisterConn(ts.hub, first)
+
+	// A later connection of the same client is untouched by either
+	again := registerConn(t, ts.hub, "client-x", "Ann", room.id)
+	unregisterConn(ts.hub, first)
+	if !inRoom(room, "client-x") {
+		t.Error("a repeated unregister of a closed connection removed the client")
+	}
+	unregisterConn(ts.hub, again)
+}
+
+func TestStateSendsToARoomGoOneAtATime(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	ann := registerConn(t, ts.hub, "client-ann", "Ann", room.id)
+	ts.hub.FlushStateTo(room.id)
+	for len(ann.send) > 0 {
+		<-ann.send
+	}
+
+	// A send already under way, as the coalesced broadcast's timer may be
+	// when a flush comes in
+	unlock := ts.hub.lockStateSend(room.id)
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		ts.hub.FlushStateTo(room.id)
+	}()
+	waiting := func() int {
+		ts.hub.pendingMu.Lock()
+		defer ts.hub.pendingMu.Unlock()
+		if send := ts.hub.stateSends[room.id]; send != nil {
+			return send.users
+		}
+		return 0
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for waiting() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("the flush never queued up behind the send under way")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-flushed:
+		t.Fatal("the flush went out while another send to the room was under way")
+	case msg := <-ann.send:
+		t.Fatalf("a state was sent while another send was under way: %s", msg)
+	default:
+	}
+
+	unlock()
+	<-flushed
+	if len(ann.send) == 0 {
+		t.Error("the flush never went out")
+	}
+	if n := waiting(); n != 0 {
+		t.Errorf("%d sends still counted once all were done", n)
+	}
+}
+
+func TestQueueDropsOldestWhenFull(t *testing.T) {
+	c := &wsClient{send: make(chan []byte, 2)}
+	for _, msg := range []string{"1", "2", "3", "4"} {
+		c.queue([]byte(msg))
+	}
+	if got := string(<-c.send) + string(<-c.send); got != "34" {
+		t.Errorf("queued %q, want the newest two, 34", got)
+	}
+}
+
+func TestBlockedReaderDoesntPanicOrLeak(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	reader := ts.dial(t, "name=Ann&room="+room.id)
+	blocked := ts.dial(t, "name=Bea&room="+room.id)
+	before := runtime.NumGoroutine()
+
+	// Bea stops reading. Ann keeps up, and must get the last message even
+	// though Bea's queue overflows over and over.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		reader.SetReadDeadline(time.Now().Add(30 * time.Second))
+		for {
+			var msg map[string]interface{}
+			if err := reader.ReadJSON(&msg); err != nil || msg["type"] == "done" {
+				return
+			}
+		}
+	}()
+
+	ts.hub.mu.RLock()
+	bea := ts.hub.byID[blocked.clientID]
+	ts.hub.mu.RUnlock()
+
+	// Flood the room from several goroutines until Bea's socket is full,
+	// and so her queue stays full once the flood stops. The pad is random,
+	// so compression doesn't shrink it.
+	pad := make([]byte, 12<<10)
+	rand.Read(pad)
+	flood, _ := json.Marshal(map[string]string{"type": "flood", "pad": base64.StdEncoding.EncodeToString(pad)})
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					ts.hub.sendToRoom(room.id, flood)
+				}
+			}()
+		}
+		wg.Wait()
+		time.Sleep(100 * time.Millisecond)
+		// Stuck writing, her writePump takes at most one more message
+		if len(bea.send) >= cap(bea.send)-1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the blocked client kept up (%d of %d queued); the test didn't overflow its queue", len(bea.send), cap(bea.send))
+		}
+	}
+
+	ts.hub.sendToRoom(room.id, []byte(`{"type":"done"}`))
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("the reading client never got the last message")
+	}
+
+	// Bea's connection goes; her pumps must go with it
+	blocked.Close()
+	deadline = time.Now().Add(15 * time.Second)
+	for runtime.NumGoroutine() > before-2 {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<20)
+			t.Fatalf("%d goroutines, want at most %d:\n%s",
+				runtime.NumGoroutine(), before-2, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	ts.hub.mu.RLock()
+	_, still := ts.hub.byID[blocked.clientID]
+	ts.hub.mu.RUnlock()
+	if still {
+		t.Error("the blocked client is still registered")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import "online-trail/pkg/game"
+
+// A hunt waits for the player's shot. If it doesn't come within
+// game.HuntTimeLimit of the prompt, because the player wandered off or lost
+// their connection, the hunt is scored as a miss and the turn goes on.
+//
+// The state leaves the word out until it is due to be shown, then it is
+// pushed to the room.
+
+// startHuntTimer starts clientID's hunt clock.
+// NOTE: caller must hold room.mu.
+func (s *Server) startHuntTimer(room *GameRoom, clientID string) {
+	s.stopHuntTimer(room, clientID)
+	var t Timer
+	t = s.clock.AfterFunc(game.HuntTimeLimit, func() {
+		s.handleHuntTimeout(room, clientID, t)
+	})
+	room.huntTimers[clientID] = t
+}
+
+// stopHuntTimer stops clientID's hunt clock, if running.
+// NOTE: caller must hold room.mu.
+func (s *Server) stopHuntTimer(room *GameRoom, clientID string) {
+	if t, ok := room.huntTimers[clientID]; ok {
+		t.Stop()
+		delete(room.huntTimers, clientID)
+	}
+}
+
+// startHuntTimerIfHunting starts the hunt clock when the action just taken
+// in g opened a hunt.
+// NOTE: caller must hold room.mu.
+func (s *Server) startHuntTimerIfHunting(room *GameRoom, clientID string, g *game.GameState) {
+	if g.TurnPhase == game.PhaseHunting {
+		s.startHuntTimer(room, clientID)
+		s.clock.AfterFunc(g.HuntWordAt().Sub(s.clock.Now()), func() {
+			if s.hub != nil {
+				s.hub.BroadcastStateTo(room.id)
+			}
+		})
+	}
+}
+
+// handleHuntTimeout scores a hunt the player never shot in as a miss. In a
+// paused room the hunt stays open; the turn clock takes over on resume.
+func (s *Server) handleHuntTimeout(room *GameRoom, clientID string, t Timer) {
+	room.mu.Lock()
+	if room.huntTimers[clientID] != t {
+		// Shot already, or a later hunt started its own clock
+		room.mu.Unlock()
+		return
+	}
+	delete(room.huntTimers, clientID)
+	if room.closed || room.paused {
+		room.mu.Unlock()
+		return
+	}
+	name := clientID
+	if c, ok := room.clients[clientID]; ok {
+		name = c.Name
+	}
+	result := s.resolveHunt(room, clientID, "hunt_timeout", (*game.GameState).TimeOutHunt)
+	room.mu.Unlock()
+	if result.Refusal() != nil || len(result.Events) == 0 {
+		return
+	}
+
+	if s.hub != nil {
+		s.hub.BroadcastResultTo(room.id, name, "hunt", result)
+		s.hub.BroadcastTrailNewsTo(room.id)
+		s.hub.BroadcastGameOverTo(room.id)
+		s.hub.BroadcastStateTo(room.id)
+	}
+}
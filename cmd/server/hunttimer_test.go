@@ -0,0 +1,149 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+func TestStateWithholdsHuntWord(t *testing.T) {
+	s := newTestServer(t)
+	room := s.GetRoom(legacyRoomID)
+	s.AddClient(&Client{ID: "hunter", Name: "Hunter"}, room.id)
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	g := room.playerGames["hunter"]
+	g.TurnPhase = game.PhaseHunting
+	g.IssueHuntPrompt()
+
+	word := func() interface{} {
+		states := s.getContinuousState(room)["player_states"].(map[string]map[string]interface{})
+		return states["hunter"]["hunt_word"]
+	}
+	if got := word(); got != "" {
+		t.Fatalf("hunt_word = %q before the delay, want it withheld", got)
+	}
+	g.HuntStartedAt = g.HuntStartedAt.Add(-time.Duration(g.HuntDelayMs) * time.Millisecond)
+	if got := word(); got != g.HuntWord {
+		t.Fatalf("hunt_word = %q after the delay, want %q", got, g.HuntWord)
+	}
+}
+
+// startHunt seats a hunter on the open trail and opens a hunt for them, as
+// choosing "hunt" does.
+func startHunt(t *testing.T, s *Server) (*GameRoom, *game.GameState) {
+	t.Helper()
+	room := s.GetRoom(legacyRoomID)
+	s.AddClient(&Client{ID: "hunter", Name: "Hunter"}, room.id)
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	g := room.playerGames["hunter"]
+	g.Bullets, g.TurnNumber = 500, 1
+	g.TurnPhase = game.PhaseHunting
+	g.IssueHuntPrompt()
+	s.startHuntTimerIfHunting(room, "hunter", g)
+	return room, g
+}
+
+// hasCode reports whether the result holds an event with the code.
+func hasCode(result *game.TurnResult, code string) bool {
+	for _, e := range result.Events {
+		if e.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// hunting reports whether the hunter's hunt is still open, and its clock
+// still running.
+func hunting(room *GameRoom, g *game.GameState) (bool, bool) {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	_, timed := room.huntTimers["hunter"]
+	return g.TurnPhase == game.PhaseHunting, timed
+}
+
+func TestHuntShotResolvesIt(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	room, g := startHunt(t, s)
+
+	result := s.HandleHuntShoot("hunter", room.id, g.HuntWord, 0)
+	if !hasCode(result, game.ResultHuntHit) && !hasCode(result, game.ResultHuntMiss) {
+		t.Fatalf("the shot wasn't scored: %+v", result.Events)
+	}
+	if open, timed := hunting(room, g); open || timed {
+		t.Errorf("after the shot: hunting %v, clock running %v", open, timed)
+	}
+	if g.Bullets >= 500-50 {
+		t.Errorf("bullets = %.0f, want the 50-bullet ante and the shot paid", g.Bullets)
+	}
+
+	// The clock running out later changes nothing
+	bullets, turn := g.Bullets, g.TurnNumber
+	clock.Advance(game.HuntTimeLimit)
+	if g.Bullets != bullets || g.TurnNumber != turn {
+		t.Errorf("the hunt timed out after the shot: %.0f bullets, turn %d", g.Bullets, g.TurnNumber)
+	}
+	if again := s.HandleHuntShoot("hunter", room.id, g.HuntWord, 0); again.Refusal() == nil {
+		t.Error("a second shot at the same hunt was taken")
+	}
+}
+
+func TestHuntCancelFiresNoShot(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	room, g := startHunt(t, s)
+
+	result := s.HandleHuntCancel("hunter", room.id)
+	if !hasCode(result, game.ResultHuntCancel) {
+		t.Fatalf("cancel: %+v", result.Events)
+	}
+	if open, timed := hunting(room, g); open || timed {
+		t.Errorf("after cancelling: hunting %v, clock running %v", open, timed)
+	}
+	if hasCode(result, game.ResultHuntHit) || hasCode(result, game.ResultHuntMiss) {
+		t.Errorf("cancelling fired a shot: %+v", result.Events)
+	}
+
+	clock.Advance(game.HuntTimeLimit)
+	if hasCode(s.HandleHuntCancel("hunter", room.id), game.ResultHuntCancel) {
+		t.Error("a hunt was cancelled twice")
+	}
+}
+
+func TestHuntTimesOutAsAMiss(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	room, g := startHunt(t, s)
+
+	clock.Advance(game.HuntTimeLimit - time.Second)
+	if open, timed := hunting(room, g); !open || !timed {
+		t.Fatalf("before the limit: hunting %v, clock running %v", open, timed)
+	}
+	clock.Advance(time.Second)
+	if open, timed := hunting(room, g); open || timed {
+		t.Fatalf("after the limit: hunting %v, clock running %v", open, timed)
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if g.Bullets > 500-50 {
+		t.Errorf("bullets = %.0f, want the timed-out shot paid for", g.Bullets)
+	}
+	if g.TurnPhase == game.PhaseMainMenu && g.TurnNumber != 2 {
+		t.Errorf("turn = %d after the hunt, want 2", g.TurnNumber)
+	}
+}
+
+func TestPausedHuntStaysOpen(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	room, g := startHunt(t, s)
+	room.mu.Lock()
+	room.paused = true
+	room.mu.Unlock()
+
+	clock.Advance(game.HuntTimeLimit)
+	if open, _ := hunting(room, g); !open {
+		t.Error("a paused room's hunt timed out")
+	}
+}
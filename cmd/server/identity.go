@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// ActiveRoom is a room where a player currently holds a live game.
+type ActiveRoom struct {
+	RoomID   string   `json:"room_id"`
+	RoomName string   `json:"room_name"`
+	RoomType RoomType `json:"room_type"`
+}
+
+// Identity is who a connection is, for counting and giving up their seats.
+// A seat is theirs if it was taken under their client ID, or by a client
+// on their session. Display names are never used: anyone can pick any
+// name.
+type Identity struct {
+	ClientID  string
+	SessionID string
+}
+
+// identityOf returns the Identity behind a session.
+func identityOf(sess *Session) Identity {
+	return Identity{ClientID: sess.ClientID, SessionID: sess.ID}
+}
+
+// holdsSeat reports whether the seat taken under playerID is who's.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) holdsSeat(who Identity, playerID string) bool {
+	if playerID == "" {
+		return false
+	}
+	if playerID == who.ClientID {
+		return true
+	}
+	c, ok := r.clients[playerID]
+	return ok && who.SessionID != "" && c.SessionID == who.SessionID
+}
+
+// ActiveRoomsFor returns every room in which who holds an active,
+// unfinished game.
+func (s *Server) ActiveRoomsFor(who Identity) []ActiveRoom {
+	s.roomsMu.RLock()
+	rooms := make([]*GameRoom, 0, len(s.rooms))
+	for _, room := range s.rooms {
+		rooms = append(rooms, room)
+	}
+	s.roomsMu.RUnlock()
+
+	active := make([]ActiveRoom, 0)
+	for _, room := range rooms {
+		room.mu.RLock()
+		if roomHoldsPlayer(room, who) {
+			active = append(active, ActiveRoom{RoomID: room.id, RoomName: room.name, RoomType: room.roomType})
+		}
+		room.mu.RUnlock()
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].RoomID < active[j].RoomID })
+	return active
+}
+
+// nameInUse reports whether a client other than clientID is connected to
+// the room under name (matched case-insensitively).
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) nameInUse(name, clientID string) bool {
+	for id, c := range r.clients {
+		if id != clientID && strings.EqualFold(c.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueName returns name with the first free " (n)" suffix in the room.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) uniqueName(name, clientID string) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)", name, n)
+		if !r.nameInUse(candidate, clientID) {
+			return candidate
+		}
+	}
+}
+
+// roomHoldsPlayer reports whether who has a live player in the room.
+// NOTE: caller must hold room.mu.
+func roomHoldsPlayer(room *GameRoom, who Identity) bool {
+	if room.roomType != RoomTypeContinuous && room.status == StatusFinished {
+		return false
+	}
+	if room.perPlayerGames() {
+		for id, g := range room.playerGames {
+			if g.Win || !room.holdsSeat(who, id) {
+				continue
+			}
+			for _, p := range g.Players {
+				if p.Alive {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for _, p := range room.game.Players {
+		if p.Alive && room.holdsSeat(who, p.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckGameCap returns an error if joining roomType would put who over their
+// concurrent game cap. Callers skip this for reconnects to seats the player
+// already holds.
+func (s *Server) CheckGameCap(who Identity, roomType RoomType) error {
+	limit := s.maxPartyRooms
+	label := "party rooms"
+	if roomType == RoomTypeContinuous {
+		limit = s.maxContinuousRuns
+		label = "continuous runs"
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	active := s.ActiveRoomsFor(who)
+	count := 0
+	where := make([]string, 0, len(active))
+	for _, a := range active {
+		// Races count against the party room cap
+		if (a.RoomType == RoomTypeContinuous) == (roomType == RoomTypeContinuous) {
+			count++
+		}
+		where = append(where, fmt.Sprintf("%s (%s)", a.RoomName, a.RoomID))
+	}
+	if count < limit {
+		return nil
+	}
+	return fmt.Errorf("you already have %d of %d %s going: %s. Send leave_room with a room ID to free a slot",
+		count, limit, label, strings.Join(where, ", "))
+}
+
+// LeaveRoom removes who's seats from a room so they count against their cap
+// no more. Returns the client IDs that were removed.
+func (s *Server) LeaveRoom(who Identity, roomID string) []string {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return nil
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	removed := make([]string, 0)
+	if room.perPlayerGames() {
+		for id := range room.playerGames {
+			if room.holdsSeat(who, id) {
+				removed = append(removed, id)
+			}
+		}
+		for _, id := range removed {
+			delete(room.playerGames, id)
+		}
+	} else {
+		for _, p := range append(room.game.Players[:0:0], room.game.Players...) {
+			if room.holdsSeat(who, p.ID) {
+				s.removeSharedPlayer(room, p.ID)
+				removed = append(removed, p.ID)
+			}
+		}
+	}
+	for _, id := range removed {
+		s.dropClient(room, id)
+		room.releaseSeat(id)
+	}
+	if len(removed) > 0 {
+		log.Printf("Client %s left room %s to free a slot", who.ClientID, roomID)
+	}
+	return removed
+}
@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestSeatsBelongToClientsNotNames(t *testing.T) {
+	s := newTestServer(t)
+	room := s.GetRoom(legacyRoomID)
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann", SessionID: "sess-ann"}, room.id)
+	s.AddClient(&Client{ID: "client-imp", Name: "Imp", SessionID: "sess-imp"}, room.id)
+	// The impostor takes the same name as Ann
+	room.mu.Lock()
+	room.playerGames["client-imp"].Players[0].Name = "Ann"
+	room.mu.Unlock()
+
+	ann := Identity{ClientID: "client-ann", SessionID: "sess-ann"}
+	imp := Identity{ClientID: "client-imp", SessionID: "sess-imp"}
+	stranger := Identity{ClientID: "client-new", SessionID: "sess-new"}
+
+	if got := s.ActiveRoomsFor(stranger); len(got) != 0 {
+		t.Fatalf("stranger's active rooms = %v, want none", got)
+	}
+	if got := s.ActiveRoomsFor(ann); len(got) != 1 || got[0].RoomID != room.id {
+		t.Fatalf("Ann's active rooms = %v", got)
+	}
+	if err := s.CheckGameCap(ann, RoomTypeContinuous); err == nil {
+		t.Fatal("Ann is at the continuous cap but CheckGameCap allowed another run")
+	}
+	if err := s.CheckGameCap(stranger, RoomTypeContinuous); err != nil {
+		t.Fatalf("stranger CheckGameCap: %v", err)
+	}
+
+	// Leaving removes only the caller's own wagon, whatever names say
+	if removed := s.LeaveRoom(imp, room.id); len(removed) != 1 || removed[0] != "client-imp" {
+		t.Fatalf("impostor LeaveRoom removed %v, want only their own wagon", removed)
+	}
+	room.mu.RLock()
+	_, annKept := room.playerGames["client-ann"]
+	room.mu.RUnlock()
+	if !annKept {
+		t.Fatal("Ann's wagon was removed by someone else leaving")
+	}
+
+	// A new connection on Ann's session counts as Ann
+	sameSession := Identity{ClientID: "client-ann-2", SessionID: "sess-ann"}
+	if removed := s.LeaveRoom(sameSession, room.id); len(removed) != 1 || removed[0] != "client-ann" {
+		t.Fatalf("LeaveRoom on Ann's session removed %v", removed)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// A waiting room can only be started by its owner. An owner who leaves the
+// tab open and walks away would hold everyone else in the lobby, so after
+// owner_idle_timeout with nothing from them the room goes to whoever was
+// active most recently.
+
+// ownerIdleCheckInterval is how often waiting rooms are checked for an idle
+// owner.
+const ownerIdleCheckInterval = 30 * time.Second
+
+// automaticMessages are the messages a client sends without the player
+// doing anything, which mustn't keep an owner counted as active.
+var automaticMessages = map[string]bool{
+	"ping":       true, // the latency ping every 10 s
+	"state_full": true, // a resync after a missed state patch
+}
+
+// MarkActive records that a client just sent something the player did.
+func (s *Server) MarkActive(roomID, clientID string) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return
+	}
+	room.mu.Lock()
+	if c, ok := room.clients[clientID]; ok {
+		c.LastActiveAt = s.clock.Now()
+	}
+	room.mu.Unlock()
+}
+
+// ownerGone reports whether the room has no owner, or one who is no longer
+// in it at all. An owner held for reconnect still counts as there.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) ownerGone() bool {
+	if r.ownerID == "" {
+		return true
+	}
+	_, ok := r.clients[r.ownerID]
+	return !ok
+}
+
+// handOffIdleOwners hands each waiting room whose owner has been idle past
+// ownerIdleTimeout to its most recently active client, and tells the room.
+func (s *Server) handOffIdleOwners() {
+	s.roomsMu.RLock()
+	rooms := make([]*GameRoom, 0, len(s.rooms))
+	for _, room := range s.rooms {
+		rooms = append(rooms, room)
+	}
+	s.roomsMu.RUnlock()
+
+	now := s.clock.Now()
+	for _, room := range rooms {
+		room.mu.Lock()
+		oldName, newName, ok := s.handOffIdleOwner(room, now)
+		room.mu.Unlock()
+		if !ok {
+			continue
+		}
+		if s.hub != nil {
+			s.hub.BroadcastEventTo(room.id, "System", "owner",
+				fmt.Sprintf("%s is now the lobby owner, as %s has been idle for %s.", newName, oldName, s.ownerIdleTimeout))
+			s.hub.BroadcastStateTo(room.id)
+		}
+	}
+}
+
+// handOffIdleOwner hands room on if its owner has gone idle and someone
+// else is still active. Returns the old and new owners' names.
+// NOTE: caller must hold room.mu.
+func (s *Server) handOffIdleOwner(room *GameRoom, now time.Time) (string, string, bool) {
+	if room.closed || !room.hasLobby() || room.status != StatusWaiting {
+		return "", "", false
+	}
+	owner, ok := room.clients[room.ownerID]
+	if !ok || owner.Disconnected || now.Sub(owner.LastActiveAt) < s.ownerIdleTimeout {
+		return "", "", false
+	}
+	var next *Client
+	for _, c := range room.clients {
+		if c.ID == owner.ID || c.Disconnected || now.Sub(c.LastActiveAt) >= s.ownerIdleTimeout {
+			continue
+		}
+		if next == nil || c.LastActiveAt.After(next.LastActiveAt) {
+			next = c
+		}
+	}
+	if next == nil {
+		return "", "", false
+	}
+	room.ownerID = next.ID
+	delete(room.coOwners, next.ID)
+	log.Printf("Ownership of room %s handed to %s: %s was idle", room.id, next.Name, owner.Name)
+	return owner.Name, next.Name, true
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPingsDontKeepOwnerActive(t *testing.T) {
+	ts := newTestSite(t)
+	ts.ownerIdleTimeout = time.Minute
+	room := newTestRoom(t, ts.Server)
+	ann := ts.dial(t, "name=Ann&room="+room.id)
+	bea := ts.dial(t, "name=Bea&room="+room.id)
+
+	idle := ts.clock.Now().Add(-2 * ts.ownerIdleTimeout)
+	room.mu.Lock()
+	if room.ownerID != ann.clientID {
+		room.mu.Unlock()
+		t.Fatalf("owner is %q, want Ann (%s)", room.ownerID, ann.clientID)
+	}
+	for _, c := range room.clients {
+		c.LastActiveAt = idle
+	}
+	room.mu.Unlock()
+
+	ann.sync()
+	ann.send(map[string]interface{}{"type": "state_full"})
+	ann.sync()
+	bea.send(map[string]interface{}{"type": "chat", "message": "anyone there?"})
+	bea.sync()
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if got := room.clients[ann.clientID].LastActiveAt; !got.Equal(idle) {
+		t.Errorf("Ann's pings marked her active at %v", got)
+	}
+	if _, newName, ok := ts.handOffIdleOwner(room, ts.clock.Now()); !ok || newName != "Bea" {
+		t.Errorf("handOffIdleOwner = %q, %v; want Bea to take over", newName, ok)
+	}
+}
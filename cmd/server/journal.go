@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"online-trail/pkg/game"
+)
+
+// historyEntries is how much of the journal a reconnecting client is sent.
+const historyEntries = 50
+
+// RoomJournal returns the last n entries of a room's journal, or all of them
+// if n is not positive. In continuous and race rooms each wagon keeps its
+// own journal: player picks one by name, and without it the wagons' entries
+// are merged in time order. It reports false if there is no such room or
+// player.
+func (s *Server) RoomJournal(roomID, player string, n int) ([]game.JournalEntry, bool) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return nil, false
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if room.closed {
+		return nil, false
+	}
+
+	if !room.perPlayerGames() {
+		return room.game.RecentJournal(n), true
+	}
+	var entries []game.JournalEntry
+	found := player == ""
+	for id, g := range room.playerGames {
+		if player != "" {
+			if _, p := s.getPlayerGame(room, id); p == nil || !strings.EqualFold(p.Name, player) {
+				continue
+			}
+			found = true
+		}
+		entries = append(entries, g.EventLog...)
+	}
+	if !found {
+		return nil, false
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return append([]game.JournalEntry{}, entries...), true
+}
+
+// ClientJournal returns the last n entries of the journal the client plays
+// in: their own wagon's in continuous and race rooms, the shared wagon's
+// otherwise.
+func (s *Server) ClientJournal(roomID, clientID string, n int) []game.JournalEntry {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return nil
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if !room.perPlayerGames() {
+		return room.game.RecentJournal(n)
+	}
+	if g, ok := room.playerGames[clientID]; ok && g != nil {
+		return g.RecentJournal(n)
+	}
+	return nil
+}
+
+// handleRoomLog serves GET /api/rooms/{id}/log. The operator may read any
+// room's journal, and pick a wagon with the player query parameter; a
+// signed-in player may read the journal of the room they are in, and in
+// continuous and race rooms only their own wagon's.
+func (s *Server) handleRoomLog(w http.ResponseWriter, r *http.Request, roomID string) {
+	player := r.URL.Query().Get("player")
+	if !s.isAdmin(r) {
+		sess, ok := s.sessionFromRequest(r)
+		if !ok || sess.RoomID != roomID {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if room := s.GetRoom(roomID); room != nil && room.perPlayerGames() {
+			player = sess.Name
+		}
+	}
+	entries, ok := s.RoomJournal(roomID, player, 0)
+	if !ok {
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"room_id": roomID,
+		"player":  player,
+		"entries": entries,
+	})
+}
+
+// sendHistory sends a reconnecting client the end of their game's journal.
+func (h *Hub) sendHistory(client *wsClient) {
+	msgJSON, err := json.Marshal(map[string]interface{}{
+		"type": "history",
+		"data": h.server.ClientJournal(client.roomID, client.clientID, historyEntries),
+	})
+	if err != nil {
+		return
+	}
+	client.send <- msgJSON
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// Finished journeys. Every win is kept in full in journeysFile, apart from
+// the leaderboard, so the ranking file stays small. Only the latest
+// maxJourneys are kept.
+const (
+	journeysFile          = "journeys.json"
+	maxJourneys           = 200
+	journeyJournalEntries = 20
+	defaultJourneyPage    = 20
+	maxJourneyPage        = 100
+)
+
+// JourneyRecord is one won journey as it ended.
+type JourneyRecord struct {
+	ID          string              `json:"id"`
+	PlayerName  string              `json:"player_name"`
+	RoomID      string              `json:"room_id"`
+	RoomName    string              `json:"room_name"`
+	Mode        string              `json:"mode"`
+	Occupation  string              `json:"occupation,omitempty"`
+	Date        string              `json:"date"` // RFC3339
+	ArrivalDate string              `json:"arrival_date"`
+	Turns       int                 `json:"turns"`
+	Miles       float64             `json:"miles"`
+	Score       int                 `json:"score"`
+	Survivors   int                 `json:"survivors"`
+	Party       []game.PartyMember  `json:"party,omitempty"`
+	Resources   map[string]float64  `json:"resources,omitempty"`
+	Journal     []game.JournalEntry `json:"journal,omitempty"`
+	ClientID    string              `json:"client_id,omitempty"` // who played it; kept off the public list
+}
+
+// public returns the record as the journey pages show it.
+func (j JourneyRecord) public() JourneyRecord {
+	j.ClientID = ""
+	return j
+}
+
+// brief is the record as the journey list shows it, without its party,
+// resources and journal.
+func (j JourneyRecord) brief() JourneyRecord {
+	j.Party, j.Resources, j.Journal = nil, nil, nil
+	return j.public()
+}
+
+// newJourneyRecord records how p's journey in g ended at now.
+func newJourneyRecord(room *GameRoom, g *game.GameState, p *game.Player, mode string, now time.Time) JourneyRecord {
+	summary := g.Summary()
+	return JourneyRecord{
+		ID:          game.GenerateShortID(),
+		PlayerName:  p.Name,
+		RoomID:      room.id,
+		RoomName:    room.name,
+		Mode:        mode,
+		Occupation:  string(p.Occupation),
+		Date:        now.Format(time.RFC3339),
+		ArrivalDate: g.FinalDate,
+		Turns:       g.TurnNumber,
+		Miles:       g.Mileage,
+		Score:       g.ComputeScore(p),
+		Survivors:   g.CountSurvivors(p),
+		Party:       append([]game.PartyMember(nil), p.Party...),
+		Resources:   summary.Inventory,
+		Journal:     g.RecentJournal(journeyJournalEntries),
+		ClientID:    p.ID,
+	}
+}
+
+// JourneyLog keeps the latest won journeys, oldest first.
+type JourneyLog struct {
+	records  []JourneyRecord
+	filePath string
+	mu       sync.RWMutex
+}
+
+func NewJourneyLog(dataPath string) *JourneyLog {
+	if dataPath == "" {
+		dataPath = "."
+	}
+	jl := &JourneyLog{filePath: filepath.Join(dataPath, journeysFile)}
+	jl.load()
+	return jl
+}
+
+func (jl *JourneyLog) load() {
+	err := readSaveFile(jl.filePath, func(data []byte) error {
+		return json.Unmarshal(data, &jl.records)
+	})
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Failed to load journeys: %v", err)
+		}
+		return
+	}
+	log.Printf("Loaded %d journeys from %s", len(jl.records), jl.filePath)
+}
+
+// Add keeps a journey, dropping the oldest past maxJourneys.
+func (jl *JourneyLog) Add(rec JourneyRecord) {
+	jl.mu.Lock()
+	defer jl.mu.Unlock()
+	jl.records = append(jl.records, rec)
+	if over := len(jl.records) - maxJourneys; over > 0 {
+		jl.records = append([]JourneyRecord(nil), jl.records[over:]...)
+	}
+	jl.save()
+}
+
+// save writes the journeys to disk.
+// NOTE: caller must hold jl.mu.
+func (jl *JourneyLog) save() {
+	data, err := json.MarshalIndent(jl.records, "", "  ")
+	if err != nil {
+		log.Printf("Failed to marshal journeys: %v", err)
+		return
+	}
+	if err := writeSaveFile(jl.filePath, data); err != nil {
+		log.Printf("Failed to save journeys to %s: %v", jl.filePath, err)
+	}
+}
+
+// For returns every kept journey the client played, oldest first.
+func (jl *JourneyLog) For(clientID string) []JourneyRecord {
+	jl.mu.RLock()
+	defer jl.mu.RUnlock()
+	records := make([]JourneyRecord, 0)
+	for _, rec := range jl.records {
+		if clientID != "" && rec.ClientID == clientID {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+// Anonymize removes the client's name and ID from their journeys, keeping
+// the journeys. It returns how many were changed.
+func (jl *JourneyLog) Anonymize(clientID string) int {
+	if clientID == "" {
+		return 0
+	}
+	jl.mu.Lock()
+	defer jl.mu.Unlock()
+	count := 0
+	for i := range jl.records {
+		if jl.records[i].ClientID == clientID {
+			jl.records[i].PlayerName = anonymousPlayerName
+			jl.records[i].ClientID = ""
+			count++
+		}
+	}
+	if count > 0 {
+		jl.save()
+	}
+	return count
+}
+
+// Page returns up to limit journeys, newest first, skipping the first
+// offset, and how many are kept in all.
+func (jl *JourneyLog) Page(offset, limit int) ([]JourneyRecord, int) {
+	jl.mu.RLock()
+	defer jl.mu.RUnlock()
+	total := len(jl.records)
+	page := make([]JourneyRecord, 0, limit)
+	for i := total - 1 - offset; i >= 0 && len(page) < limit; i-- {
+		page = append(page, jl.records[i].brief())
+	}
+	return page, total
+}
+
+// Get returns the journey with the given ID.
+func (jl *JourneyLog) Get(id string) (JourneyRecord, bool) {
+	jl.mu.RLock()
+	defer jl.mu.RUnlock()
+	for _, rec := range jl.records {
+		if rec.ID == id {
+			return rec, true
+		}
+	}
+	return JourneyRecord{}, false
+}
+
+// handleJourneys serves GET /api/journeys, the kept journeys newest first.
+// offset and limit page through them.
+func (s *Server) handleJourneys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	offset, limit := 0, defaultJourneyPage
+	var err error
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			http.Error(w, "offset must be a number, 0 or more", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxJourneyPage {
+			http.Error(w, "limit must be a number from 1 to "+strconv.Itoa(maxJourneyPage), http.StatusBadRequest)
+			return
+		}
+	}
+	journeys, total := s.journeys.Page(offset, limit)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total":    total,
+		"offset":   offset,
+		"limit":    limit,
+		"journeys": journeys,
+	})
+}
+
+// handleJourney serves GET /api/journeys/{id}, one journey in full.
+func (s *Server) handleJourney(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/api/journeys/")
+	rec, ok := s.journeys.Get(id)
+	if id == "" || !ok {
+		http.Error(w, "Journey not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(rec.public())
+}
@@ -0,0 +1,65 @@
+package main
+
+import "encoding/json"
+
+// Connection latency. A client pings with its own clock's time, which the
+// pong echoes back, and the round trip it measured last time. The average
+// of its last latencySamples reports, each capped at maxLatencySampleMs, is
+// its latency.
+const (
+	latencySamples     = 5
+	maxLatencySampleMs = 2000
+)
+
+// latencyWindow is a rolling average of round trips.
+type latencyWindow struct {
+	samples [latencySamples]int
+	n, next int
+}
+
+// add records a round trip and returns the new average.
+func (w *latencyWindow) add(ms int) int {
+	w.samples[w.next] = ms
+	w.next = (w.next + 1) % latencySamples
+	if w.n < latencySamples {
+		w.n++
+	}
+	sum := 0
+	for _, s := range w.samples[:w.n] {
+		sum += s
+	}
+	return sum / w.n
+}
+
+// handlePing answers a ping at once and takes in the round trip it reports.
+func (c *wsClient) handlePing(roomID string, msg map[string]interface{}) {
+	if pongJSON, err := json.Marshal(map[string]interface{}{
+		"type": "pong",
+		"t":    msg["t"],
+	}); err == nil {
+		c.hub.SendToClient(c.clientID, pongJSON)
+	}
+
+	rtt, ok := msg["rtt"].(float64)
+	if !ok || rtt < 0 {
+		return
+	}
+	if rtt > maxLatencySampleMs {
+		rtt = maxLatencySampleMs
+	}
+	c.hub.server.SetLatency(roomID, c.clientID, c.latency.add(int(rtt)))
+}
+
+// SetLatency records a client's average round trip, shown beside them on
+// the scoreboard and allowed for when timing their hunting shots.
+func (s *Server) SetLatency(roomID, clientID string, ms int) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if c, ok := room.clients[clientID]; ok {
+		c.LatencyMs = ms
+	}
+}
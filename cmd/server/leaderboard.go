@@ -2,12 +2,16 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"online-trail/pkg/game"
 )
 
 type LeaderboardEntry struct {
@@ -15,14 +19,72 @@ type LeaderboardEntry struct {
 	Won        bool    `json:"won"`
 	Miles      float64 `json:"miles"`
 	TurnCount  int     `json:"turn_count"`
-	Date       string  `json:"date"`
+	Score      *int    `json:"score,omitempty"` // nil for legacy entries recorded before scoring
+	Survivors  int     `json:"survivors"`
+	Date       string  `json:"date"` // RFC3339; legacy entries used 2006-01-02
 	GameMode   string  `json:"game_mode"`
+	Occupation string  `json:"occupation,omitempty"`
+	Verified   bool    `json:"verified,omitempty"`  // played under a registered name, so by its owner
+	ClientID   string  `json:"client_id,omitempty"` // who played it; kept off the public boards
+}
+
+// public returns the entry as the public boards show it.
+func (e LeaderboardEntry) public() LeaderboardEntry {
+	e.ClientID = ""
+	return e
+}
+
+// PlayerStats aggregates every recorded run for one player. Unlike entries,
+// which are trimmed to the top runs per mode, stats cover all runs.
+type PlayerStats struct {
+	PlayerName  string            `json:"player_name"`
+	GamesPlayed int               `json:"games_played"`
+	Wins        int               `json:"wins"`
+	Deaths      int               `json:"deaths"`
+	BestMiles   float64           `json:"best_miles"`
+	TotalMiles  float64           `json:"total_miles"`
+	TotalTurns  int               `json:"total_turns"`
+	BestRun     *LeaderboardEntry `json:"best_run,omitempty"`
+	LastPlayed  string            `json:"last_played"`
+
+	// The clients whose runs are counted here; a name can be played by
+	// more than one
+	ClientIDs []string `json:"client_ids,omitempty"`
+
+	// Lifetime totals from the player's turns at the reins in party games
+	PartyTurns        int     `json:"party_turns,omitempty"`
+	PartyMiles        float64 `json:"party_miles,omitempty"`
+	PartyFoodHunted   float64 `json:"party_food_hunted,omitempty"`
+	PartyFortSpending float64 `json:"party_fort_spending,omitempty"`
+	PartyDamageTaken  int     `json:"party_damage_taken,omitempty"`
+
+	// Registration of the player's name, from before claims moved to
+	// names.json; only read, to move it there
+	NameClaim *NameClaim `json:"name_claim,omitempty"`
+
+	// Tutorial hints already shown, and whether the player turned them off
+	HintsSeen     map[string]bool `json:"hints_seen,omitempty"`
+	HintsDisabled bool            `json:"hints_disabled,omitempty"`
+}
+
+// AverageTurns returns the mean number of turns per recorded run.
+func (ps PlayerStats) AverageTurns() float64 {
+	if ps.GamesPlayed == 0 {
+		return 0
+	}
+	return float64(ps.TotalTurns) / float64(ps.GamesPlayed)
 }
 
 type Leaderboard struct {
-	entries  []LeaderboardEntry
-	filePath string
-	mu       sync.RWMutex
+	entries       []LeaderboardEntry
+	stats         map[string]*PlayerStats // keyed by lower-cased player name
+	champions     []Champion              // the hall of fame, oldest first
+	filePath      string
+	statsFilePath string
+	hallFilePath  string
+	claims        map[string]*NameClaim // keyed like stats
+	namesFilePath string
+	mu            sync.RWMutex
 }
 
 func NewLeaderboard(dataPath string) *Leaderboard {
@@ -30,10 +92,18 @@ func NewLeaderboard(dataPath string) *Leaderboard {
 		dataPath = "."
 	}
 	lb := &Leaderboard{
-		entries:  make([]LeaderboardEntry, 0),
-		filePath: filepath.Join(dataPath, "leaderboard.json"),
+		entries:       make([]LeaderboardEntry, 0),
+		stats:         make(map[string]*PlayerStats),
+		filePath:      filepath.Join(dataPath, "leaderboard.json"),
+		statsFilePath: filepath.Join(dataPath, "player_stats.json"),
+		hallFilePath:  filepath.Join(dataPath, "champions.json"),
+		claims:        make(map[string]*NameClaim),
+		namesFilePath: filepath.Join(dataPath, "names.json"),
 	}
 	lb.Load()
+	lb.loadStats()
+	lb.loadClaims()
+	lb.loadChampions()
 	return lb
 }
 
@@ -49,6 +119,10 @@ func (lb *Leaderboard) Load() {
 		return
 	}
 	lb.entries = entries
+	if lb.migrateDates() {
+		lb.Save()
+	}
+	lb.sortEntries()
 	log.Printf("Leaderboard loaded %d entries from %s", len(entries), lb.filePath)
 }
 
@@ -70,52 +144,147 @@ func (lb *Leaderboard) Save() {
 	}
 }
 
-func (lb *Leaderboard) AddEntry(name string, won bool, miles float64, turns int, mode string) {
+// legacyDateLayout is the date-only format entries were stored in before
+// timestamps switched to RFC3339.
+const legacyDateLayout = "2006-01-02"
+
+// parseEntryDate parses an entry date in either RFC3339 or the legacy format.
+func parseEntryDate(date string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(legacyDateLayout, date); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// migrateDates rewrites legacy date-only entries as RFC3339 (midnight UTC).
+// Returns true if any entry changed.
+func (lb *Leaderboard) migrateDates() bool {
+	changed := false
+	for i := range lb.entries {
+		e := &lb.entries[i]
+		if _, err := time.Parse(time.RFC3339, e.Date); err == nil {
+			continue
+		}
+		if t, err := time.Parse(legacyDateLayout, e.Date); err == nil {
+			e.Date = t.Format(time.RFC3339)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// entryLess orders entries: wins first, winners by score (legacy unscored
+// entries after scored ones), then by miles descending.
+func entryLess(a, b LeaderboardEntry) bool {
+	if a.Won != b.Won {
+		return a.Won
+	}
+	if a.Won {
+		if (a.Score == nil) != (b.Score == nil) {
+			return a.Score != nil
+		}
+		if a.Score != nil && *a.Score != *b.Score {
+			return *a.Score > *b.Score
+		}
+	}
+	return a.Miles > b.Miles
+}
+
+func (lb *Leaderboard) sortEntries() {
+	sort.SliceStable(lb.entries, func(i, j int) bool {
+		return entryLess(lb.entries[i], lb.entries[j])
+	})
+}
+
+// AddEntry records a finished run and returns its rank among the runs of
+// its mode, or 0 if it didn't make the kept top runs.
+func (lb *Leaderboard) AddEntry(clientID, name string, won bool, miles float64, turns, score, survivors int, mode, occupation string) int {
 	lb.mu.Lock()
 	defer lb.mu.Unlock()
 
+	now := time.Now().UTC()
 	entry := LeaderboardEntry{
 		PlayerName: name,
 		Won:        won,
 		Miles:      miles,
 		TurnCount:  turns,
-		Date:       time.Now().Format("2006-01-02"),
+		Score:      &score,
+		Survivors:  survivors,
+		Date:       now.Format(time.RFC3339),
 		GameMode:   mode,
+		Occupation: occupation,
+		ClientID:   clientID,
+	}
+	if lb.claims[statsKey(name)] != nil {
+		entry.Verified = true
 	}
 	lb.entries = append(lb.entries, entry)
+	lb.recordStats(entry)
 
-	// Sort: wins first, then by miles descending
-	sort.Slice(lb.entries, func(i, j int) bool {
-		if lb.entries[i].Won != lb.entries[j].Won {
-			return lb.entries[i].Won
-		}
-		return lb.entries[i].Miles > lb.entries[j].Miles
-	})
+	lb.sortEntries()
+	lb.trimEntries(now)
+
+	lb.Save()
+	lb.saveStats()
 
-	// Keep top 500 entries per mode (1000 total max)
-	continuous := make([]LeaderboardEntry, 0)
-	party := make([]LeaderboardEntry, 0)
+	placement := 0
 	for _, e := range lb.entries {
-		m := e.GameMode
-		if m == "" {
-			m = "continuous"
+		if m := e.GameMode; m != mode && (m != "" || mode != "continuous") {
+			continue
 		}
-		if m == "party" && len(party) < 500 {
-			party = append(party, e)
-		} else if m != "party" && len(continuous) < 500 {
-			continuous = append(continuous, e)
+		placement++
+		if e.Score == entry.Score {
+			return placement
 		}
 	}
-	lb.entries = append(continuous, party...)
-	// Re-sort after merge
-	sort.Slice(lb.entries, func(i, j int) bool {
-		if lb.entries[i].Won != lb.entries[j].Won {
-			return lb.entries[i].Won
-		}
-		return lb.entries[i].Miles > lb.entries[j].Miles
-	})
+	return 0
+}
 
-	lb.Save()
+// keptPerPeriod is how many runs of each mode are kept for each period the
+// leaderboard shows.
+const keptPerPeriod = 500
+
+// leaderboardPeriods are the periods the leaderboard can be shown for.
+var leaderboardPeriods = []string{"all", "month", "week"}
+
+// trimEntries keeps each mode's top keptPerPeriod runs of every period, so
+// a week's board is ranked from every run of the week, not only from those
+// good enough to make the all-time cut.
+// NOTE: caller must hold lb.mu, with the entries sorted.
+func (lb *Leaderboard) trimEntries(now time.Time) {
+	starts := make([]time.Time, len(leaderboardPeriods))
+	for i, period := range leaderboardPeriods {
+		starts[i], _ = PeriodStart(period, now)
+	}
+	counts := make(map[string][]int)
+	kept := lb.entries[:0]
+	for _, e := range lb.entries {
+		mode := e.GameMode
+		if mode == "" {
+			mode = "continuous"
+		}
+		if counts[mode] == nil {
+			counts[mode] = make([]int, len(leaderboardPeriods))
+		}
+		date, dated := parseEntryDate(e.Date)
+		keep := false
+		for i, since := range starts {
+			if !since.IsZero() && (!dated || date.Before(since)) {
+				continue
+			}
+			if counts[mode][i] < keptPerPeriod {
+				counts[mode][i]++
+				keep = true
+			}
+		}
+		if keep {
+			kept = append(kept, e)
+		}
+	}
+	lb.entries = kept
 }
 
 func (lb *Leaderboard) GetTop(n int) []LeaderboardEntry {
@@ -126,11 +295,19 @@ func (lb *Leaderboard) GetTop(n int) []LeaderboardEntry {
 		n = len(lb.entries)
 	}
 	result := make([]LeaderboardEntry, n)
-	copy(result, lb.entries[:n])
+	for i, e := range lb.entries[:n] {
+		result[i] = e.public()
+	}
 	return result
 }
 
 func (lb *Leaderboard) GetTopByMode(n int, mode string) []LeaderboardEntry {
+	return lb.GetTopByModeSince(n, mode, time.Time{})
+}
+
+// GetTopByModeSince returns the top n entries for a mode recorded at or after
+// since. A zero since includes every entry.
+func (lb *Leaderboard) GetTopByModeSince(n int, mode string, since time.Time) []LeaderboardEntry {
 	lb.mu.RLock()
 	defer lb.mu.RUnlock()
 
@@ -140,8 +317,14 @@ func (lb *Leaderboard) GetTopByMode(n int, mode string) []LeaderboardEntry {
 		if entryMode == "" {
 			entryMode = "continuous" // legacy entries default to public trail
 		}
+		if !since.IsZero() {
+			date, ok := parseEntryDate(e.Date)
+			if !ok || date.Before(since) {
+				continue
+			}
+		}
 		if entryMode == mode {
-			result = append(result, e)
+			result = append(result, e.public())
 			if len(result) >= n {
 				break
 			}
@@ -149,3 +332,209 @@ func (lb *Leaderboard) GetTopByMode(n int, mode string) []LeaderboardEntry {
 	}
 	return result
 }
+
+// PeriodStart returns the start of a leaderboard period ("all", "week" or
+// "month") relative to now. "all" and "" return the zero time. A month back
+// from a day the month before doesn't have, like March 31st, is the last day
+// of that month.
+func PeriodStart(period string, now time.Time) (time.Time, error) {
+	switch period {
+	case "", "all":
+		return time.Time{}, nil
+	case "week":
+		return now.AddDate(0, 0, -7), nil
+	case "month":
+		start := now.AddDate(0, -1, 0)
+		if start.Day() != now.Day() {
+			// AddDate rolled over into this month
+			start = start.AddDate(0, 0, -start.Day())
+		}
+		return start, nil
+	}
+	return time.Time{}, fmt.Errorf("unknown period %q", period)
+}
+
+// statsKey normalizes a player name so stats match case-insensitively.
+func statsKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// recordStats folds a new entry into the player's aggregate stats.
+// NOTE: caller must hold lb.mu.
+func (lb *Leaderboard) recordStats(e LeaderboardEntry) {
+	key := statsKey(e.PlayerName)
+	ps, ok := lb.stats[key]
+	if !ok {
+		ps = &PlayerStats{PlayerName: e.PlayerName}
+		lb.stats[key] = ps
+	}
+	ps.GamesPlayed++
+	if e.Won {
+		ps.Wins++
+	} else {
+		ps.Deaths++
+	}
+	if e.Miles > ps.BestMiles {
+		ps.BestMiles = e.Miles
+	}
+	ps.TotalMiles += e.Miles
+	ps.TotalTurns += e.TurnCount
+	if ps.BestRun == nil || entryLess(e, *ps.BestRun) {
+		best := e
+		ps.BestRun = &best
+	}
+	ps.LastPlayed = e.Date
+	ps.addClient(e.ClientID)
+}
+
+// addClient records that clientID played under the stats' name.
+func (ps *PlayerStats) addClient(clientID string) {
+	if clientID == "" {
+		return
+	}
+	for _, id := range ps.ClientIDs {
+		if id == clientID {
+			return
+		}
+	}
+	ps.ClientIDs = append(ps.ClientIDs, clientID)
+}
+
+// removeClient forgets clientID, reporting whether it was there.
+func (ps *PlayerStats) removeClient(clientID string) bool {
+	for i, id := range ps.ClientIDs {
+		if id == clientID {
+			ps.ClientIDs = append(ps.ClientIDs[:i], ps.ClientIDs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// RecordContribution adds a finished party game's contribution to the
+// player's lifetime totals.
+func (lb *Leaderboard) RecordContribution(clientID, name string, c game.Contribution) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	ps := lb.statsFor(name)
+	ps.addClient(clientID)
+	ps.PartyTurns += c.Turns
+	ps.PartyMiles += c.Miles
+	ps.PartyFoodHunted += c.FoodHunted
+	ps.PartyFortSpending += c.FortSpending
+	ps.PartyDamageTaken += c.DamageTaken
+	lb.saveStats()
+}
+
+// GetPlayerStats returns the aggregate stats for a player, matching the name
+// case-insensitively, without the clients who played under it.
+func (lb *Leaderboard) GetPlayerStats(name string) (PlayerStats, bool) {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+	ps, ok := lb.stats[statsKey(name)]
+	if !ok {
+		return PlayerStats{}, false
+	}
+	stats := *ps
+	stats.ClientIDs = nil
+	if stats.BestRun != nil {
+		best := stats.BestRun.public()
+		stats.BestRun = &best
+	}
+	return stats, true
+}
+
+func (lb *Leaderboard) loadStats() {
+	data, err := os.ReadFile(lb.statsFilePath)
+	if err != nil {
+		// Build stats from the surviving leaderboard entries on first run
+		for _, e := range lb.entries {
+			lb.recordStats(e)
+		}
+		return
+	}
+	var stats []*PlayerStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		log.Printf("Failed to parse player stats: %v", err)
+		return
+	}
+	for _, ps := range stats {
+		lb.stats[statsKey(ps.PlayerName)] = ps
+	}
+	log.Printf("Player stats loaded for %d players from %s", len(stats), lb.statsFilePath)
+}
+
+func (lb *Leaderboard) saveStats() {
+	stats := make([]*PlayerStats, 0, len(lb.stats))
+	for _, ps := range lb.stats {
+		stats = append(stats, ps)
+	}
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		log.Printf("Failed to marshal player stats: %v", err)
+		return
+	}
+	if err := os.WriteFile(lb.statsFilePath, data, 0644); err != nil {
+		log.Printf("Failed to save player stats to %s: %v", lb.statsFilePath, err)
+	}
+}
+
+// anonymousPlayerName replaces a player's name when they delete their data.
+const anonymousPlayerName = "Departed Pioneer"
+
+// GetEntriesFor returns every leaderboard entry recorded for a client.
+// Entries from before client IDs were recorded belong to no one.
+func (lb *Leaderboard) GetEntriesFor(clientID string) []LeaderboardEntry {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+
+	result := make([]LeaderboardEntry, 0)
+	for _, e := range lb.entries {
+		if clientID != "" && e.ClientID == clientID {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Anonymize renames a client's entries and hall of fame places to
+// anonymousPlayerName, and takes them out of the stats of the names they
+// played under. Stats no other client played into are deleted. Other
+// players under the same name keep theirs. Returns the number of entries
+// anonymized.
+func (lb *Leaderboard) Anonymize(clientID string) int {
+	if clientID == "" {
+		return 0
+	}
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	count := 0
+	for i := range lb.entries {
+		if lb.entries[i].ClientID == clientID {
+			lb.entries[i].PlayerName = anonymousPlayerName
+			lb.entries[i].ClientID = ""
+			count++
+		}
+	}
+	for key, ps := range lb.stats {
+		if ps.removeClient(clientID) && len(ps.ClientIDs) == 0 {
+			delete(lb.stats, key)
+		}
+	}
+	crowned := false
+	for i := range lb.champions {
+		if lb.champions[i].ClientID == clientID {
+			lb.champions[i].PlayerName = anonymousPlayerName
+			lb.champions[i].ClientID = ""
+			crowned = true
+		}
+	}
+
+	lb.Save()
+	lb.saveStats()
+	if crowned {
+		lb.saveChampions()
+	}
+	return count
+}
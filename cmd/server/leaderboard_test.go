@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	at := func(value string) time.Time {
+		t.Helper()
+		v, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		period, now, want string
+	}{
+		{"week", "2026-03-08T12:00:00Z", "2026-03-01T12:00:00Z"},
+		{"week", "2026-01-03T09:30:00Z", "2025-12-27T09:30:00Z"},
+		{"month", "2026-03-15T12:00:00Z", "2026-02-15T12:00:00Z"},
+		{"month", "2026-01-10T00:00:00Z", "2025-12-10T00:00:00Z"},
+		// February has no 31st, or 29th this year
+		{"month", "2026-03-31T18:00:00Z", "2026-02-28T18:00:00Z"},
+		{"month", "2026-03-29T18:00:00Z", "2026-02-28T18:00:00Z"},
+		{"month", "2028-03-30T18:00:00Z", "2028-02-29T18:00:00Z"},
+		{"month", "2026-05-31T08:00:00Z", "2026-04-30T08:00:00Z"},
+	}
+	for _, tt := range tests {
+		got, err := PeriodStart(tt.period, at(tt.now))
+		if err != nil || !got.Equal(at(tt.want)) {
+			t.Errorf("PeriodStart(%s, %s) = %v, %v; want %s", tt.period, tt.now, got, err, tt.want)
+		}
+	}
+
+	for _, period := range []string{"", "all"} {
+		if got, err := PeriodStart(period, time.Now()); err != nil || !got.IsZero() {
+			t.Errorf("PeriodStart(%q) = %v, %v; want the zero time", period, got, err)
+		}
+	}
+	if _, err := PeriodStart("fortnight", time.Now()); err == nil {
+		t.Error("PeriodStart accepted an unknown period")
+	}
+}
+
+func TestLegacyDatesAreMigratedOnLoad(t *testing.T) {
+	dir := t.TempDir()
+	legacy := []LeaderboardEntry{
+		{PlayerName: "Ann", Miles: 900, Date: "2026-01-05", GameMode: "continuous"},
+		{PlayerName: "Bea", Miles: 400, Date: "2026-01-07T15:04:05Z", GameMode: "continuous"},
+	}
+	data, _ := json.Marshal(legacy)
+	path := filepath.Join(dir, "leaderboard.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lb := NewLeaderboard(dir)
+	names := func(since string) []string {
+		t.Helper()
+		at, _ := time.Parse(time.RFC3339, since)
+		var got []string
+		for _, e := range lb.GetTopByModeSince(10, "continuous", at) {
+			got = append(got, e.PlayerName+" "+e.Date)
+		}
+		return got
+	}
+	// Ann's date-only run counts from midnight UTC that day
+	if got := names("2026-01-05T00:00:00Z"); len(got) != 2 || got[0] != "Ann 2026-01-05T00:00:00Z" {
+		t.Errorf("since the 5th = %v, want Ann's run first, dated midnight", got)
+	}
+	if got := names("2026-01-05T00:00:01Z"); len(got) != 1 || got[0] != "Bea 2026-01-07T15:04:05Z" {
+		t.Errorf("after midnight on the 5th = %v, want only Bea's run", got)
+	}
+
+	// The migration is saved, so the next load has nothing to do
+	var saved []LeaderboardEntry
+	data, _ = os.ReadFile(path)
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range saved {
+		if _, err := time.Parse(time.RFC3339, e.Date); err != nil {
+			t.Errorf("%s's run is still saved as %q", e.PlayerName, e.Date)
+		}
+	}
+}
+
+func TestPeriodBoardsRankEveryRunOfThePeriod(t *testing.T) {
+	lb := NewLeaderboard(t.TempDir())
+	// A full all-time board of old runs, every one better than this week's
+	old := time.Now().UTC().AddDate(0, -3, 0).Format(time.RFC3339)
+	lb.mu.Lock()
+	for i := 0; i < keptPerPeriod; i++ {
+		score := 5000
+		lb.entries = append(lb.entries, LeaderboardEntry{
+			PlayerName: "Old hand", Won: true, Miles: 2040, Score: &score, Date: old, GameMode: "continuous",
+		})
+	}
+	lb.mu.Unlock()
+
+	lb.AddEntry("client-ann", "Ann", false, 300, 12, 80, 2, "continuous", "banker")
+	week, _ := PeriodStart("week", time.Now())
+	if got := lb.GetTopByModeSince(10, "continuous", week); len(got) != 1 || got[0].PlayerName != "Ann" {
+		t.Fatalf("this week's board = %+v, want Ann's run", got)
+	}
+	// Kept for the week, the run still ranks below the all-time board
+	all := lb.GetTopByMode(keptPerPeriod+10, "continuous")
+	if len(all) != keptPerPeriod+1 || all[keptPerPeriod-1].PlayerName != "Old hand" || all[keptPerPeriod].PlayerName != "Ann" {
+		t.Errorf("all-time board has %d runs, with %s last; want the %d old ones, then Ann", len(all), all[len(all)-1].PlayerName, keptPerPeriod)
+	}
+
+	// Reloading keeps it, as the run was saved and not trimmed away
+	if got := NewLeaderboard(filepath.Dir(lb.filePath)).GetTopByModeSince(10, "continuous", week); len(got) != 1 {
+		t.Errorf("after a reload this week's board = %+v", got)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// autoStartCountdown is how long a full party game or race waits before it
+// starts on its own.
+const autoStartCountdown = 15 * time.Second
+
+// SetReady marks a player ready or not ready to start a party game or race
+// that hasn't begun yet.
+func (s *Server) SetReady(roomID, clientID string, ready bool) error {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return errors.New("room not found")
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	c, ok := room.clients[clientID]
+	switch {
+	case !room.hasLobby():
+		return errors.New("only party games and races have a ready check")
+	case room.status != StatusWaiting:
+		return wrongPhase("the game has already started")
+	case !ok || c.Player == nil:
+		return errors.New("spectators don't need to ready up")
+	}
+	c.Ready = ready
+	return nil
+}
+
+// SetOccupation chooses a player's occupation. In a party game or race it
+// must be chosen before the game starts, and if the owner picked one for everyone
+// no other is accepted; in continuous rooms it applies from the player's
+// next run.
+func (s *Server) SetOccupation(roomID, clientID string, occupation game.Occupation) error {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return errors.New("room not found")
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	c, ok := room.clients[clientID]
+	switch {
+	case !ok || c.Player == nil:
+		return errors.New("spectators don't have an occupation")
+	case room.hasLobby() && room.status != StatusWaiting:
+		return wrongPhase("the game has already started")
+	case room.rules.Occupation != "" && occupation != "" && occupation != room.rules.Occupation:
+		return fmt.Errorf("everyone in this room is a %s", room.rules.Occupation)
+	}
+	if room.rules.Occupation != "" {
+		occupation = room.rules.Occupation
+	}
+	c.Player.Occupation = occupation
+	return nil
+}
+
+// StartGame starts a waiting party game or race. Only the owner or a
+// co-owner may start it, and every connected player must be ready unless force is set.
+func (s *Server) StartGame(roomID, requesterID string, force bool) error {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return errors.New("room not found")
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	return s.startGameLocked(room, requesterID, force)
+}
+
+// startGameLocked is StartGame for a caller already holding the lock.
+// Starting the game counts as the starter's own ready.
+// NOTE: caller must hold room.mu.
+func (s *Server) startGameLocked(room *GameRoom, requesterID string, force bool) error {
+	players := len(room.game.Players)
+	if room.roomType == RoomTypeRace {
+		players = len(room.playerGames)
+	}
+	switch {
+	case !room.hasLobby():
+		return errors.New("only party games and races are started by the owner")
+	case !room.canModerate(requesterID):
+		return ownerOnly("only the lobby owner can start the game")
+	case room.status != StatusWaiting:
+		return wrongPhase("the game has already started")
+	case players == 0:
+		return errors.New("there is nobody to play")
+	}
+
+	if owner, ok := room.clients[requesterID]; ok && owner.Player != nil {
+		owner.Ready = true
+	}
+	if !force {
+		if waiting := room.unreadyNames(); len(waiting) > 0 {
+			return fmt.Errorf("still waiting on %s", strings.Join(waiting, ", "))
+		}
+	}
+
+	s.beginGame(room)
+	log.Printf("Room %s started by owner (forced: %v)", room.id, force)
+	return nil
+}
+
+// beginGame sets up the shared wagon and starts the first turn, or starts
+// the race.
+// NOTE: caller must hold room.mu.
+func (s *Server) beginGame(room *GameRoom) {
+	if room.roomType == RoomTypeRace {
+		s.beginRace(room)
+		return
+	}
+	s.cancelCountdown(room)
+	initRoomResources(room)
+	room.game.TurnNumber = 1
+	room.game.GameOver = false
+	room.status = StatusPlaying
+	if cp := room.game.GetCurrentPlayer(); cp != nil {
+		s.StartTurnTimer(room, cp.ID)
+	}
+}
+
+// StartCountdownIfFull starts the auto-start countdown once the last seat
+// of a waiting party game or race is taken. It reports whether a countdown was
+// started.
+func (s *Server) StartCountdownIfFull(roomID string) bool {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return false
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if !room.hasLobby() || room.status != StatusWaiting ||
+		room.countdownTimer != nil || !room.isFull() {
+		return false
+	}
+	room.countdownDeadline = s.clock.Now().Add(autoStartCountdown)
+	room.countdownTimer = s.clock.AfterFunc(autoStartCountdown, func() {
+		s.handleCountdownExpired(room)
+	})
+	log.Printf("Room %s is full, starting in %s", roomID, autoStartCountdown)
+	return true
+}
+
+// CancelCountdown lets the owner call off the auto-start countdown.
+func (s *Server) CancelCountdown(roomID, requesterID string) error {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return errors.New("room not found")
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	switch {
+	case room.ownerID != requesterID:
+		return ownerOnly("only the lobby owner can cancel the countdown")
+	case room.countdownTimer == nil:
+		return wrongPhase("there is no countdown running")
+	}
+	s.cancelCountdown(room)
+	return nil
+}
+
+// cancelCountdown stops the auto-start countdown, if any.
+// NOTE: caller must hold room.mu.
+func (s *Server) cancelCountdown(room *GameRoom) {
+	if room.countdownTimer != nil {
+		room.countdownTimer.Stop()
+		room.countdownTimer = nil
+	}
+	room.countdownDeadline = time.Time{}
+}
+
+// cancelCountdownIfNotFull calls off the countdown after someone leaves.
+// NOTE: caller must hold room.mu.
+func (s *Server) cancelCountdownIfNotFull(room *GameRoom) {
+	if room.countdownTimer != nil && !room.isFull() {
+		s.cancelCountdown(room)
+		log.Printf("Room %s is no longer full, countdown cancelled", room.id)
+	}
+}
+
+// handleCountdownExpired starts the game when the countdown runs out.
+func (s *Server) handleCountdownExpired(room *GameRoom) {
+	room.mu.Lock()
+	if room.closed || room.countdownTimer == nil || room.status != StatusWaiting {
+		room.mu.Unlock()
+		return
+	}
+	room.countdownTimer = nil
+	room.countdownDeadline = time.Time{}
+	if !room.isFull() {
+		room.mu.Unlock()
+		return
+	}
+	s.beginGame(room)
+	roomID := room.id
+	room.mu.Unlock()
+
+	log.Printf("Room %s auto-started after countdown", roomID)
+	if s.hub != nil {
+		s.hub.BroadcastEventTo(roomID, "System", "start", "The wagon train is full. The journey begins!")
+		s.hub.FlushStateTo(roomID)
+	}
+}
+
+// isFull reports whether a room with a player cap has no open seats.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) isFull() bool {
+	return r.maxPlayers > 0 && r.Seats().Open == 0
+}
+
+// unreadyNames lists the connected players who haven't readied up.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) unreadyNames() []string {
+	names := make([]string, 0)
+	for _, c := range r.clients {
+		if c.Player != nil && !c.Spectator && !c.Disconnected && !c.Ready {
+			names = append(names, c.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
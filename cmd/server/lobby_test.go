@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestResetGameWaitsForTheNextStart(t *testing.T) {
+	s := newTestServer(t)
+	room := startPartyGame(t, s)
+	room.mu.Lock()
+	room.game.GameOver = true
+	room.mu.Unlock()
+
+	if !s.ResetGame(room.id) {
+		t.Fatal("the finished game didn't reset")
+	}
+	room.mu.RLock()
+	status, ticking := room.status, room.turnTimer != nil
+	room.mu.RUnlock()
+	if status != StatusWaiting || ticking {
+		t.Fatalf("after the reset the room is %s (turn clock running: %v), want it waiting", status, ticking)
+	}
+
+	// Ann started the last game, which readied her; this one needs
+	// everyone to ready up again
+	if err := s.StartGame(room.id, "client-bea", false); err == nil {
+		t.Error("Bea started the game without owning it")
+	}
+	if err := s.StartGame(room.id, "client-ann", false); err == nil {
+		t.Error("the next game started before Bea was ready")
+	}
+	if err := s.SetReady(room.id, "client-bea", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.StartGame(room.id, "client-ann", false); err != nil {
+		t.Fatalf("starting the next game: %v", err)
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if room.status != StatusPlaying || room.game.Food != 100 || len(room.game.Players) != 2 {
+		t.Errorf("next game is %s with %.0f food and %d players", room.status, room.game.Food, len(room.game.Players))
+	}
+}
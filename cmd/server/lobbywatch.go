@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// lobbyPushInterval is the most often lobby watchers are sent the list.
+const lobbyPushInterval = time.Second
+
+// lobbyWatch is one lobby page's subscription: the filter from its query
+// string, the session it was opened with, if any, and the last list it was
+// sent.
+type lobbyWatch struct {
+	filter    LobbyFilter
+	sessionID string
+	last      []byte
+}
+
+// serveLobbyWatcher upgrades a lobby page's /ws?lobby=1 connection. The
+// socket gets the lobby list now and again whenever it changes, filtered
+// and sorted by the same query parameters as /api/lobbies.
+func serveLobbyWatcher(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	filter, err := parseLobbyFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Lobby watcher upgrade error: %v", err)
+		return
+	}
+	watcher := &wsClient{
+		hub:  hub,
+		conn: conn,
+		send: make(chan []byte, 16),
+	}
+	watch := &lobbyWatch{filter: filter}
+	if sess, ok := hub.server.sessionFromRequest(r); ok {
+		watch.sessionID = sess.ID
+	}
+	if msg, err := hub.lobbyMessage(watch); err == nil {
+		watch.last = msg
+		watcher.send <- msg
+	}
+
+	hub.watchMu.Lock()
+	hub.lobbyWatchers[watcher] = watch
+	hub.watchMu.Unlock()
+
+	go watcher.writePump()
+	go watcher.watchLobby()
+}
+
+// watchLobby reads (and ignores) anything the lobby page sends so the
+// connection's close is noticed, then drops the watcher.
+func (c *wsClient) watchLobby() {
+	defer func() {
+		c.hub.watchMu.Lock()
+		if _, ok := c.hub.lobbyWatchers[c]; ok {
+			delete(c.hub.lobbyWatchers, c)
+			close(c.send)
+		}
+		c.hub.watchMu.Unlock()
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(512)
+	c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// LobbyChanged marks the lobby list as possibly changed. It never blocks,
+// so it is safe to call while holding room or server locks.
+func (h *Hub) LobbyChanged() {
+	select {
+	case h.lobbyDirty <- struct{}{}:
+	default:
+	}
+}
+
+// notifyLobby tells lobby watchers that a room appeared, went away or
+// changed.
+func (s *Server) notifyLobby() {
+	if s.hub != nil {
+		s.hub.LobbyChanged()
+	}
+}
+
+// RunLobbyPush sends each watcher its lobby list after a change, at most
+// once per lobbyPushInterval, and only when the list actually differs from
+// the last one it was sent.
+func (h *Hub) RunLobbyPush() {
+	for range h.lobbyDirty {
+		h.watchMu.Lock()
+		for watcher, watch := range h.lobbyWatchers {
+			msg, err := h.lobbyMessage(watch)
+			if err != nil || bytes.Equal(msg, watch.last) {
+				continue
+			}
+			select {
+			case watcher.send <- msg:
+				watch.last = msg
+			default:
+			}
+		}
+		h.watchMu.Unlock()
+		time.Sleep(lobbyPushInterval)
+	}
+}
+
+// lobbyMessage builds the "lobbies" message carrying watch's list.
+func (h *Hub) lobbyMessage(watch *lobbyWatch) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"type":    "lobbies",
+		"lobbies": h.server.ListLobbies(watch.filter, watch.sessionID),
+	})
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialSession opens a websocket that resumes the session by its cookie.
+func (ts *testSite) dialSession(t *testing.T, sessionID string) *testConn {
+	t.Helper()
+	header := http.Header{"Cookie": {"session_id=" + sessionID}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.url, "http")+"/ws?v=2", header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	c := &testConn{Conn: conn, t: t}
+	c.clientID, _ = c.await("your_id")["client_id"].(string)
+	return c
+}
+
+// logout posts to /api/logout with the session's cookie, if one is given,
+// and returns the response and whether it says the session was ended.
+func (ts *testSite) logout(t *testing.T, sessionID string) (*http.Response, bool) {
+	t.Helper()
+	r, _ := http.NewRequest(http.MethodPost, ts.url+"/api/logout", nil)
+	if sessionID != "" {
+		r.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+	}
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		LoggedOut bool `json:"logged_out"`
+	}
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp, body.LoggedOut
+}
+
+// clearsCookie reports whether the response expires the session cookie.
+func clearsCookie(resp *http.Response) bool {
+	for _, c := range resp.Cookies() {
+		if c.Name == "session_id" && c.Value == "" && c.MaxAge < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLogoutClosesTheSocket(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	sessionID := ts.sessionManager.NewSession("Ann", "client-ann", room.id)
+	ann := ts.dialSession(t, sessionID)
+	if ann.clientID != "client-ann" {
+		t.Fatalf("the socket resumed as %s", ann.clientID)
+	}
+
+	resp, loggedOut := ts.logout(t, sessionID)
+	if resp.StatusCode != http.StatusOK || !loggedOut {
+		t.Fatalf("logout: %d, logged out %v", resp.StatusCode, loggedOut)
+	}
+	if !clearsCookie(resp) {
+		t.Errorf("logout didn't expire the cookie: %v", resp.Header["Set-Cookie"])
+	}
+	if _, ok := ts.sessionManager.GetSessionByID(sessionID); ok {
+		t.Error("the session outlived the logout")
+	}
+
+	ann.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := ann.ReadMessage(); err != nil {
+			if ne, ok := err.(interface{ Timeout() bool }); ok && ne.Timeout() {
+				t.Fatal("the socket stayed open after the logout")
+			}
+			break
+		}
+	}
+	if ts.GetRoom(room.id) != nil {
+		t.Error("the room Ann left empty wasn't cleaned up")
+	}
+}
+
+func TestLogoutGivesUpAHeldSeat(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	annSession := ts.sessionManager.NewSession("Ann", "client-ann", room.id)
+	ann := ts.dialSession(t, annSession)
+	ts.dial(t, "name=Bea&room="+room.id)
+	if err := ts.StartGame(room.id, "client-ann", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ann's tab closes mid-game; her seat is held for her to come back
+	ann.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		room.mu.RLock()
+		c := room.clients["client-ann"]
+		held := c != nil && c.Disconnected
+		room.mu.RUnlock()
+		if held {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Ann's seat was never held")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if resp, loggedOut := ts.logout(t, annSession); !loggedOut || !clearsCookie(resp) {
+		t.Fatalf("logout without a socket: %d, logged out %v", resp.StatusCode, loggedOut)
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if _, ok := room.clients["client-ann"]; ok {
+		t.Error("Ann is still in the room")
+	}
+	if _, ok := room.graceTimers["client-ann"]; ok {
+		t.Error("Ann's grace timer is still running")
+	}
+	if _, ok := room.reservedSeats["client-ann"]; ok {
+		t.Error("Ann's seat is still reserved")
+	}
+	for _, p := range room.game.Players {
+		if p.ID == "client-ann" {
+			t.Error("Ann's wagon is still in the game")
+		}
+	}
+}
+
+func TestLogoutWithoutASession(t *testing.T) {
+	ts := newTestSite(t)
+	for _, sessionID := range []string{"", "no-such-session"} {
+		resp, loggedOut := ts.logout(t, sessionID)
+		if resp.StatusCode != http.StatusOK || loggedOut {
+			t.Errorf("logout with %q: %d, logged out %v", sessionID, resp.StatusCode, loggedOut)
+		}
+		if !clearsCookie(resp) {
+			t.Errorf("logout with %q didn't expire the cookie", sessionID)
+		}
+	}
+
+	resp, err := http.Get(ts.url + "/api/logout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /api/logout: %d, want 405", resp.StatusCode)
+	}
+}
@@ -1,18 +1,22 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"encoding/json"
-	"flag"
 	"fmt"
 	"log"
-	"math/rand"
-	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"online-trail/pkg/game"
+	"online-trail/pkg/network"
 )
 
 type RoomType string
@@ -20,6 +24,7 @@ type RoomType string
 const (
 	RoomTypeContinuous RoomType = "continuous"
 	RoomTypeScheduled  RoomType = "scheduled"
+	RoomTypeRace       RoomType = "race"
 )
 
 type GameStatus string
@@ -31,42 +36,215 @@ const (
 )
 
 type GameRoom struct {
-	id           string
-	name         string
-	roomType     RoomType
-	status       GameStatus
-	password     string
-	ownerID      string
-	maxPlayers   int
-	createdAt    time.Time
-	game         *game.GameState            // used for scheduled/private mode (shared game)
-	playerGames  map[string]*game.GameState // continuous mode: each player has their own game state
-	clients      map[string]*Client
-	deadPlayers  map[string]bool // names banned from rejoining until reset
-	turnTimer    *time.Timer
-	turnDeadline time.Time
-	mu           sync.RWMutex
+	id            string
+	name          string
+	roomType      RoomType
+	status        GameStatus
+	password      *NameClaim // the join password, hashed; nil if open
+	ownerID       string
+	creator       string          // session ID that made the room over /api/lobbies/create
+	coOwners      map[string]bool // clientIDs who may also kick and start
+	maxPlayers    int
+	botSeats      int                  // planned CPU players
+	botsTakeSeats bool                 // whether CPU players count against maxPlayers (owner's choice)
+	reservedSeats map[string]time.Time // clientID -> seat held until (reconnect grace)
+	lockedSeats   int                  // seats left by dead players mid-game, kept empty until reset
+	clock         Clock                // the server's, for seat reservations and pauses running out
+	createdAt     time.Time
+	game          *game.GameState            // used for scheduled/private mode (shared game)
+	playerGames   map[string]*game.GameState // continuous and race modes: each player has their own game state
+	clients       map[string]*Client
+	deadPlayers   map[string]bool      // names banned from rejoining until reset
+	bannedClients map[string]string    // ban key (session or name) -> kicked player's name
+	chatMutes     map[string]time.Time // clientID -> owner-issued chat mute expiry
+	graceTimers   map[string]Timer     // clientID -> reconnect grace expiry
+	fortTimers    map[string]Timer     // clientID -> fort visit expiry
+	huntTimers    map[string]Timer     // clientID -> hunt shot deadline
+	timeouts      map[string]int       // clientID -> consecutive turn timeouts
+	rules         RoomRules
+	chatMode      ChatMode
+	turnTimer     Timer
+	turnDeadline  time.Time
+	warnTimer     Timer // fires turnWarningLead before turnDeadline
+	// Auto-start countdown once a waiting scheduled room fills up
+	countdownTimer    Timer
+	countdownDeadline time.Time
+	closed            bool   // set once the room is removed; late timer callbacks bail out
+	persistent        bool   // always-on continuous room, saved to saveFile and never cleaned up
+	shard             bool   // one of the open trail's shards, which new players are spread across
+	saveFile          string // file name under the data path for persistent rooms
+	stateLoaded       bool   // saved state was read at startup
+
+	// Pause state for scheduled rooms: the turn time left when paused
+	paused          bool
+	pausedAt        time.Time
+	pausedRemaining time.Duration
+
+	// restored marks a party game brought back after a restart; it stays
+	// paused until its first player reconnects
+	restored bool
+
+	// Raids between wagons in continuous rooms
+	raids         map[string]*pendingRaid // defender clientID -> raid awaiting their answer
+	raidCooldowns map[string]time.Time    // clientID -> left alone by raiders until
+
+	gameOver *GameOverSummary // a game that just ended, until it is broadcast
+
+	// Trail news in continuous rooms waiting to be broadcast, and when each
+	// client's wagon made news within the last hour
+	trailNews []string
+	newsSent  map[string][]time.Time
+
+	// When a seasonal reset will wipe the continuous trail, if one is due
+	seasonEndsAt time.Time
+	seasonTimer  Timer
+
+	mu sync.RWMutex
+}
+
+// RoomRules are optional rule tweaks chosen by the room's creator.
+type RoomRules struct {
+	// NoHandHolding skips the confirmation asked before fatal moves
+	NoHandHolding bool `json:"no_hand_holding"`
+	// Occupation, if set, is everyone's occupation; players can't choose
+	Occupation game.Occupation `json:"occupation,omitempty"`
+	// FortInterval, if set, is how many turns apart forts are in this room
+	// instead of the server's fort_interval
+	FortInterval int `json:"fort_interval,omitempty"`
 }
 
 type LobbyInfo struct {
-	ID            string `json:"id"`
-	Name          string `json:"name"`
-	RoomType      string `json:"room_type"`
-	PlayerCount   int    `json:"player_count"`
-	MaxPlayers    int    `json:"max_players"`
-	HasPassword   bool   `json:"has_password"`
-	Status        string `json:"status"`
-	OwnerID       string `json:"owner_id"`
-	LootSiteCount int    `json:"loot_site_count"`
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	RoomType      string    `json:"room_type"`
+	PlayerCount   int       `json:"player_count"`
+	MaxPlayers    int       `json:"max_players"`
+	HasPassword   bool      `json:"has_password"`
+	Status        string    `json:"status"`
+	OwnerID       string    `json:"owner_id"`
+	LootSiteCount int       `json:"loot_site_count"`
+	Seats         SeatInfo  `json:"seats"`
+	Rules         RoomRules `json:"rules"`
+	CreatedAt     time.Time `json:"created_at"`
+	Joinable      bool      `json:"joinable"` // a new player could take a seat now
+	IsMine        bool      `json:"is_mine"`  // made by the session asking
+}
+
+// LobbyFilter narrows and orders the lobby list. The zero value lists every
+// room. Continuous rooms are always listed, first.
+type LobbyFilter struct {
+	Status      GameStatus // only rooms with this status, if set
+	HasPassword *bool      // only rooms with or without a password, if set
+	HideFull    bool       // drop rooms with no open seat
+	Sort        string     // "created", "players" or "name"; default is by ID
+}
+
+// matches reports whether a scheduled room's lobby entry passes the filter.
+func (f LobbyFilter) matches(info LobbyInfo) bool {
+	if f.Status != "" && GameStatus(info.Status) != f.Status {
+		return false
+	}
+	if f.HasPassword != nil && info.HasPassword != *f.HasPassword {
+		return false
+	}
+	if f.HideFull && info.Seats.Open == 0 {
+		return false
+	}
+	return true
+}
+
+// less orders two lobby entries, continuous rooms first.
+func (f LobbyFilter) less(a, b LobbyInfo) bool {
+	aCont, bCont := a.RoomType == string(RoomTypeContinuous), b.RoomType == string(RoomTypeContinuous)
+	if aCont != bCont {
+		return aCont
+	}
+	switch f.Sort {
+	case "created":
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+	case "players":
+		if a.PlayerCount != b.PlayerCount {
+			return a.PlayerCount > b.PlayerCount
+		}
+	case "name":
+		if an, bn := strings.ToLower(a.Name), strings.ToLower(b.Name); an != bn {
+			return an < bn
+		}
+	}
+	return a.ID < b.ID
 }
 
 type Server struct {
+	clock          Clock
 	rooms          map[string]*GameRoom
 	roomsMu        sync.RWMutex
 	sessionManager *SessionManager
 	leaderboard    *Leaderboard
+	journeys       *JourneyLog
+	chatFilter     *ChatFilter
 	hub            *Hub
 	dataPath       string
+
+	// roomsFileMu orders writes of the saved party games
+	roomsFileMu sync.Mutex
+	// saves counts the saves running in the background
+	saves sync.WaitGroup
+
+	// Caps on how many games one player name may hold at once
+	maxContinuousRuns int
+	maxPartyRooms     int
+
+	// defaultRoomID is where players land when they don't ask for a room;
+	// empty when the server has no persistent rooms
+	defaultRoomID string
+
+	// adminToken authorizes operator endpoints; they're disabled when empty
+	adminToken string
+
+	// saveSecret signs the saves players export, and saveNonces records
+	// the ones already imported
+	saveSecret []byte
+	saveNonces *saveNonces
+
+	// staticDir serves the web client from disk instead of the embedded
+	// copy, for development
+	staticDir string
+
+	// seasonalReset starts a new season on a continuous trail a day after
+	// someone wins it; npcSeed is what the fresh trail is seeded with
+	seasonalReset bool
+	npcSeed       NPCSeedConfig
+
+	// maxRooms caps the rooms players may create, and maxScheduledRooms the
+	// party rooms among them; 0 is no cap
+	maxRooms          int
+	maxScheduledRooms int
+
+	// Room creations each address has left, roomCreateLimit per
+	// roomCreateWindow
+	roomCreateLimit  int
+	roomCreateWindow time.Duration
+	createLimits     map[string]*tokenBucket
+	createLimitsMu   sync.Mutex
+
+	// turnTime is how long each turn of a scheduled game lasts; a fort
+	// appears every fortInterval turns unless the room sets its own
+	turnTime     time.Duration
+	fortInterval int
+
+	// ownerIdleTimeout is how long a waiting room's owner may go quiet
+	// before the room is handed on; 0 never does
+	ownerIdleTimeout time.Duration
+
+	// Private notices waiting for their client to connect
+	notices   map[string][]Notice
+	noticesMu sync.Mutex
+
+	// clientID -> the room the client most recently joined
+	clientRooms   map[string]string
+	clientRoomsMu sync.RWMutex
 }
 
 type Client struct {
@@ -75,44 +253,120 @@ type Client struct {
 	Player    *game.Player
 	SessionID string
 	RoomID    string
+	Spectator bool
+
+	// Disconnected is set while a dropped player's seat is held for reconnect
+	Disconnected bool
+	// Ready is the player's answer to the ready check before a party game
+	Ready bool
+	// JoinedAt is when the client first joined; a resumed session keeps it
+	JoinedAt time.Time
+	// LatencyMs is the client's average round trip, as its pings report it
+	// (0 until one does)
+	LatencyMs int
+	// LastActiveAt is when the player last did anything; see
+	// automaticMessages
+	LastActiveAt time.Time
 }
 
 const roomIDChars = "abcdefghijklmnopqrstuvwxyz0123456789"
 
+// generateRoomID returns a random 6-character room ID from crypto/rand.
 func generateRoomID() string {
-	b := make([]byte, 6)
-	for i := range b {
-		b[i] = roomIDChars[rand.Intn(len(roomIDChars))]
+	// Bytes at or above the largest multiple of len(roomIDChars) are
+	// rejected so every character is equally likely
+	limit := byte(256 - 256%len(roomIDChars))
+	id := make([]byte, 0, 6)
+	buf := make([]byte, 16)
+	for len(id) < cap(id) {
+		rand.Read(buf)
+		for _, b := range buf {
+			if b < limit && len(id) < cap(id) {
+				id = append(id, roomIDChars[int(b)%len(roomIDChars)])
+			}
+		}
 	}
-	return string(b)
+	return string(id)
 }
 
-func NewGameRoom(id, name string, roomType RoomType) *GameRoom {
+func NewGameRoom(id, name string, roomType RoomType, clock Clock) *GameRoom {
 	return &GameRoom{
-		id:          id,
-		name:        name,
-		roomType:    roomType,
-		status:      StatusWaiting,
-		createdAt:   time.Now(),
-		game:        game.NewGameState(),
-		playerGames: make(map[string]*game.GameState),
-		clients:     make(map[string]*Client),
-		deadPlayers: make(map[string]bool),
+		id:            id,
+		name:          name,
+		roomType:      roomType,
+		status:        StatusWaiting,
+		clock:         clock,
+		createdAt:     clock.Now(),
+		game:          game.NewGameState(),
+		playerGames:   make(map[string]*game.GameState),
+		clients:       make(map[string]*Client),
+		deadPlayers:   make(map[string]bool),
+		bannedClients: make(map[string]string),
+		reservedSeats: make(map[string]time.Time),
+		chatMutes:     make(map[string]time.Time),
+		graceTimers:   make(map[string]Timer),
+		fortTimers:    make(map[string]Timer),
+		huntTimers:    make(map[string]Timer),
+		timeouts:      make(map[string]int),
+		coOwners:      make(map[string]bool),
+		raids:         make(map[string]*pendingRaid),
+		raidCooldowns: make(map[string]time.Time),
+		newsSent:      make(map[string][]time.Time),
+		chatMode:      ChatOpen,
 	}
 }
 
-func NewServer(dataPath string) *Server {
+func NewServer(cfg *Config) *Server {
+	clock := realClock{}
 	s := &Server{
+		clock:          clock,
 		rooms:          make(map[string]*GameRoom),
-		sessionManager: NewSessionManager(),
-		leaderboard:    NewLeaderboard(dataPath),
-		dataPath:       dataPath,
-	}
-	// Create the permanent continuous room
-	continuous := NewGameRoom("continuous", "The Open Trail", RoomTypeContinuous)
-	s.rooms["continuous"] = continuous
-	// Load persisted game state if exists
-	s.loadGameState()
+		sessionManager: NewSessionManager(clock),
+		leaderboard:    NewLeaderboard(cfg.DataPath),
+		journeys:       NewJourneyLog(cfg.DataPath),
+		chatFilter:     NewChatFilter(filepath.Join(cfg.DataPath, chatFilterFile)),
+		dataPath:       cfg.DataPath,
+		notices:        make(map[string][]Notice),
+		clientRooms:    make(map[string]string),
+
+		maxContinuousRuns: cfg.MaxContinuousRuns,
+		maxPartyRooms:     cfg.MaxPartyRooms,
+		maxRooms:          cfg.MaxRooms,
+		maxScheduledRooms: cfg.MaxScheduledRooms,
+
+		roomCreateLimit:  cfg.RoomCreateLimit,
+		roomCreateWindow: time.Duration(cfg.RoomCreateWindow),
+		createLimits:     make(map[string]*tokenBucket),
+
+		adminToken:    cfg.AdminToken,
+		staticDir:     cfg.StaticDir,
+		seasonalReset: cfg.SeasonalReset,
+		turnTime:      time.Duration(cfg.TurnTime),
+		fortInterval:  cfg.FortInterval,
+
+		ownerIdleTimeout: time.Duration(cfg.OwnerIdleTimeout),
+	}
+	secret, err := loadSaveSecret(cfg.SaveSecret, cfg.DataPath)
+	if err != nil {
+		log.Fatalf("Can't set up the save secret: %v", err)
+	}
+	s.saveSecret = secret
+	s.saveNonces = loadSaveNonces(cfg.DataPath)
+	s.npcSeed = defaultNPCSeed
+	s.npcSeed.Sites = cfg.NPCSites
+	s.npcSeed.Graves = cfg.NPCGraves
+
+	// Create the persistent rooms and load their saved state if it exists
+	for _, rc := range cfg.rooms {
+		room := newPersistentRoom(rc, s.clock)
+		s.rooms[room.id] = room
+		s.loadGameState(room)
+	}
+	s.defaultRoomID = cfg.DefaultRoom
+	if s.defaultRoomID == "" && len(cfg.rooms) > 0 {
+		s.defaultRoomID = cfg.rooms[0].ID
+	}
+	s.loadRooms()
 	return s
 }
 
@@ -122,141 +376,318 @@ func initRoomResources(room *GameRoom) {
 	room.game.Bullets = 50
 	room.game.Clothing = 20
 	room.game.MiscSupplies = 10
-	room.game.Cash = 700
+	if room.rules.Occupation != "" {
+		for _, p := range room.game.Players {
+			p.Occupation = room.rules.Occupation
+		}
+	}
+	room.game.Cash = game.PartyStartingCash(room.game.Players)
 	room.game.GameOver = false
 	room.game.Win = false
 	room.game.CurrentPlayerIdx = 0
 }
 
+// newOwnGame makes a player's own game, as a continuous player or racer has,
+// standing at the start of the trail.
+func newOwnGame(name, clientID string) (*game.GameState, *game.Player) {
+	g := game.NewGameState()
+	g.OxenCost = 220
+	g.Food = 100
+	g.Bullets = 50
+	g.Clothing = 20
+	g.MiscSupplies = 10
+	g.Cash = 700
+	g.GameOver = false
+	g.Win = false
+	g.CurrentPlayerIdx = 0
+	g.TurnNumber = 1
+	g.TurnPhase = game.PhaseMainMenu
+	g.Week = 1
+	g.Day = 1
+
+	player := g.AddPlayer(name, game.PlayerTypeHuman)
+	player.ID = clientID
+	return g, player
+}
+
+// outfitWagon sets a player's own game out from the start of the trail with
+// fresh supplies and a healthy party.
+func outfitWagon(g *game.GameState, player *game.Player) {
+	g.OxenCost = 220
+	g.Food = 100
+	g.Bullets = 50
+	g.Clothing = 20
+	g.MiscSupplies = 10
+	g.Cash = player.Occupation.StartingCash()
+	g.GameOver = false
+	g.Win = false
+	g.TurnNumber = 1
+	g.Mileage = 0
+	g.DistanceTraveled = 0
+	g.Week = 1
+	g.Day = 1
+	g.MilestonesReached = 0
+	g.TurnPhase = game.PhaseMainMenu
+
+	// Reset player party
+	names := []string{"You", "Wife", "Son", "Daughter", "Baby"}
+	for i := range player.Party {
+		player.Party[i].Alive = true
+		player.Party[i].Health = 100
+		player.Party[i].Injured = false
+		player.Party[i].DiedOf = ""
+		player.Party[i].DiedOnTurn = 0
+		if i < len(names) {
+			player.Party[i].Name = names[i]
+		}
+	}
+	player.Alive = true
+}
+
 type PersistedGameState struct {
-	PlayerName       string          `json:"player_name"`
-	TurnNumber       int             `json:"turn_number"`
-	Mileage          float64         `json:"mileage"`
-	DistanceTraveled int             `json:"distance_traveled"`
-	Week             int             `json:"week"`
-	Day              int             `json:"day"`
-	Food             float64         `json:"food"`
-	Bullets          float64         `json:"bullets"`
-	Clothing         float64         `json:"clothing"`
-	MiscSupplies     float64         `json:"misc_supplies"`
-	Cash             float64         `json:"cash"`
-	OxenCost         float64         `json:"oxen_cost"`
-	TurnPhase        game.TurnPhase  `json:"turn_phase"`
-	GameOver         bool            `json:"game_over"`
-	Win              bool            `json:"win"`
-	CurrentPlayerIdx int             `json:"current_player_idx"`
-	LootSites        []game.LootSite `json:"loot_sites"`
-	FortAvailable    bool            `json:"fort_available"`
+	PlayerName       string             `json:"player_name"`
+	TurnNumber       int                `json:"turn_number"`
+	Mileage          float64            `json:"mileage"`
+	DistanceTraveled int                `json:"distance_traveled"`
+	LandmarksReached int                `json:"landmarks_reached,omitempty"`
+	Week             int                `json:"week"`
+	Day              int                `json:"day"`
+	Weather          string             `json:"weather,omitempty"`
+	Pace             game.Pace          `json:"pace,omitempty"`
+	Food             float64            `json:"food"`
+	Bullets          float64            `json:"bullets"`
+	Clothing         float64            `json:"clothing"`
+	MiscSupplies     float64            `json:"misc_supplies"`
+	Cash             float64            `json:"cash"`
+	OxenCost         float64            `json:"oxen_cost"`
+	TurnPhase        game.TurnPhase     `json:"turn_phase"`
+	GameOver         bool               `json:"game_over"`
+	Win              bool               `json:"win"`
+	CurrentPlayerIdx int                `json:"current_player_idx"`
+	TurnOrder        []string           `json:"turn_order,omitempty"`
+	LootSites        []game.LootSite    `json:"loot_sites"`
+	FortAvailable    bool               `json:"fort_available"`
+	FortTrades       int                `json:"fort_trades,omitempty"`
+	HasStrongAxle    bool               `json:"has_strong_axle,omitempty"`
+	HasWaterBarrel   bool               `json:"has_water_barrel,omitempty"`
+	HasRifleScope    bool               `json:"has_rifle_scope,omitempty"`
+	HasWagonBed      bool               `json:"has_wagon_bed,omitempty"`
+	Party            []game.PartyMember `json:"party,omitempty"`
+	Occupation       game.Occupation    `json:"occupation,omitempty"`
+	Peaceful         bool               `json:"peaceful,omitempty"`
+	Parts            map[string]int     `json:"parts,omitempty"`
+	Seed             int64              `json:"seed,omitempty"`
+	Draws            uint64             `json:"draws,omitempty"`
+
+	Contributions    map[string]*game.Contribution `json:"contributions,omitempty"`
+	TurnStartMileage float64                       `json:"turn_start_mileage,omitempty"`
+	EventLog         []game.JournalEntry           `json:"event_log,omitempty"`
+	Milestones       int                           `json:"milestones_reached,omitempty"`
 }
 
 // PersistedContinuousState saves the state for continuous mode (per-player games)
 type PersistedContinuousState struct {
-	LootSites      []game.LootSite               `json:"loot_sites"`
-	PlayerGames    map[string]PersistedGameState `json:"player_games"`
-	GameWon        bool                          `json:"game_won"`
-	WinnerPlayerID string                        `json:"winner_player_id"`
-}
+	SchemaVersion int `json:"schema_version"`
 
-func (s *Server) getGameStateFilePath() string {
-	if s.dataPath == "" {
-		s.dataPath = "."
-	}
-	return filepath.Join(s.dataPath, "game_state.json")
+	LootSites    []game.LootSite               `json:"loot_sites"`
+	TrailNotes   []game.TrailNote              `json:"trail_notes,omitempty"`
+	PlayerGames  map[string]PersistedGameState `json:"player_games"`
+	SeasonEndsAt *time.Time                    `json:"season_ends_at,omitempty"`
 }
 
-func (s *Server) loadGameState() {
-	room := s.GetRoom("continuous")
-	if room == nil {
-		return
-	}
-
-	filePath := s.getGameStateFilePath()
-	data, err := os.ReadFile(filePath)
-	if err != nil {
+func (s *Server) loadGameState(room *GameRoom) {
+	filePath := s.gameStateFilePath(room)
+	var doc map[string]json.RawMessage
+	err := readSaveFile(filePath, func(data []byte) (err error) {
+		doc, err = parseSaveDoc(data)
+		return err
+	})
+	if os.IsNotExist(err) {
 		log.Printf("No saved game state found at %s (this is normal on first run)", filePath)
 		return
 	}
-
 	var persisted PersistedContinuousState
-	if err := json.Unmarshal(data, &persisted); err != nil {
-		log.Printf("Failed to parse saved game state: %v", err)
-		return
+	if err == nil {
+		err = migrateSave(doc, continuousMigrations, &persisted)
+	}
+	if err != nil {
+		// Starting over would overwrite whatever is left of the save
+		log.Fatalf("Can't load saved game state from %s: %v", filePath, err)
 	}
 
 	room.mu.Lock()
 	defer room.mu.Unlock()
+	room.stateLoaded = true
 
-	// Load loot sites
+	// Load loot sites and trail notes
 	room.game.LootSites = persisted.LootSites
+	room.game.TrailNotes = persisted.TrailNotes
+	room.game.PruneNotes(s.clock.Now())
 
 	// Load each player's game state
 	for playerID, playerData := range persisted.PlayerGames {
-		playerGame := game.NewGameState()
-		playerGame.TurnNumber = playerData.TurnNumber
-		playerGame.Mileage = playerData.Mileage
-		playerGame.DistanceTraveled = playerData.DistanceTraveled
-		playerGame.Week = playerData.Week
-		playerGame.Day = playerData.Day
-		playerGame.Food = playerData.Food
-		playerGame.Bullets = playerData.Bullets
-		playerGame.Clothing = playerData.Clothing
-		playerGame.MiscSupplies = playerData.MiscSupplies
-		playerGame.Cash = playerData.Cash
-		playerGame.OxenCost = playerData.OxenCost
-		playerGame.TurnPhase = playerData.TurnPhase
-		playerGame.GameOver = playerData.GameOver
-		playerGame.Win = playerData.Win
-		playerGame.CurrentPlayerIdx = playerData.CurrentPlayerIdx
-		playerGame.FortAvailable = playerData.FortAvailable
-
-		// Add player to the game
-		player := playerGame.AddPlayer(playerData.PlayerName, game.PlayerTypeHuman)
-		player.ID = playerID
-		player.Alive = !playerData.GameOver
-
-		room.playerGames[playerID] = playerGame
+		room.playerGames[playerID] = restorePlayerGame(playerID, playerData.PlayerName, playerData)
 
 		log.Printf("Loaded game for player %s: Turn %d, Mileage %.0f, Week %d",
 			playerID, playerData.TurnNumber, playerData.Mileage, playerData.Week)
 	}
 
-	if persisted.GameWon {
-		room.status = StatusFinished
-	} else if len(room.playerGames) > 0 {
+	if persisted.SeasonEndsAt != nil {
+		room.seasonEndsAt = *persisted.SeasonEndsAt
+	}
+	if len(room.playerGames) > 0 {
 		room.status = StatusPlaying
 	}
 
-	log.Printf("Game state loaded: %d players, %d loot sites, Status %s",
-		len(room.playerGames), len(room.game.LootSites), room.status)
+	log.Printf("Game state loaded for %s: %d players, %d loot sites, Status %s",
+		room.id, len(room.playerGames), len(room.game.LootSites), room.status)
 }
 
-func (s *Server) saveGameState() {
-	room := s.GetRoom("continuous")
-	if room == nil {
-		return
+// restoreGame rebuilds a game from its saved form, without its players.
+func restoreGame(saved PersistedGameState) *game.GameState {
+	// The draws resume where the save left them. Saves from before seeds
+	// or draws were kept get a fresh seed rather than replaying the
+	// sequence from its start.
+	g := game.NewGameState()
+	if saved.Seed != 0 && saved.Draws > 0 {
+		g = game.NewGameStateWithSeed(saved.Seed)
+		g.ResumeDraws(saved.Draws)
+	}
+	g.TurnNumber = saved.TurnNumber
+	g.Mileage = saved.Mileage
+	g.DistanceTraveled = saved.DistanceTraveled
+	g.Week = saved.Week
+	g.Day = saved.Day
+	g.Weather = saved.Weather
+	if saved.Pace != "" {
+		g.Pace = saved.Pace
+	}
+	g.Food = saved.Food
+	g.Bullets = saved.Bullets
+	g.Clothing = saved.Clothing
+	g.MiscSupplies = saved.MiscSupplies
+	g.Cash = saved.Cash
+	g.OxenCost = saved.OxenCost
+	g.TurnPhase = saved.TurnPhase
+	if g.TurnPhase == game.PhaseHunting {
+		// The hunt clock doesn't survive a restart
+		g.IssueHuntPrompt()
+	}
+	g.GameOver = saved.GameOver
+	g.Win = saved.Win
+	g.CurrentPlayerIdx = saved.CurrentPlayerIdx
+	g.TurnOrder = saved.TurnOrder
+	g.FortAvailable = saved.FortAvailable
+	g.FortTrades = saved.FortTrades
+	g.HasStrongAxle = saved.HasStrongAxle
+	g.HasWaterBarrel = saved.HasWaterBarrel
+	g.HasRifleScope = saved.HasRifleScope
+	g.HasWagonBed = saved.HasWagonBed
+	g.AddParts(saved.Parts)
+	g.TurnStartMileage = saved.TurnStartMileage
+	g.LandmarksReached = saved.LandmarksReached
+	if g.LandmarksReached == 0 {
+		// Saved before landmarks were tracked
+		g.MarkLandmarksPassed()
+	}
+	g.MilestonesReached = saved.Milestones
+	if g.MilestonesReached == 0 {
+		// Saved before milestones were tracked
+		g.MarkMilestonesPassed()
+	}
+	if saved.Contributions != nil {
+		g.Contributions = saved.Contributions
+	} else {
+		// Saved before contributions were tracked
+		g.TurnStartMileage = saved.Mileage
 	}
+	return g
+}
 
-	room.mu.RLock()
-	defer room.mu.RUnlock()
+// restorePlayerGame rebuilds a continuous-mode player game, with its player,
+// from its saved form.
+func restorePlayerGame(playerID, playerName string, saved PersistedGameState) *game.GameState {
+	playerGame := restoreGame(saved)
+
+	// Add player to the game
+	player := playerGame.AddPlayer(playerName, game.PlayerTypeHuman)
+	player.ID = playerID
+	player.Alive = !saved.GameOver
+	player.Occupation = saved.Occupation
+	player.Peaceful = saved.Peaceful
+	playerGame.EventLog = append(playerGame.EventLog, saved.EventLog...)
+	if len(saved.Party) > 0 {
+		player.Party = saved.Party
+		player.Alive = player.Party[0].Alive && !saved.GameOver
+	}
+	return playerGame
+}
 
-	// Check if someone won
-	gameWon := false
-	winnerID := ""
-	for playerID, playerGame := range room.playerGames {
-		if playerGame.Win {
-			gameWon = true
-			winnerID = playerID
+// persistPlayerGame converts a continuous-mode player game into its saved form.
+func persistPlayerGame(playerID, playerName string, playerGame *game.GameState) PersistedGameState {
+	var party []game.PartyMember
+	var occupation game.Occupation
+	peaceful := false
+	for _, p := range playerGame.Players {
+		if p.ID == playerID {
+			party = append(party, p.Party...)
+			occupation = p.Occupation
+			peaceful = p.Peaceful
 			break
 		}
 	}
 
-	if gameWon {
-		filePath := s.getGameStateFilePath()
-		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
-			log.Printf("Failed to delete saved game state: %v", err)
-		} else {
-			log.Printf("Game won by %s - saved game state deleted", winnerID)
-		}
+	return PersistedGameState{
+		PlayerName:       playerName,
+		TurnNumber:       playerGame.TurnNumber,
+		Mileage:          playerGame.Mileage,
+		DistanceTraveled: playerGame.DistanceTraveled,
+		LandmarksReached: playerGame.LandmarksReached,
+		Week:             playerGame.Week,
+		Day:              playerGame.Day,
+		Weather:          playerGame.Weather,
+		Pace:             playerGame.CurrentPace(),
+		Food:             playerGame.Food,
+		Bullets:          playerGame.Bullets,
+		Clothing:         playerGame.Clothing,
+		MiscSupplies:     playerGame.MiscSupplies,
+		Cash:             playerGame.Cash,
+		OxenCost:         playerGame.OxenCost,
+		TurnPhase:        playerGame.TurnPhase,
+		GameOver:         playerGame.GameOver,
+		Win:              playerGame.Win,
+		CurrentPlayerIdx: playerGame.CurrentPlayerIdx,
+		TurnOrder:        playerGame.TurnOrder,
+		FortAvailable:    playerGame.FortAvailable,
+		FortTrades:       playerGame.FortTrades,
+		HasStrongAxle:    playerGame.HasStrongAxle,
+		HasWaterBarrel:   playerGame.HasWaterBarrel,
+		HasRifleScope:    playerGame.HasRifleScope,
+		HasWagonBed:      playerGame.HasWagonBed,
+		Contributions:    playerGame.Contributions,
+		TurnStartMileage: playerGame.TurnStartMileage,
+		Party:            party,
+		Occupation:       occupation,
+		Peaceful:         peaceful,
+		EventLog:         playerGame.EventLog,
+		Milestones:       playerGame.MilestonesReached,
+		Parts:            game.CopyParts(playerGame.Parts),
+		Seed:             playerGame.Seed,
+		Draws:            playerGame.Draws(),
+	}
+}
+
+// saveGameState writes a persistent room's state to disk.
+func (s *Server) saveGameState(room *GameRoom) {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	s.saveGameStateLocked(room)
+}
+
+// saveGameStateLocked is saveGameState for callers already holding the lock.
+// NOTE: caller must hold room.mu.
+func (s *Server) saveGameStateLocked(room *GameRoom) {
+	if !room.persistent {
 		return
 	}
 
@@ -271,31 +702,17 @@ func (s *Server) saveGameState() {
 			}
 		}
 
-		playerGames[playerID] = PersistedGameState{
-			PlayerName:       playerName,
-			TurnNumber:       playerGame.TurnNumber,
-			Mileage:          playerGame.Mileage,
-			DistanceTraveled: playerGame.DistanceTraveled,
-			Week:             playerGame.Week,
-			Day:              playerGame.Day,
-			Food:             playerGame.Food,
-			Bullets:          playerGame.Bullets,
-			Clothing:         playerGame.Clothing,
-			MiscSupplies:     playerGame.MiscSupplies,
-			Cash:             playerGame.Cash,
-			OxenCost:         playerGame.OxenCost,
-			TurnPhase:        playerGame.TurnPhase,
-			GameOver:         playerGame.GameOver,
-			Win:              playerGame.Win,
-			CurrentPlayerIdx: playerGame.CurrentPlayerIdx,
-			FortAvailable:    playerGame.FortAvailable,
-		}
+		playerGames[playerID] = persistPlayerGame(playerID, playerName, playerGame)
 	}
 
 	persisted := PersistedContinuousState{
-		LootSites:   room.game.LootSites,
-		PlayerGames: playerGames,
-		GameWon:     gameWon,
+		SchemaVersion: continuousSchemaVersion,
+		LootSites:     room.game.LootSites,
+		TrailNotes:    room.game.TrailNotes,
+		PlayerGames:   playerGames,
+	}
+	if !room.seasonEndsAt.IsZero() {
+		persisted.SeasonEndsAt = &room.seasonEndsAt
 	}
 
 	data, err := json.MarshalIndent(persisted, "", "  ")
@@ -304,16 +721,10 @@ func (s *Server) saveGameState() {
 		return
 	}
 
-	filePath := s.getGameStateFilePath()
-	dir := filepath.Dir(filePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		log.Printf("Failed to create data directory: %v", err)
-		return
-	}
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	if err := writeSaveFile(s.gameStateFilePath(room), data); err != nil {
 		log.Printf("Failed to save game state: %v", err)
 	} else {
-		log.Printf("Game state saved: %d players, %d loot sites", len(playerGames), len(room.game.LootSites))
+		log.Printf("Game state saved for %s: %d players, %d loot sites", room.id, len(playerGames), len(room.game.LootSites))
 	}
 }
 
@@ -338,23 +749,41 @@ func (s *Server) IsPlayerBanned(roomID, name string) bool {
 }
 
 func (s *Server) FindRoomForClient(clientID string) *GameRoom {
-	s.roomsMu.RLock()
-	defer s.roomsMu.RUnlock()
-	for _, room := range s.rooms {
-		room.mu.RLock()
-		_, ok := room.clients[clientID]
-		room.mu.RUnlock()
-		if ok {
-			return room
-		}
+	s.clientRoomsMu.RLock()
+	roomID, ok := s.clientRooms[clientID]
+	s.clientRoomsMu.RUnlock()
+	if !ok {
+		return nil
 	}
-	return nil
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return nil
+	}
+	room.mu.RLock()
+	_, ok = room.clients[clientID]
+	room.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return room
 }
 
-func (s *Server) CreateRoom(name, password, ownerID string, maxPlayers int) *GameRoom {
+// CreateRoom opens a private room for creator: a party game around a shared
+// wagon, or a race in which every player drives their own. Races have no
+// CPU players. The room has no owner until a player's websocket claims it.
+// It fails when players have already made as many rooms as the server
+// allows, or creator already has maxRoomsPerSession open.
+func (s *Server) CreateRoom(name, password string, creator *Session, roomType RoomType, maxPlayers, botSeats int, botsTakeSeats bool, rules RoomRules) (*GameRoom, error) {
 	s.roomsMu.Lock()
 	defer s.roomsMu.Unlock()
 
+	if s.roomLimitReached(roomType) {
+		return nil, errServerFull
+	}
+	if s.openRoomsBy(creator.ID) >= maxRoomsPerSession {
+		return nil, errTooManyOwnRooms
+	}
+
 	// Generate unique room ID
 	var id string
 	for {
@@ -364,43 +793,71 @@ func (s *Server) CreateRoom(name, password, ownerID string, maxPlayers int) *Gam
 		}
 	}
 
-	room := NewGameRoom(id, name, RoomTypeScheduled)
-	room.password = password
-	room.ownerID = ownerID
+	if roomType == RoomTypeRace {
+		botSeats = 0
+	}
+	room := NewGameRoom(id, name, roomType, s.clock)
+	if password != "" {
+		room.password, _ = newNameClaim(password)
+	}
+	room.creator = creator.ID
 	room.maxPlayers = maxPlayers
+	room.botSeats = botSeats
+	room.botsTakeSeats = botsTakeSeats
+	room.rules = rules
 	s.rooms[id] = room
-	log.Printf("Room created: %s (%s) by %s", name, id, ownerID)
-	return room
+	s.notifyLobby()
+	log.Printf("Room created: %s (%s) by %s", name, id, creator.Name)
+	return room, nil
 }
 
-func (s *Server) ListLobbies() []LobbyInfo {
+// ListLobbies lists the rooms filter lets through, marking those made by
+// sessionID as the caller's own.
+func (s *Server) ListLobbies(filter LobbyFilter, sessionID string) []LobbyInfo {
 	s.roomsMu.RLock()
 	defer s.roomsMu.RUnlock()
 
 	lobbies := make([]LobbyInfo, 0, len(s.rooms))
 	for _, room := range s.rooms {
 		room.mu.RLock()
-		var lootCount int
-		if room.roomType == RoomTypeContinuous && room.game != nil {
-			lootCount = len(room.game.LootSites)
-		}
-		info := LobbyInfo{
-			ID:            room.id,
-			Name:          room.name,
-			RoomType:      string(room.roomType),
-			PlayerCount:   len(room.clients),
-			MaxPlayers:    room.maxPlayers,
-			HasPassword:   room.password != "",
-			Status:        string(room.status),
-			OwnerID:       room.ownerID,
-			LootSiteCount: lootCount,
-		}
+		info := room.lobbyInfo()
 		room.mu.RUnlock()
+		info.IsMine = sessionID != "" && room.creator == sessionID
+		if room.roomType != RoomTypeContinuous && !filter.matches(info) {
+			continue
+		}
 		lobbies = append(lobbies, info)
 	}
+	sort.Slice(lobbies, func(i, j int) bool { return filter.less(lobbies[i], lobbies[j]) })
 	return lobbies
 }
 
+// lobbyInfo builds the room's entry in the lobby list.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) lobbyInfo() LobbyInfo {
+	var lootCount int
+	if r.roomType == RoomTypeContinuous && r.game != nil {
+		lootCount = len(r.game.LootSites)
+	}
+	seats := r.Seats()
+	return LobbyInfo{
+		ID:            r.id,
+		Name:          r.name,
+		RoomType:      string(r.roomType),
+		PlayerCount:   seats.Humans,
+		MaxPlayers:    r.maxPlayers,
+		HasPassword:   r.password != nil,
+		Status:        string(r.status),
+		OwnerID:       r.ownerID,
+		LootSiteCount: lootCount,
+		Seats:         seats,
+		Rules:         r.rules,
+		CreatedAt:     r.createdAt,
+		Joinable: seats.Open != 0 &&
+			(r.roomType == RoomTypeContinuous || r.status == StatusWaiting),
+	}
+}
+
 func (s *Server) AddClient(c *Client, roomID string) {
 	room := s.GetRoom(roomID)
 	if room == nil {
@@ -409,21 +866,49 @@ func (s *Server) AddClient(c *Client, roomID string) {
 	room.mu.Lock()
 	defer room.mu.Unlock()
 
+	if room.closed {
+		log.Printf("Player %s tried to join %s but the room was closed", c.Name, roomID)
+		return
+	}
+
+	// Re-check capacity under the write lock; anyone who lost the race for
+	// the last seat watches instead of overfilling the room
+	if !c.Spectator && !room.CanJoin(SeatHuman, c.ID) {
+		log.Printf("Room %s is full, %s joins as a spectator", roomID, c.Name)
+		c.Spectator = true
+	}
+
+	// Lost a race with another connection using the same name
+	if room.nameInUse(c.Name, c.ID) {
+		c.Name = room.uniqueName(c.Name, c.ID)
+		log.Printf("Name collision in %s, joining as %s", roomID, c.Name)
+	}
+
+	reconnected := s.resumeFromReconnect(room, c.ID)
+	defer s.notifyLobby()
+
 	c.RoomID = roomID
+	if old, ok := room.clients[c.ID]; ok {
+		c.JoinedAt = old.JoinedAt
+	} else {
+		c.JoinedAt = s.clock.Now()
+	}
+	c.LastActiveAt = s.clock.Now()
 	room.clients[c.ID] = c
+	s.indexClient(c.ID, roomID)
+	room.releaseSeat(c.ID)
 
 	if c.SessionID != "" {
 		s.sessionManager.UpdateClient(c.SessionID, c.ID)
 	}
 
-	// In scheduled mode, only add player if game is waiting
-	if room.roomType == RoomTypeScheduled && room.status != StatusWaiting {
-		log.Printf("Player %s tried to join %s but game already started", c.Name, roomID)
+	if c.Spectator {
+		log.Printf("Spectator %s joined %s (ID: %s)", c.Name, roomID, c.ID)
 		return
 	}
 
-	if room.roomType == RoomTypeContinuous {
-		// Continuous mode: each player has their own independent game
+	if room.perPlayerGames() {
+		// Continuous and race modes: each player has their own independent game
 		if existingGame, ok := room.playerGames[c.ID]; ok {
 			// Reconnecting player - find their player in the game
 			c.Player = nil
@@ -439,57 +924,57 @@ func (s *Server) AddClient(c *Client, roomID string) {
 				player.ID = c.ID
 				c.Player = player
 			}
-			log.Printf("Player %s reconnected to continuous %s (ID: %s)", c.Name, roomID, c.ID)
+			log.Printf("Player %s reconnected to %s %s (ID: %s)", c.Name, room.roomType, roomID, c.ID)
+		} else if room.roomType == RoomTypeRace && room.status != StatusWaiting {
+			// Only racers already on the trail may join once the race has started
+			log.Printf("Player %s tried to join race %s but it already started", c.Name, roomID)
+			return
 		} else {
 			// New player in continuous mode - create their own game state
-			newGame := game.NewGameState()
-			newGame.OxenCost = 220
-			newGame.Food = 100
-			newGame.Bullets = 50
-			newGame.Clothing = 20
-			newGame.MiscSupplies = 10
-			newGame.Cash = 700
-			newGame.GameOver = false
-			newGame.Win = false
-			newGame.CurrentPlayerIdx = 0
-			newGame.TurnNumber = 1
-			newGame.TurnPhase = game.PhaseMainMenu
-			newGame.Week = 1
-			newGame.Day = 1
-
-			player := newGame.AddPlayer(c.Name, game.PlayerTypeHuman)
-			player.ID = c.ID
+			newGame, player := newOwnGame(c.Name, c.ID)
 			c.Player = player
 
 			room.playerGames[c.ID] = newGame
-			room.status = StatusPlaying
-			log.Printf("Continuous room %s: player %s started fresh at Turn 1, Mileage 0",
-				roomID, c.Name)
+			// A racer's wagon waits for a ready check and the owner's start
+			if room.roomType == RoomTypeContinuous {
+				room.status = StatusPlaying
+				log.Printf("Continuous room %s: player %s started fresh at Turn 1, Mileage 0",
+					roomID, c.Name)
+			}
 		}
 	} else {
 		// Scheduled/private mode: players share the room's game
 		var existingPlayer *game.Player
 		for _, p := range room.game.Players {
-			if p.ID == c.ID || p.Name == c.Name {
+			// Take over a player by name only if its original client is gone
+			if p.ID == c.ID || (p.Name == c.Name && room.clients[p.ID] == nil) {
 				existingPlayer = p
 				break
 			}
 		}
 
+		// Only players already in the game may join once it has started
+		if existingPlayer == nil && room.status != StatusWaiting {
+			log.Printf("Player %s tried to join %s but game already started", c.Name, roomID)
+			return
+		}
+
 		if existingPlayer != nil {
-			existingPlayer.ID = c.ID
+			room.game.ReplacePlayerID(existingPlayer, c.ID)
 			c.Player = existingPlayer
 			log.Printf("Player %s reconnected to %s (ID: %s)", c.Name, roomID, c.ID)
+			s.resumeRestored(room)
+
+			// Back within the grace period on their own turn: restart the clock
+			if cp := room.game.GetCurrentPlayer(); reconnected && cp == existingPlayer &&
+				room.status == StatusPlaying && !room.game.GameOver {
+				s.StartTurnTimer(room, c.ID)
+			}
 		} else {
 			player := room.game.AddPlayer(c.Name, game.PlayerTypeHuman)
 			player.ID = c.ID
 			c.Player = player
-
-			// If this is the first player in scheduled mode and waiting, auto-start
-			if room.status == StatusWaiting && len(room.clients) >= 1 {
-				initRoomResources(room)
-				room.status = StatusPlaying
-			}
+			// The game waits for a ready check and the owner's start
 		}
 
 		if room.game.GetCurrentPlayer() == nil && len(room.game.Players) > 0 {
@@ -500,26 +985,29 @@ func (s *Server) AddClient(c *Client, roomID string) {
 	log.Printf("Player %s joined %s (ID: %s)", c.Name, roomID, c.ID)
 }
 
-func (s *Server) RemoveClient(clientID string, roomID string) {
+// RemoveClient handles a dropped connection. Players in a running scheduled
+// game keep their seat for reconnectGrace; it reports whether that happened.
+func (s *Server) RemoveClient(clientID string, roomID string) bool {
 	room := s.GetRoom(roomID)
 	if room == nil {
-		return
+		return false
 	}
 	room.mu.Lock()
 	defer room.mu.Unlock()
+	defer s.notifyLobby()
 	if c, ok := room.clients[clientID]; ok {
-		delete(room.clients, clientID)
-		// Transfer ownership if the leaving client is the owner
-		if room.ownerID == clientID && len(room.clients) > 0 {
-			for _, next := range room.clients {
-				room.ownerID = next.ID
-				log.Printf("Ownership of room %s transferred to %s", roomID, next.Name)
-				break
-			}
+		if room.roomType == RoomTypeScheduled && room.status == StatusPlaying && !room.game.GameOver &&
+			!c.Spectator && c.Player != nil && c.Player.Alive {
+			s.holdForReconnect(room, c)
+			return true
 		}
+		s.dropClient(room, clientID)
+		s.cancelCountdownIfNotFull(room)
+		// Transfer ownership if the leaving client is the owner
+		transferOwnership(room, clientID)
 		log.Printf("Player %s disconnected from %s", c.Name, roomID)
-
 	}
+	return false
 }
 
 func (s *Server) LogoutClient(clientID, sessionID string, roomID string) {
@@ -535,14 +1023,15 @@ func (s *Server) LogoutClient(clientID, sessionID string, roomID string) {
 		if cp := room.game.GetCurrentPlayer(); cp != nil && cp.ID == clientID {
 			wasCurrentPlayer = true
 		}
-		for i, p := range room.game.Players {
+		for _, p := range room.game.Players {
 			if p.ID == clientID {
-				// If the player is dead and the game is in progress, reduce maxPlayers
+				// If the player is dead and the game is in progress, lock their
+				// seat so nobody takes it mid-game
 				// In continuous mode, allow dead players to rejoin as fresh players
 				// In private mode, ban them until the game resets
 				if !p.Alive && room.status == StatusPlaying {
 					if room.maxPlayers > 0 {
-						room.maxPlayers--
+						room.lockedSeats++
 					}
 					if room.roomType != RoomTypeContinuous {
 						room.deadPlayers[c.Name] = true
@@ -551,22 +1040,20 @@ func (s *Server) LogoutClient(clientID, sessionID string, roomID string) {
 						log.Printf("Dead player %s logged out of continuous room %s, can rejoin as fresh player", c.Name, roomID)
 					}
 				}
-				room.game.Players = append(room.game.Players[:i], room.game.Players[i+1:]...)
-				if room.game.CurrentPlayerIdx >= len(room.game.Players) && len(room.game.Players) > 0 {
-					room.game.CurrentPlayerIdx = 0
-				}
+				room.game.RemovePlayer(p.ID)
 				break
 			}
 		}
-		delete(room.clients, clientID)
-		// Transfer ownership if the leaving client is the owner
-		if room.ownerID == clientID && len(room.clients) > 0 {
-			for _, next := range room.clients {
-				room.ownerID = next.ID
-				log.Printf("Ownership of room %s transferred to %s", roomID, next.Name)
-				break
-			}
+		s.dropClient(room, clientID)
+		// A player held for reconnect gives up the seat for good
+		if t, ok := room.graceTimers[clientID]; ok {
+			t.Stop()
+			delete(room.graceTimers, clientID)
 		}
+		room.releaseSeat(clientID)
+		s.cancelCountdownIfNotFull(room)
+		// Transfer ownership if the leaving client is the owner
+		transferOwnership(room, clientID)
 		// If the leaving player was the current turn holder, reset phase and start timer for new current player
 		if wasCurrentPlayer && room.status == StatusPlaying && !room.game.GameOver {
 			room.game.TurnPhase = game.PhaseMainMenu
@@ -589,8 +1076,8 @@ func (s *Server) KickClient(roomID, requesterID, targetID string) bool {
 	room.mu.Lock()
 	defer room.mu.Unlock()
 
-	// Only the owner can kick
-	if room.ownerID != requesterID {
+	// Only the owner and co-owners can kick, and nobody kicks the owner
+	if !room.canModerate(requesterID) || targetID == room.ownerID {
 		return false
 	}
 	// Can't kick yourself
@@ -599,48 +1086,66 @@ func (s *Server) KickClient(roomID, requesterID, targetID string) bool {
 	}
 
 	if c, ok := room.clients[targetID]; ok {
-		wasCurrentPlayer := false
-		if cp := room.game.GetCurrentPlayer(); cp != nil && cp.ID == targetID {
-			wasCurrentPlayer = true
-		}
-		for i, p := range room.game.Players {
-			if p.ID == targetID {
-				room.game.Players = append(room.game.Players[:i], room.game.Players[i+1:]...)
-				if room.game.CurrentPlayerIdx >= len(room.game.Players) && len(room.game.Players) > 0 {
-					room.game.CurrentPlayerIdx = 0
-				}
-				break
-			}
-		}
-		delete(room.clients, targetID)
-		// If kicked player was the current turn holder, reset phase and start timer for new current player
-		if wasCurrentPlayer && room.status == StatusPlaying && !room.game.GameOver {
-			room.game.TurnPhase = game.PhaseMainMenu
-			if np := room.game.GetCurrentPlayer(); np != nil && np.Alive {
-				s.StartTurnTimer(room, np.ID)
-			}
+		s.removeSharedPlayer(room, targetID)
+		if room.roomType == RoomTypeRace {
+			delete(room.playerGames, targetID)
 		}
+		s.dropClient(room, targetID)
+		s.cancelCountdownIfNotFull(room)
+		room.ban(c.SessionID, c.Name)
 		log.Printf("Player %s kicked from room %s by owner", c.Name, roomID)
 		return true
 	}
 	return false
 }
 
-func (s *Server) CleanupRoomIfEmpty(roomID string) {
-	if roomID == "continuous" {
-		return
+// removeSharedPlayer drops a player from a room's shared game. If they held
+// the turn, the phase is reset and the timer restarted for the next player.
+// NOTE: caller must hold room.mu.
+func (s *Server) removeSharedPlayer(room *GameRoom, playerID string) {
+	wasCurrentPlayer := false
+	if cp := room.game.GetCurrentPlayer(); cp != nil && cp.ID == playerID {
+		wasCurrentPlayer = true
+	}
+	room.game.RemovePlayer(playerID)
+	if wasCurrentPlayer && room.status == StatusPlaying && !room.game.GameOver {
+		room.game.TurnPhase = game.PhaseMainMenu
+		if np := room.game.GetCurrentPlayer(); np != nil && np.Alive {
+			s.StartTurnTimer(room, np.ID)
+		}
 	}
+}
+
+// closeRoom stops all pending timers on a room and marks it closed so that
+// any callback already waiting on the room lock bails out immediately.
+// Scheduled rooms hold no persisted state, so there is nothing to flush.
+// Sessions still in the room are pointed back at the default room.
+// NOTE: caller must hold s.roomsMu and must not hold room.mu.
+func (s *Server) closeRoom(room *GameRoom) {
+	room.mu.Lock()
+	s.CancelTurnTimer(room)
+	s.cancelCountdown(room)
+	room.closed = true
+	room.mu.Unlock()
+	if n := s.sessionManager.ClearRoom(room.id); n > 0 {
+		log.Printf("Room %s closed; %d sessions will resume in the default room", room.id, n)
+	}
+}
+
+func (s *Server) CleanupRoomIfEmpty(roomID string) {
 	s.roomsMu.Lock()
 	defer s.roomsMu.Unlock()
 	room, ok := s.rooms[roomID]
-	if !ok {
+	if !ok || room.persistent {
 		return
 	}
 	room.mu.RLock()
 	empty := len(room.clients) == 0
 	room.mu.RUnlock()
 	if empty {
+		s.closeRoom(room)
 		delete(s.rooms, roomID)
+		s.notifyLobby()
 		log.Printf("Room %s (%s) cleaned up (empty)", room.name, roomID)
 	}
 }
@@ -648,32 +1153,59 @@ func (s *Server) CleanupRoomIfEmpty(roomID string) {
 func (s *Server) CleanupStaleRooms() {
 	s.roomsMu.Lock()
 	defer s.roomsMu.Unlock()
-	now := time.Now()
+	now := s.clock.Now()
+	s.pruneCreateLimits(now)
 	for id, room := range s.rooms {
-		if id == "continuous" {
+		if room.persistent {
 			continue
 		}
 		room.mu.RLock()
 		empty := len(room.clients) == 0
 		status := room.status
 		created := room.createdAt
+		paused := room.pauseProtected()
+		unclaimed := room.hasLobby() && room.ownerID == ""
 		room.mu.RUnlock()
 
+		// A paused game is waiting on its players, not abandoned
+		if paused {
+			continue
+		}
+
+		// A room nobody has claimed yet gives its creator time to connect,
+		// then goes. One with players in it has been joined, and is left
+		// to the checks below.
+		if unclaimed && empty {
+			if now.Sub(created) > unclaimedRoomTTL {
+				s.closeRoom(room)
+				delete(s.rooms, id)
+				s.notifyLobby()
+				log.Printf("Stale room %s (%s) cleaned up (never claimed)", room.name, id)
+			}
+			continue
+		}
+
 		// Remove empty rooms
 		if empty {
+			s.closeRoom(room)
 			delete(s.rooms, id)
+			s.notifyLobby()
 			log.Printf("Stale room %s (%s) cleaned up (empty)", room.name, id)
 			continue
 		}
 		// Remove finished rooms older than 10 minutes
 		if status == StatusFinished && now.Sub(created) > 10*time.Minute {
+			s.closeRoom(room)
 			delete(s.rooms, id)
+			s.notifyLobby()
 			log.Printf("Stale room %s (%s) cleaned up (finished)", room.name, id)
 			continue
 		}
 		// Remove waiting rooms older than 24 hours
 		if status == StatusWaiting && now.Sub(created) > 24*time.Hour {
+			s.closeRoom(room)
 			delete(s.rooms, id)
+			s.notifyLobby()
 			log.Printf("Stale room %s (%s) cleaned up (stale waiting)", room.name, id)
 			continue
 		}
@@ -688,7 +1220,13 @@ func (s *Server) ResetGame(roomID string) bool {
 	room.mu.Lock()
 	defer room.mu.Unlock()
 
-	if !room.game.GameOver {
+	// A race is over once it is finished; its racers' own games end it,
+	// not the room's
+	over := room.game.GameOver
+	if room.roomType == RoomTypeRace {
+		over = room.status == StatusFinished
+	}
+	if !over {
 		return false
 	}
 
@@ -700,17 +1238,46 @@ func (s *Server) ResetGame(roomID string) bool {
 	room.game.ResetGame()
 	room.status = StatusWaiting
 	room.deadPlayers = make(map[string]bool)
+	room.lockedSeats = 0
+	room.bannedClients = make(map[string]string)
+	room.timeouts = make(map[string]int)
 
+	if room.roomType == RoomTypeRace {
+		s.resetRace(room)
+		log.Printf("Race reset in %s - racers back at the start", roomID)
+		return true
+	}
+
+	// Everyone takes their seat again in the order they first joined
+	clients := make([]*Client, 0, len(room.clients))
 	for _, c := range room.clients {
+		clients = append(clients, c)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		if !clients[i].JoinedAt.Equal(clients[j].JoinedAt) {
+			return clients[i].JoinedAt.Before(clients[j].JoinedAt)
+		}
+		return clients[i].ID < clients[j].ID
+	})
+	for _, c := range clients {
 		player := room.game.AddPlayer(c.Name, game.PlayerTypeHuman)
 		player.ID = c.ID
+		if c.Player != nil {
+			player.Occupation = c.Player.Occupation
+		}
 		c.Player = player
 	}
 
 	// Reset current player index to ensure it points to a valid player
 	room.game.CurrentPlayerIdx = 0
 
-	if len(room.clients) > 0 {
+	// A party game waits on the ready check and the owner's start again,
+	// which outfit the wagon; the open trail rolls straight on
+	if room.hasLobby() {
+		for _, c := range room.clients {
+			c.Ready = false
+		}
+	} else if len(room.clients) > 0 {
 		initRoomResources(room)
 		room.status = StatusPlaying
 	}
@@ -728,27 +1295,56 @@ func (s *Server) createLootSite(room *GameRoom, c *Client) {
 		return
 	}
 
+	now := s.clock.Now()
 	lootSite := game.LootSite{
-		ID:           fmt.Sprintf("loot-%s-%d", c.ID, time.Now().Unix()),
-		Mileage:      room.game.Mileage,
-		PlayerName:   c.Name,
-		Food:         room.game.Food,
-		Bullets:      room.game.Bullets,
-		Clothing:     room.game.Clothing,
-		MiscSupplies: room.game.MiscSupplies,
-		Cash:         room.game.Cash,
-		OxenCost:     room.game.OxenCost,
-		DateCreated:  time.Now(),
-		IsLooted:     false,
+		ID:            fmt.Sprintf("loot-%s-%d", c.ID, now.Unix()),
+		Mileage:       room.game.Mileage,
+		PlayerName:    c.Name,
+		Food:          room.game.Food,
+		Bullets:       room.game.Bullets,
+		Clothing:      room.game.Clothing,
+		MiscSupplies:  room.game.MiscSupplies,
+		Cash:          room.game.Cash,
+		OxenCost:      room.game.OxenCost,
+		Parts:         game.CopyParts(room.game.Parts),
+		WagonBed:      room.game.HasWagonBed,
+		DateCreated:   now,
+		LastDecayedAt: now,
+		IsLooted:      false,
 	}
 
 	room.game.LootSites = append(room.game.LootSites, lootSite)
 	log.Printf("Loot site created at mile %.0f for dead player %s in room %s", room.game.Mileage, c.Name, room.id)
+
+	s.leaderboard.AddEntry(c.ID, c.Name, false, room.game.Mileage, room.game.TurnNumber,
+		room.game.ComputeScore(c.Player), room.game.CountSurvivors(c.Player), "continuous", string(c.Player.Occupation))
+}
+
+// lootSiteFromGame builds an unlooted loot site from a player's remaining
+// supplies, left at now.
+func lootSiteFromGame(playerID, playerName string, playerGame *game.GameState, now time.Time) game.LootSite {
+	return game.LootSite{
+		ID:            fmt.Sprintf("loot-%s-%d", playerID, now.Unix()),
+		Mileage:       playerGame.Mileage,
+		PlayerName:    playerName,
+		Food:          playerGame.Food,
+		Bullets:       playerGame.Bullets,
+		Clothing:      playerGame.Clothing,
+		MiscSupplies:  playerGame.MiscSupplies,
+		Cash:          playerGame.Cash,
+		OxenCost:      playerGame.OxenCost,
+		Parts:         game.CopyParts(playerGame.Parts),
+		WagonBed:      playerGame.HasWagonBed,
+		DateCreated:   now,
+		LastDecayedAt: now,
+		IsLooted:      false,
+	}
 }
 
 // createLootSiteFromPlayer creates a loot site from a player's individual game state (continuous mode)
 func (s *Server) createLootSiteFromPlayer(room *GameRoom, player *game.Player, playerGame *game.GameState) {
-	if player == nil || playerGame == nil {
+	// Only the open trail keeps wrecks; a race records its standings at the end
+	if player == nil || playerGame == nil || room.roomType != RoomTypeContinuous {
 		return
 	}
 
@@ -761,26 +1357,20 @@ func (s *Server) createLootSiteFromPlayer(room *GameRoom, player *game.Player, p
 		}
 	}
 
-	lootSite := game.LootSite{
-		ID:           fmt.Sprintf("loot-%s-%d", player.ID, time.Now().Unix()),
-		Mileage:      playerGame.Mileage,
-		PlayerName:   clientName,
-		Food:         playerGame.Food,
-		Bullets:      playerGame.Bullets,
-		Clothing:     playerGame.Clothing,
-		MiscSupplies: playerGame.MiscSupplies,
-		Cash:         playerGame.Cash,
-		OxenCost:     playerGame.OxenCost,
-		DateCreated:  time.Now(),
-		IsLooted:     false,
-	}
+	lootSite := lootSiteFromGame(player.ID, clientName, playerGame, s.clock.Now())
 
 	room.game.LootSites = append(room.game.LootSites, lootSite)
 	log.Printf("Loot site created at mile %.0f for dead player %s in continuous room",
 		playerGame.Mileage, clientName)
+
+	// Record the lost run so player stats aren't wins-only
+	s.leaderboard.AddEntry(player.ID, clientName, false, playerGame.Mileage, playerGame.TurnNumber,
+		playerGame.ComputeScore(player), playerGame.CountSurvivors(player), "continuous", string(player.Occupation))
 }
 
-// deteriorateLootSites applies decay to unlooted sites every 24 hours
+// deteriorateLootSites decays unlooted sites for the time since each last
+// decayed. It runs every loot_decay_interval; how often only changes how
+// smoothly supplies go, not how fast.
 func (s *Server) deteriorateLootSites() {
 	s.roomsMu.RLock()
 	rooms := make([]*GameRoom, 0, len(s.rooms))
@@ -797,28 +1387,16 @@ func (s *Server) deteriorateLootSites() {
 			continue
 		}
 
+		now := s.clock.Now()
 		room.mu.Lock()
 		for i := range room.game.LootSites {
-			site := &room.game.LootSites[i]
-			if site.IsLooted {
-				continue
-			}
-
-			// Apply deterioration (24 hours have passed)
-			site.Food *= 0.90     // 10% rot
-			site.Bullets *= 0.95  // 5% damage
-			site.Clothing *= 0.97 // 3% weather wear
-			site.MiscSupplies *= 0.95
-			site.OxenCost *= 0.98 // 2% wagon part decay
-			// Cash doesn't decay
+			room.game.LootSites[i].Decay(now)
 		}
+		room.game.PruneNotes(now)
 		room.mu.Unlock()
 	}
 }
 
-const turnTimeLimit = 20 * time.Second
-const fortInterval = 3 // trade post appears every N turns
-
 // advanceTurnAndCheckFort calls NextTurn and auto-enters fort every fortInterval turns.
 // Pauses the turn timer during fort; starts it otherwise.
 // Returns true if the fort was auto-triggered.
@@ -836,7 +1414,7 @@ func (s *Server) advanceTurnAndCheckFort(room *GameRoom) bool {
 		return false
 	}
 	fortTriggered := false
-	if room.game.TurnNumber > 0 && room.game.TurnNumber%fortInterval == 0 {
+	if s.fortTurn(room, room.game) {
 		// Make fort available for this turn
 		room.game.FortAvailable = true
 		fortTriggered = true
@@ -845,24 +1423,98 @@ func (s *Server) advanceTurnAndCheckFort(room *GameRoom) bool {
 	return fortTriggered
 }
 
+// fortTurn reports whether g's current turn brings a fort, which happens
+// every fort interval turns.
+func (s *Server) fortTurn(room *GameRoom, g *game.GameState) bool {
+	interval := s.fortInterval
+	if room.rules.FortInterval > 0 {
+		interval = room.rules.FortInterval
+	}
+	return g.TurnNumber > 0 && g.TurnNumber%interval == 0
+}
+
+// advancePlayerTurn moves a wagon in a continuous or race room to its next
+// turn. Each wagon keeps its own fort cadence: a fort is there for a turn
+// every fort interval turns and gone on the next.
+// NOTE: caller must hold room.mu.
+func (s *Server) advancePlayerTurn(room *GameRoom, playerGame *game.GameState) {
+	playerGame.NextTurn()
+	playerGame.FortAvailable = s.fortTurn(room, playerGame)
+}
+
+// recordContributions feeds each player's share of a finished party game
+// into their stats and returns the summary for the game over result.
+// NOTE: caller must hold room.mu.
+func (s *Server) recordContributions(room *GameRoom) string {
+	if room.roomType != RoomTypeScheduled {
+		return ""
+	}
+	room.game.SettleTurn()
+	for _, cl := range room.clients {
+		if cl.Player != nil {
+			s.leaderboard.RecordContribution(cl.ID, cl.Name, room.game.ContributionFor(cl.Player.ID))
+		}
+	}
+	return room.game.ContributionSummary()
+}
+
 // saveGameStateAfterTurn saves the game state after a turn is completed.
 // Should be called outside the room lock to avoid deadlock.
 func (s *Server) saveGameStateAfterTurn(roomID string) {
-	if roomID == "continuous" {
-		go s.saveGameState()
+	room := s.GetRoom(roomID)
+	switch {
+	case room == nil:
+	case room.persistent:
+		s.saveInBackground(func() { s.saveGameState(room) })
+	case room.roomType == RoomTypeScheduled:
+		s.saveInBackground(s.saveRooms)
 	}
 }
 
+// saveInBackground runs save in a goroutine of its own, counted in s.saves
+// so shutting down can wait for it.
+func (s *Server) saveInBackground(save func()) {
+	s.saves.Add(1)
+	go func() {
+		defer s.saves.Done()
+		save()
+	}()
+}
+
 // StartTurnTimer starts a turn timer for the given player.
 // NOTE: caller must hold room.mu.
 func (s *Server) StartTurnTimer(room *GameRoom, playerID string) {
+	s.startTurnTimerFor(room, playerID, s.turnTime)
+}
+
+// startTurnTimerFor starts a turn timer that expires after d. In a paused
+// room the timer isn't armed; d is kept for when the room resumes.
+// NOTE: caller must hold room.mu.
+func (s *Server) startTurnTimerFor(room *GameRoom, playerID string, d time.Duration) {
 	if room.turnTimer != nil {
 		room.turnTimer.Stop()
 	}
-	room.turnDeadline = time.Now().Add(turnTimeLimit)
-	room.turnTimer = time.AfterFunc(turnTimeLimit, func() {
+	s.stopTurnWarning(room)
+	if room.closed {
+		return
+	}
+	if room.paused {
+		room.turnTimer = nil
+		room.turnDeadline = time.Time{}
+		room.pausedRemaining = d
+		return
+	}
+	// A dropped player's turn waits on their reconnect grace period instead
+	if c, ok := room.clients[playerID]; ok && c.Disconnected {
+		room.turnTimer = nil
+		room.turnDeadline = room.reservedSeats[playerID]
+		return
+	}
+	room.turnDeadline = s.clock.Now().Add(d)
+	room.turnTimer = s.clock.AfterFunc(d, func() {
 		s.handleTurnTimeout(room, playerID)
 	})
+	s.startTurnWarning(room, playerID, d)
 }
 
 // CancelTurnTimer stops the turn timer.
@@ -872,6 +1524,7 @@ func (s *Server) CancelTurnTimer(room *GameRoom) {
 		room.turnTimer.Stop()
 		room.turnTimer = nil
 	}
+	s.stopTurnWarning(room)
 	room.turnDeadline = time.Time{}
 }
 
@@ -879,18 +1532,31 @@ func (s *Server) handleTurnTimeout(room *GameRoom, expectedPlayerID string) {
 	// Phase 1: game logic under room lock
 	room.mu.Lock()
 
+	if room.closed {
+		room.mu.Unlock()
+		return
+	}
+
 	current := room.game.GetCurrentPlayer()
-	if current == nil || current.ID != expectedPlayerID ||
+	if current == nil || current.ID != expectedPlayerID || room.paused ||
 		room.game.GameOver || room.status != StatusPlaying || !current.Alive {
 		room.mu.Unlock()
 		return
 	}
 
+	before := partySnapshot(current)
+	supplies := suppliesSnapshot(room.game)
+	turn := room.game.TurnNumber
+	room.timeouts[current.ID]++
+
 	result := "Time's up! Dysentery strikes the party while they dawdle!\n"
-	result += room.game.DamageRandomMember(current, 999)
+	result += room.game.DamageRandomMember(current, 999, "dysentery")
+	room.game.Record(current.Name, "timeout", game.NewResult(game.ResultText, result), s.clock.Now())
 
 	playerName := current.Name
+	playerID := current.ID
 	roomID := room.id
+	notice := timeoutNotice(room, current, before, supplies, turn, room.timeouts[current.ID])
 
 	// Check if player died from timeout damage (for 24/7 continuous mode)
 	if !current.Alive && room.roomType == RoomTypeContinuous {
@@ -917,11 +1583,8 @@ func (s *Server) handleTurnTimeout(room *GameRoom, expectedPlayerID string) {
 				modeLabel = "party"
 			}
 			// Add all players to leaderboard
-			for _, cl := range room.clients {
-				if cl.Player != nil {
-					s.leaderboard.AddEntry(cl.Name, room.game.Win, room.game.Mileage, room.game.TurnNumber, modeLabel)
-				}
-			}
+			s.recordGameOver(room, room.game, modeLabel, room.clientPlayers())
+			result += s.recordContributions(room)
 			room.status = StatusFinished
 			room.turnDeadline = time.Time{}
 		}
@@ -933,15 +1596,41 @@ func (s *Server) handleTurnTimeout(room *GameRoom, expectedPlayerID string) {
 
 	// Phase 2: broadcast outside of room lock to avoid deadlock
 	if s.hub != nil {
+		s.hub.BroadcastEventTo(roomID, "System", "turn_timeout", fmt.Sprintf("%s ran out of time!", playerName))
 		s.hub.BroadcastEventTo(roomID, playerName, "continue", result)
-		s.hub.BroadcastStateTo(roomID)
+		s.hub.BroadcastGameOverTo(roomID)
+		s.hub.FlushStateTo(roomID)
 	}
+	// The room saw the event; the player also gets a private summary that
+	// waits for them if they dropped
+	s.QueueNotice(playerID, notice)
 
 	// Save game state for persistence
 	s.saveGameStateAfterTurn(roomID)
 }
 
-func (s *Server) GetState(roomID string) interface{} {
+// TurnMarker identifies whose turn it is in a scheduled room, so callers can
+// tell when an action moved the game to a new turn.
+func (s *Server) TurnMarker(roomID string) string {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return ""
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if room.roomType != RoomTypeScheduled {
+		return ""
+	}
+	id := ""
+	if cp := room.game.GetCurrentPlayer(); cp != nil {
+		id = cp.ID
+	}
+	return fmt.Sprintf("%s/%d/%s", room.status, room.game.TurnNumber, id)
+}
+
+// GetState returns the room's state in the shape of the given schema
+// (protocol major version).
+func (s *Server) GetState(roomID string, schema int) interface{} {
 	room := s.GetRoom(roomID)
 	if room == nil {
 		return map[string]interface{}{"error": "room not found"}
@@ -949,10 +1638,17 @@ func (s *Server) GetState(roomID string) interface{} {
 	room.mu.RLock()
 	defer room.mu.RUnlock()
 
-	// For continuous mode, build per-player states
-	if room.roomType == RoomTypeContinuous {
-		return s.getContinuousState(room)
+	// For continuous and race modes, build per-player states
+	if room.perPlayerGames() {
+		return versionState(s.getContinuousState(room), schema)
 	}
+	return versionState(s.getSharedState(room), schema)
+}
+
+// getSharedState returns the state for scheduled and party rooms, where the
+// players share one game.
+// NOTE: caller must hold room.mu
+func (s *Server) getSharedState(room *GameRoom) map[string]interface{} {
 
 	// Scheduled/private mode: shared game state
 	currentPlayer := room.game.GetCurrentPlayer()
@@ -969,31 +1665,48 @@ func (s *Server) GetState(roomID string) interface{} {
 		"clothing":          room.game.Clothing,
 		"misc_supplies":     room.game.MiscSupplies,
 		"cash":              room.game.Cash,
+		"oxen_cost":         room.game.OxenCost,
+		"oxen_critical":     room.game.OxenCritical(),
+		"parts":             game.CopyParts(room.game.Parts),
+		"capacity":          room.game.Capacities(),
+		"next_landmark":     room.game.NextLandmark(),
+		"season":            room.game.Season(),
+		"weather":           room.game.Weather,
+		"pace":              room.game.CurrentPace(),
 		"game_over":         room.game.GameOver,
 		"win":               room.game.Win,
 		"final_date":        room.game.FinalDate,
 		"turn_phase":        room.game.TurnPhase,
 		"current_player_id": currentPlayerID,
+		"turn_order":        turnQueue(room.game),
 		"players":           s.getPlayerInfo(room),
 		"room_id":           room.id,
 		"room_name":         room.name,
 		"room_type":         room.roomType,
 		"owner_id":          room.ownerID,
+		"co_owners":         room.coOwnerIDs(),
 		"game_status":       room.status,
+		"chat_mode":         room.chatMode,
 	}
 
 	if room.game.TurnPhase == game.PhaseFort {
 		state["fort_prices"] = game.GetFortPrices()
+		state["fort_upgrades"] = room.game.AvailableUpgrades()
+		state["heal_costs"] = room.game.HealCosts(currentPlayer)
+		state["fort_parts"] = game.GetFortParts()
+		state["forecast_price"] = game.ForecastPrice
 	}
 
 	// Always include fort availability and prices when fort is available
 	if room.game.FortAvailable {
 		state["fort_available"] = true
 		state["fort_prices"] = game.GetFortPrices()
+		state["fort_upgrades"] = room.game.AvailableUpgrades()
 	}
 
 	if room.game.TurnPhase == game.PhaseHunting {
-		state["hunt_word"] = room.game.HuntWord
+		state["hunt_word"] = room.game.ShownHuntWord(s.clock.Now())
+		state["hunt_delay_ms"] = room.game.HuntDelayMs
 	}
 
 	if room.game.TurnPhase == game.PhaseRiders {
@@ -1006,6 +1719,17 @@ func (s *Server) GetState(roomID string) interface{} {
 		state["turn_deadline"] = room.turnDeadline.UnixMilli()
 	}
 
+	// Auto-start countdown for a full lobby
+	if !room.countdownDeadline.IsZero() && room.status == StatusWaiting {
+		state["countdown_deadline"] = room.countdownDeadline.UnixMilli()
+	}
+
+	// Pause overlay: the turn clock restarts with pause_remaining_ms on resume
+	state["paused"] = room.paused
+	if room.paused {
+		state["pause_remaining_ms"] = room.pausedRemaining.Milliseconds()
+	}
+
 	// Party health for current player (backwards compat)
 	if currentPlayer != nil {
 		state["party_health"] = room.game.GetPartyHealth(currentPlayer)
@@ -1028,7 +1752,7 @@ func (s *Server) GetState(roomID string) interface{} {
 	return state
 }
 
-// getContinuousState returns the state for continuous mode where each player has their own game
+// getContinuousState returns the state for continuous and race modes where each player has their own game
 func (s *Server) getContinuousState(room *GameRoom) map[string]interface{} {
 	state := map[string]interface{}{
 		"turn_number":   0, // Not used in continuous mode
@@ -1045,8 +1769,12 @@ func (s *Server) getContinuousState(room *GameRoom) map[string]interface{} {
 		"room_name":     room.name,
 		"room_type":     room.roomType,
 		"game_status":   room.status,
+		"chat_mode":     room.chatMode,
 		"loot_sites":    room.game.LootSites,
 	}
+	if !room.seasonEndsAt.IsZero() {
+		state["season_ends_at"] = room.seasonEndsAt.UnixMilli()
+	}
 
 	// Build player states - each player has their own independent game
 	playerStates := make(map[string]map[string]interface{})
@@ -1081,20 +1809,43 @@ func (s *Server) getContinuousState(room *GameRoom) map[string]interface{} {
 				"misc_supplies":     playerGame.MiscSupplies,
 				"cash":              playerGame.Cash,
 				"oxen_cost":         playerGame.OxenCost,
+				"oxen_critical":     playerGame.OxenCritical(),
+				"parts":             game.CopyParts(playerGame.Parts),
+				"capacity":          playerGame.Capacities(),
+				"next_landmark":     playerGame.NextLandmark(),
+				"season":            playerGame.Season(),
+				"weather":           playerGame.Weather,
+				"pace":              playerGame.CurrentPace(),
 				"game_over":         playerGame.GameOver,
 				"win":               playerGame.Win,
 				"turn_phase":        playerGame.TurnPhase,
 				"fort_available":    playerGame.FortAvailable,
-				"hunt_word":         playerGame.HuntWord,
+				"fort_upgrades":     playerGame.AvailableUpgrades(),
+				"hunt_word":         playerGame.ShownHuntWord(s.clock.Now()),
+				"hunt_delay_ms":     playerGame.HuntDelayMs,
 				"rider_hostile":     playerGame.PendingRiderHostile,
 				"rider_count":       playerGame.PendingRiderCount,
 				"alive":             playerAlive,
 				"player_alive":      playerAlive,
+				"trail_notes":       room.game.NotesNear(playerGame.Mileage, s.clock.Now()),
+				"peaceful":          player != nil && player.Peaceful,
+			}
+			if raid, ok := room.raids[c.ID]; ok {
+				playerStates[c.ID]["incoming_raid"] = map[string]interface{}{
+					"attacker": raid.attackerName,
+					"deadline": raid.deadline.UnixMilli(),
+				}
 			}
 
-			// Add party health
+			// Add party health, and the doctor's prices at a fort
 			if player != nil {
 				playerStates[c.ID]["party_health"] = playerGame.GetPartyHealth(player)
+				if playerGame.TurnPhase == game.PhaseFort {
+					playerStates[c.ID]["fort_prices"] = game.GetFortPrices()
+					playerStates[c.ID]["heal_costs"] = playerGame.HealCosts(player)
+					playerStates[c.ID]["fort_parts"] = game.GetFortParts()
+					playerStates[c.ID]["forecast_price"] = game.ForecastPrice
+				}
 			}
 		} else {
 			// Player has no game yet (just joined)
@@ -1118,25 +1869,46 @@ func (s *Server) getContinuousState(room *GameRoom) map[string]interface{} {
 		}
 
 		// Player list info
+		var occupation game.Occupation
+		if c.Player != nil {
+			occupation = c.Player.Occupation
+		}
 		playersInfo = append(playersInfo, map[string]interface{}{
 			"id":           c.ID,
 			"name":         c.Name,
 			"alive":        playerAlive,
 			"player_alive": playerAlive,
 			"score":        0, // Will be filled from playerStates
+			"spectator":    c.Spectator,
+			"connected":    !c.Disconnected,
+			"ready":        c.Ready,
+			"occupation":   occupation,
+			"latency_ms":   c.LatencyMs,
 		})
 	}
 
 	// Update scores from player states
 	for i, p := range playersInfo {
 		if ps, ok := playerStates[p["id"].(string)]; ok {
-			playersInfo[i]["score"] = int(ps["mileage"].(float64))
+			if miles, ok := ps["mileage"].(float64); ok {
+				playersInfo[i]["score"] = int(miles)
+			}
 		}
 	}
 
 	state["player_states"] = playerStates
 	state["players"] = playersInfo
 
+	// A race has an owner who starts it, and standings everyone can follow
+	if room.roomType == RoomTypeRace {
+		state["owner_id"] = room.ownerID
+		state["co_owners"] = room.coOwnerIDs()
+		state["standings"] = room.raceStandings()
+		if !room.countdownDeadline.IsZero() && room.status == StatusWaiting {
+			state["countdown_deadline"] = room.countdownDeadline.UnixMilli()
+		}
+	}
+
 	return state
 }
 
@@ -1145,8 +1917,10 @@ func (s *Server) getPlayerInfo(room *GameRoom) []map[string]interface{} {
 	players := make([]map[string]interface{}, 0)
 	for _, c := range room.clients {
 		playerAlive := true
+		var occupation game.Occupation
 		if c.Player != nil {
 			playerAlive = c.Player.Alive
+			occupation = c.Player.Occupation
 		}
 		players = append(players, map[string]interface{}{
 			"id":           c.ID,
@@ -1154,62 +1928,128 @@ func (s *Server) getPlayerInfo(room *GameRoom) []map[string]interface{} {
 			"alive":        playerAlive,
 			"player_alive": playerAlive,
 			"score":        int(room.game.Mileage),
+			"spectator":    c.Spectator,
+			"connected":    !c.Disconnected,
+			"ready":        c.Ready,
+			"co_owner":     room.coOwners[c.ID],
+			"occupation":   occupation,
+			"contribution": room.game.ContributionFor(c.ID),
+			"latency_ms":   c.LatencyMs,
 		})
 	}
 	return players
 }
 
-func (s *Server) HandleAction(clientID string, roomID string, action string) string {
+// turnQueue lists the players still taking turns in g, the current player
+// first, with how many turns until each one's comes round.
+func turnQueue(g *game.GameState) []map[string]interface{} {
+	upcoming := g.UpcomingTurns()
+	queue := make([]map[string]interface{}, 0, len(upcoming))
+	for i, p := range upcoming {
+		queue = append(queue, map[string]interface{}{
+			"id":          p.ID,
+			"name":        p.Name,
+			"turns_until": i,
+		})
+	}
+	return queue
+}
+
+// PlayerStatus returns a one-line summary of the supplies a client's wagon
+// is carrying, for the /status chat command.
+func (s *Server) PlayerStatus(clientID, roomID string) string {
 	room := s.GetRoom(roomID)
 	if room == nil {
-		return ""
+		return "Room not found."
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+
+	g := room.game
+	if room.perPlayerGames() {
+		g, _ = s.getPlayerGame(room, clientID)
+	}
+	c, ok := room.clients[clientID]
+	if g == nil || !ok || c.Player == nil {
+		return "You don't have a wagon in this game."
+	}
+	return fmt.Sprintf("Mile %.0f | Food %.0f | Bullets %.0f | Clothing %.0f | Misc %.0f | Cash $%.0f",
+		g.Mileage, g.Food, g.Bullets, g.Clothing, g.MiscSupplies, g.Cash)
+}
+
+// RoomRoster returns the names of everyone connected to a room.
+func (s *Server) RoomRoster(roomID string) []string {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return nil
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+
+	names := make([]string, 0, len(room.clients))
+	for _, c := range room.clients {
+		name := c.Name
+		if c.Spectator {
+			name += " (watching)"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) HandleAction(clientID string, roomID string, action string) *game.TurnResult {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return &game.TurnResult{}
 	}
 	room.mu.Lock()
 	defer room.mu.Unlock()
 
+	if room.paused {
+		return game.Refuse(errPaused, gamePausedMsg)
+	}
+
 	// For continuous mode, each player has their own game
 	if room.roomType == RoomTypeContinuous {
 		log.Printf("DEBUG HandleAction: routing to handleContinuousAction, clientID=%s, action=%s", clientID, action)
 		return s.handleContinuousAction(room, clientID, action)
 	}
 
-	// Scheduled/private mode: shared game state
-	if action == "start" {
-		initRoomResources(room)
-		room.game.TurnNumber = 1
-		room.game.GameOver = false
-		room.status = StatusPlaying
-		if cp := room.game.GetCurrentPlayer(); cp != nil {
-			s.StartTurnTimer(room, cp.ID)
+	// Scheduled/private and race modes wait for the owner to start
+	if action == "start" || action == "start_game" {
+		if err := s.startGameLocked(room, clientID, false); err != nil {
+			return game.Refuse(game.ReasonInvalid, fmt.Sprintf("Can't start: %v.\n", err))
+		}
+		if action == "start" {
+			return game.NewResult(game.ResultText, "The journey begins! Head west on the Online Trail!")
 		}
-		return "The journey begins! Head west on the Online Trail!"
+		return game.NewResult(game.ResultText, "All players ready! The wagon train departs!")
 	}
 
-	if action == "start_game" {
-		room.status = StatusPlaying
-		room.game.TurnNumber = 1
-		room.game.GameOver = false
-		initRoomResources(room)
-		if cp := room.game.GetCurrentPlayer(); cp != nil {
-			s.StartTurnTimer(room, cp.ID)
-		}
-		return "All players ready! The wagon train departs!"
+	// Race mode: each racer drives their own game
+	if room.roomType == RoomTypeRace {
+		return s.handleRaceAction(room, clientID, action)
 	}
 
+	// Scheduled/private mode: shared game state
 	c, ok := room.clients[clientID]
 	if !ok {
-		return ""
+		return &game.TurnResult{}
 	}
 
 	// Dead players cannot take actions
 	if c.Player == nil {
-		return "Error: Player not found.\n"
+		return game.Refuse(game.ReasonInvalid, "Error: Player not found.\n")
 	}
 	if !c.Player.Alive {
-		return "Your party has perished. You are spectating.\n"
+		return game.NewResult(game.ResultSpectating, "Your party has perished. You are spectating.\n")
 	}
 
 	result := room.game.ProcessTurn(c.Player, action)
+	s.startHuntTimerIfHunting(room, clientID, room.game)
+	room.game.Record(c.Player.Name, action, result, s.clock.Now())
+	delete(room.timeouts, clientID)
 
 	// Check if player died during this turn (for 24/7 continuous mode)
 	if !c.Player.Alive && room.roomType == RoomTypeContinuous {
@@ -1227,12 +2067,9 @@ func (s *Server) HandleAction(clientID string, roomID string, action string) str
 				modeLabel = "party"
 			}
 			// Add all players to leaderboard
-			for _, cl := range room.clients {
-				if cl.Player != nil {
-					s.leaderboard.AddEntry(cl.Name, room.game.Win, room.game.Mileage, room.game.TurnNumber, modeLabel)
-				}
-			}
+			s.recordGameOver(room, room.game, modeLabel, room.clientPlayers())
 
+			result.Text(s.recordContributions(room))
 			room.status = StatusFinished
 			s.CancelTurnTimer(room)
 		}
@@ -1248,10 +2085,39 @@ func (s *Server) HandleAction(clientID string, roomID string, action string) str
 	return result
 }
 
+// FatalMoveWarning returns a warning if the client's move would all but
+// certainly doom their party and the room wants such moves confirmed.
+// It returns "" when the move is safe, the room opted out, or it isn't the
+// client's turn to move.
+func (s *Server) FatalMoveWarning(clientID, roomID, move string) string {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return ""
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+
+	if room.rules.NoHandHolding {
+		return ""
+	}
+	if room.perPlayerGames() {
+		playerGame, player := s.getPlayerGame(room, clientID)
+		if playerGame == nil || player == nil || !player.Alive {
+			return ""
+		}
+		return playerGame.FatalMoveWarning(move)
+	}
+	cp := room.game.GetCurrentPlayer()
+	if cp == nil || cp.ID != clientID || !cp.Alive {
+		return ""
+	}
+	return room.game.FatalMoveWarning(move)
+}
+
 // getPlayerGame returns the player's game state for the given room and client
-// Returns nil if not found or not continuous mode
+// Returns nil if not found or the room shares one game
 func (s *Server) getPlayerGame(room *GameRoom, clientID string) (*game.GameState, *game.Player) {
-	if room.roomType != RoomTypeContinuous {
+	if !room.perPlayerGames() {
 		return nil, nil
 	}
 
@@ -1259,6 +2125,9 @@ func (s *Server) getPlayerGame(room *GameRoom, clientID string) (*game.GameState
 	if !ok || playerGame == nil {
 		return nil, nil
 	}
+	if c, ok := room.clients[clientID]; ok && c.Player != nil && c.Player.ID == clientID {
+		return playerGame, c.Player
+	}
 
 	// Find the player's player struct
 	var player *game.Player
@@ -1272,15 +2141,15 @@ func (s *Server) getPlayerGame(room *GameRoom, clientID string) (*game.GameState
 	return playerGame, player
 }
 
-// handleContinuousAction handles player actions in continuous mode.
-// Each player has their own independent game state.
-func (s *Server) handleContinuousAction(room *GameRoom, clientID string, action string) string {
+// handleContinuousAction handles player actions in continuous mode, and
+// in a running race. Each player has their own independent game state.
+func (s *Server) handleContinuousAction(room *GameRoom, clientID string, action string) *game.TurnResult {
 	log.Printf("DEBUG handleContinuousAction: clientID=%s, action=%s, playerGames count=%d", clientID, action, len(room.playerGames))
 
 	playerGame, ok := room.playerGames[clientID]
 	if !ok || playerGame == nil {
 		log.Printf("DEBUG: playerGame not found for clientID=%s", clientID)
-		return "Error: Your game state not found. Please rejoin.\n"
+		return game.Refuse(game.ReasonInvalid, "Error: Your game state not found. Please rejoin.\n")
 	}
 
 	// Get the player's player from their game
@@ -1293,52 +2162,39 @@ func (s *Server) handleContinuousAction(room *GameRoom, clientID string, action
 	}
 	if player == nil {
 		log.Printf("DEBUG: player not found in playerGame for clientID=%s", clientID)
-		return "Error: Player not found in game state.\n"
+		return game.Refuse(game.ReasonInvalid, "Error: Player not found in game state.\n")
 	}
 
 	log.Printf("DEBUG: processing action=%s for player=%s, TurnPhase=%s", action, player.Name, playerGame.TurnPhase)
 
-	// Handle start action - reset player's game
-	if action == "start" {
-		playerGame.OxenCost = 220
-		playerGame.Food = 100
-		playerGame.Bullets = 50
-		playerGame.Clothing = 20
-		playerGame.MiscSupplies = 10
-		playerGame.Cash = 700
-		playerGame.GameOver = false
-		playerGame.Win = false
-		playerGame.TurnNumber = 1
-		playerGame.Mileage = 0
-		playerGame.DistanceTraveled = 0
-		playerGame.Week = 1
-		playerGame.Day = 1
-		playerGame.TurnPhase = game.PhaseMainMenu
-
-		// Reset player party
-		names := []string{"You", "Wife", "Son", "Daughter", "Baby"}
-		for i := range player.Party {
-			player.Party[i].Alive = true
-			player.Party[i].Health = 100
-			player.Party[i].Injured = false
-			if i < len(names) {
-				player.Party[i].Name = names[i]
-			}
-		}
-		player.Alive = true
+	// Dead players cannot take actions; on the open trail they may start
+	// a fresh journey
+	if !player.Alive && (action != "start" || room.roomType != RoomTypeContinuous) {
+		return game.NewResult(game.ResultSpectating, "Your party has perished. You are spectating.\n")
+	}
 
-		log.Printf("Continuous: player %s started fresh at Turn 1")
-		s.saveGameState()
-		return "Your journey begins! Head west on the Online Trail!"
+	// A race starts everyone at once, and only once
+	if action == "start" && room.roomType == RoomTypeRace && room.status == StatusPlaying {
+		return game.Refuse(game.ReasonInvalidPhase, "The race is already under way.\n")
 	}
 
-	// Dead players cannot take actions
-	if !player.Alive {
-		return "Your party has perished. You are spectating.\n"
+	// Handle start action - reset player's game
+	if action == "start" {
+		outfitWagon(playerGame, player)
+		log.Printf("Continuous: player %s started fresh at Turn 1", player.Name)
+		result := game.NewResult(game.ResultText, "Your journey begins! Head west on the Online Trail!")
+		playerGame.Record(player.Name, action, result, s.clock.Now())
+		s.saveGameStateLocked(room)
+		return result
 	}
 
 	// Process the turn using player's own game state
+	mileageBefore := playerGame.Mileage
 	result := playerGame.ProcessTurn(player, action)
+	s.startHuntTimerIfHunting(room, clientID, playerGame)
+	result.Append(room.game.PassNotes(clientID, mileageBefore, playerGame.Mileage, s.clock.Now()))
+	playerGame.Record(player.Name, action, result, s.clock.Now())
+	s.queueTrailNews(room, clientID, playerGame, player)
 
 	// Check if player died during this turn
 	if !player.Alive {
@@ -1350,165 +2206,228 @@ func (s *Server) handleContinuousAction(room *GameRoom, clientID string, action
 	// In continuous mode, each player has their own turn - increment TurnNumber after action
 	// Only if not in an interactive phase (hunt/fort/riders will increment when they complete)
 	if playerGame.TurnPhase == game.PhaseMainMenu {
-		playerGame.NextTurn()
+		s.advancePlayerTurn(room, playerGame)
 	}
 
+	// A race ends with the first wagon in or the last party lost
+	result.Text(s.finishRaceIfOver(room))
+
 	// Check for win
 	if playerGame.Win {
 		log.Printf("Continuous: player %s WON at Mileage %.0f!", player.Name, playerGame.Mileage)
-		room.status = StatusFinished
+		if room.roomType == RoomTypeContinuous {
+			s.recordGameOver(room, playerGame, "continuous", []*game.Player{player})
+			s.crownChampion(room, playerGame, player)
+		} else {
+			room.status = StatusFinished
+		}
 	}
 
 	// Save state after each action
-	s.saveGameState()
+	s.saveGameStateLocked(room)
 
 	return result
 }
 
-func (s *Server) HandleFortBuy(clientID string, roomID string, item string, qty int) string {
-	room := s.GetRoom(roomID)
-	if room == nil {
-		return ""
-	}
-	room.mu.Lock()
-	defer room.mu.Unlock()
+func (s *Server) HandleFortBuy(clientID string, roomID string, item string, qty int) *game.TurnResult {
+	return s.fortTrade(clientID, roomID, "fort_buy", func(g *game.GameState, p *game.Player) *game.TurnResult {
+		return g.HandleFortBuy(item, qty)
+	})
+}
 
-	// Continuous mode: get player's own game
-	if room.roomType == RoomTypeContinuous {
-		playerGame, player := s.getPlayerGame(room, clientID)
-		if playerGame == nil || player == nil {
-			return "Error: Your game state not found. Please rejoin.\n"
-		}
-		result := playerGame.HandleFortBuy(item, qty)
-		s.saveGameState()
-		return result
-	}
+func (s *Server) HandleFortSell(clientID string, roomID string, item string, qty int) *game.TurnResult {
+	return s.fortTrade(clientID, roomID, "fort_sell", func(g *game.GameState, p *game.Player) *game.TurnResult {
+		return g.HandleFortSell(item, qty)
+	})
+}
 
-	c, ok := room.clients[clientID]
-	if !ok {
-		return ""
-	}
+// HandleFortBuyMax buys as much of an item as the client can afford.
+func (s *Server) HandleFortBuyMax(clientID string, roomID string, item string) *game.TurnResult {
+	return s.fortTrade(clientID, roomID, "fort_buy_max", func(g *game.GameState, p *game.Player) *game.TurnResult {
+		return g.HandleFortBuyMax(item)
+	})
+}
 
-	currentPlayer := room.game.GetCurrentPlayer()
-	if currentPlayer == nil || currentPlayer.ID != c.ID {
-		return "It's not your turn.\n"
-	}
+// HandleFortHeal pays the fort doctor to heal one of the client's party.
+func (s *Server) HandleFortHeal(clientID string, roomID string, memberIdx int) *game.TurnResult {
+	return s.fortTrade(clientID, roomID, "fort_heal", func(g *game.GameState, p *game.Player) *game.TurnResult {
+		return g.HandleFortHeal(p, memberIdx)
+	})
+}
+
+// HandleFortBasket buys a basket of goods, all or nothing.
+func (s *Server) HandleFortBasket(clientID string, roomID string, orders []game.FortOrder) *game.TurnResult {
+	return s.fortTrade(clientID, roomID, "fort_basket", func(g *game.GameState, p *game.Player) *game.TurnResult {
+		return g.HandleFortBasket(orders)
+	})
+}
 
-	return room.game.HandleFortBuy(item, qty)
+// SetPace changes how hard the client's wagon travels. On the shared wagon
+// only the player whose turn it is may set it.
+func (s *Server) SetPace(clientID string, roomID string, pace string) *game.TurnResult {
+	return s.fortTrade(clientID, roomID, "set_pace", func(g *game.GameState, p *game.Player) *game.TurnResult {
+		return g.SetPace(pace)
+	})
 }
 
-func (s *Server) HandleFortSell(clientID string, roomID string, item string, qty int) string {
+// fortTrade runs a trade at the fort, or another choice made for the wagon,
+// against the client's game and player: their own game in continuous and
+// race modes, the shared wagon on their turn otherwise. The result goes in
+// that game's journal under action.
+func (s *Server) fortTrade(clientID, roomID, action string, trade func(g *game.GameState, p *game.Player) *game.TurnResult) *game.TurnResult {
 	room := s.GetRoom(roomID)
 	if room == nil {
-		return ""
+		return &game.TurnResult{}
 	}
 	room.mu.Lock()
 	defer room.mu.Unlock()
 
-	// Continuous mode: get player's own game
-	if room.roomType == RoomTypeContinuous {
+	if room.paused {
+		return game.Refuse(errPaused, gamePausedMsg)
+	}
+
+	// Continuous and race modes: get player's own game
+	if room.perPlayerGames() {
 		playerGame, player := s.getPlayerGame(room, clientID)
 		if playerGame == nil || player == nil {
-			return "Error: Your game state not found. Please rejoin.\n"
+			return game.Refuse(game.ReasonInvalid, "Error: Your game state not found. Please rejoin.\n")
 		}
-		result := playerGame.HandleFortSell(item, qty)
-		s.saveGameState()
+		result := trade(playerGame, player)
+		playerGame.Record(player.Name, action, result, s.clock.Now())
+		s.saveGameStateLocked(room)
 		return result
 	}
 
 	c, ok := room.clients[clientID]
 	if !ok {
-		return ""
+		return &game.TurnResult{}
 	}
 
 	currentPlayer := room.game.GetCurrentPlayer()
 	if currentPlayer == nil || currentPlayer.ID != c.ID {
-		return "It's not your turn.\n"
+		return game.Refuse(game.ReasonNotYourTurn, "It's not your turn.\n")
 	}
 
-	return room.game.HandleFortSell(item, qty)
+	result := trade(room.game, currentPlayer)
+	room.game.Record(currentPlayer.Name, action, result, s.clock.Now())
+	return result
 }
 
-func (s *Server) HandleFortEnter(clientID string, roomID string) string {
+// fortArriveMsg is what a wagon entering the fort is told.
+const fortArriveMsg = "You arrive at a fort. You can buy supplies here.\n"
+
+func (s *Server) HandleFortEnter(clientID string, roomID string) *game.TurnResult {
 	room := s.GetRoom(roomID)
 	if room == nil {
-		return ""
+		return &game.TurnResult{}
 	}
 	room.mu.Lock()
 	defer room.mu.Unlock()
 
-	// Continuous mode: get player's own game
-	if room.roomType == RoomTypeContinuous {
+	if room.paused {
+		return game.Refuse(errPaused, gamePausedMsg)
+	}
+
+	// Continuous and race modes: get player's own game
+	if room.perPlayerGames() {
 		playerGame, player := s.getPlayerGame(room, clientID)
 		if playerGame == nil || player == nil {
-			return "Error: Your game state not found. Please rejoin.\n"
+			return game.Refuse(game.ReasonInvalid, "Error: Your game state not found. Please rejoin.\n")
+		}
+		if playerGame.TurnPhase == game.PhaseFort {
+			return game.NewResult(game.ResultText, alreadyInFortMsg)
 		}
 		if !playerGame.FortAvailable {
-			return "No fort is available at this location.\n"
+			return game.Refuse(game.ReasonInvalidPhase, "No fort is available at this location.\n")
 		}
-		playerGame.TurnPhase = game.PhaseFort
+		playerGame.EnterFort()
+		playerGame.FortAvailable = false
 		playerGame.Mileage -= 45
 		playerGame.ClampResources()
-		s.saveGameState()
-		return "You arrive at a fort. You can buy supplies here.\n"
+		result := game.NewResult(game.ResultFortArrive, fortArriveMsg)
+		playerGame.Record(player.Name, "fort_enter", result, s.clock.Now())
+		s.startFortTimer(room, clientID)
+		s.saveGameStateLocked(room)
+		return result
 	}
 
 	c, ok := room.clients[clientID]
 	if !ok {
-		return ""
+		return &game.TurnResult{}
 	}
 
 	currentPlayer := room.game.GetCurrentPlayer()
 	if currentPlayer == nil || currentPlayer.ID != c.ID {
-		return "It's not your turn.\n"
+		return game.Refuse(game.ReasonNotYourTurn, "It's not your turn.\n")
 	}
 
+	if room.game.TurnPhase == game.PhaseFort {
+		return game.NewResult(game.ResultText, alreadyInFortMsg)
+	}
 	if !room.game.FortAvailable {
-		return "No fort is available at this location.\n"
+		return game.Refuse(game.ReasonInvalidPhase, "No fort is available at this location.\n")
 	}
 
-	// Enter the fort
-	room.game.TurnPhase = game.PhaseFort
+	// Enter the fort; the visit's own clock stands in for the turn timer
+	room.game.EnterFort()
+	room.game.FortAvailable = false
 	room.game.Mileage -= 45
 	room.game.ClampResources()
+	result := game.NewResult(game.ResultFortArrive, fortArriveMsg)
+	room.game.Record(currentPlayer.Name, "fort_enter", result, s.clock.Now())
 	s.CancelTurnTimer(room)
-	return "You arrive at a fort. You can buy supplies here.\n"
+	s.startFortTimer(room, clientID)
+	return result
 }
 
-func (s *Server) HandleFortLeave(clientID string, roomID string) string {
+func (s *Server) HandleFortLeave(clientID string, roomID string) *game.TurnResult {
 	room := s.GetRoom(roomID)
 	if room == nil {
-		return ""
+		return &game.TurnResult{}
 	}
 	room.mu.Lock()
 	defer room.mu.Unlock()
 
-	// Continuous mode: get player's own game
-	if room.roomType == RoomTypeContinuous {
+	if room.paused {
+		return game.Refuse(errPaused, gamePausedMsg)
+	}
+
+	// Continuous and race modes: get player's own game
+	if room.perPlayerGames() {
 		playerGame, player := s.getPlayerGame(room, clientID)
 		if playerGame == nil || player == nil {
-			return "Error: Your game state not found. Please rejoin.\n"
+			return game.Refuse(game.ReasonInvalid, "Error: Your game state not found. Please rejoin.\n")
+		}
+		if playerGame.TurnPhase != game.PhaseFort {
+			return game.Refuse(game.ReasonInvalidPhase, notInFortMsg)
 		}
+		s.stopFortTimer(room, clientID)
 		result := playerGame.HandleFortLeave()
-		playerGame.FortAvailable = false
+		playerGame.Record(player.Name, "fort_leave", result, s.clock.Now())
+		s.queueTrailNews(room, clientID, playerGame, player)
 		// Increment turn after leaving fort
-		playerGame.NextTurn()
-		s.saveGameState()
+		s.advancePlayerTurn(room, playerGame)
+		result.Text(s.finishRaceIfOver(room))
+		s.saveGameStateLocked(room)
 		return result
 	}
 
 	c, ok := room.clients[clientID]
 	if !ok {
-		return ""
+		return &game.TurnResult{}
 	}
 
 	currentPlayer := room.game.GetCurrentPlayer()
 	if currentPlayer == nil || currentPlayer.ID != c.ID {
-		return "It's not your turn.\n"
+		return game.Refuse(game.ReasonNotYourTurn, "It's not your turn.\n")
 	}
 
+	if room.game.TurnPhase != game.PhaseFort {
+		return game.Refuse(game.ReasonInvalidPhase, notInFortMsg)
+	}
+	s.stopFortTimer(room, clientID)
 	result := room.game.HandleFortLeave()
-	room.game.FortAvailable = false // Reset fort availability
+	room.game.Record(currentPlayer.Name, "fort_leave", result, s.clock.Now())
 	s.advanceTurnAndCheckFort(room)
 
 	// Save game state for persistence
@@ -1518,23 +2437,23 @@ func (s *Server) HandleFortLeave(clientID string, roomID string) string {
 }
 
 // HandleLootClaim attempts to claim loot from a loot site within 50 miles
-func (s *Server) HandleLootClaim(clientID string, roomID string, lootSiteID string) string {
+func (s *Server) HandleLootClaim(clientID string, roomID string, lootSiteID string) *game.TurnResult {
 	room := s.GetRoom(roomID)
 	if room == nil {
-		return ""
+		return &game.TurnResult{}
 	}
 	room.mu.Lock()
 	defer room.mu.Unlock()
 
 	// Only available in continuous mode
 	if room.roomType != RoomTypeContinuous {
-		return "Loot sites are only available in continuous mode.\n"
+		return game.Refuse(game.ReasonInvalid, "Loot sites are only available in continuous mode.\n")
 	}
 
 	// Get player's own game
 	playerGame, player := s.getPlayerGame(room, clientID)
 	if playerGame == nil || player == nil {
-		return "Error: Your game state not found. Please rejoin.\n"
+		return game.Refuse(game.ReasonInvalid, "Error: Your game state not found. Please rejoin.\n")
 	}
 
 	// Find the loot site
@@ -1543,87 +2462,145 @@ func (s *Server) HandleLootClaim(clientID string, roomID string, lootSiteID stri
 		if site.ID == lootSiteID {
 			// Check if within 50 miles
 			if playerGame.Mileage < site.Mileage-50 || playerGame.Mileage > site.Mileage+50 {
-				return "You're too far from that loot site.\n"
+				return game.Refuse(game.ReasonInvalid, "You're too far from that loot site.\n")
 			}
 
-			if site.IsLooted {
-				return "This wagon has already been scavenged by " + site.LootedBy + ".\n"
+			if site.Epitaph != "" {
+				return game.NewResult(game.ResultText, fmt.Sprintf("A weathered grave marker for %s reads: \"%s\"\n", site.PlayerName, site.Epitaph))
 			}
 
-			// Claim the loot - add to player's resources
-			playerGame.Food += site.Food
-			playerGame.Bullets += site.Bullets
-			playerGame.Clothing += site.Clothing
-			playerGame.MiscSupplies += site.MiscSupplies
-			playerGame.Cash += site.Cash
+			if site.IsLooted {
+				return game.Refuse(game.ReasonInvalid, "This wagon has already been scavenged by "+site.LootedBy+".\n")
+			}
 
-			// Mark as looted
-			site.IsLooted = true
-			site.LootedBy = player.Name
+			// Claim what fits; the site is looted once nothing is left
+			hadParts := len(site.Parts) > 0
+			hadBed := site.WagonBed && !playerGame.HasWagonBed
+			leftBehind := playerGame.ClaimLoot(site)
+			if leftBehind == "" {
+				site.IsLooted = true
+				site.LootedBy = player.Name
+			}
 
-			s.saveGameState()
-			return fmt.Sprintf("You scavenged the abandoned wagon of %s!\n", site.PlayerName)
+			msg := fmt.Sprintf("You scavenged the abandoned wagon of %s!\n", site.PlayerName)
+			if hadParts {
+				msg += "You also salvaged some spare parts from it.\n"
+			}
+			if hadBed {
+				msg += "You fitted its extended wagon bed to your own wagon.\n"
+			}
+			msg += leftBehind
+			result := game.NewResult(game.ResultText, msg)
+			playerGame.Record(player.Name, "loot", result, s.clock.Now())
+			s.saveGameStateLocked(room)
+			return result
 		}
 	}
 
-	return "Loot site not found.\n"
+	return game.Refuse(game.ReasonInvalid, "Loot site not found.\n")
 }
 
-func (s *Server) HandleHuntShoot(clientID string, roomID string, reactionTimeMs int) string {
+func (s *Server) HandleHuntShoot(clientID string, roomID string, word string, reactionTimeMs int) *game.TurnResult {
 	room := s.GetRoom(roomID)
 	if room == nil {
-		return ""
+		return &game.TurnResult{}
 	}
 	room.mu.Lock()
 	defer room.mu.Unlock()
 
-	// Continuous mode: get player's own game
-	if room.roomType == RoomTypeContinuous {
+	if room.paused {
+		return game.Refuse(errPaused, gamePausedMsg)
+	}
+	latencyMs := 0
+	if c, ok := room.clients[clientID]; ok {
+		latencyMs = c.LatencyMs
+	}
+	return s.resolveHunt(room, clientID, "hunt_shoot", func(g *game.GameState, p *game.Player) *game.TurnResult {
+		return g.HandleHuntShoot(p, word, reactionTimeMs, latencyMs)
+	})
+}
+
+// HandleHuntCancel ends the client's hunt without a shot; the turn goes on
+// as travel.
+func (s *Server) HandleHuntCancel(clientID string, roomID string) *game.TurnResult {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return &game.TurnResult{}
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.paused {
+		return game.Refuse(errPaused, gamePausedMsg)
+	}
+	return s.resolveHunt(room, clientID, "hunt_cancel", (*game.GameState).CancelHunt)
+}
+
+// resolveHunt ends the client's hunt with resolve and finishes the turn.
+// NOTE: caller must hold room.mu.
+func (s *Server) resolveHunt(room *GameRoom, clientID, action string, resolve func(*game.GameState, *game.Player) *game.TurnResult) *game.TurnResult {
+	// Continuous and race modes: get player's own game
+	if room.perPlayerGames() {
 		playerGame, player := s.getPlayerGame(room, clientID)
 		if playerGame == nil || player == nil {
-			return "Error: Your game state not found. Please rejoin.\n"
+			return game.Refuse(game.ReasonInvalid, "Error: Your game state not found. Please rejoin.\n")
 		}
 		if playerGame.TurnPhase != game.PhaseHunting {
-			return "You're not hunting right now.\n"
+			return game.Refuse(game.ReasonInvalidPhase, "You're not hunting right now.\n")
 		}
-		result := playerGame.HandleHuntShoot(player, reactionTimeMs)
+		s.stopHuntTimer(room, clientID)
+		result := resolve(playerGame, player)
+		playerGame.Record(player.Name, action, result, s.clock.Now())
+		s.queueTrailNews(room, clientID, playerGame, player)
 
 		// Check for death
 		if !player.Alive {
 			s.createLootSiteFromPlayer(room, player, playerGame)
 		}
 
-		// Increment turn after hunt completes
-		playerGame.NextTurn()
+		// Increment turn after hunt completes, unless the trail it went
+		// on to stopped at riders
+		if playerGame.TurnPhase == game.PhaseMainMenu {
+			s.advancePlayerTurn(room, playerGame)
+		}
+
+		result.Text(s.finishRaceIfOver(room))
 
 		// Check for win
 		if playerGame.Win {
-			room.status = StatusFinished
+			if room.roomType == RoomTypeContinuous {
+				s.recordGameOver(room, playerGame, "continuous", []*game.Player{player})
+				s.crownChampion(room, playerGame, player)
+			} else {
+				room.status = StatusFinished
+			}
 		}
 
-		s.saveGameState()
+		s.saveGameStateLocked(room)
 		return result
 	}
 
 	c, ok := room.clients[clientID]
 	if !ok {
-		return ""
+		return &game.TurnResult{}
 	}
 
 	currentPlayer := room.game.GetCurrentPlayer()
 	if currentPlayer == nil || currentPlayer.ID != c.ID {
-		return "It's not your turn.\n"
+		return game.Refuse(game.ReasonNotYourTurn, "It's not your turn.\n")
 	}
 
 	if room.game.TurnPhase != game.PhaseHunting {
-		return "You're not hunting right now.\n"
+		return game.Refuse(game.ReasonInvalidPhase, "You're not hunting right now.\n")
 	}
 
 	if c.Player == nil {
-		return "Error: Player not found.\n"
+		return game.Refuse(game.ReasonInvalid, "Error: Player not found.\n")
 	}
 
-	result := room.game.HandleHuntShoot(c.Player, reactionTimeMs)
+	s.stopHuntTimer(room, clientID)
+	result := resolve(room.game, c.Player)
+	room.game.Record(c.Player.Name, action, result, s.clock.Now())
 
 	if room.game.GameOver {
 		modeLabel := "continuous"
@@ -1631,44 +2608,47 @@ func (s *Server) HandleHuntShoot(clientID string, roomID string, reactionTimeMs
 			modeLabel = "party"
 		}
 		// Add all players to leaderboard
-		for _, cl := range room.clients {
-			if cl.Player != nil {
-				s.leaderboard.AddEntry(cl.Name, room.game.Win, room.game.Mileage, room.game.TurnNumber, modeLabel)
-			}
-		}
+		s.recordGameOver(room, room.game, modeLabel, room.clientPlayers())
+		result.Text(s.recordContributions(room))
 		room.status = StatusFinished
 		s.CancelTurnTimer(room)
-	} else {
+	} else if room.game.TurnPhase == game.PhaseMainMenu {
 		s.advanceTurnAndCheckFort(room)
 	}
 
 	// Save game state for persistence
-	s.saveGameStateAfterTurn(roomID)
+	s.saveGameStateAfterTurn(room.id)
 
 	return result
 }
 
-func (s *Server) HandleRiderTactic(clientID string, roomID string, tactic int) string {
+func (s *Server) HandleRiderTactic(clientID string, roomID string, tactic int) *game.TurnResult {
 	room := s.GetRoom(roomID)
 	if room == nil {
-		return ""
+		return &game.TurnResult{}
 	}
 	room.mu.Lock()
 	defer room.mu.Unlock()
 
-	// Continuous mode: get player's own game
-	if room.roomType == RoomTypeContinuous {
+	if room.paused {
+		return game.Refuse(errPaused, gamePausedMsg)
+	}
+
+	// Continuous and race modes: get player's own game
+	if room.perPlayerGames() {
 		playerGame, player := s.getPlayerGame(room, clientID)
 		if playerGame == nil || player == nil {
-			return "Error: Your game state not found. Please rejoin.\n"
+			return game.Refuse(game.ReasonInvalid, "Error: Your game state not found. Please rejoin.\n")
 		}
 		if playerGame.TurnPhase != game.PhaseRiders {
-			return "There are no riders right now.\n"
+			return game.Refuse(game.ReasonInvalidPhase, "There are no riders right now.\n")
 		}
 		if tactic < 1 || tactic > 4 {
 			tactic = 3
 		}
 		result := playerGame.HandleRiderTactic(player, tactic)
+		playerGame.Record(player.Name, "rider_tactic", result, s.clock.Now())
+		s.queueTrailNews(room, clientID, playerGame, player)
 
 		// Check for death
 		if !player.Alive {
@@ -1676,33 +2656,40 @@ func (s *Server) HandleRiderTactic(clientID string, roomID string, tactic int) s
 		}
 
 		// Increment turn after rider tactic is resolved
-		playerGame.NextTurn()
+		s.advancePlayerTurn(room, playerGame)
+
+		result.Text(s.finishRaceIfOver(room))
 
 		// Check for win
 		if playerGame.Win {
-			room.status = StatusFinished
+			if room.roomType == RoomTypeContinuous {
+				s.recordGameOver(room, playerGame, "continuous", []*game.Player{player})
+				s.crownChampion(room, playerGame, player)
+			} else {
+				room.status = StatusFinished
+			}
 		}
 
-		s.saveGameState()
+		s.saveGameStateLocked(room)
 		return result
 	}
 
 	c, ok := room.clients[clientID]
 	if !ok {
-		return ""
+		return &game.TurnResult{}
 	}
 
 	currentPlayer := room.game.GetCurrentPlayer()
 	if currentPlayer == nil || currentPlayer.ID != c.ID {
-		return "It's not your turn.\n"
+		return game.Refuse(game.ReasonNotYourTurn, "It's not your turn.\n")
 	}
 
 	if room.game.TurnPhase != game.PhaseRiders {
-		return "There are no riders right now.\n"
+		return game.Refuse(game.ReasonInvalidPhase, "There are no riders right now.\n")
 	}
 
 	if c.Player == nil {
-		return "Error: Player not found.\n"
+		return game.Refuse(game.ReasonInvalid, "Error: Player not found.\n")
 	}
 
 	if tactic < 1 || tactic > 4 {
@@ -1710,6 +2697,7 @@ func (s *Server) HandleRiderTactic(clientID string, roomID string, tactic int) s
 	}
 
 	result := room.game.HandleRiderTactic(c.Player, tactic)
+	room.game.Record(c.Player.Name, "rider_tactic", result, s.clock.Now())
 
 	if room.game.GameOver {
 		modeLabel := "continuous"
@@ -1717,11 +2705,8 @@ func (s *Server) HandleRiderTactic(clientID string, roomID string, tactic int) s
 			modeLabel = "party"
 		}
 		// Add all players to leaderboard
-		for _, cl := range room.clients {
-			if cl.Player != nil {
-				s.leaderboard.AddEntry(cl.Name, room.game.Win, room.game.Mileage, room.game.TurnNumber, modeLabel)
-			}
-		}
+		s.recordGameOver(room, room.game, modeLabel, room.clientPlayers())
+		result.Text(s.recordContributions(room))
 		room.status = StatusFinished
 		s.CancelTurnTimer(room)
 	} else {
@@ -1735,147 +2720,73 @@ func (s *Server) HandleRiderTactic(clientID string, roomID string, tactic int) s
 }
 
 func main() {
-	httpPort := flag.String("http", "8080", "HTTP server port")
-	flag.Parse()
-
-	if httpPortEnv := os.Getenv("HTTP_PORT"); httpPortEnv != "" {
-		*httpPort = httpPortEnv
+	cfg, err := LoadConfig(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
 	}
+	cfg.logEffective()
 
-	dataPath := os.Getenv("DATA_PATH")
-	if dataPath == "" {
-		dataPath = "./data"
+	if cfg.DevAnyOrigin {
+		log.Println("Warning: -dev-any-origin lets any web page connect as your players")
 	}
+	origins = cfg.origins
 
-	s := NewServer(dataPath)
+	s := NewServer(cfg)
+	for _, room := range s.PersistentRooms() {
+		s.seedNPCLootSites(room, s.npcSeed)
+		s.resumeSeason(room)
+	}
 
-	hub := NewHub(s)
+	hub := NewHub(s, cfg)
 	s.hub = hub
 	go hub.Run()
+	go hub.RunLobbyPush()
 
 	// Periodic cleanup of stale rooms
-	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-		for range ticker.C {
-			s.CleanupStaleRooms()
-		}
-	}()
+	s.every(time.Duration(cfg.RoomCleanupInterval), s.CleanupStaleRooms)
 
-	// Periodic loot deterioration (every 24 hours)
-	go func() {
-		// Run immediately on startup, then every 24 hours
-		s.deteriorateLootSites()
-		ticker := time.NewTicker(24 * time.Hour)
-		defer ticker.Stop()
-		for range ticker.C {
-			s.deteriorateLootSites()
-		}
-	}()
+	// Periodic handoff of waiting rooms whose owner went quiet
+	if s.ownerIdleTimeout > 0 {
+		s.every(ownerIdleCheckInterval, s.handOffIdleOwners)
+	}
 
-	http.HandleFunc("/", serveStatic)
-	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		serveWs(hub, w, r)
-	})
-	http.HandleFunc("/api/session", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		cookie, err := r.Cookie("session_id")
-		if err != nil {
-			json.NewEncoder(w).Encode(map[string]interface{}{"valid": false})
-			return
-		}
-		sess, ok := s.sessionManager.GetSessionByID(cookie.Value)
-		if !ok {
-			json.NewEncoder(w).Encode(map[string]interface{}{"valid": false})
-			return
-		}
-		// Check if the room still exists
-		roomExists := false
-		if sess.RoomID != "" {
-			s.roomsMu.RLock()
-			_, roomExists = s.rooms[sess.RoomID]
-			s.roomsMu.RUnlock()
-		}
-		if !roomExists {
-			json.NewEncoder(w).Encode(map[string]interface{}{"valid": false})
-			return
-		}
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"valid":   true,
-			"name":    sess.Name,
-			"room_id": sess.RoomID,
-		})
-	})
-	http.HandleFunc("/api/lobbies", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		if r.Method == http.MethodGet {
-			lobbies := s.ListLobbies()
-			json.NewEncoder(w).Encode(lobbies)
-			return
-		}
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	})
-	http.HandleFunc("/api/lobbies/create", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		var req struct {
-			Name       string `json:"name"`
-			Password   string `json:"password"`
-			MaxPlayers int    `json:"max_players"`
-		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Bad request", http.StatusBadRequest)
-			return
-		}
-		if req.Name == "" {
-			req.Name = "Pioneer Party"
-		}
-		// Owner ID will be set when they connect via WebSocket
-		room := s.CreateRoom(req.Name, req.Password, "", req.MaxPlayers)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"id":   room.id,
-			"name": room.name,
-		})
-	})
-	http.HandleFunc("/api/leaderboard", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Cache-Control", "no-cache")
-		mode := r.URL.Query().Get("mode")
-		if mode != "" {
-			entries := s.leaderboard.GetTopByMode(10, mode)
-			log.Printf("Leaderboard API: mode=%s, entries=%d", mode, len(entries))
-			json.NewEncoder(w).Encode(entries)
-		} else {
-			continuous := s.leaderboard.GetTopByMode(10, "continuous")
-			party := s.leaderboard.GetTopByMode(10, "party")
-			log.Printf("Leaderboard API: continuous=%d, party=%d", len(continuous), len(party))
-			result := map[string][]LeaderboardEntry{
-				"continuous": continuous,
-				"party":      party,
-			}
-			json.NewEncoder(w).Encode(result)
+	// Periodic loot deterioration: once on startup, then every
+	// loot_decay_interval
+	go s.deteriorateLootSites()
+	s.every(time.Duration(cfg.LootDecayInterval), s.deteriorateLootSites)
+
+	// Periodic sweep of sessions whose player is long gone
+	s.every(sessionSweepInterval, func() {
+		if n := s.sessionManager.Sweep(); n > 0 {
+			log.Printf("Swept %d expired sessions", n)
 		}
 	})
 
-	log.Printf("HTTP server listening on :%s", *httpPort)
+	httpServers := serveHTTP(NewRouter(s, hub), cfg.HTTPPort, cfg.tlsSettings(), cfg.DataPath)
 
-	// Create HTTP server with timeouts
-	httpServer := &http.Server{
-		Addr:         ":" + *httpPort,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  120 * time.Second,
-	}
-
-	go func() {
-		if err := httpServer.ListenAndServe(); err != nil {
+	var gameServer *network.GameServer
+	if cfg.TCPAddr != "" {
+		if gameServer, err = network.StartGameServer(cfg.TCPAddr); err != nil {
 			log.Fatal(err)
 		}
-	}()
+		log.Printf("TCP game server listening on %s", cfg.TCPAddr)
+	}
 
 	log.Println("Online Trail server running!")
-	select {}
+
+	// Stop listening on a signal, giving requests in flight a moment
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	log.Println("Shutting down...")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, srv := range httpServers {
+		srv.Shutdown(ctx)
+	}
+	s.saves.Wait()
+	s.saveRooms()
+	if gameServer != nil {
+		gameServer.Stop()
+	}
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Save migrations. Each save records the schema_version it was written in.
+// On load it is upgraded one version at a time, by the migrations registered
+// for its kind, before it is decoded; the migrations work on the raw JSON so
+// a field can be renamed or change meaning, not only be added. A save
+// always writes the latest version, which is the number of migrations.
+
+// saveMigration upgrades a save document by one version.
+type saveMigration func(doc map[string]json.RawMessage) error
+
+// continuousMigrations[i] upgrades a continuous room's save from version i.
+var continuousMigrations = []saveMigration{
+	// 0 to 1: saves from before versions were kept. Fields added since are
+	// filled in by restoreGame.
+	func(doc map[string]json.RawMessage) error { return nil },
+}
+
+// roomsMigrations[i] upgrades rooms.json from version i.
+var roomsMigrations = []saveMigration{
+	// 0 to 1: the file was a bare list of rooms, read as "rooms"
+	func(doc map[string]json.RawMessage) error { return nil },
+}
+
+var (
+	continuousSchemaVersion = len(continuousMigrations)
+	roomsSchemaVersion      = len(roomsMigrations)
+)
+
+// parseSaveDoc splits a save into its top-level fields. A save that is a
+// bare list, as rooms.json once was, becomes the document's "rooms".
+func parseSaveDoc(data []byte) (map[string]json.RawMessage, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if !json.Valid(trimmed) {
+			return nil, fmt.Errorf("invalid JSON list")
+		}
+		return map[string]json.RawMessage{"rooms": json.RawMessage(trimmed)}, nil
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("save is empty")
+	}
+	return doc, nil
+}
+
+// saveVersion is the version a save document was written in.
+func saveVersion(doc map[string]json.RawMessage) (int, error) {
+	raw, ok := doc["schema_version"]
+	if !ok {
+		return 0, nil
+	}
+	var v int
+	if err := json.Unmarshal(raw, &v); err != nil || v < 0 {
+		return 0, fmt.Errorf("bad schema_version %s", raw)
+	}
+	return v, nil
+}
+
+// migrateSave upgrades doc to the latest version and decodes it into v. It
+// fails for a save from a newer server, which this one can't read.
+func migrateSave(doc map[string]json.RawMessage, migrations []saveMigration, v interface{}) error {
+	version, err := saveVersion(doc)
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("saved as version %d, but this server only reads up to version %d", version, len(migrations))
+	}
+	for ; version < len(migrations); version++ {
+		if err := migrations[version](doc); err != nil {
+			return fmt.Errorf("migrating from version %d: %v", version, err)
+		}
+	}
+	doc["schema_version"] = json.RawMessage(fmt.Sprint(version))
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Every schema version a save has been written in has a fixture under
+// testdata/saves, named <kind>_v<version>.json. A new migration needs a
+// fixture of the version it upgrades from.
+
+// fixture returns the path of a save fixture.
+func fixture(kind string, version int) string {
+	return filepath.Join("testdata", "saves", fmt.Sprintf("%s_v%d.json", kind, version))
+}
+
+// startWithSave starts a server whose data directory holds the fixture as
+// file.
+func startWithSave(t *testing.T, fixturePath, file string) *Server {
+	t.Helper()
+	data, err := os.ReadFile(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig(t)
+	if err := os.WriteFile(filepath.Join(cfg.DataPath, file), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return startServer(t, cfg)
+}
+
+func TestEverySaveVersionHasAFixture(t *testing.T) {
+	for kind, latest := range map[string]int{"game_state": continuousSchemaVersion, "rooms": roomsSchemaVersion} {
+		for v := 0; v <= latest; v++ {
+			if _, err := os.Stat(fixture(kind, v)); err != nil {
+				t.Errorf("%s version %d: %v", kind, v, err)
+			}
+		}
+	}
+}
+
+func TestGameStateFixturesLoad(t *testing.T) {
+	for v := 0; v <= continuousSchemaVersion; v++ {
+		t.Run(fmt.Sprintf("v%d", v), func(t *testing.T) {
+			s := startWithSave(t, fixture("game_state", v), "game_state.json")
+			room := s.GetRoom(legacyRoomID)
+			if room == nil {
+				t.Fatal("no open trail")
+			}
+			g := room.playerGames["client-ann"]
+			if g == nil {
+				t.Fatal("Ann's game wasn't loaded")
+			}
+			if g.TurnNumber != 7 || g.Mileage != 420 || g.Food != 300 || !g.FortAvailable {
+				t.Errorf("Ann's game: turn %d, %.0f miles, %.0f food, fort %v",
+					g.TurnNumber, g.Mileage, g.Food, g.FortAvailable)
+			}
+			if len(g.Players) != 1 || g.Players[0].ID != "client-ann" || len(g.Players[0].Party) == 0 {
+				t.Fatalf("Ann's players = %+v, want Ann with a party", g.Players)
+			}
+			if len(room.game.LootSites) == 0 || room.game.LootSites[0].ID != "loot-1" {
+				t.Errorf("loot sites = %+v, want loot-1 first", room.game.LootSites)
+			}
+			if room.status != StatusPlaying {
+				t.Errorf("status = %s, want playing", room.status)
+			}
+
+			// The save is always written back in the latest version
+			s.saveGameState(room)
+			data, err := os.ReadFile(s.gameStateFilePath(room))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var saved PersistedContinuousState
+			if err := json.Unmarshal(data, &saved); err != nil {
+				t.Fatal(err)
+			}
+			if saved.SchemaVersion != continuousSchemaVersion {
+				t.Errorf("saved as version %d, want %d", saved.SchemaVersion, continuousSchemaVersion)
+			}
+		})
+	}
+}
+
+func TestGameStateV1KeepsPartyAndSeed(t *testing.T) {
+	s := startWithSave(t, fixture("game_state", 1), "game_state.json")
+	room := s.GetRoom(legacyRoomID)
+	g := room.playerGames["client-ann"]
+	if g == nil {
+		t.Fatal("Ann's game wasn't loaded")
+	}
+	party := g.Players[0].Party
+	if len(party) != 2 || party[1].Name != "Bo" || party[1].Alive || party[1].DiedOf != "cholera" {
+		t.Errorf("party = %+v, want Ann and the late Bo", party)
+	}
+	if g.Seed != 1848 {
+		t.Errorf("seed = %d, want the saved 1848", g.Seed)
+	}
+	if room.seasonEndsAt.IsZero() {
+		t.Error("the season end wasn't loaded")
+	}
+}
+
+func TestRoomsFixturesLoad(t *testing.T) {
+	sessionIDs := map[int]string{0: "legacy-session-ann", 1: "current-session-ann"}
+	for v := 0; v <= roomsSchemaVersion; v++ {
+		t.Run(fmt.Sprintf("v%d", v), func(t *testing.T) {
+			s := startWithSave(t, fixture("rooms", v), roomsFile)
+			room := s.GetRoom("party-1")
+			if room == nil {
+				t.Fatal("the party game wasn't restored")
+			}
+			if room.name != "Saturday wagon" || room.ownerID != "client-ann" || room.status != StatusPlaying {
+				t.Errorf("room = %q owned by %s, %s", room.name, room.ownerID, room.status)
+			}
+			if len(room.game.Players) != 2 || room.game.TurnNumber != 4 || room.game.CurrentPlayerIdx != 1 {
+				t.Errorf("game: %d players, turn %d, player %d; want 2, 4, 1",
+					len(room.game.Players), room.game.TurnNumber, room.game.CurrentPlayerIdx)
+			}
+			if !room.paused || !room.restored {
+				t.Error("a restored game should wait, paused, for its players")
+			}
+			sess, ok := s.sessionManager.GetSessionByID(sessionIDs[v])
+			if !ok || sess.ClientID != "client-ann" || sess.RoomID != "party-1" {
+				t.Errorf("Ann's cookie resumed %+v, %v", sess, ok)
+			}
+
+			s.saveRooms()
+			data, err := os.ReadFile(s.roomsFilePath())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var saved PersistedRooms
+			if err := json.Unmarshal(data, &saved); err != nil {
+				t.Fatal(err)
+			}
+			if saved.SchemaVersion != roomsSchemaVersion || len(saved.Rooms) != 1 {
+				t.Errorf("saved as version %d with %d rooms, want version %d with 1",
+					saved.SchemaVersion, len(saved.Rooms), roomsSchemaVersion)
+			}
+		})
+	}
+}
+
+func TestNewerSaveIsRefused(t *testing.T) {
+	for _, tc := range []struct {
+		kind       string
+		migrations []saveMigration
+	}{
+		{"game_state", continuousMigrations},
+		{"rooms", roomsMigrations},
+	} {
+		data, err := os.ReadFile(fixture(tc.kind, len(tc.migrations)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc, err := parseSaveDoc(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc["schema_version"] = json.RawMessage(fmt.Sprint(len(tc.migrations) + 1))
+		var v map[string]interface{}
+		if err := migrateSave(doc, tc.migrations, &v); err == nil {
+			t.Errorf("%s: a save from a newer server was read", tc.kind)
+		}
+	}
+}
+
+func TestBadSchemaVersionIsRefused(t *testing.T) {
+	for _, raw := range []string{`-1`, `"2"`, `1.5`} {
+		doc := map[string]json.RawMessage{"schema_version": json.RawMessage(raw)}
+		var v map[string]interface{}
+		if err := migrateSave(doc, continuousMigrations, &v); err == nil {
+			t.Errorf("schema_version %s was accepted", raw)
+		}
+	}
+}
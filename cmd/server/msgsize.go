@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+	"unicode/utf8"
+)
+
+// Message sizes. A client's messages are small apart from chat, and chat
+// longer than maxChatLen is cut short, so defaultMaxMessageBytes leaves
+// room for a pasted wall of text to be clipped rather than refused. A
+// message over the limit gets a message_too_large error and the connection
+// is closed, since the rest of it can't be skipped.
+const (
+	defaultMaxMessageBytes = 16 << 10
+	maxChatLen             = 200
+)
+
+// errMessageTooLarge is the code a client is closed with for an oversized
+// message.
+const errMessageTooLarge = "message_too_large"
+
+// oversizedDrainTime is how long the rest of an oversized message is read
+// for before the connection is closed anyway.
+const oversizedDrainTime = 5 * time.Second
+
+// errOversized is returned by readMessage for a message over the limit.
+var errOversized = errors.New("message over the size limit")
+
+// readMessage reads the client's next message, reading no more than the
+// hub's limit allows.
+func (c *wsClient) readMessage() ([]byte, error) {
+	_, r, err := c.conn.NextReader()
+	if err != nil {
+		return nil, err
+	}
+	limit := c.hub.maxMessageBytes
+	message, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(message)) > limit {
+		// Read out the rest, so closing doesn't reset the connection before
+		// the client has the error
+		c.conn.SetReadDeadline(time.Now().Add(oversizedDrainTime))
+		io.Copy(io.Discard, r)
+		return nil, errOversized
+	}
+	return message, nil
+}
+
+// refuseOversized tells the client its message was too large. readPump
+// stops after it, and writePump sends the error before the close frame.
+func (c *wsClient) refuseOversized() {
+	log.Printf("Closing connection for %s: message over %d bytes", c.clientID, c.hub.maxMessageBytes)
+	c.request = ""
+	c.flushOnClose = true
+	c.sendError(errMessageTooLarge, fmt.Sprintf("That message was over the %d byte limit.", c.hub.maxMessageBytes))
+}
+
+// clipChat cuts a chat message to maxChatLen characters, telling the sender
+// if it had to.
+func (c *wsClient) clipChat(message string) string {
+	if utf8.RuneCountInString(message) <= maxChatLen {
+		return message
+	}
+	c.hub.SendChatToClient(c.clientID, fmt.Sprintf("Your message was cut to %d characters.", maxChatLen))
+	return string([]rune(message)[:maxChatLen])
+}
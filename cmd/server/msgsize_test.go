@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+)
+
+// paddedPing is a ping of exactly size bytes.
+func paddedPing(size int) []byte {
+	msg := `{"type":"ping","t":1,"pad":""}`
+	return []byte(strings.Replace(msg, `""`, `"`+strings.Repeat("x", size-len(msg))+`"`, 1))
+}
+
+func TestMessageAtTheLimitIsRead(t *testing.T) {
+	ts := newTestSite(t)
+	ts.hub.maxMessageBytes = 1024
+	ann := ts.dial(t, "name=Ann")
+
+	if err := ann.WriteMessage(websocket.TextMessage, paddedPing(1024)); err != nil {
+		t.Fatal(err)
+	}
+	ann.await("pong")
+}
+
+func TestOversizedMessageGetsAnErrorThenAClose(t *testing.T) {
+	ts := newTestSite(t)
+	ts.hub.maxMessageBytes = 1024
+	ann := ts.dial(t, "name=Ann")
+
+	if err := ann.WriteMessage(websocket.TextMessage, paddedPing(1025)); err != nil {
+		t.Fatal(err)
+	}
+	if got := ann.await("error"); got["code"] != errMessageTooLarge {
+		t.Errorf("error = %v, want %s", got, errMessageTooLarge)
+	}
+	ann.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := ann.ReadMessage(); err != nil {
+			// A close frame, not a dropped connection
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				t.Errorf("the connection ended with %v, want a close frame", err)
+			}
+			break
+		}
+	}
+}
+
+func TestLongChatIsClipped(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	ann := ts.dial(t, "name=Ann&room="+room.id)
+	bea := ts.dial(t, "name=Bea&room="+room.id)
+
+	// Several thousand bytes, none of them cut in half
+	long := strings.Repeat("é", 3*maxChatLen)
+	ann.send(map[string]interface{}{"type": "chat", "message": long})
+	data, _ := ann.await("chat")["data"].(map[string]interface{})
+	if notice, _ := data["message"].(string); !strings.Contains(notice, "cut to 200 characters") {
+		t.Errorf("Ann was told %q, want that her message was cut", notice)
+	}
+
+	data, _ = bea.await("chat")["data"].(map[string]interface{})
+	got, _ := data["message"].(string)
+	if got != strings.Repeat("é", maxChatLen) || !utf8.ValidString(got) {
+		t.Errorf("Bea got %d characters, want the first %d", utf8.RuneCountInString(got), maxChatLen)
+	}
+
+	// The connection is still up
+	ann.sync()
+}
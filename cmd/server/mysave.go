@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// Save export and import. A player can download their continuous wagon as a
+// signed blob and load it again later, on this server or on another with the
+// same save_secret. The signature covers who the save was exported for and
+// a nonce, so a save only loads for the player it was made for, and only
+// once. The bounds below catch saves signed with a leaked secret that are
+// still implausible.
+const (
+	saveSecretFile = "save_secret"
+	saveNoncesFile = "save_nonces.json"
+	maxImportBytes = 1 << 20
+	maxImportCash  = 20000
+	maxImportTurns = 5000
+	maxImportParty = 5
+)
+
+// signedSave is what /api/my-save hands out and takes back.
+type signedSave struct {
+	RoomID    string          `json:"room_id,omitempty"`
+	ClientID  string          `json:"client_id"`
+	Name      string          `json:"name"`
+	Nonce     string          `json:"nonce"`
+	Save      json.RawMessage `json:"save"`
+	Signature string          `json:"signature"`
+}
+
+// signedPart is the part of a signedSave its signature covers.
+func (b *signedSave) signedPart() []byte {
+	data, _ := json.Marshal(struct {
+		ClientID string          `json:"client_id"`
+		Name     string          `json:"name"`
+		Nonce    string          `json:"nonce"`
+		Save     json.RawMessage `json:"save"`
+	}{b.ClientID, b.Name, b.Nonce, b.Save})
+	return data
+}
+
+// saveNonces records the nonces of saves already imported, so each export
+// loads once. It is kept in the data directory across restarts.
+type saveNonces struct {
+	path string
+	used map[string]string // nonce -> when it was used
+	mu   sync.Mutex
+}
+
+func loadSaveNonces(dataPath string) *saveNonces {
+	n := &saveNonces{path: filepath.Join(dataPath, saveNoncesFile), used: make(map[string]string)}
+	err := readSaveFile(n.path, func(data []byte) error {
+		return json.Unmarshal(data, &n.used)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("Error loading %s: %v", n.path, err)
+	}
+	return n
+}
+
+// isUsed reports whether a save with the nonce was already imported.
+func (n *saveNonces) isUsed(nonce string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, ok := n.used[nonce]
+	return ok
+}
+
+// use marks the nonce used, reporting false if it already was.
+func (n *saveNonces) use(nonce string, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.used[nonce]; ok {
+		return false
+	}
+	n.used[nonce] = now.UTC().Format(time.RFC3339)
+	data, err := json.MarshalIndent(n.used, "", "  ")
+	if err == nil {
+		err = writeSaveFile(n.path, data)
+	}
+	if err != nil {
+		log.Printf("Error saving %s: %v", n.path, err)
+	}
+	return true
+}
+
+// loadSaveSecret returns the key saves are signed with: the configured one,
+// or one generated on first run and kept in the data directory.
+func loadSaveSecret(configured, dataPath string) ([]byte, error) {
+	if configured != "" {
+		return []byte(configured), nil
+	}
+	path := filepath.Join(dataPath, saveSecretFile)
+	if data, err := os.ReadFile(path); err == nil {
+		if secret := strings.TrimSpace(string(data)); secret != "" {
+			return []byte(secret), nil
+		}
+	}
+	secret := GenerateSecureID()
+	if err := os.MkdirAll(dataPath, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(secret+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return []byte(secret), nil
+}
+
+// checkImportBounds rejects a save no real run could have reached.
+func checkImportBounds(saved *PersistedGameState) error {
+	switch {
+	case saved.GameOver || saved.Win:
+		return errors.New("that run is already over")
+	case saved.TurnNumber < 1 || saved.TurnNumber > maxImportTurns:
+		return errors.New("turn number is out of range")
+	case saved.Mileage < 0 || saved.Mileage > game.TrailLength:
+		return errors.New("mileage is out of range")
+	case saved.DistanceTraveled < 0 || saved.Week < 1 || saved.Day < 1:
+		return errors.New("trail date is out of range")
+	case saved.Cash < 0 || saved.Cash > maxImportCash:
+		return errors.New("cash is out of range")
+	case len(saved.Party) < 1 || len(saved.Party) > maxImportParty:
+		return errors.New("party size is out of range")
+	}
+	caps := (&game.GameState{HasWagonBed: saved.HasWagonBed}).Capacities()
+	for item, amount := range map[string]float64{
+		"food":     saved.Food,
+		"bullets":  saved.Bullets,
+		"clothing": saved.Clothing,
+		"misc":     saved.MiscSupplies,
+		"oxen":     saved.OxenCost,
+	} {
+		if amount < 0 || amount > caps[item] {
+			return fmt.Errorf("%s is out of range", item)
+		}
+	}
+	for _, m := range saved.Party {
+		if m.Health < 0 || m.Health > 100 {
+			return errors.New("party health is out of range")
+		}
+	}
+	for _, n := range saved.Parts {
+		if n < 0 || n > 10 {
+			return errors.New("spare parts are out of range")
+		}
+	}
+	return nil
+}
+
+// handleMySave exports the caller's continuous wagon on GET and imports one
+// on POST. ?room= picks the trail, otherwise the caller's shard of the open
+// trail or the default room.
+func (s *Server) handleMySave(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	sess, ok := s.sessionFromRequest(r)
+	if !ok {
+		http.Error(w, "Not signed in", http.StatusUnauthorized)
+		return
+	}
+	roomID := r.URL.Query().Get("room")
+	if roomID == "" {
+		roomID = s.landingRoom(sess.ClientID)
+	}
+	room := s.GetRoom(roomID)
+	if room == nil || !room.persistent {
+		http.Error(w, "No such trail", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.exportSave(w, sess, room)
+	case http.MethodPost:
+		if !requireOrigin(w, r) {
+			return
+		}
+		s.importSave(w, r, sess, room)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) exportSave(w http.ResponseWriter, sess *Session, room *GameRoom) {
+	room.mu.RLock()
+	playerGame, ok := room.playerGames[sess.ClientID]
+	var saved PersistedGameState
+	if ok {
+		saved = persistPlayerGame(sess.ClientID, sess.Name, playerGame)
+	}
+	room.mu.RUnlock()
+	if !ok {
+		http.Error(w, "You have no wagon on this trail", http.StatusNotFound)
+		return
+	}
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		http.Error(w, "Couldn't export the save", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Audit: save export for %s (client %s) from %s", sess.Name, sess.ClientID, room.id)
+	w.Header().Set("Content-Disposition", `attachment; filename="online-trail-save.json"`)
+	blob := signedSave{
+		RoomID:   room.id,
+		ClientID: sess.ClientID,
+		Name:     sess.Name,
+		Nonce:    GenerateSecureID(),
+		Save:     data,
+	}
+	blob.Signature = SignPayload(s.saveSecret, blob.signedPart())
+	json.NewEncoder(w).Encode(blob)
+}
+
+const wagonNotFreshMessage = "You already have a wagon on this trail; a save can only be loaded into a fresh one"
+
+// freshWagon reports whether a slot on the trail is free for an import: no
+// wagon, or one that hasn't left yet.
+func freshWagon(g *game.GameState) bool {
+	return g == nil || (g.TurnNumber <= 1 && g.Mileage == 0)
+}
+
+// importSave loads a signed save into the caller's slot on the trail, which
+// must be empty or hold a wagon that hasn't left yet. The save must have
+// been exported for the caller's client and not imported before. The wagon
+// keeps the caller's name, whatever name it was exported under.
+func (s *Server) importSave(w http.ResponseWriter, r *http.Request, sess *Session, room *GameRoom) {
+	var blob signedSave
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportBytes)).Decode(&blob); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if !ValidSignature(s.saveSecret, blob.signedPart(), blob.Signature) {
+		http.Error(w, "That save wasn't made by this server, or was changed since", http.StatusForbidden)
+		return
+	}
+	if blob.ClientID != sess.ClientID {
+		http.Error(w, "That save was exported by another player", http.StatusForbidden)
+		return
+	}
+	if blob.Nonce == "" || s.saveNonces.isUsed(blob.Nonce) {
+		http.Error(w, "That save has already been loaded", http.StatusConflict)
+		return
+	}
+	var saved PersistedGameState
+	if err := json.Unmarshal(blob.Save, &saved); err != nil {
+		http.Error(w, "Bad save", http.StatusBadRequest)
+		return
+	}
+	if err := checkImportBounds(&saved); err != nil {
+		http.Error(w, "Can't import this save: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	room.mu.RLock()
+	existing := room.playerGames[sess.ClientID]
+	room.mu.RUnlock()
+	if !freshWagon(existing) {
+		http.Error(w, wagonNotFreshMessage, http.StatusConflict)
+		return
+	}
+	if existing == nil {
+		if err := s.CheckGameCap(identityOf(sess), RoomTypeContinuous); err != nil {
+			http.Error(w, "Too many games: "+err.Error(), http.StatusConflict)
+			return
+		}
+	}
+
+	room.mu.Lock()
+	// The wagon may have set out while the cap was checked
+	if !freshWagon(room.playerGames[sess.ClientID]) {
+		room.mu.Unlock()
+		http.Error(w, wagonNotFreshMessage, http.StatusConflict)
+		return
+	}
+	if !s.saveNonces.use(blob.Nonce, s.clock.Now()) {
+		room.mu.Unlock()
+		http.Error(w, "That save has already been loaded", http.StatusConflict)
+		return
+	}
+	playerGame := restorePlayerGame(sess.ClientID, sess.Name, saved)
+	room.playerGames[sess.ClientID] = playerGame
+	if c, ok := room.clients[sess.ClientID]; ok {
+		for _, p := range playerGame.Players {
+			if p.ID == sess.ClientID {
+				c.Player = p
+			}
+		}
+	}
+	room.status = StatusPlaying
+	room.mu.Unlock()
+	s.saveGameState(room)
+	if s.hub != nil {
+		s.hub.BroadcastStateTo(room.id)
+	}
+
+	log.Printf("Audit: save import for %s (client %s) into %s: turn %d, mileage %.0f",
+		sess.Name, sess.ClientID, room.id, saved.TurnNumber, saved.Mileage)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"imported": true,
+		"room_id":  room.id,
+		"turn":     saved.TurnNumber,
+		"mileage":  saved.Mileage,
+		"message":  fmt.Sprintf("Your wagon is back on the trail at mile %.0f.", saved.Mileage),
+	})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"online-trail/pkg/game"
+)
+
+// midTrailSave is a plausible save of a wagon some way along the trail.
+func midTrailSave(t *testing.T) PersistedGameState {
+	t.Helper()
+	g := game.NewGameState()
+	g.OxenCost, g.Food, g.Bullets, g.Clothing, g.MiscSupplies, g.Cash = 220, 100, 50, 20, 10, 300
+	g.TurnNumber, g.Week, g.Day, g.Mileage = 6, 6, 1, 400
+	p := g.AddPlayer("Ann", game.PlayerTypeHuman)
+	p.ID = "client-ann"
+	return persistPlayerGame(p.ID, p.Name, g)
+}
+
+// mySave calls /api/my-save as the session, with the body if one is given.
+func mySave(s *Server, sessionID, origin string, body []byte) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/api/my-save", nil)
+	if body != nil {
+		r = httptest.NewRequest(http.MethodPost, "/api/my-save", bytes.NewReader(body))
+	}
+	r.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	w := httptest.NewRecorder()
+	s.handleMySave(w, r)
+	return w
+}
+
+func TestSaveImportsOnceForItsOwner(t *testing.T) {
+	s := newTestServer(t)
+	room := s.GetRoom(s.defaultRoomID)
+	ann := s.sessionManager.NewSession("Ann", "client-ann", room.id)
+	bea := s.sessionManager.NewSession("Bea", "client-bea", room.id)
+	room.playerGames["client-ann"] = restorePlayerGame("client-ann", "Ann", midTrailSave(t))
+
+	export := mySave(s, ann, "", nil)
+	if export.Code != http.StatusOK {
+		t.Fatalf("export: %d %s", export.Code, export.Body)
+	}
+	blob := export.Body.Bytes()
+	delete(room.playerGames, "client-ann")
+
+	var edited signedSave
+	json.Unmarshal(blob, &edited)
+	edited.Save = bytes.Replace(edited.Save, []byte(`"mileage":400`), []byte(`"mileage":1900`), 1)
+	if !bytes.Contains(edited.Save, []byte(`"mileage":1900`)) {
+		t.Fatalf("couldn't edit the mileage in %s", edited.Save)
+	}
+	editedBlob, _ := json.Marshal(edited)
+
+	steps := []struct {
+		name    string
+		session string
+		origin  string
+		body    []byte
+		want    int
+	}{
+		{"another player", bea, "", blob, http.StatusForbidden},
+		{"another site", ann, "https://evil.example", blob, http.StatusForbidden},
+		{"edited save", ann, "", editedBlob, http.StatusForbidden},
+		{"owner", ann, "", blob, http.StatusOK},
+		{"owner again", ann, "", blob, http.StatusConflict},
+	}
+	for _, step := range steps {
+		if step.name == "owner again" {
+			delete(room.playerGames, "client-ann")
+		}
+		if w := mySave(s, step.session, step.origin, step.body); w.Code != step.want {
+			t.Errorf("%s: got %d %s, want %d", step.name, w.Code, w.Body, step.want)
+		}
+	}
+	if g := room.playerGames["client-ann"]; g != nil {
+		t.Errorf("second import left a wagon at mile %.0f", g.Mileage)
+	}
+
+	// Used nonces outlast a restart
+	if !loadSaveNonces(s.dataPath).isUsed(edited.Nonce) {
+		t.Error("the save's nonce wasn't recorded as used")
+	}
+}
+
+func TestImportBoundsFollowCapacities(t *testing.T) {
+	tests := []struct {
+		name   string
+		edit   func(*PersistedGameState)
+		wantOK bool
+	}{
+		{"plausible", func(*PersistedGameState) {}, true},
+		{"food at capacity", func(p *PersistedGameState) { p.Food = game.FoodCapacity }, true},
+		{"food over capacity", func(p *PersistedGameState) { p.Food = game.FoodCapacity + 1 }, false},
+		{"food over capacity with a wagon bed", func(p *PersistedGameState) {
+			p.HasWagonBed = true
+			p.Food = game.FoodCapacity + 1
+		}, true},
+		{"bullets over capacity", func(p *PersistedGameState) { p.Bullets = game.BulletCapacity + 1 }, false},
+		{"negative clothing", func(p *PersistedGameState) { p.Clothing = -1 }, false},
+		{"misc over capacity", func(p *PersistedGameState) { p.MiscSupplies = game.MiscCapacity + 1 }, false},
+		{"oxen over strength", func(p *PersistedGameState) { p.OxenCost = game.OxenMaxStrength + 1 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := midTrailSave(t)
+			tt.edit(&saved)
+			if err := checkImportBounds(&saved); (err == nil) != tt.wantOK {
+				t.Errorf("checkImportBounds = %v, want ok %v", err, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// NameClaim is a player's registration of their name. Joins using a claimed
+// name need the PIN unless they resume an existing session. Hash is a
+// bcrypt hash; claims made before bcrypt have a Salt and an iterated
+// SHA-256 hash instead, replaced the next time their PIN is given.
+type NameClaim struct {
+	Salt      string `json:"salt,omitempty"`
+	Hash      string `json:"hash"`
+	ClaimedAt string `json:"claimed_at"`
+}
+
+// PIN length bounds and the hashing work factors.
+const (
+	minPINLength   = 4
+	maxPINLength   = 64
+	pinHashCost    = bcrypt.DefaultCost
+	pinHashRounds  = 10000 // legacy claims only
+	pinMaxFailures = 5
+	pinLockout     = 10 * time.Minute
+)
+
+// pinHeader carries the PIN of a registered name when joining over /ws.
+const pinHeader = "X-Trail-PIN"
+
+var (
+	errNameClaimed   = errors.New("that name is already registered")
+	errNameUnclaimed = errors.New("that name isn't registered")
+	errWrongPIN      = errors.New("wrong PIN")
+	errPINLocked     = errors.New("too many wrong PINs, try again later")
+)
+
+// validatePIN checks a PIN before it is hashed: 4 to 64 digits.
+func validatePIN(pin string) error {
+	if n := len(pin); n < minPINLength || n > maxPINLength {
+		return errors.New("PIN must be 4 to 64 digits")
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return errors.New("PIN must be digits only")
+		}
+	}
+	return nil
+}
+
+// hashPIN derives a legacy claim's hash for a PIN with an iterated, salted
+// SHA-256.
+func hashPIN(salt, pin string) string {
+	sum := sha256.Sum256([]byte(salt + pin))
+	for i := 1; i < pinHashRounds; i++ {
+		sum = sha256.Sum256(append([]byte(salt), sum[:]...))
+	}
+	return hex.EncodeToString(sum[:])
+}
+
+// newNameClaim hashes pin with bcrypt, under a fresh random salt.
+func newNameClaim(pin string) (*NameClaim, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), pinHashCost)
+	if err != nil {
+		return nil, err
+	}
+	return &NameClaim{
+		Hash:      string(hash),
+		ClaimedAt: time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// legacy reports whether the claim predates bcrypt.
+func (nc *NameClaim) legacy() bool {
+	return nc.Salt != ""
+}
+
+// matches reports whether pin is the claim's PIN.
+func (nc *NameClaim) matches(pin string) bool {
+	if nc.legacy() {
+		got := hashPIN(nc.Salt, pin)
+		return subtle.ConstantTimeCompare([]byte(got), []byte(nc.Hash)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(nc.Hash), []byte(pin)) == nil
+}
+
+// pinGuard counts recent wrong PINs per name and source address to slow
+// down guessing. Keying on the source too means someone guessing at a name
+// can't lock its owner out.
+type pinGuard struct {
+	failures map[string][]time.Time
+	mu       sync.Mutex
+}
+
+var pinFailures = &pinGuard{failures: make(map[string][]time.Time)}
+
+// pinGuardKey is the pinGuard key for guesses at name from source.
+func pinGuardKey(name, source string) string {
+	return statsKey(name) + "\x00" + source
+}
+
+// locked reports whether the key has had too many recent wrong PINs.
+func (g *pinGuard) locked(key string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	recent := g.failures[key][:0]
+	for _, t := range g.failures[key] {
+		if now.Sub(t) < pinLockout {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) == 0 {
+		delete(g.failures, key)
+	} else {
+		g.failures[key] = recent
+	}
+	return len(recent) >= pinMaxFailures
+}
+
+func (g *pinGuard) fail(key string, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.failures[key] = append(g.failures[key], now)
+}
+
+func (g *pinGuard) clear(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.failures, key)
+}
+
+// clearName forgets the wrong PINs for name from every source.
+func (g *pinGuard) clearName(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	prefix := pinGuardKey(name, "")
+	for key := range g.failures {
+		if strings.HasPrefix(key, prefix) {
+			delete(g.failures, key)
+		}
+	}
+}
+
+// loadClaims reads the name claims from names.json. Claims used to be kept
+// with each name's stats; any still there are moved over.
+// NOTE: caller must hold lb.mu, or not yet have shared lb.
+func (lb *Leaderboard) loadClaims() {
+	err := readSaveFile(lb.namesFilePath, func(data []byte) error {
+		var claims map[string]*NameClaim
+		if err := json.Unmarshal(data, &claims); err != nil {
+			return err
+		}
+		for name, claim := range claims {
+			if claim != nil {
+				lb.claims[statsKey(name)] = claim
+			}
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to load name claims from %s: %v", lb.namesFilePath, err)
+		return
+	}
+
+	moved := 0
+	for key, ps := range lb.stats {
+		if ps.NameClaim == nil {
+			continue
+		}
+		if _, ok := lb.claims[key]; !ok {
+			lb.claims[key] = ps.NameClaim
+			moved++
+		}
+		ps.NameClaim = nil
+	}
+	if moved > 0 {
+		// Claims first, so a crash between the two saves loses none
+		lb.saveClaims()
+		lb.saveStats()
+		log.Printf("Moved %d name claims from %s to %s", moved, lb.statsFilePath, lb.namesFilePath)
+	}
+}
+
+// saveClaims writes the name claims to names.json.
+// NOTE: caller must hold lb.mu.
+func (lb *Leaderboard) saveClaims() {
+	data, err := json.MarshalIndent(lb.claims, "", "  ")
+	if err != nil {
+		log.Printf("Failed to marshal name claims: %v", err)
+		return
+	}
+	if err := writeSaveFile(lb.namesFilePath, data); err != nil {
+		log.Printf("Failed to save name claims to %s: %v", lb.namesFilePath, err)
+	}
+}
+
+// IsNameClaimed reports whether a name is registered.
+func (lb *Leaderboard) IsNameClaimed(name string) bool {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+	return lb.claims[statsKey(name)] != nil
+}
+
+// CheckNamePIN verifies the PIN for a registered name, given from source,
+// the address it came from.
+func (lb *Leaderboard) CheckNamePIN(name, pin, source string) error {
+	key := pinGuardKey(name, source)
+	now := time.Now()
+	if pinFailures.locked(key, now) {
+		return errPINLocked
+	}
+	lb.mu.RLock()
+	claim := lb.claims[statsKey(name)]
+	lb.mu.RUnlock()
+	if claim == nil {
+		return errNameUnclaimed
+	}
+	if !claim.matches(pin) {
+		pinFailures.fail(key, now)
+		return errWrongPIN
+	}
+	pinFailures.clear(key)
+	if claim.legacy() {
+		lb.upgradeClaim(name, claim, pin)
+	}
+	return nil
+}
+
+// upgradeClaim rehashes a legacy claim with bcrypt now that its PIN is
+// known, unless the claim changed meanwhile.
+func (lb *Leaderboard) upgradeClaim(name string, old *NameClaim, pin string) {
+	claim, err := newNameClaim(pin)
+	if err != nil {
+		return
+	}
+	claim.ClaimedAt = old.ClaimedAt
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if key := statsKey(name); lb.claims[key] == old {
+		lb.claims[key] = claim
+		lb.saveClaims()
+		log.Printf("PIN hash for %s upgraded to bcrypt", name)
+	}
+}
+
+// ClaimName registers an unclaimed name with a PIN.
+func (lb *Leaderboard) ClaimName(name, pin string) error {
+	if err := validatePIN(pin); err != nil {
+		return err
+	}
+	claim, err := newNameClaim(pin)
+	if err != nil {
+		return err
+	}
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	key := statsKey(name)
+	if lb.claims[key] != nil {
+		return errNameClaimed
+	}
+	lb.claims[key] = claim
+	lb.saveClaims()
+	log.Printf("Name %s registered", name)
+	return nil
+}
+
+// ChangeNamePIN replaces the PIN of a registered name.
+func (lb *Leaderboard) ChangeNamePIN(name, oldPIN, newPIN, source string) error {
+	if err := validatePIN(newPIN); err != nil {
+		return err
+	}
+	if err := lb.CheckNamePIN(name, oldPIN, source); err != nil {
+		return err
+	}
+	claim, err := newNameClaim(newPIN)
+	if err != nil {
+		return err
+	}
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	key := statsKey(name)
+	if lb.claims[key] == nil {
+		return errNameUnclaimed
+	}
+	lb.claims[key] = claim
+	lb.saveClaims()
+	log.Printf("PIN changed for %s", name)
+	return nil
+}
+
+// ReleaseName drops a name's registration so anyone may use it again.
+func (lb *Leaderboard) ReleaseName(name string) bool {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	key := statsKey(name)
+	if lb.claims[key] == nil {
+		return false
+	}
+	delete(lb.claims, key)
+	lb.saveClaims()
+	pinFailures.clearName(name)
+	log.Printf("Audit: registration of %s released", name)
+	return true
+}
+
+// isAdmin reports whether the request carries the operator's admin token.
+func (s *Server) isAdmin(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return s.adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) == 1
+}
+
+// handleAdminReleaseName lets an operator release an abandoned name. It is
+// disabled unless ADMIN_TOKEN is set, and expects it as a bearer token.
+func (s *Server) handleAdminReleaseName(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.isAdmin(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "Missing name", http.StatusBadRequest)
+		return
+	}
+	if !s.leaderboard.ReleaseName(strings.TrimSpace(req.Name)) {
+		http.Error(w, "Name is not registered", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"released": true})
+}
+
+// handleRegister serves POST /api/register, the web form of the /register
+// command: it claims name with pin, or changes an already claimed name's
+// PIN from pin to new_pin.
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireOrigin(w, r) {
+		return
+	}
+	var req struct {
+		Name   string `json:"name"`
+		PIN    string `json:"pin"`
+		NewPIN string `json:"new_pin"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	name, err := validatePlayerName(req.Name)
+	if err != nil {
+		http.Error(w, "Invalid player name: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.NewPIN != "" {
+		err = s.leaderboard.ChangeNamePIN(name, req.PIN, req.NewPIN, requestIP(r))
+	} else {
+		err = s.leaderboard.ClaimName(name, req.PIN)
+	}
+	switch err {
+	case nil:
+	case errNameClaimed:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errNameUnclaimed:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errWrongPIN:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case errPINLocked:
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"registered":  true,
+		"name":        name,
+		"pin_changed": req.NewPIN != "",
+	})
+}
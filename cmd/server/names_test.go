@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatePIN(t *testing.T) {
+	tests := []struct {
+		pin    string
+		wantOK bool
+	}{
+		{"", false},
+		{"123", false},
+		{"1234", true},
+		{"0042", true},
+		{"correct horse", false},
+		{"12a4", false},
+		{"12 34", false},
+		{"١٢٣٤", false},
+		{strings.Repeat("9", maxPINLength), true},
+		{strings.Repeat("9", maxPINLength+1), false},
+	}
+	for _, tt := range tests {
+		if err := validatePIN(tt.pin); (err == nil) != tt.wantOK {
+			t.Errorf("validatePIN(%q) = %v, want ok %v", tt.pin, err, tt.wantOK)
+		}
+	}
+}
+
+func TestPINHashing(t *testing.T) {
+	claim, err := newNameClaim("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(claim.Hash, "$2") || strings.Contains(claim.Hash, "1234") {
+		t.Errorf("hash %q isn't bcrypt", claim.Hash)
+	}
+	if claim.legacy() || !claim.matches("1234") || claim.matches("1235") {
+		t.Error("bcrypt claim doesn't match only its PIN")
+	}
+	again, _ := newNameClaim("1234")
+	if again.Hash == claim.Hash {
+		t.Error("two hashes of one PIN are the same; the salt isn't fresh")
+	}
+}
+
+func TestLegacyClaimIsRehashed(t *testing.T) {
+	lb := NewLeaderboard(t.TempDir())
+	defer pinFailures.clearName("Ann")
+	legacy := &NameClaim{Salt: "salt", Hash: hashPIN("salt", "1234")}
+	lb.claims[statsKey("Ann")] = legacy
+
+	if err := lb.CheckNamePIN("Ann", "1234", "10.0.0.1"); err != nil {
+		t.Fatalf("legacy PIN: %v", err)
+	}
+	upgraded := lb.claims[statsKey("Ann")]
+	if upgraded == legacy || upgraded.legacy() || !upgraded.matches("1234") {
+		t.Errorf("legacy claim not rehashed with bcrypt: %+v", upgraded)
+	}
+}
+
+func TestPINLockoutExpires(t *testing.T) {
+	g := &pinGuard{failures: make(map[string][]time.Time)}
+	start := time.Now()
+	for i := 0; i < pinMaxFailures-1; i++ {
+		g.fail("k", start)
+	}
+	if g.locked("k", start) {
+		t.Errorf("locked after %d failures, want %d", pinMaxFailures-1, pinMaxFailures)
+	}
+	g.fail("k", start)
+	if !g.locked("k", start.Add(pinLockout-time.Second)) {
+		t.Error("not locked within the lockout")
+	}
+	if g.locked("k", start.Add(pinLockout)) {
+		t.Error("still locked once the lockout has passed")
+	}
+}
+
+func TestClaimsLiveInNamesFile(t *testing.T) {
+	dir := t.TempDir()
+	lb := NewLeaderboard(dir)
+	if err := lb.ClaimName("Ann", "1234"); err != nil {
+		t.Fatal(err)
+	}
+	lb.AddEntry("client-ann", "Ann", false, 100, 5, 50, 0, "continuous", "")
+
+	stats, _ := os.ReadFile(filepath.Join(dir, "player_stats.json"))
+	if strings.Contains(string(stats), "name_claim") {
+		t.Errorf("player_stats.json holds a claim: %s", stats)
+	}
+	if !NewLeaderboard(dir).IsNameClaimed("ann") {
+		t.Error("claim didn't survive a reload from names.json")
+	}
+}
+
+func TestClaimsMoveOutOfStats(t *testing.T) {
+	dir := t.TempDir()
+	claim, _ := newNameClaim("1234")
+	old, _ := json.Marshal([]*PlayerStats{{PlayerName: "Ann", GamesPlayed: 3, NameClaim: claim}})
+	if err := os.WriteFile(filepath.Join(dir, "player_stats.json"), old, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lb := NewLeaderboard(dir)
+	defer pinFailures.clearName("Ann")
+	if err := lb.CheckNamePIN("Ann", "1234", "10.0.0.1"); err != nil {
+		t.Fatalf("moved claim: %v", err)
+	}
+	if stats, _ := lb.GetPlayerStats("Ann"); stats.GamesPlayed != 3 || stats.NameClaim != nil {
+		t.Errorf("stats after the move: %+v", stats)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "names.json")); err != nil {
+		t.Errorf("names.json not written: %v", err)
+	}
+}
+
+func TestNameClaimLifecycle(t *testing.T) {
+	lb := NewLeaderboard(t.TempDir())
+	defer pinFailures.clearName("Ann")
+
+	steps := []struct {
+		name string
+		do   func() error
+		want error
+	}{
+		{"unclaimed", func() error { return lb.CheckNamePIN("Ann", "1234", "10.0.0.1") }, errNameUnclaimed},
+		{"claim", func() error { return lb.ClaimName("Ann", "1234") }, nil},
+		{"claim again", func() error { return lb.ClaimName("ann", "9999") }, errNameClaimed},
+		{"correct PIN", func() error { return lb.CheckNamePIN("Ann", "1234", "10.0.0.1") }, nil},
+		{"any case", func() error { return lb.CheckNamePIN("ANN", "1234", "10.0.0.1") }, nil},
+		{"wrong PIN", func() error { return lb.CheckNamePIN("Ann", "4321", "10.0.0.1") }, errWrongPIN},
+		{"change with wrong PIN", func() error { return lb.ChangeNamePIN("Ann", "4321", "5678", "10.0.0.1") }, errWrongPIN},
+		{"change", func() error { return lb.ChangeNamePIN("Ann", "1234", "5678", "10.0.0.1") }, nil},
+		{"old PIN after change", func() error { return lb.CheckNamePIN("Ann", "1234", "10.0.0.1") }, errWrongPIN},
+		{"new PIN after change", func() error { return lb.CheckNamePIN("Ann", "5678", "10.0.0.1") }, nil},
+	}
+	for _, step := range steps {
+		if err := step.do(); err != step.want {
+			t.Fatalf("%s: got %v, want %v", step.name, err, step.want)
+		}
+	}
+
+	if !lb.ReleaseName("Ann") {
+		t.Fatal("ReleaseName found no claim")
+	}
+	if lb.IsNameClaimed("Ann") {
+		t.Error("name still claimed after release")
+	}
+	if lb.ReleaseName("Ann") {
+		t.Error("released an unclaimed name")
+	}
+	if err := lb.ClaimName("Ann", "2468"); err != nil {
+		t.Errorf("claiming a released name: %v", err)
+	}
+}
+
+func TestPINLockoutIsPerSource(t *testing.T) {
+	lb := NewLeaderboard(t.TempDir())
+	defer pinFailures.clearName("Ann")
+	if err := lb.ClaimName("Ann", "1234"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < pinMaxFailures; i++ {
+		if err := lb.CheckNamePIN("Ann", "0000", "203.0.113.9"); err != errWrongPIN {
+			t.Fatalf("guess %d: got %v, want %v", i+1, err, errWrongPIN)
+		}
+	}
+	if err := lb.CheckNamePIN("Ann", "1234", "203.0.113.9"); err != errPINLocked {
+		t.Errorf("guesser with the right PIN: got %v, want %v", err, errPINLocked)
+	}
+	if err := lb.CheckNamePIN("Ann", "1234", "198.51.100.4"); err != nil {
+		t.Errorf("owner from another address: got %v, want nil", err)
+	}
+	lb.ReleaseName("Ann")
+	lb.ClaimName("Ann", "1234")
+	if err := lb.CheckNamePIN("Ann", "1234", "203.0.113.9"); err != nil {
+		t.Errorf("after release the lockout should be gone: got %v", err)
+	}
+}
+
+func TestJoinTakesPINFromHeaderOnly(t *testing.T) {
+	s := newTestServer(t)
+	hub := NewHub(s, testConfig(t))
+	defer pinFailures.clearName("Ann")
+	if err := s.leaderboard.ClaimName("Ann", "1234"); err != nil {
+		t.Fatal(err)
+	}
+
+	preflight := func(query, header string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/ws?name=Ann&preflight=1&v=2"+query, nil)
+		if header != "" {
+			r.Header.Set(pinHeader, header)
+		}
+		w := httptest.NewRecorder()
+		serveWs(hub, w, r)
+		return w
+	}
+	if w := preflight("", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("no PIN: got %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if w := preflight("&pin=1234", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("PIN in the query: got %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	w := preflight("", "1234")
+	if w.Code != http.StatusOK {
+		t.Fatalf("PIN in the header: got %d %s", w.Code, w.Body)
+	}
+	var sessionID string
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "session_id" {
+			sessionID = c.Value
+		}
+	}
+	if sess, ok := s.sessionManager.GetSessionByID(sessionID); !ok || sess.Name != "Ann" {
+		t.Error("a proven preflight didn't start a session for the websocket to resume")
+	}
+}
+
+func TestRegisterChecksOriginAndPIN(t *testing.T) {
+	s := newTestServer(t)
+	register := func(body, origin string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/register", strings.NewReader(body))
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		s.handleRegister(w, r)
+		return w
+	}
+
+	if w := register(`{"name": "Ann", "pin": "2468"}`, "https://evil.example"); w.Code != http.StatusForbidden {
+		t.Errorf("from another site: got %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if s.leaderboard.IsNameClaimed("Ann") {
+		t.Fatal("another site registered Ann")
+	}
+	if w := register(`{"name": "Ann", "pin": "hunter"}`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("letters for a PIN: got %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := register(`{"name": "Ann", "pin": "2468"}`, ""); w.Code != http.StatusOK {
+		t.Errorf("register: got %d %s", w.Code, w.Body)
+	}
+}
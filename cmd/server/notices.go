@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// maxQueuedNotices bounds how many undelivered notices a client keeps; the
+// oldest are dropped first.
+const maxQueuedNotices = 20
+
+// Notice is a private message for one client that is held until they are
+// connected to receive it.
+type Notice struct {
+	Kind      string                 `json:"kind"`
+	RoomID    string                 `json:"room_id"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// QueueNotice stores a notice for clientID and delivers it right away if
+// they're connected.
+func (s *Server) QueueNotice(clientID string, n Notice) {
+	s.noticesMu.Lock()
+	queue := append(s.notices[clientID], n)
+	if len(queue) > maxQueuedNotices {
+		queue = queue[len(queue)-maxQueuedNotices:]
+	}
+	s.notices[clientID] = queue
+	s.noticesMu.Unlock()
+
+	if s.hub != nil {
+		s.hub.DeliverNotices(clientID)
+	}
+}
+
+// takeNotices removes and returns every queued notice for clientID.
+func (s *Server) takeNotices(clientID string) []Notice {
+	s.noticesMu.Lock()
+	defer s.noticesMu.Unlock()
+	queue := s.notices[clientID]
+	delete(s.notices, clientID)
+	return queue
+}
+
+// requeueNotices puts notices that couldn't be sent back at the front of
+// the client's queue.
+func (s *Server) requeueNotices(clientID string, pending []Notice) {
+	if len(pending) == 0 {
+		return
+	}
+	s.noticesMu.Lock()
+	defer s.noticesMu.Unlock()
+	queue := append(pending, s.notices[clientID]...)
+	if len(queue) > maxQueuedNotices {
+		queue = queue[len(queue)-maxQueuedNotices:]
+	}
+	s.notices[clientID] = queue
+}
+
+// DeliverNotices sends clientID's queued notices to one of their open
+// connections. Notices stay queued if they have none.
+func (h *Hub) DeliverNotices(clientID string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, client := range h.clients {
+		if client.clientID == clientID {
+			h.flushNotices(client)
+			return
+		}
+	}
+}
+
+// flushNotices sends a client's queued notices, requeueing any that don't
+// fit in its send buffer.
+func (h *Hub) flushNotices(client *wsClient) {
+	pending := h.server.takeNotices(client.clientID)
+	for i, n := range pending {
+		msgJSON, err := json.Marshal(map[string]interface{}{
+			"type": "notice",
+			"data": n,
+		})
+		if err != nil {
+			continue
+		}
+		select {
+		case client.send <- msgJSON:
+		default:
+			h.server.requeueNotices(client.clientID, pending[i:])
+			return
+		}
+	}
+}
+
+// partySnapshot records each party member's health before something happens
+// to the party.
+func partySnapshot(p *game.Player) []game.PartyMember {
+	return append([]game.PartyMember(nil), p.Party...)
+}
+
+// timeoutNotice describes what a turn timeout did to the player's party and
+// supplies, given their state from before the timeout.
+// NOTE: caller must hold room.mu.
+func timeoutNotice(room *GameRoom, p *game.Player, before []game.PartyMember, supplies map[string]float64, turn, streak int) Notice {
+	lines := []string{fmt.Sprintf("Your turn %d timed out while you were away.", turn)}
+
+	party := make([]map[string]interface{}, 0)
+	for i, was := range before {
+		if i >= len(p.Party) {
+			break
+		}
+		now := p.Party[i]
+		if was.Health == now.Health && was.Alive == now.Alive {
+			continue
+		}
+		party = append(party, map[string]interface{}{
+			"name":          now.Name,
+			"health_before": was.Health,
+			"health_after":  now.Health,
+			"died":          was.Alive && !now.Alive,
+			"cause":         now.DiedOf,
+		})
+		if was.Alive && !now.Alive {
+			lines = append(lines, fmt.Sprintf("%s died of %s.", now.Name, now.DiedOf))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s was hurt (HP %d -> %d).", now.Name, was.Health, now.Health))
+		}
+	}
+	if len(party) == 0 {
+		lines = append(lines, "Nobody in your party was harmed.")
+	}
+	if !p.Alive {
+		lines = append(lines, "Your party leader has fallen and you are out of the game.")
+	}
+
+	lost := make(map[string]float64)
+	after := suppliesSnapshot(room.game)
+	for _, name := range []string{"food", "bullets", "clothing", "misc_supplies", "cash"} {
+		if d := supplies[name] - after[name]; d > 0 {
+			lost[name] = d
+			lines = append(lines, fmt.Sprintf("Lost %.0f %s.", d, strings.ReplaceAll(name, "_", " ")))
+		}
+	}
+
+	if streak > 1 {
+		lines = append(lines, fmt.Sprintf("That's %d timeouts in a row.", streak))
+	}
+
+	return Notice{
+		Kind:    "turn_timeout",
+		RoomID:  room.id,
+		Message: strings.Join(lines, "\n"),
+		Details: map[string]interface{}{
+			"turn":                 turn,
+			"consecutive_timeouts": streak,
+			"party":                party,
+			"resources_lost":       lost,
+		},
+		CreatedAt: room.clock.Now(),
+	}
+}
+
+// suppliesSnapshot returns the shared wagon's supplies by state key.
+func suppliesSnapshot(g *game.GameState) map[string]float64 {
+	return map[string]float64{
+		"food":          g.Food,
+		"bullets":       g.Bullets,
+		"clothing":      g.Clothing,
+		"misc_supplies": g.MiscSupplies,
+		"cash":          g.Cash,
+	}
+}
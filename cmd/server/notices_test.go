@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimedOutPlayerGetsANotice(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	room := startPartyGame(t, s)
+	clock.Advance(s.turnTime)
+
+	// Nobody is connected to take it, so it waits
+	notices := s.takeNotices("client-ann")
+	if len(notices) != 1 {
+		t.Fatalf("Ann has %d notices, want 1", len(notices))
+	}
+	n := notices[0]
+	if n.Kind != "turn_timeout" || n.RoomID != room.id || n.Details["turn"] != 1 || n.Details["consecutive_timeouts"] != 1 {
+		t.Errorf("notice = %+v", n)
+	}
+	if !strings.Contains(n.Message, "Your turn 1 timed out") || !strings.Contains(n.Message, "died of dysentery") {
+		t.Errorf("message = %q, want the turn and who died", n.Message)
+	}
+	if others := s.takeNotices("client-bea"); len(others) != 0 {
+		t.Errorf("Bea was sent Ann's notice: %+v", others)
+	}
+}
+
+func TestNoticesWaitForTheirClient(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	for i := 0; i < maxQueuedNotices+5; i++ {
+		ts.QueueNotice("client-x", Notice{Kind: "test", RoomID: room.id, Message: fmt.Sprint(i)})
+	}
+
+	c := registerConn(t, ts.hub, "client-x", "Ann", room.id)
+	var got []string
+	for len(got) < maxQueuedNotices {
+		select {
+		case msgJSON := <-c.send:
+			var msg struct {
+				Type string
+				Data Notice
+			}
+			if json.Unmarshal(msgJSON, &msg) == nil && msg.Type == "notice" {
+				got = append(got, msg.Data.Message)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d notices on connecting, want %d", len(got), maxQueuedNotices)
+		}
+	}
+	// The oldest were dropped to keep the queue bounded
+	if got[0] != "5" || got[len(got)-1] != fmt.Sprint(maxQueuedNotices+4) {
+		t.Errorf("delivered %v, want 5 to %d", got, maxQueuedNotices+4)
+	}
+	if left := ts.takeNotices("client-x"); len(left) != 0 {
+		t.Errorf("%d notices still queued after delivery", len(left))
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// originPolicy decides which web pages may open a websocket to the server,
+// or make the API calls that change a player's data. A browser sends the
+// page's origin with the request, and the player's cookies with it, so a
+// page from anywhere else could otherwise play or act as them. Pages served
+// by the server itself are always allowed, and so are clients that send no
+// Origin, since every browser sends one.
+type originPolicy struct {
+	allowed map[string]bool
+	any     bool
+}
+
+// origins is the policy requests are checked against. main sets it
+// from the allowed_origins and dev_any_origin settings.
+var origins = &originPolicy{}
+
+// parseAllowedOrigins reads a comma-separated list of origins, such as
+// "https://trail.example.com,http://localhost:3000".
+func parseAllowedOrigins(list string) (*originPolicy, error) {
+	p := &originPolicy{allowed: make(map[string]bool)}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		u, err := url.Parse(entry)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("%q is not an origin like https://example.com", entry)
+		}
+		p.allowed[strings.ToLower(u.Scheme+"://"+u.Host)] = true
+	}
+	return p, nil
+}
+
+// allows reports whether the request's Origin is allowed.
+func (p *originPolicy) allows(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || p.any {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return p.allowed[strings.ToLower(u.Scheme+"://"+u.Host)]
+}
+
+// checkOrigin is the upgrader's CheckOrigin, logging what it turns away.
+func checkOrigin(r *http.Request) bool {
+	if origins.allows(r) {
+		return true
+	}
+	log.Printf("Refused %s %s from origin %q (host %s)", r.Method, r.URL.Path, r.Header.Get("Origin"), r.Host)
+	return false
+}
+
+// requireOrigin answers a request from a disallowed origin with a 403 and
+// reports false, before anything else about the request is looked at. It
+// guards the websocket handshake and every API call that changes something.
+func requireOrigin(w http.ResponseWriter, r *http.Request) bool {
+	if checkOrigin(r) {
+		return true
+	}
+	http.Error(w, "Requests from "+r.Header.Get("Origin")+" are not allowed on this server", http.StatusForbidden)
+	return false
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOriginPolicy(t *testing.T) {
+	policy, err := parseAllowedOrigins("https://Trail.example.com, http://localhost:3000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name   string
+		origin string
+		want   bool
+	}{
+		{"no Origin", "", true},
+		{"same host", "http://game.example", true},
+		{"same host, other scheme", "https://game.example", true},
+		{"allowed", "https://trail.example.com", true},
+		{"allowed, other port", "http://localhost:3000", true},
+		{"allowed host, other scheme", "http://trail.example.com", false},
+		{"rejected", "https://evil.example", false},
+		{"rejected lookalike", "https://game.example.evil.example", false},
+		{"unparseable", "://", false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "http://game.example/ws", nil)
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		if got := policy.allows(r); got != tc.want {
+			t.Errorf("%s (%q): allows = %v, want %v", tc.name, tc.origin, got, tc.want)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "http://game.example/ws", nil)
+	r.Header.Set("Origin", "https://evil.example")
+	if !(&originPolicy{any: true}).allows(r) {
+		t.Error("dev_any_origin refused an origin")
+	}
+}
+
+func TestParseAllowedOriginsRejectsNonOrigins(t *testing.T) {
+	for _, list := range []string{"trail.example.com", "https://", "/path"} {
+		if _, err := parseAllowedOrigins(list); err == nil {
+			t.Errorf("parseAllowedOrigins(%q) accepted it", list)
+		}
+	}
+	if p, err := parseAllowedOrigins(" , "); err != nil || len(p.allowed) != 0 {
+		t.Errorf("empty list = %+v, %v", p, err)
+	}
+}
+
+func TestAPIRefusesOtherOrigins(t *testing.T) {
+	s := newTestServer(t)
+	router := NewRouter(s, nil)
+	sessionID := s.sessionManager.NewSession("Ann", "client-ann", s.defaultRoomID)
+	sess, _ := s.sessionManager.GetSessionByID(sessionID)
+	room, err := s.CreateRoom("Ann's room", "", sess, RoomTypeScheduled, 4, 0, false, RoomRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.roomsMu.RLock()
+	rooms := len(s.rooms)
+	s.roomsMu.RUnlock()
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodPost, "/api/logout", ""},
+		{http.MethodPost, "/api/lobbies/create", `{"name": "Evil room"}`},
+		{http.MethodDelete, "/api/lobbies/" + room.id, ""},
+		{http.MethodPost, "/api/me/delete", ""},
+		{http.MethodPost, "/api/my-save", `{}`},
+	} {
+		r := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		r.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+		r.Header.Set("Origin", "https://evil.example")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s from another origin: %d %s", tc.method, tc.path, w.Code, w.Body)
+		}
+	}
+
+	if _, ok := s.sessionManager.GetSessionByID(sessionID); !ok {
+		t.Error("another origin logged Ann out")
+	}
+	if s.GetRoom(room.id) == nil {
+		t.Error("another origin deleted Ann's room")
+	}
+	s.roomsMu.RLock()
+	after := len(s.rooms)
+	s.roomsMu.RUnlock()
+	if after != rooms {
+		t.Errorf("rooms = %d, want %d", after, rooms)
+	}
+
+	// The server's own pages can still log out
+	r := httptest.NewRequest(http.MethodPost, "/api/logout", nil)
+	r.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+	r.Header.Set("Origin", "http://"+r.Host)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("logout from the server's own page: %d %s", w.Code, w.Body)
+	}
+	if _, ok := s.sessionManager.GetSessionByID(sessionID); ok {
+		t.Error("logout from the server's own page left the session")
+	}
+}
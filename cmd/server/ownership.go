@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"sort"
+)
+
+// TransferOwnership hands the room to another client. Only the owner may do
+// this. Returns the new owner's name.
+func (s *Server) TransferOwnership(roomID, requesterID, targetID string) (string, error) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return "", errors.New("room not found")
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	target, ok := room.clients[targetID]
+	switch {
+	case room.ownerID != requesterID:
+		return "", ownerOnly("only the lobby owner can hand over the room")
+	case requesterID == targetID:
+		return "", errors.New("you already own the room")
+	case !ok || target.Disconnected:
+		return "", errors.New("that player isn't here")
+	}
+	room.ownerID = targetID
+	delete(room.coOwners, targetID)
+	log.Printf("Ownership of room %s handed to %s by owner", roomID, target.Name)
+	return target.Name, nil
+}
+
+// SetCoOwner adds or removes a co-owner, who may kick players and start the
+// game alongside the owner. Only the owner may do this. Returns the target's
+// name.
+func (s *Server) SetCoOwner(roomID, requesterID, targetID string, coOwner bool) (string, error) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return "", errors.New("room not found")
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	target, ok := room.clients[targetID]
+	switch {
+	case room.ownerID != requesterID:
+		return "", ownerOnly("only the lobby owner can choose co-owners")
+	case requesterID == targetID:
+		return "", errors.New("you already own the room")
+	case !ok || target.Spectator:
+		return "", errors.New("that player isn't in the game")
+	}
+	if coOwner {
+		room.coOwners[targetID] = true
+	} else {
+		delete(room.coOwners, targetID)
+	}
+	log.Printf("Player %s co-owner of room %s: %v", target.Name, roomID, coOwner)
+	return target.Name, nil
+}
+
+// canModerate reports whether a client is the owner or a co-owner still in
+// the room.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) canModerate(clientID string) bool {
+	if clientID == "" {
+		return false
+	}
+	if r.ownerID == clientID {
+		return true
+	}
+	_, present := r.clients[clientID]
+	return present && r.coOwners[clientID]
+}
+
+// coOwnerIDs lists the co-owners still in the room, sorted.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) coOwnerIDs() []string {
+	ids := make([]string, 0, len(r.coOwners))
+	for id := range r.coOwners {
+		if _, ok := r.clients[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// transferOwnership hands the room to another client if the leaving client
+// owned it. Connected clients are preferred, then co-owners, then whoever
+// joined first.
+// NOTE: caller must hold room.mu.
+func transferOwnership(room *GameRoom, leavingID string) {
+	if room.ownerID != leavingID || len(room.clients) == 0 {
+		return
+	}
+	var next *Client
+	for _, c := range room.clients {
+		if next == nil || betterOwner(room, c, next) {
+			next = c
+		}
+	}
+	room.ownerID = next.ID
+	delete(room.coOwners, next.ID)
+	log.Printf("Ownership of room %s transferred to %s", room.id, next.Name)
+}
+
+// betterOwner reports whether a should be picked over b as the next owner.
+func betterOwner(room *GameRoom, a, b *Client) bool {
+	if a.Disconnected != b.Disconnected {
+		return !a.Disconnected
+	}
+	if room.coOwners[a.ID] != room.coOwners[b.ID] {
+		return room.coOwners[a.ID]
+	}
+	if !a.JoinedAt.Equal(b.JoinedAt) {
+		return a.JoinedAt.Before(b.JoinedAt)
+	}
+	return a.ID < b.ID
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// gamePausedMsg is returned by every game action while the room is paused.
+const gamePausedMsg = "The game is paused.\n"
+
+// maxPauseDuration bounds how long a paused room is protected from stale
+// room cleanup and holds its dropped players' seats.
+const maxPauseDuration = time.Hour
+
+// PauseRoom stops the turn clock in a scheduled room. Only the owner may
+// pause, and only while a game is running.
+func (s *Server) PauseRoom(roomID, requesterID string) error {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return errors.New("room not found")
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	switch {
+	case room.roomType != RoomTypeScheduled:
+		return errors.New("only party games can be paused")
+	case room.ownerID != requesterID:
+		return ownerOnly("only the lobby owner can pause the game")
+	case room.status != StatusPlaying || room.game.GameOver:
+		return wrongPhase("there is no game running")
+	case room.paused:
+		return wrongPhase("the game is already paused")
+	}
+
+	room.pausedRemaining = s.turnTime
+	if !room.turnDeadline.IsZero() {
+		room.pausedRemaining = room.turnDeadline.Sub(s.clock.Now())
+		if room.pausedRemaining < 0 {
+			room.pausedRemaining = 0
+		}
+	}
+	s.CancelTurnTimer(room)
+	room.paused = true
+	room.pausedAt = s.clock.Now()
+	log.Printf("Room %s paused by owner with %s left on the turn", roomID, room.pausedRemaining)
+	return nil
+}
+
+// ResumeRoom restarts the turn clock with the time that was left when the
+// room was paused.
+func (s *Server) ResumeRoom(roomID, requesterID string) error {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return errors.New("room not found")
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	switch {
+	case room.ownerID != requesterID:
+		return ownerOnly("only the lobby owner can resume the game")
+	case !room.paused:
+		return wrongPhase("the game isn't paused")
+	}
+
+	room.paused = false
+	room.pausedAt = time.Time{}
+	room.restored = false
+	if cp := room.game.GetCurrentPlayer(); cp != nil && cp.Alive && !room.game.GameOver {
+		s.startTurnTimerFor(room, cp.ID, room.pausedRemaining)
+	}
+	room.pausedRemaining = 0
+	log.Printf("Room %s resumed by owner", roomID)
+	return nil
+}
+
+// pauseProtected reports whether a paused room is still within
+// maxPauseDuration.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) pauseProtected() bool {
+	return r.paused && r.clock.Now().Sub(r.pausedAt) < maxPauseDuration
+}
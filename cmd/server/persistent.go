@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PersistentRoomConfig describes an always-on continuous room whose state is
+// saved to disk between restarts.
+type PersistentRoomConfig struct {
+	ID    string
+	Name  string
+	Shard bool // one of the open trail's shards
+}
+
+// legacyRoomID is the open trail's original room ID. Its state keeps the
+// original game_state.json file name so existing saves still load.
+const legacyRoomID = "continuous"
+
+// maxContinuousShards caps continuous_shards.
+const maxContinuousShards = 20
+
+// parsePersistentRooms parses a comma-separated list of id=name pairs, as
+// given to -persistent-rooms. An empty list or "none" disables the mode.
+func parsePersistentRooms(spec string) ([]PersistentRoomConfig, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "none" {
+		return nil, nil
+	}
+	seen := make(map[string]bool)
+	rooms := make([]PersistentRoomConfig, 0)
+	for _, part := range strings.Split(spec, ",") {
+		id, name, _ := strings.Cut(strings.TrimSpace(part), "=")
+		id = strings.TrimSpace(id)
+		name = strings.TrimSpace(name)
+		if id == "" {
+			return nil, fmt.Errorf("persistent room %q has no ID", part)
+		}
+		if strings.ContainsAny(id, `/\.`) {
+			return nil, fmt.Errorf("persistent room ID %q may not contain path characters", id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("persistent room %q listed twice", id)
+		}
+		seen[id] = true
+		if name == "" {
+			name = id
+		}
+		rooms = append(rooms, PersistentRoomConfig{ID: id, Name: name})
+	}
+	return rooms, nil
+}
+
+// addShards splits the open trail into shards rooms: itself, then
+// "continuous-2", "continuous-3" and so on, each a persistent room of its
+// own. One shard leaves rooms as they are.
+func addShards(rooms []PersistentRoomConfig, shards int) ([]PersistentRoomConfig, error) {
+	if shards <= 1 {
+		return rooms, nil
+	}
+	base := -1
+	for i, rc := range rooms {
+		if rc.ID == legacyRoomID {
+			base = i
+		}
+	}
+	if base < 0 {
+		return nil, fmt.Errorf("the open trail (%q) isn't among persistent_rooms", legacyRoomID)
+	}
+	rooms[base].Shard = true
+	for n := 2; n <= shards; n++ {
+		id := fmt.Sprintf("%s-%d", legacyRoomID, n)
+		for _, rc := range rooms {
+			if rc.ID == id {
+				return nil, fmt.Errorf("shard %q is already a persistent room", id)
+			}
+		}
+		rooms = append(rooms, PersistentRoomConfig{
+			ID:    id,
+			Name:  fmt.Sprintf("%s %d", rooms[base].Name, n),
+			Shard: true,
+		})
+	}
+	return rooms, nil
+}
+
+// newPersistentRoom creates a continuous room that is never cleaned up and
+// whose state is saved to its own file.
+func newPersistentRoom(cfg PersistentRoomConfig, clock Clock) *GameRoom {
+	room := NewGameRoom(cfg.ID, cfg.Name, RoomTypeContinuous, clock)
+	room.persistent = true
+	room.shard = cfg.Shard
+	room.chatMode = ChatFiltered
+	room.saveFile = "game_state-" + cfg.ID + ".json"
+	if cfg.ID == legacyRoomID {
+		room.saveFile = "game_state.json"
+	}
+	return room
+}
+
+// gameStateFilePath returns where a persistent room's state is saved.
+func (s *Server) gameStateFilePath(room *GameRoom) string {
+	if s.dataPath == "" {
+		s.dataPath = "."
+	}
+	return filepath.Join(s.dataPath, room.saveFile)
+}
+
+// PersistentRooms returns the server's persistent rooms, ordered by ID.
+func (s *Server) PersistentRooms() []*GameRoom {
+	s.roomsMu.RLock()
+	defer s.roomsMu.RUnlock()
+	rooms := make([]*GameRoom, 0)
+	for _, room := range s.rooms {
+		if room.persistent {
+			rooms = append(rooms, room)
+		}
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].id < rooms[j].id })
+	return rooms
+}
+
+// landingRoom is where a player lands who didn't ask for a room, or whose
+// session's room is gone: the default room, unless that is a shard of the
+// open trail. Then it is the shard holding clientID's wagon, or for a new
+// player the shard with the fewest.
+func (s *Server) landingRoom(clientID string) string {
+	if def := s.GetRoom(s.defaultRoomID); def == nil || !def.shard {
+		return s.defaultRoomID
+	}
+	landing, fewest := s.defaultRoomID, -1
+	for _, room := range s.PersistentRooms() {
+		if !room.shard {
+			continue
+		}
+		room.mu.RLock()
+		_, home := room.playerGames[clientID]
+		wagons := len(room.playerGames)
+		room.mu.RUnlock()
+		if home {
+			return room.id
+		}
+		if fewest < 0 || wagons < fewest {
+			landing, fewest = room.id, wagons
+		}
+	}
+	return landing
+}
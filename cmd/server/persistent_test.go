@@ -0,0 +1,111 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParsePersistentRooms(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []PersistentRoomConfig
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"none", nil, false},
+		{"  none ", nil, false},
+		{"continuous=The Open Trail", []PersistentRoomConfig{{ID: "continuous", Name: "The Open Trail"}}, false},
+		{" plains = The Plains , hills", []PersistentRoomConfig{{ID: "plains", Name: "The Plains"}, {ID: "hills", Name: "hills"}}, false},
+		{"=Nameless", nil, true},
+		{"plains,plains=Again", nil, true},
+		{"../etc=Escape", nil, true},
+		{"a.b", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parsePersistentRooms(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePersistentRooms(%q) error = %v, want error: %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePersistentRooms(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestOpenTrailCanBeTurnedOff(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.PersistentRooms = "none"
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+	s := startServer(t, &cfg)
+	if rooms := s.PersistentRooms(); len(rooms) != 0 {
+		t.Errorf("with the open trail off the server has %d persistent rooms", len(rooms))
+	}
+	if s.defaultRoomID != "" || s.GetRoom(legacyRoomID) != nil {
+		t.Errorf("with the open trail off, default room %q", s.defaultRoomID)
+	}
+
+	// Shards split the open trail, so there must be one to split
+	cfg.ContinuousShards = 2
+	if err := cfg.validate(); err == nil {
+		t.Error("shards of a turned-off open trail were accepted")
+	}
+}
+
+func TestPersistentRoomsSaveSeparately(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.DataPath = t.TempDir()
+	cfg.PersistentRooms = "continuous=The Open Trail,plains=The Plains"
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+	s := startServer(t, &cfg)
+	trail, plains := s.GetRoom("continuous"), s.GetRoom("plains")
+	if trail == nil || plains == nil {
+		t.Fatalf("rooms = %v", s.PersistentRooms())
+	}
+	// The open trail keeps the save file it had before rooms were configurable
+	if got := filepath.Base(s.gameStateFilePath(trail)); got != "game_state.json" {
+		t.Errorf("the open trail saves to %s", got)
+	}
+	if got := filepath.Base(s.gameStateFilePath(plains)); got != "game_state-plains.json" {
+		t.Errorf("the plains save to %s, want game_state-plains.json", got)
+	}
+
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann"}, trail.id)
+	s.AddClient(&Client{ID: "client-bea", Name: "Bea"}, plains.id)
+	trail.mu.Lock()
+	trail.playerGames["client-ann"].Mileage = 300
+	trail.mu.Unlock()
+	plains.mu.Lock()
+	plains.playerGames["client-bea"].Mileage = 700
+	plains.mu.Unlock()
+	s.saveGameState(trail)
+	s.saveGameState(plains)
+	s.saves.Wait()
+
+	// After a restart each room has only its own wagons back
+	restarted := startServer(t, &cfg)
+	for id, want := range map[string]struct {
+		client, other string
+		miles         float64
+	}{
+		"continuous": {"client-ann", "client-bea", 300},
+		"plains":     {"client-bea", "client-ann", 700},
+	} {
+		room := restarted.GetRoom(id)
+		room.mu.RLock()
+		g, other := room.playerGames[want.client], room.playerGames[want.other]
+		room.mu.RUnlock()
+		if g == nil || g.Mileage != want.miles {
+			t.Errorf("%s after a restart: %s's wagon = %+v, want it at mile %.0f", id, want.client, g, want.miles)
+		}
+		if other != nil {
+			t.Errorf("%s after a restart has %s's wagon from the other room", id, want.other)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// sessionFromRequest returns the live session named by the request's
+// session cookie.
+func (s *Server) sessionFromRequest(r *http.Request) (*Session, bool) {
+	cookie, err := r.Cookie("session_id")
+	if err != nil {
+		return nil, false
+	}
+	return s.sessionManager.GetSessionByID(cookie.Value)
+}
+
+// sessionCookie is the cookie that carries sessionID, good for 30 days.
+func sessionCookie(r *http.Request, sessionID string) *http.Cookie {
+	return &http.Cookie{
+		Name:     "session_id",
+		Value:    sessionID,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   requestIsSecure(r),
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   86400 * 30,
+	}
+}
+
+// clearSessionCookie tells the browser to forget its session cookie.
+func clearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_id",
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   requestIsSecure(r),
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+		Expires:  time.Unix(0, 0),
+	})
+}
+
+// handleMyData returns everything the server stores about the caller.
+// Achievements and chat history are not persisted, so they are always empty.
+func (s *Server) handleMyData(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := s.sessionFromRequest(r)
+	if !ok {
+		http.Error(w, "Not signed in", http.StatusUnauthorized)
+		return
+	}
+
+	saves := make(map[string]PersistedGameState)
+	for _, room := range s.PersistentRooms() {
+		room.mu.RLock()
+		if playerGame, ok := room.playerGames[sess.ClientID]; ok {
+			saves[room.id] = persistPlayerGame(sess.ClientID, sess.Name, playerGame)
+		}
+		room.mu.RUnlock()
+	}
+
+	var stats *PlayerStats
+	if ps, ok := s.leaderboard.GetPlayerStats(sess.Name); ok {
+		stats = &ps
+	}
+
+	log.Printf("Audit: data export for %s (client %s)", sess.Name, sess.ClientID)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session": map[string]interface{}{
+			"name":       sess.Name,
+			"client_id":  sess.ClientID,
+			"room_id":    sess.RoomID,
+			"created_at": sess.CreatedAt,
+			"last_seen":  sess.LastSeen,
+		},
+		"continuous_saves":    saves,
+		"leaderboard_entries": s.leaderboard.GetEntriesFor(sess.ClientID),
+		"journeys":            s.journeys.For(sess.ClientID),
+		"stats":               stats,
+		"achievements":        []string{},
+		"chat_lines":          []string{},
+	})
+}
+
+// handleMyDelete removes the caller's personal data: their continuous wagon
+// becomes an unnamed loot site, their save and stats are deleted, leaderboard
+// entries and journeys are anonymized and all of their sessions are
+// invalidated.
+func (s *Server) handleMyDelete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireOrigin(w, r) {
+		return
+	}
+	sess, ok := s.sessionFromRequest(r)
+	if !ok {
+		http.Error(w, "Not signed in", http.StatusUnauthorized)
+		return
+	}
+	name, clientID := sess.Name, sess.ClientID
+
+	// Hold each persistent room's lock so no turn can run against the wagon
+	// while it is being converted and removed.
+	wagonAbandoned := false
+	for _, room := range s.PersistentRooms() {
+		room.mu.Lock()
+		if playerGame, ok := room.playerGames[clientID]; ok {
+			alive := false
+			for _, p := range playerGame.Players {
+				if p.ID == clientID {
+					alive = p.Alive
+					break
+				}
+			}
+			if alive && !playerGame.Win {
+				room.game.LootSites = append(room.game.LootSites, lootSiteFromGame(clientID, "", playerGame, s.clock.Now()))
+				wagonAbandoned = true
+			}
+			delete(room.playerGames, clientID)
+		}
+		s.dropClient(room, clientID)
+		room.mu.Unlock()
+		s.saveGameState(room)
+	}
+
+	anonymized := s.leaderboard.Anonymize(clientID)
+	journeys := s.journeys.Anonymize(clientID)
+	sessions := s.sessionManager.DeleteSessionsFor(clientID)
+
+	if s.hub != nil {
+		s.hub.DisconnectClient(clientID)
+	}
+
+	clearSessionCookie(w, r)
+
+	log.Printf("Audit: data deletion for %s (client %s): %d entries and %d journeys anonymized, %d sessions removed, wagon abandoned=%v",
+		name, clientID, anonymized, journeys, sessions, wagonAbandoned)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"deleted":             true,
+		"entries_anonymized":  anonymized,
+		"journeys_anonymized": journeys,
+		"sessions_removed":    sessions,
+		"wagon_left_as_loot":  wagonAbandoned,
+	})
+}
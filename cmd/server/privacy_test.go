@@ -0,0 +1,133 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// deleteMyData posts /api/me/delete as the session from origin.
+func deleteMyData(s *Server, sessionID, origin string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/me/delete", nil)
+	r.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	w := httptest.NewRecorder()
+	s.handleMyDelete(w, r)
+	return w
+}
+
+func TestDeleteMyDataSparesNamesakes(t *testing.T) {
+	s := newTestServer(t)
+	mine := s.sessionManager.NewSession("Ann", "client-mine", s.defaultRoomID)
+	theirs := s.sessionManager.NewSession("Ann", "client-theirs", s.defaultRoomID)
+	s.leaderboard.AddEntry("client-mine", "Ann", false, 300, 10, 100, 0, "continuous", "")
+	s.leaderboard.AddEntry("client-theirs", "Ann", false, 500, 12, 200, 1, "continuous", "")
+
+	if w := deleteMyData(s, mine, ""); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body)
+	}
+
+	if _, ok := s.sessionManager.GetSessionByID(mine); ok {
+		t.Error("the deleted client's session survived")
+	}
+	if _, ok := s.sessionManager.GetSessionByID(theirs); !ok {
+		t.Error("another client's session under the same name was deleted")
+	}
+	names := map[float64]string{}
+	for _, e := range s.leaderboard.GetTopByMode(10, "continuous") {
+		names[e.Miles] = e.PlayerName
+	}
+	if names[300] != anonymousPlayerName || names[500] != "Ann" {
+		t.Errorf("entries after delete: %v, want only the 300 mile run anonymized", names)
+	}
+	if stats, ok := s.leaderboard.GetPlayerStats("Ann"); !ok || stats.GamesPlayed == 0 {
+		t.Error("the namesake's stats were deleted")
+	}
+}
+
+func TestDeleteMyDataRefusesOtherSites(t *testing.T) {
+	s := newTestServer(t)
+	sessionID := s.sessionManager.NewSession("Ann", "client-ann", s.defaultRoomID)
+	if w := deleteMyData(s, sessionID, "https://evil.example"); w.Code != http.StatusForbidden {
+		t.Errorf("cross-site delete: got %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if _, ok := s.sessionManager.GetSessionByID(sessionID); !ok {
+		t.Error("a cross-site delete removed the session")
+	}
+	if c := sessionCookie(httptest.NewRequest(http.MethodGet, "/", nil), sessionID); c.SameSite != http.SameSiteLaxMode {
+		t.Errorf("session cookie SameSite = %v, want Lax", c.SameSite)
+	}
+}
+
+func TestPublicBoardsHideClientIDs(t *testing.T) {
+	s := newTestServer(t)
+	s.leaderboard.AddEntry("client-ann", "Ann", true, 2040, 30, 900, 5, "continuous", "")
+	for _, e := range s.leaderboard.GetTop(10) {
+		if e.ClientID != "" {
+			t.Errorf("public entry carries client ID %q", e.ClientID)
+		}
+	}
+	if stats, _ := s.leaderboard.GetPlayerStats("Ann"); stats.ClientIDs != nil || stats.BestRun.ClientID != "" {
+		t.Error("public stats carry client IDs")
+	}
+	if got := s.leaderboard.GetEntriesFor("client-ann"); len(got) != 1 {
+		t.Errorf("GetEntriesFor = %d entries, want 1", len(got))
+	}
+}
+
+func TestJourneysFollowTheirPlayer(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	room := newTestRoom(t, s)
+	g := game.NewGameStateWithSeed(1)
+	ann := g.AddPlayer("Ann", game.PlayerTypeHuman)
+	ann.ID = "client-ann"
+	g.Win = true
+	room.mu.Lock()
+	s.recordGameOver(room, g, "party", g.Players)
+	room.mu.Unlock()
+	s.journeys.Add(JourneyRecord{ID: "bea-trip", PlayerName: "Bea", ClientID: "client-bea"})
+
+	journeys, _ := s.journeys.Page(0, 10)
+	if len(journeys) != 2 {
+		t.Fatalf("kept %d journeys, want 2", len(journeys))
+	}
+	anns := journeys[1]
+	if anns.ClientID != "" || journeys[0].ClientID != "" {
+		t.Error("the journey list shows client IDs")
+	}
+	if anns.Date != clock.Now().Format(time.RFC3339) {
+		t.Errorf("journey dated %s, want the server clock's %s", anns.Date, clock.Now().Format(time.RFC3339))
+	}
+	router := NewRouter(s, nil)
+	if body := call(router, http.MethodGet, "/api/journeys/"+anns.ID, "", "").Body.String(); strings.Contains(body, "client-ann") {
+		t.Errorf("a journey's page shows its client ID: %s", body)
+	}
+
+	// Ann's export has her journey and not Bea's
+	sessionID := s.sessionManager.NewSession("Ann", "client-ann", s.defaultRoomID)
+	var export struct{ Journeys []JourneyRecord }
+	decode(t, call(router, http.MethodGet, "/api/me/data", "", sessionID), &export)
+	if len(export.Journeys) != 1 || export.Journeys[0].ID != anns.ID {
+		t.Errorf("exported journeys %+v, want only Ann's", export.Journeys)
+	}
+
+	if w := deleteMyData(s, sessionID, ""); w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body)
+	}
+	// Also once the journeys are read back from disk
+	for _, jl := range []*JourneyLog{s.journeys, NewJourneyLog(s.dataPath)} {
+		rec, _ := jl.Get(anns.ID)
+		if rec.PlayerName != anonymousPlayerName || rec.ClientID != "" {
+			t.Errorf("Ann's journey after delete: %s, client %q", rec.PlayerName, rec.ClientID)
+		}
+		if rec, _ := jl.Get("bea-trip"); rec.PlayerName != "Bea" || rec.ClientID != "client-bea" {
+			t.Errorf("Bea's journey after Ann's delete: %+v", rec)
+		}
+	}
+}
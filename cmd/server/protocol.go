@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Protocol versions. A client names the version it speaks with ?v= on /ws;
+// one that names none is taken to speak v1, the shape clients cached before
+// versions existed. States are built in the shape of the client's major
+// version, down to minProtocolMajor; a client outside that range is told to
+// upgrade and closed.
+const (
+	protocolVersion  = "2.0"
+	protocolMajor    = 2
+	minProtocolMajor = 1
+)
+
+// errUpgradeRequired is the code a refused client is closed with.
+const errUpgradeRequired = "upgrade_required"
+
+// clientSchema reads the major version a client declared, 1 if it declared
+// none.
+func clientSchema(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("v")
+	if v == "" {
+		return 1, nil
+	}
+	major, _, _ := strings.Cut(v, ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, fmt.Errorf("bad protocol version %q", v)
+	}
+	return n, nil
+}
+
+// checkProtocol turns away a client whose major version this server no
+// longer speaks, or doesn't speak yet, with an "upgrade_required" message.
+// It returns the schema to build the client's states in, and false if the
+// client was turned away.
+func checkProtocol(w http.ResponseWriter, r *http.Request) (int, bool) {
+	schema, err := clientSchema(r)
+	if err == nil && schema >= minProtocolMajor && schema <= protocolMajor {
+		return schema, true
+	}
+	message := "This page is out of date with the server. Reload to get the current version."
+	if err == nil && schema > protocolMajor {
+		message = "The server is older than this page. Try again once it has been updated."
+	}
+	msgJSON, _ := json.Marshal(map[string]interface{}{
+		"type":             errUpgradeRequired,
+		"protocol_version": protocolVersion,
+		"min_version":      minProtocolMajor,
+		"message":          message,
+	})
+	refuseSocket(w, r, http.StatusUpgradeRequired, errUpgradeRequired, message, msgJSON)
+	return 0, false
+}
+
+// versionState adds the fields of the given schema to a state built in the
+// v1 shape. v2 adds server_time, the server's clock when the state was
+// built, so clients can count down the deadlines on their own clocks.
+func versionState(state map[string]interface{}, schema int) map[string]interface{} {
+	state["protocol_version"] = protocolVersion
+	state["schema_version"] = schema
+	if schema >= 2 {
+		state["server_time"] = time.Now().UnixMilli()
+	}
+	return state
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"online-trail/pkg/game"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files under testdata/protocol")
+
+// The state a client is sent is pinned for each schema by a golden file
+// under testdata/protocol, named state_v<schema>.json. A change to what a
+// schema sends shows up as a diff against its file; if the change is meant,
+// rewrite the files with go test -run TestStateGoldenFiles -update.
+
+// golden returns the path of a schema's golden state.
+func golden(schema int) string {
+	return filepath.Join("testdata", "protocol", fmt.Sprintf("state_v%d.json", schema))
+}
+
+// goldenRoom is a started party room whose state is the same on every run:
+// fixed IDs, a seeded game and a fake clock.
+func goldenRoom(t *testing.T) (*Server, *GameRoom) {
+	t.Helper()
+	s, _ := newFakeClockServer(t)
+	room := addTestRooms(s, 1)[0]
+	room.game = game.NewGameStateWithSeed(1)
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann"}, room.id)
+	s.AddClient(&Client{ID: "client-bea", Name: "Bea"}, room.id)
+	room.mu.Lock()
+	room.ownerID = "client-ann"
+	room.mu.Unlock()
+	if err := s.StartGame(room.id, "client-ann", true); err != nil {
+		t.Fatal(err)
+	}
+	return s, room
+}
+
+func TestStateGoldenFiles(t *testing.T) {
+	s, room := goldenRoom(t)
+	for schema := minProtocolMajor; schema <= protocolMajor; schema++ {
+		t.Run(fmt.Sprintf("v%d", schema), func(t *testing.T) {
+			state := s.GetState(room.id, schema).(map[string]interface{})
+			// server_time is the wall clock; pin it once it has been checked
+			if st, ok := state["server_time"].(int64); ok {
+				if d := time.Since(time.UnixMilli(st)); d < 0 || d > time.Minute {
+					t.Errorf("server_time is %v off the clock", d)
+				}
+				state["server_time"] = 0
+			}
+			// Players are listed in no set order
+			players := state["players"].([]map[string]interface{})
+			sort.Slice(players, func(i, j int) bool {
+				return players[i]["id"].(string) < players[j]["id"].(string)
+			})
+			got, err := json.MarshalIndent(state, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden(schema)), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden(schema), got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden(schema))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				gotLines, wantLines := strings.Split(string(got), "\n"), strings.Split(string(want), "\n")
+				for i := range gotLines {
+					if i >= len(wantLines) || gotLines[i] != wantLines[i] {
+						t.Fatalf("the v%d state differs from %s at line %d: %q\n%s", schema, golden(schema), i+1, gotLines[i], got)
+					}
+				}
+				t.Fatalf("the v%d state is shorter than %s:\n%s", schema, golden(schema), got)
+			}
+		})
+	}
+}
+
+func TestEverySchemaHasAGoldenFile(t *testing.T) {
+	for schema := minProtocolMajor; schema <= protocolMajor; schema++ {
+		if _, err := os.Stat(golden(schema)); err != nil {
+			t.Errorf("schema %d: %v", schema, err)
+		}
+	}
+}
+
+func TestClientSchema(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  int
+		ok    bool
+	}{
+		{"", 1, true},
+		{"v=1", 1, true},
+		{"v=2.0", 2, true},
+		{"v=2.7", 2, true},
+		{"v=3", 3, true},
+		{"v=two", 0, false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/ws?"+tc.query, nil)
+		got, err := clientSchema(r)
+		if got != tc.want || (err == nil) != tc.ok {
+			t.Errorf("%q: schema %d, %v; want %d", tc.query, got, err, tc.want)
+		}
+	}
+}
+
+func TestOtherProtocolVersionsAreTurnedAway(t *testing.T) {
+	ts := newTestSite(t)
+	for _, tc := range []struct {
+		v    string
+		want int
+	}{
+		{"", http.StatusOK},
+		{"1.0", http.StatusOK},
+		{"2.0", http.StatusOK},
+		{"0.9", http.StatusUpgradeRequired},
+		{"3.0", http.StatusUpgradeRequired},
+		{"latest", http.StatusUpgradeRequired},
+	} {
+		resp, err := http.Get(ts.url + "/ws?preflight=1&name=Ann&v=" + tc.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Errorf("v=%s: %d, want %d", tc.v, resp.StatusCode, tc.want)
+		}
+	}
+
+	// A websocket is told why before it is closed
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.url, "http")+"/ws?v=3.0&name=Ann", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var msg map[string]interface{}
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg["type"] != errUpgradeRequired || msg["protocol_version"] != protocolVersion {
+		t.Errorf("a v3 client was sent %v", msg)
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Errorf("a v3 client's socket ended with %v, want a policy violation close", err)
+	}
+}
+
+func TestUnversionedClientGetsV1States(t *testing.T) {
+	ts := newTestSite(t)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.url, "http")+"/ws?name=Ann", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &testConn{Conn: conn, t: t}
+	defer conn.Close()
+	if v := c.await("your_id")["protocol_version"]; v != protocolVersion {
+		t.Errorf("your_id protocol_version = %v, want %s", v, protocolVersion)
+	}
+	state, _ := c.await("state")["data"].(map[string]interface{})
+	if state["schema_version"] != float64(1) {
+		t.Errorf("schema_version = %v, want 1", state["schema_version"])
+	}
+	if _, ok := state["server_time"]; ok {
+		t.Error("a v1 state carries server_time")
+	}
+}
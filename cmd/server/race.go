@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"online-trail/pkg/game"
+)
+
+// perPlayerGames reports whether each player drives their own game rather
+// than sharing the room's wagon: true in continuous and race rooms.
+func (r *GameRoom) perPlayerGames() bool {
+	return r.roomType == RoomTypeContinuous || r.roomType == RoomTypeRace
+}
+
+// hasLobby reports whether the room waits for a ready check and the owner's
+// start: true in party games and races.
+func (r *GameRoom) hasLobby() bool {
+	return r.roomType == RoomTypeScheduled || r.roomType == RoomTypeRace
+}
+
+// RaceStanding is one racer's place in a race: how far they got and, if
+// they reached the end of the trail, on which turn.
+type RaceStanding struct {
+	Name      string  `json:"name"`
+	Mileage   float64 `json:"mileage"`
+	TurnCount int     `json:"turn_count"`
+	Alive     bool    `json:"alive"`
+	Arrived   bool    `json:"arrived"`
+}
+
+// raceStandings ranks the racers: those who arrived by turn, then everyone
+// else by how far they got.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) raceStandings() []RaceStanding {
+	standings := make([]RaceStanding, 0, len(r.playerGames))
+	for id, g := range r.playerGames {
+		for _, p := range g.Players {
+			if p.ID != id {
+				continue
+			}
+			standings = append(standings, RaceStanding{
+				Name:      p.Name,
+				Mileage:   g.Mileage,
+				TurnCount: g.TurnNumber,
+				Alive:     p.Alive,
+				Arrived:   g.Win,
+			})
+		}
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		switch {
+		case a.Arrived != b.Arrived:
+			return a.Arrived
+		case a.Arrived && a.TurnCount != b.TurnCount:
+			return a.TurnCount < b.TurnCount
+		case a.Mileage != b.Mileage:
+			return a.Mileage > b.Mileage
+		}
+		return a.Name < b.Name
+	})
+	return standings
+}
+
+// beginRace outfits every racer's wagon and sends them all off at once.
+// NOTE: caller must hold room.mu.
+func (s *Server) beginRace(room *GameRoom) {
+	s.cancelCountdown(room)
+	for id, g := range room.playerGames {
+		for _, p := range g.Players {
+			if p.ID != id {
+				continue
+			}
+			if room.rules.Occupation != "" {
+				p.Occupation = room.rules.Occupation
+			}
+			outfitWagon(g, p)
+		}
+	}
+	room.status = StatusPlaying
+}
+
+// resetRace lines everyone seated back up at the start for another race,
+// waiting on the ready check and the owner's start like the first.
+// NOTE: caller must hold room.mu.
+func (s *Server) resetRace(room *GameRoom) {
+	room.playerGames = make(map[string]*game.GameState)
+	for _, c := range room.clients {
+		c.Ready = false
+		if c.Spectator {
+			continue
+		}
+		g, player := newOwnGame(c.Name, c.ID)
+		if c.Player != nil {
+			player.Occupation = c.Player.Occupation
+			player.Peaceful = c.Player.Peaceful
+		}
+		c.Player = player
+		room.playerGames[c.ID] = g
+	}
+	room.status = StatusWaiting
+}
+
+// handleRaceAction runs a racer's move on their own wagon while the race is
+// on.
+// NOTE: caller must hold room.mu.
+func (s *Server) handleRaceAction(room *GameRoom, clientID, action string) *game.TurnResult {
+	switch room.status {
+	case StatusWaiting:
+		return game.Refuse(game.ReasonInvalidPhase, "The race hasn't started yet.\n")
+	case StatusFinished:
+		return game.Refuse(game.ReasonInvalidPhase, "The race is over.\n")
+	}
+	return s.handleContinuousAction(room, clientID, action)
+}
+
+// finishRaceIfOver ends a running race once a wagon reaches the end of the
+// trail or every party has perished. Each racer's run goes on the party
+// leaderboard and the final standings are returned for the result; it
+// returns "" while the race goes on.
+// NOTE: caller must hold room.mu.
+func (s *Server) finishRaceIfOver(room *GameRoom) string {
+	if room.roomType != RoomTypeRace || room.status != StatusPlaying {
+		return ""
+	}
+	standings := room.raceStandings()
+	if len(standings) == 0 {
+		return ""
+	}
+	over := standings[0].Arrived
+	if !over {
+		over = true
+		for _, st := range standings {
+			if st.Alive {
+				over = false
+				break
+			}
+		}
+	}
+	if !over {
+		return ""
+	}
+
+	room.status = StatusFinished
+	for id, g := range room.playerGames {
+		for _, p := range g.Players {
+			if p.ID == id {
+				s.recordGameOver(room, g, "party", []*game.Player{p})
+			}
+		}
+	}
+
+	var b strings.Builder
+	if standings[0].Arrived {
+		b.WriteString(fmt.Sprintf("%s reached the end of the trail first and wins the race!\n", standings[0].Name))
+	} else {
+		b.WriteString("Every party has perished. The race is over.\n")
+	}
+	b.WriteString("FINAL STANDINGS:\n")
+	for i, st := range standings {
+		switch {
+		case st.Arrived:
+			b.WriteString(fmt.Sprintf("%d. %s - arrived on turn %d\n", i+1, st.Name, st.TurnCount))
+		case st.Alive:
+			b.WriteString(fmt.Sprintf("%d. %s - mile %.0f\n", i+1, st.Name, st.Mileage))
+		default:
+			b.WriteString(fmt.Sprintf("%d. %s - perished at mile %.0f\n", i+1, st.Name, st.Mileage))
+		}
+	}
+	log.Printf("Race in room %s finished, %s came first", room.id, standings[0].Name)
+	return b.String()
+}
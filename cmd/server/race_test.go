@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+
+	"online-trail/pkg/game"
+)
+
+// startRace seats Ann then Bea in a fresh race room and starts it.
+func startRace(t *testing.T, s *Server) *GameRoom {
+	t.Helper()
+	room, err := s.CreateRoom("Race", "", &Session{ID: "creator"}, RoomTypeRace, 4, 0, false, RoomRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann"}, room.id)
+	s.AddClient(&Client{ID: "client-bea", Name: "Bea"}, room.id)
+	room.mu.Lock()
+	room.ownerID = "client-ann"
+	room.mu.Unlock()
+	if err := s.StartGame(room.id, "client-ann", true); err != nil {
+		t.Fatal(err)
+	}
+	return room
+}
+
+func TestRacerCannotStartOverMidRace(t *testing.T) {
+	s := newTestServer(t)
+	room := startRace(t, s)
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	ann := room.playerGames["client-ann"]
+	ann.Mileage, ann.Food = 300, 5
+
+	if r := s.handleRaceAction(room, "client-ann", "start"); r.Refusal() == nil {
+		t.Errorf("start mid-race = %s, want it refused", r)
+	}
+	if ann.Mileage != 300 || ann.Food != 5 {
+		t.Errorf("Ann's wagon was outfitted again: mile %.0f, %.0f food", ann.Mileage, ann.Food)
+	}
+}
+
+func TestDeadRacerStaysOut(t *testing.T) {
+	s := newTestServer(t)
+	room := startRace(t, s)
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	bea := room.playerGames["client-bea"]
+	_, p := s.getPlayerGame(room, "client-bea")
+	p.Alive = false
+	bea.Mileage = 120
+
+	for _, action := range []string{"start", "continue"} {
+		r := s.handleContinuousAction(room, "client-bea", action)
+		if len(r.Events) != 1 || r.Events[0].Code != game.ResultSpectating {
+			t.Errorf("%s from a dead racer = %s, want them spectating", action, r)
+		}
+	}
+	if p.Alive || bea.Mileage != 120 {
+		t.Errorf("a dead racer got back on the trail: alive %v at mile %.0f", p.Alive, bea.Mileage)
+	}
+}
+
+func TestFinishedRaceResetsForTheNext(t *testing.T) {
+	s := newTestServer(t)
+	room := startRace(t, s)
+	room.mu.Lock()
+	ann := room.playerGames["client-ann"]
+	ann.Mileage, ann.Win = game.TrailLength, true
+	standings := s.finishRaceIfOver(room)
+	status := room.status
+	room.mu.Unlock()
+	if standings == "" || status != StatusFinished {
+		t.Fatalf("Ann arrived but the race is %s", status)
+	}
+
+	if !s.ResetGame(room.id) {
+		t.Fatal("a finished race couldn't be reset")
+	}
+	room.mu.RLock()
+	if room.status != StatusWaiting {
+		t.Errorf("reset race is %s, want it waiting on the start", room.status)
+	}
+	for _, id := range []string{"client-ann", "client-bea"} {
+		g, p := s.getPlayerGame(room, id)
+		if g == nil || p == nil || g.Mileage != 0 || g.Win || room.clients[id].Player != p {
+			t.Errorf("%s isn't back at the start with a fresh wagon", id)
+		}
+		if room.clients[id].Ready {
+			t.Errorf("%s is still ready from the last race", id)
+		}
+	}
+	room.mu.RUnlock()
+
+	if err := s.StartGame(room.id, "client-ann", true); err != nil {
+		t.Fatalf("starting the next race: %v", err)
+	}
+}
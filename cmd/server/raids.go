@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// pendingRaid is a raid on a wagon in a continuous room waiting for the
+// defender's answer.
+type pendingRaid struct {
+	attackerID   string
+	attackerName string
+	defenderID   string
+	deadline     time.Time
+	timer        Timer
+}
+
+// SetPeaceful opts the client's run in or out of raids. It can only be
+// chosen at the start of a run, before the wagon has moved.
+func (s *Server) SetPeaceful(roomID, clientID string, peaceful bool) error {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return errors.New("room not found")
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.roomType != RoomTypeContinuous {
+		return errors.New("raids only happen on the open trail")
+	}
+	playerGame, player := s.getPlayerGame(room, clientID)
+	switch {
+	case playerGame == nil || player == nil:
+		return errors.New("you don't have a wagon on the trail")
+	case playerGame.TurnNumber > 1 || playerGame.Mileage > 0:
+		return errors.New("it can only be chosen at the start of a run")
+	}
+	player.Peaceful = peaceful
+	s.saveGameStateLocked(room)
+	return nil
+}
+
+// StartRaid sends the client's raiders after another wagon within
+// game.RaidRange miles. The defender has game.RaidResponseTime to answer
+// before the raid goes ahead anyway. It returns the deadline.
+func (s *Server) StartRaid(roomID, attackerID, targetID string) (time.Time, error) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return time.Time{}, errors.New("room not found")
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.roomType != RoomTypeContinuous {
+		return time.Time{}, errors.New("raids only happen on the open trail")
+	}
+	atkGame, ap := s.getPlayerGame(room, attackerID)
+	defGame, dp := s.getPlayerGame(room, targetID)
+	now := s.clock.Now()
+	switch {
+	case atkGame == nil || ap == nil || !ap.Alive || atkGame.GameOver:
+		return time.Time{}, errors.New("you don't have a wagon on the trail")
+	case attackerID == targetID:
+		return time.Time{}, errors.New("you can't raid yourself")
+	case defGame == nil || dp == nil || !dp.Alive || defGame.GameOver:
+		return time.Time{}, errors.New("there is no such wagon on the trail")
+	case ap.Peaceful:
+		return time.Time{}, errors.New("you chose a peaceful run")
+	case dp.Peaceful:
+		return time.Time{}, fmt.Errorf("%s is travelling in peace", dp.Name)
+	case math.Abs(atkGame.Mileage-defGame.Mileage) > game.RaidRange:
+		return time.Time{}, fmt.Errorf("%s is more than %d miles away", dp.Name, game.RaidRange)
+	case defGame.TurnPhase == game.PhaseFort:
+		return time.Time{}, fmt.Errorf("%s is safe inside a fort", dp.Name)
+	case room.inRaid(attackerID) || room.inRaid(targetID):
+		return time.Time{}, errors.New("a raid is already under way")
+	case now.Before(room.raidCooldowns[attackerID]):
+		return time.Time{}, fmt.Errorf("your raiders need another %s to regroup",
+			room.raidCooldowns[attackerID].Sub(now).Round(time.Minute))
+	case now.Before(room.raidCooldowns[targetID]):
+		return time.Time{}, fmt.Errorf("%s was raided recently", dp.Name)
+	}
+
+	raid := &pendingRaid{
+		attackerID:   attackerID,
+		attackerName: ap.Name,
+		defenderID:   targetID,
+		deadline:     now.Add(game.RaidResponseTime),
+	}
+	raid.timer = s.clock.AfterFunc(game.RaidResponseTime, func() {
+		s.handleRaidTimeout(room, raid)
+	})
+	room.raids[targetID] = raid
+	log.Printf("Player %s is raiding %s in room %s", ap.Name, dp.Name, roomID)
+	return raid.deadline, nil
+}
+
+// AnswerRaid resolves the raid on the client's wagon with their tactic.
+func (s *Server) AnswerRaid(roomID, defenderID string, tactic game.RaidTactic) (*game.TurnResult, error) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return nil, errors.New("room not found")
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	raid, ok := room.raids[defenderID]
+	if !ok {
+		return nil, errors.New("nobody is raiding your wagon")
+	}
+	return s.resolveRaid(room, raid, tactic), nil
+}
+
+// handleRaidTimeout lets the raid go ahead when the defender never answered.
+func (s *Server) handleRaidTimeout(room *GameRoom, raid *pendingRaid) {
+	room.mu.Lock()
+	if room.closed || room.raids[raid.defenderID] != raid {
+		room.mu.Unlock()
+		return
+	}
+	result := s.resolveRaid(room, raid, "")
+	roomID := room.id
+	room.mu.Unlock()
+
+	if s.hub != nil {
+		s.hub.BroadcastResultTo(roomID, raid.attackerName, "raid", result)
+		s.hub.FlushStateTo(roomID)
+	}
+}
+
+// resolveRaid settles a pending raid and leaves both wagons alone for
+// game.RaidCooldown. A party the raid wiped out leaves its wreck behind.
+// NOTE: caller must hold room.mu.
+func (s *Server) resolveRaid(room *GameRoom, raid *pendingRaid, tactic game.RaidTactic) *game.TurnResult {
+	delete(room.raids, raid.defenderID)
+	raid.timer.Stop()
+
+	atkGame, ap := s.getPlayerGame(room, raid.attackerID)
+	defGame, dp := s.getPlayerGame(room, raid.defenderID)
+	if atkGame == nil || ap == nil || !ap.Alive || defGame == nil || dp == nil || !dp.Alive {
+		return game.NewResult(game.ResultText, "The raid came to nothing.\n")
+	}
+	result := game.ResolveRaid(atkGame, ap, defGame, dp, tactic)
+	now := s.clock.Now()
+	atkGame.Record(ap.Name, "raid", result, now)
+	defGame.Record(ap.Name, "raid", result, now)
+	if !ap.Alive {
+		s.createLootSiteFromPlayer(room, ap, atkGame)
+	}
+	if !dp.Alive {
+		s.createLootSiteFromPlayer(room, dp, defGame)
+	}
+
+	until := now.Add(game.RaidCooldown)
+	room.raidCooldowns[raid.attackerID] = until
+	room.raidCooldowns[raid.defenderID] = until
+	s.saveGameStateLocked(room)
+	return result
+}
+
+// inRaid reports whether the client is raiding or being raided.
+// NOTE: caller must hold room.mu.
+func (r *GameRoom) inRaid(clientID string) bool {
+	for _, raid := range r.raids {
+		if raid.attackerID == clientID || raid.defenderID == clientID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package main
+
+import "time"
+
+// Per-connection message limits. These are variables so tests can tighten
+// or relax them.
+var (
+	actionRatePerSec  = 5.0
+	actionBurst       = 10.0
+	chatRatePerSec    = 3.0
+	chatBurst         = 5.0
+	maxRateViolations = 20
+	violationWindow   = 10 * time.Second
+)
+
+// tokenBucket is a simple token-bucket rate limiter. It is not safe for
+// concurrent use; each wsClient's readPump owns its buckets.
+type tokenBucket struct {
+	tokens float64
+	rate   float64
+	burst  float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full bucket, refilling from now.
+func newTokenBucket(rate, burst float64, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		tokens: burst,
+		rate:   rate,
+		burst:  burst,
+		last:   now,
+	}
+}
+
+// Allow refills the bucket for the time elapsed since the last call and
+// takes one token if available.
+func (b *tokenBucket) Allow(now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimiter tracks the buckets and violation count for one connection.
+type rateLimiter struct {
+	actions       *tokenBucket
+	chat          *tokenBucket
+	violations    int
+	lastViolation time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	now := time.Now()
+	return &rateLimiter{
+		actions: newTokenBucket(actionRatePerSec, actionBurst, now),
+		chat:    newTokenBucket(chatRatePerSec, chatBurst, now),
+	}
+}
+
+// Allow reports whether a message of the given type may be processed now.
+// The second result is true once the connection has violated the limit
+// often enough that it should be closed.
+func (rl *rateLimiter) Allow(msgType string, now time.Time) (allowed bool, disconnect bool) {
+	bucket := rl.actions
+	if msgType == "chat" || msgType == "whisper" {
+		bucket = rl.chat
+	}
+	if bucket.Allow(now) {
+		return true, false
+	}
+	if now.Sub(rl.lastViolation) > violationWindow {
+		rl.violations = 0
+	}
+	rl.violations++
+	rl.lastViolation = now
+	return false, rl.violations >= maxRateViolations
+}
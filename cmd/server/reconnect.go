@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// reconnectGrace is how long a dropped player in a scheduled game keeps
+// their seat and wagon before they're removed and their turn skipped.
+const reconnectGrace = 60 * time.Second
+
+// holdForReconnect marks a dropped client as disconnected and reserves their
+// seat for reconnectGrace. If it was their turn, the turn timer is replaced
+// by the grace deadline.
+// NOTE: caller must hold room.mu.
+func (s *Server) holdForReconnect(room *GameRoom, c *Client) {
+	c.Disconnected = true
+	until := s.clock.Now().Add(reconnectGrace)
+	room.reserveSeat(c.ID, until)
+
+	if t, ok := room.graceTimers[c.ID]; ok {
+		t.Stop()
+	}
+	clientID := c.ID
+	room.graceTimers[clientID] = s.clock.AfterFunc(reconnectGrace, func() {
+		s.handleReconnectExpired(room, clientID)
+	})
+
+	if cp := room.game.GetCurrentPlayer(); cp != nil && cp.ID == c.ID && !room.paused {
+		s.CancelTurnTimer(room)
+		room.turnDeadline = until
+	}
+	log.Printf("Player %s dropped from %s, holding their seat for %s", c.Name, room.id, reconnectGrace)
+}
+
+// resumeFromReconnect cancels the grace timer for a client who came back.
+// It reports whether the client was being held.
+// NOTE: caller must hold room.mu.
+func (s *Server) resumeFromReconnect(room *GameRoom, clientID string) bool {
+	old, ok := room.clients[clientID]
+	if !ok || !old.Disconnected {
+		return false
+	}
+	if t, ok := room.graceTimers[clientID]; ok {
+		t.Stop()
+		delete(room.graceTimers, clientID)
+	}
+	return true
+}
+
+// handleReconnectExpired removes a client who didn't return within the grace
+// period, skipping their turn if they held it.
+func (s *Server) handleReconnectExpired(room *GameRoom, clientID string) {
+	room.mu.Lock()
+	if room.closed {
+		room.mu.Unlock()
+		return
+	}
+	c, ok := room.clients[clientID]
+	if !ok || !c.Disconnected {
+		room.mu.Unlock()
+		return
+	}
+	// Seats stay held while the owner has the game paused
+	if room.pauseProtected() {
+		room.reserveSeat(clientID, s.clock.Now().Add(reconnectGrace))
+		room.graceTimers[clientID] = s.clock.AfterFunc(reconnectGrace, func() {
+			s.handleReconnectExpired(room, clientID)
+		})
+		room.mu.Unlock()
+		return
+	}
+	delete(room.graceTimers, clientID)
+	s.dropClient(room, clientID)
+	room.releaseSeat(clientID)
+	s.removeSharedPlayer(room, clientID)
+	transferOwnership(room, clientID)
+	roomID := room.id
+	name := c.Name
+	room.mu.Unlock()
+
+	log.Printf("Player %s did not reconnect to %s in time and was removed", name, roomID)
+	if s.hub != nil {
+		s.hub.BroadcastEventTo(roomID, "System", "disconnect",
+			fmt.Sprintf("%s didn't make it back and has left the wagon train.", name))
+		s.hub.FlushStateTo(roomID)
+	}
+	s.CleanupRoomIfEmpty(roomID)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits on what /api/lobbies/create will make. A room made there has no
+// owner until its creator's websocket claims it, so one left unclaimed for
+// unclaimedRoomTTL is closed by CleanupStaleRooms. Each session may have
+// maxRoomsPerSession unfinished rooms open at once.
+const (
+	maxRoomNameLen     = 30
+	maxRoomPasswordLen = 64
+	maxRoomPlayers     = 8
+	maxFortInterval    = 20
+	maxRoomsPerSession = 3
+	unclaimedRoomTTL   = 10 * time.Minute
+)
+
+var (
+	errServerFull      = errors.New("the server is full of rooms right now; try again later")
+	errTooManyOwnRooms = fmt.Errorf("you already have %d rooms open; finish or delete one first", maxRoomsPerSession)
+	errNoSuchRoom      = errors.New("room not found")
+	errNotYourRoom     = errors.New("you didn't make that room")
+	errRoomClaimed     = errors.New("someone has joined that room, so it can't be deleted")
+)
+
+// validateRoomRequest checks a create request's name, password and size,
+// filling in the defaults for those left out.
+func validateRoomRequest(name, password string, maxPlayers int) (string, int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Pioneer Party"
+	}
+	if utf8.RuneCountInString(name) > maxRoomNameLen {
+		return "", 0, fmt.Errorf("room name can be at most %d characters", maxRoomNameLen)
+	}
+	for _, r := range name {
+		if !unicode.IsPrint(r) {
+			return "", 0, errors.New("room name can only have printable characters")
+		}
+	}
+	if len(password) > maxRoomPasswordLen {
+		return "", 0, fmt.Errorf("password can be at most %d characters", maxRoomPasswordLen)
+	}
+	if maxPlayers == 0 {
+		maxPlayers = maxRoomPlayers
+	}
+	if maxPlayers < 1 || maxPlayers > maxRoomPlayers {
+		return "", 0, fmt.Errorf("max_players must be between 1 and %d", maxRoomPlayers)
+	}
+	return name, maxPlayers, nil
+}
+
+// requestIP is the address a request came from. X-Forwarded-For is only
+// believed from a proxy on this host or network, such as the Caddy in front
+// of the Docker image; from anywhere else it could be made up.
+func requestIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !(ip.IsLoopback() || ip.IsPrivate()) {
+		return host
+	}
+	forwarded := r.Header.Get("X-Forwarded-For")
+	if forwarded == "" {
+		return host
+	}
+	// The proxy appends the address it saw last
+	parts := strings.Split(forwarded, ",")
+	return strings.TrimSpace(parts[len(parts)-1])
+}
+
+// allowRoomCreate takes one of ip's room creations for the current window,
+// reporting false if it has used them all.
+func (s *Server) allowRoomCreate(ip string) bool {
+	if s.roomCreateLimit <= 0 {
+		return true
+	}
+	s.createLimitsMu.Lock()
+	defer s.createLimitsMu.Unlock()
+	now := s.clock.Now()
+	b, ok := s.createLimits[ip]
+	if !ok {
+		rate := float64(s.roomCreateLimit) / s.roomCreateWindow.Seconds()
+		b = newTokenBucket(rate, float64(s.roomCreateLimit), now)
+		s.createLimits[ip] = b
+	}
+	return b.Allow(now)
+}
+
+// pruneCreateLimits forgets addresses whose allowance has refilled, as if
+// they had never created a room.
+func (s *Server) pruneCreateLimits(now time.Time) {
+	s.createLimitsMu.Lock()
+	defer s.createLimitsMu.Unlock()
+	for ip, b := range s.createLimits {
+		if b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst {
+			delete(s.createLimits, ip)
+		}
+	}
+}
+
+// openRoomsBy counts the unfinished rooms sessionID made.
+// NOTE: caller must hold s.roomsMu.
+func (s *Server) openRoomsBy(sessionID string) int {
+	count := 0
+	for _, room := range s.rooms {
+		if room.creator != sessionID {
+			continue
+		}
+		room.mu.RLock()
+		finished := room.status == StatusFinished
+		room.mu.RUnlock()
+		if !finished {
+			count++
+		}
+	}
+	return count
+}
+
+// DeleteUnclaimedRoom closes a room sessionID made that no player has
+// joined yet.
+func (s *Server) DeleteUnclaimedRoom(roomID, sessionID string) error {
+	s.roomsMu.Lock()
+	defer s.roomsMu.Unlock()
+	room, ok := s.rooms[roomID]
+	if !ok || room.persistent {
+		return errNoSuchRoom
+	}
+	if room.creator != sessionID {
+		return errNotYourRoom
+	}
+	room.mu.RLock()
+	unclaimed := room.ownerID == "" && len(room.clients) == 0
+	room.mu.RUnlock()
+	if !unclaimed {
+		return errRoomClaimed
+	}
+	s.closeRoom(room)
+	delete(s.rooms, roomID)
+	s.notifyLobby()
+	log.Printf("Room %s (%s) deleted by its creator", room.name, roomID)
+	return nil
+}
+
+// roomLimitReached reports whether players have already made as many rooms
+// as the server allows, in all or of roomType's kind.
+// NOTE: caller must hold s.roomsMu.
+func (s *Server) roomLimitReached(roomType RoomType) bool {
+	created, same := 0, 0
+	for _, room := range s.rooms {
+		if room.persistent {
+			continue
+		}
+		created++
+		if room.roomType == roomType {
+			same++
+		}
+	}
+	if s.maxRooms > 0 && created >= s.maxRooms {
+		return true
+	}
+	return roomType == RoomTypeScheduled && s.maxScheduledRooms > 0 && same >= s.maxScheduledRooms
+}
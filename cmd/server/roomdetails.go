@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"online-trail/pkg/game"
+)
+
+// RoomDetails is the public view of a room shown before joining: its lobby
+// entry plus who is in it. It never carries the password or anyone's
+// supplies.
+type RoomDetails struct {
+	LobbyInfo
+	OwnerPresent bool          `json:"owner_present"`
+	TurnNumber   int           `json:"turn_number,omitempty"` // scheduled rooms only
+	Roster       []RosterEntry `json:"roster"`
+}
+
+// RosterEntry is one member of a room as shown to outsiders.
+type RosterEntry struct {
+	Name      string   `json:"name"`
+	Alive     bool     `json:"alive"`
+	Owner     bool     `json:"owner,omitempty"`
+	CPU       bool     `json:"cpu,omitempty"`
+	Spectator bool     `json:"spectator,omitempty"`
+	Connected bool     `json:"connected"`
+	Mileage   *float64 `json:"mileage,omitempty"` // continuous rooms only
+}
+
+// RoomDetails assembles the public view of a room, or reports false if
+// there is no such room.
+func (s *Server) RoomDetails(roomID string) (RoomDetails, bool) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return RoomDetails{}, false
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if room.closed {
+		return RoomDetails{}, false
+	}
+
+	details := RoomDetails{
+		LobbyInfo: room.lobbyInfo(),
+		Roster:    make([]RosterEntry, 0, len(room.clients)),
+	}
+	if owner, ok := room.clients[room.ownerID]; ok && !owner.Disconnected {
+		details.OwnerPresent = true
+	}
+	if room.roomType == RoomTypeScheduled && room.status != StatusWaiting {
+		details.TurnNumber = room.game.TurnNumber
+	}
+
+	for _, c := range room.clients {
+		entry := RosterEntry{
+			Name:      c.Name,
+			Alive:     c.Player == nil || c.Player.Alive,
+			Owner:     c.ID == room.ownerID,
+			Spectator: c.Spectator,
+			Connected: !c.Disconnected,
+		}
+		if g, _ := s.getPlayerGame(room, c.ID); g != nil && !c.Spectator {
+			miles := g.Mileage
+			entry.Mileage = &miles
+		}
+		details.Roster = append(details.Roster, entry)
+	}
+	if room.roomType == RoomTypeScheduled && room.game != nil {
+		for _, p := range room.game.Players {
+			if p.Type == game.PlayerTypeCPU {
+				details.Roster = append(details.Roster, RosterEntry{
+					Name:      p.Name,
+					Alive:     p.Alive,
+					CPU:       true,
+					Connected: true,
+				})
+			}
+		}
+	}
+	sort.Slice(details.Roster, func(i, j int) bool {
+		return details.Roster[i].Name < details.Roster[j].Name
+	})
+	return details, true
+}
+
+// handleRoomDetails serves GET /api/rooms/{id}, and hands
+// /api/rooms/{id}/log to handleRoomLog.
+func (s *Server) handleRoomDetails(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	roomID := strings.TrimPrefix(r.URL.Path, "/api/rooms/")
+	if id, ok := strings.CutSuffix(roomID, "/log"); ok && id != "" && !strings.Contains(id, "/") {
+		s.handleRoomLog(w, r, id)
+		return
+	}
+	if roomID == "" || strings.Contains(roomID, "/") {
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	}
+	details, ok := s.RoomDetails(roomID)
+	if !ok {
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(details)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// roomsFile holds the party games in progress, so a restart doesn't end
+// them. Finished games aren't kept.
+const roomsFile = "rooms.json"
+
+// PersistedRooms is what roomsFile holds.
+type PersistedRooms struct {
+	SchemaVersion int               `json:"schema_version"`
+	Rooms         []json.RawMessage `json:"rooms"`
+}
+
+// PersistedRoom is a party game as saved in roomsFile.
+type PersistedRoom struct {
+	ID            string             `json:"id"`
+	Name          string             `json:"name"`
+	Password      *NameClaim         `json:"password,omitempty"`
+	OwnerID       string             `json:"owner_id"`
+	Creator       string             `json:"creator,omitempty"`
+	CoOwners      []string           `json:"co_owners,omitempty"`
+	MaxPlayers    int                `json:"max_players"`
+	LockedSeats   int                `json:"locked_seats,omitempty"`
+	BotSeats      int                `json:"bot_seats,omitempty"`
+	BotsTakeSeats bool               `json:"bots_take_seats,omitempty"`
+	Rules         RoomRules          `json:"rules"`
+	ChatMode      ChatMode           `json:"chat_mode,omitempty"`
+	Status        GameStatus         `json:"status"`
+	Paused        bool               `json:"paused,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	Game          PersistedGameState `json:"game"`
+	Players       []*game.Player     `json:"players"`
+	DeadPlayers   []string           `json:"dead_players,omitempty"`
+	Banned        map[string]string  `json:"banned,omitempty"`
+	Sessions      []PersistedSession `json:"sessions,omitempty"`
+}
+
+// PersistedSession is a player's session saved with their room, so their
+// cookie resumes them into it after a restart. Only a hash of the session
+// ID is kept: the ID itself is the cookie, and anyone who read the file
+// could otherwise sign in as the player.
+type PersistedSession struct {
+	Hash     string `json:"hash"`
+	ID       string `json:"id,omitempty"` // saved before hashes; read only
+	Name     string `json:"name"`
+	ClientID string `json:"client_id"`
+}
+
+func (s *Server) roomsFilePath() string {
+	return filepath.Join(s.dataPath, roomsFile)
+}
+
+// saveRooms writes every party game in progress to roomsFile.
+func (s *Server) saveRooms() {
+	s.roomsMu.RLock()
+	rooms := make([]*GameRoom, 0)
+	for _, room := range s.rooms {
+		if room.roomType == RoomTypeScheduled {
+			rooms = append(rooms, room)
+		}
+	}
+	s.roomsMu.RUnlock()
+
+	// Each room is marshaled under its own lock, since the game goes on
+	saved := make([]json.RawMessage, 0, len(rooms))
+	for _, room := range rooms {
+		room.mu.RLock()
+		if room.status == StatusPlaying && !room.game.GameOver && !room.closed {
+			if data, err := json.Marshal(s.persistRoom(room)); err == nil {
+				saved = append(saved, data)
+			} else {
+				log.Printf("Failed to marshal room %s: %v", room.id, err)
+			}
+		}
+		room.mu.RUnlock()
+	}
+
+	data, err := json.MarshalIndent(PersistedRooms{SchemaVersion: roomsSchemaVersion, Rooms: saved}, "", "  ")
+	if err != nil {
+		log.Printf("Failed to marshal rooms: %v", err)
+		return
+	}
+	s.roomsFileMu.Lock()
+	defer s.roomsFileMu.Unlock()
+	if err := writeSaveFile(s.roomsFilePath(), data); err != nil {
+		log.Printf("Failed to save rooms: %v", err)
+	}
+}
+
+// persistRoom converts a party room into its saved form.
+// NOTE: caller must hold room.mu.
+func (s *Server) persistRoom(room *GameRoom) PersistedRoom {
+	saved := PersistedRoom{
+		ID:            room.id,
+		Name:          room.name,
+		Password:      room.password,
+		OwnerID:       room.ownerID,
+		Creator:       room.creator,
+		MaxPlayers:    room.maxPlayers,
+		LockedSeats:   room.lockedSeats,
+		BotSeats:      room.botSeats,
+		BotsTakeSeats: room.botsTakeSeats,
+		Rules:         room.rules,
+		ChatMode:      room.chatMode,
+		Status:        room.status,
+		Paused:        room.paused && !room.restored,
+		CreatedAt:     room.createdAt,
+		Game:          persistPlayerGame("", "", room.game),
+		Players:       room.game.Players,
+		Banned:        room.bannedClients,
+	}
+	for id := range room.coOwners {
+		saved.CoOwners = append(saved.CoOwners, id)
+	}
+	for name := range room.deadPlayers {
+		saved.DeadPlayers = append(saved.DeadPlayers, name)
+	}
+	for _, c := range room.clients {
+		if sess := s.sessionManager.GetSession(c.SessionID); sess != nil && sess.Alive && sess.RoomID == room.id {
+			saved.Sessions = append(saved.Sessions, PersistedSession{Hash: hashSessionID(sess.ID), Name: sess.Name, ClientID: sess.ClientID})
+		}
+	}
+	// Players who haven't come back since the last restart
+	for _, sess := range s.sessionManager.PendingRestores(room.id) {
+		saved.Sessions = append(saved.Sessions, PersistedSession{Hash: sess.hash, Name: sess.Name, ClientID: sess.ClientID})
+	}
+	return saved
+}
+
+// loadRooms brings back the party games saved in roomsFile. Each comes back
+// paused, with no turn clock, until one of its players reconnects.
+func (s *Server) loadRooms() {
+	var doc map[string]json.RawMessage
+	err := readSaveFile(s.roomsFilePath(), func(data []byte) (err error) {
+		doc, err = parseSaveDoc(data)
+		return err
+	})
+	if os.IsNotExist(err) {
+		return
+	}
+	var saved struct {
+		Rooms []PersistedRoom `json:"rooms"`
+	}
+	if err == nil {
+		err = migrateSave(doc, roomsMigrations, &saved)
+	}
+	if err != nil {
+		log.Printf("Failed to load saved rooms: %v", err)
+		return
+	}
+
+	for _, sr := range saved.Rooms {
+		if sr.Status != StatusPlaying || sr.Game.GameOver || s.rooms[sr.ID] != nil {
+			continue
+		}
+		room := NewGameRoom(sr.ID, sr.Name, RoomTypeScheduled, s.clock)
+		room.password = sr.Password
+		room.ownerID = sr.OwnerID
+		room.creator = sr.Creator
+		room.maxPlayers = sr.MaxPlayers
+		room.lockedSeats = sr.LockedSeats
+		room.botSeats = sr.BotSeats
+		room.botsTakeSeats = sr.BotsTakeSeats
+		room.rules = sr.Rules
+		if sr.ChatMode != "" {
+			room.chatMode = sr.ChatMode
+		}
+		room.status = sr.Status
+		room.createdAt = sr.CreatedAt
+		for _, id := range sr.CoOwners {
+			room.coOwners[id] = true
+		}
+		for _, name := range sr.DeadPlayers {
+			room.deadPlayers[name] = true
+		}
+		for key, name := range sr.Banned {
+			room.bannedClients[key] = name
+		}
+
+		room.game = restoreGame(sr.Game)
+		room.game.EventLog = append(room.game.EventLog, sr.Game.EventLog...)
+		for _, p := range sr.Players {
+			player := room.game.AddPlayer(p.Name, p.Type)
+			player.ID = p.ID
+			player.Alive = p.Alive
+			player.Occupation = p.Occupation
+			player.Peaceful = p.Peaceful
+			player.ShootingRank = p.ShootingRank
+			if len(p.Party) > 0 {
+				player.Party = p.Party
+			}
+		}
+		room.game.CurrentPlayerIdx = sr.Game.CurrentPlayerIdx
+
+		room.paused = true
+		room.pausedAt = s.clock.Now()
+		room.pausedRemaining = s.turnTime
+		room.restored = !sr.Paused
+
+		for _, sess := range sr.Sessions {
+			hash := sess.Hash
+			if hash == "" && sess.ID != "" {
+				hash = hashSessionID(sess.ID)
+			}
+			if hash != "" {
+				s.sessionManager.RestoreSession(hash, sess.Name, sess.ClientID, room.id)
+			}
+		}
+		s.rooms[room.id] = room
+		log.Printf("Restored party game %s (%s): %d players, turn %d",
+			room.name, room.id, len(room.game.Players), room.game.TurnNumber)
+	}
+}
+
+// resumeRestored starts a restored room's turn clock again now that one of
+// its players is back. A room its owner had paused stays paused.
+// NOTE: caller must hold room.mu.
+func (s *Server) resumeRestored(room *GameRoom) {
+	if !room.restored {
+		return
+	}
+	room.restored = false
+	room.paused = false
+	room.pausedAt = time.Time{}
+	if cp := room.game.GetCurrentPlayer(); cp != nil && cp.Alive && !room.game.GameOver {
+		s.startTurnTimerFor(room, cp.ID, room.pausedRemaining)
+	}
+	room.pausedRemaining = 0
+	log.Printf("Room %s resumed after a restart", room.id)
+}
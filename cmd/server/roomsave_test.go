@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPersistedRoomHidesSessionIDs(t *testing.T) {
+	s := newTestServer(t)
+	room := newTestRoom(t, s)
+	sessionID := s.sessionManager.NewSession("Ann", "client-ann", room.id)
+	s.AddClient(&Client{ID: "client-ann", Name: "Ann", SessionID: sessionID}, room.id)
+
+	room.mu.Lock()
+	saved := s.persistRoom(room)
+	room.mu.Unlock()
+	data, err := json.Marshal(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), sessionID) {
+		t.Fatalf("saved room contains the session ID: %s", data)
+	}
+	if len(saved.Sessions) != 1 || saved.Sessions[0].Hash != hashSessionID(sessionID) {
+		t.Fatalf("sessions = %+v, want one with the ID's hash", saved.Sessions)
+	}
+
+	// After a restart the cookie still resumes the player into the room
+	restarted := NewSessionManager(realClock{})
+	restarted.RestoreSession(saved.Sessions[0].Hash, "Ann", "client-ann", room.id)
+	if got := restarted.PendingRestores(room.id); len(got) != 1 {
+		t.Fatalf("pending restores = %d, want 1", len(got))
+	}
+	sess, ok := restarted.GetSessionByID(sessionID)
+	if !ok || sess.ID != sessionID || sess.ClientID != "client-ann" || sess.RoomID != room.id {
+		t.Fatalf("GetSessionByID = %+v, %v", sess, ok)
+	}
+	if got := restarted.PendingRestores(room.id); len(got) != 0 {
+		t.Fatalf("pending restores after claim = %d, want 0", len(got))
+	}
+	if _, ok := restarted.GetSessionByID("some-other-cookie"); ok {
+		t.Fatal("an unknown cookie resumed a session")
+	}
+}
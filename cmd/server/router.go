@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"online-trail/pkg/game"
+)
+
+// NewRouter registers every HTTP handler on a fresh mux.
+func NewRouter(s *Server, hub *Hub) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", staticHandler(s.staticDir))
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		if requireOrigin(w, r) {
+			serveWs(hub, w, r)
+		}
+	})
+	mux.HandleFunc("/api/session", s.handleSession)
+	mux.HandleFunc("/api/logout", s.handleLogout)
+	mux.HandleFunc("/api/lobbies", s.handleLobbies)
+	mux.HandleFunc("/api/lobbies/create", s.handleLobbiesCreate)
+	mux.HandleFunc("/api/lobbies/", s.handleLobbyDelete)
+	mux.HandleFunc("/api/rooms/", s.handleRoomDetails)
+	mux.HandleFunc("/api/trail", s.handleTrail)
+	mux.HandleFunc("/api/me/data", s.handleMyData)
+	mux.HandleFunc("/api/me/delete", s.handleMyDelete)
+	mux.HandleFunc("/api/my-save", s.handleMySave)
+	mux.HandleFunc("/api/register", s.handleRegister)
+	mux.HandleFunc("/api/player", s.handlePlayer)
+	mux.HandleFunc("/api/leaderboard", s.handleLeaderboard)
+	mux.HandleFunc("/api/champions", s.handleChampions)
+	mux.HandleFunc("/api/journeys", s.handleJourneys)
+	mux.HandleFunc("/api/journeys/", s.handleJourney)
+	mux.HandleFunc("/api/admin/release-name", s.handleAdminReleaseName)
+	return mux
+}
+
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodPost {
+		s.signIn(w, r)
+		return
+	}
+	cookie, err := r.Cookie("session_id")
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{"valid": false})
+		return
+	}
+	sess, ok := s.sessionManager.GetSessionByID(cookie.Value)
+	if !ok {
+		json.NewEncoder(w).Encode(map[string]interface{}{"valid": false})
+		return
+	}
+	// A session whose room was cleaned up resumes where serveWs would put it
+	roomID := sess.RoomID
+	if s.GetRoom(roomID) == nil {
+		roomID = s.landingRoom(sess.ClientID)
+	}
+	if s.GetRoom(roomID) == nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{"valid": false})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"valid":        true,
+		"name":         sess.Name,
+		"client_id":    sess.ClientID,
+		"room_id":      roomID,
+		"active_rooms": s.ActiveRoomsFor(identityOf(sess)),
+	})
+}
+
+// signIn starts a session for a visitor who hasn't joined a room yet, such
+// as one about to make their own. Registered names need their PIN, as on
+// /ws. The session resumes where a new player would land until it makes a
+// room.
+func (s *Server) signIn(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+		PIN  string `json:"pin"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	name, err := validatePlayerName(req.Name)
+	if err != nil {
+		http.Error(w, "Invalid player name: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.leaderboard.IsNameClaimed(name) {
+		if err := s.leaderboard.CheckNamePIN(name, req.PIN, requestIP(r)); err != nil {
+			http.Error(w, "The name "+name+" is registered: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+	clientID := game.NewPlayerID()
+	roomID := s.landingRoom(clientID)
+	sessionID := s.sessionManager.NewSession(name, clientID, roomID)
+	http.SetCookie(w, sessionCookie(r, sessionID))
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"valid":     true,
+		"name":      name,
+		"client_id": clientID,
+		"room_id":   roomID,
+	})
+}
+
+// handleLogout ends the caller's session as the websocket "logout" message
+// does, for when they have no socket open: they leave their room, any open
+// connection is closed and the browser's cookie is cleared, so the next
+// visit starts fresh.
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireOrigin(w, r) {
+		return
+	}
+	sess, ok := s.sessionFromRequest(r)
+	clearSessionCookie(w, r)
+	if !ok {
+		json.NewEncoder(w).Encode(map[string]interface{}{"logged_out": false})
+		return
+	}
+
+	clientID, roomID := sess.ClientID, sess.RoomID
+	s.LogoutClient(clientID, sess.ID, roomID)
+	if s.hub != nil {
+		s.hub.DisconnectClient(clientID)
+		s.hub.BroadcastStateTo(roomID)
+	}
+	s.CleanupRoomIfEmpty(roomID)
+	log.Printf("Session for %s (client %s) logged out over HTTP", sess.Name, clientID)
+	json.NewEncoder(w).Encode(map[string]interface{}{"logged_out": true})
+}
+
+func (s *Server) handleLobbies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodGet {
+		filter, err := parseLobbyFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var sessionID string
+		if sess, ok := s.sessionFromRequest(r); ok {
+			sessionID = sess.ID
+		}
+		lobbies := s.ListLobbies(filter, sessionID)
+		json.NewEncoder(w).Encode(lobbies)
+		return
+	}
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
+
+// parseLobbyFilter reads the /api/lobbies query parameters: status,
+// has_password, hide_full and sort.
+func parseLobbyFilter(q url.Values) (LobbyFilter, error) {
+	var f LobbyFilter
+	switch status := GameStatus(q.Get("status")); status {
+	case "":
+	case StatusWaiting, StatusPlaying:
+		f.Status = status
+	default:
+		return f, errors.New("status must be waiting or playing")
+	}
+	if v := q.Get("has_password"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, errors.New("has_password must be true or false")
+		}
+		f.HasPassword = &b
+	}
+	if v := q.Get("hide_full"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, errors.New("hide_full must be 1 or 0")
+		}
+		f.HideFull = b
+	}
+	switch sortBy := q.Get("sort"); sortBy {
+	case "", "created", "players", "name":
+		f.Sort = sortBy
+	default:
+		return f, errors.New("sort must be created, players or name")
+	}
+	return f, nil
+}
+
+// handleLobbiesCreate makes a room for the signed-in caller. Their session
+// is moved to it, so their next websocket lands there and claims it.
+func (s *Server) handleLobbiesCreate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireOrigin(w, r) {
+		return
+	}
+	sess, ok := s.sessionFromRequest(r)
+	if !ok {
+		http.Error(w, "Not signed in", http.StatusUnauthorized)
+		return
+	}
+	var req struct {
+		Name         string   `json:"name"`
+		Password     string   `json:"password"`
+		RoomType     RoomType `json:"room_type"` // "scheduled" (default) or "race"
+		MaxPlayers   int      `json:"max_players"`
+		CPUPlayers   int      `json:"cpu_players"`
+		CPUTakeSeats bool     `json:"cpu_take_seats"`
+		RoomRules
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	var err error
+	req.Name, req.MaxPlayers, err = validateRoomRequest(req.Name, req.Password, req.MaxPlayers)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	occupation, err := game.ParseOccupation(string(req.Occupation))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Occupation = occupation
+	if req.FortInterval < 0 || req.FortInterval > maxFortInterval {
+		http.Error(w, fmt.Sprintf("fort_interval must be between 1 and %d, or 0 for the server's", maxFortInterval), http.StatusBadRequest)
+		return
+	}
+	switch req.RoomType {
+	case "":
+		req.RoomType = RoomTypeScheduled
+	case RoomTypeScheduled, RoomTypeRace:
+	default:
+		http.Error(w, "room_type must be scheduled or race", http.StatusBadRequest)
+		return
+	}
+	if req.CPUPlayers < 0 {
+		req.CPUPlayers = 0
+	}
+	if !s.allowRoomCreate(requestIP(r)) {
+		http.Error(w, "You've made a lot of rooms lately; try again in a few minutes", http.StatusTooManyRequests)
+		return
+	}
+	room, err := s.CreateRoom(req.Name, req.Password, sess, req.RoomType, req.MaxPlayers, req.CPUPlayers, req.CPUTakeSeats, req.RoomRules)
+	switch err {
+	case nil:
+	case errTooManyOwnRooms:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	s.sessionManager.UpdateRoomID(sess.ID, room.id)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":   room.id,
+		"name": room.name,
+	})
+}
+
+// handleLobbyDelete serves DELETE /api/lobbies/{id}, with which a room's
+// creator takes it down before anyone has joined.
+func (s *Server) handleLobbyDelete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireOrigin(w, r) {
+		return
+	}
+	roomID := strings.TrimPrefix(r.URL.Path, "/api/lobbies/")
+	if roomID == "" || strings.Contains(roomID, "/") {
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	}
+	sess, ok := s.sessionFromRequest(r)
+	if !ok {
+		http.Error(w, "Not signed in", http.StatusUnauthorized)
+		return
+	}
+	switch err := s.DeleteUnclaimedRoom(roomID, sess.ID); err {
+	case nil:
+	case errNoSuchRoom:
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	case errNotYourRoom:
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"deleted": true, "id": roomID})
+}
+
+func (s *Server) handlePlayer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	name := strings.TrimSpace(r.URL.Query().Get("name"))
+	if name == "" {
+		http.Error(w, "Missing name", http.StatusBadRequest)
+		return
+	}
+	stats, ok := s.leaderboard.GetPlayerStats(name)
+	if !ok {
+		http.Error(w, "Player not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"player_name":   stats.PlayerName,
+		"games_played":  stats.GamesPlayed,
+		"wins":          stats.Wins,
+		"deaths":        stats.Deaths,
+		"best_miles":    stats.BestMiles,
+		"total_miles":   stats.TotalMiles,
+		"average_turns": stats.AverageTurns(),
+		"best_run":      stats.BestRun,
+		"last_played":   stats.LastPlayed,
+		"verified":      s.leaderboard.IsNameClaimed(name),
+	})
+}
+
+func (s *Server) handleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	mode := r.URL.Query().Get("mode")
+	since, err := PeriodStart(r.URL.Query().Get("period"), s.clock.Now())
+	if err != nil {
+		http.Error(w, "Bad period: use all, week or month", http.StatusBadRequest)
+		return
+	}
+	if mode != "" {
+		entries := s.leaderboard.GetTopByModeSince(10, mode, since)
+		log.Printf("Leaderboard API: mode=%s, entries=%d", mode, len(entries))
+		json.NewEncoder(w).Encode(entries)
+	} else {
+		continuous := s.leaderboard.GetTopByModeSince(10, "continuous", since)
+		party := s.leaderboard.GetTopByModeSince(10, "party", since)
+		log.Printf("Leaderboard API: continuous=%d, party=%d", len(continuous), len(party))
+		result := map[string][]LeaderboardEntry{
+			"continuous": continuous,
+			"party":      party,
+		}
+		json.NewEncoder(w).Encode(result)
+	}
+}
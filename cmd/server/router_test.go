@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// call makes a request through the router, as the session if one is given.
+func call(router http.Handler, method, path, body, sessionID string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if sessionID != "" {
+		r.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+// decode decodes a JSON response into v, failing unless it was a 200.
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("%v: %s", err, w.Body)
+	}
+}
+
+func TestSessionEndpoint(t *testing.T) {
+	s := newTestServer(t)
+	router := NewRouter(s, nil)
+
+	var got map[string]interface{}
+	decode(t, call(router, http.MethodGet, "/api/session", "", ""), &got)
+	if got["valid"] != false {
+		t.Errorf("no cookie: %v, want not valid", got)
+	}
+	decode(t, call(router, http.MethodGet, "/api/session", "", "no-such-session"), &got)
+	if got["valid"] != false {
+		t.Errorf("unknown cookie: %v, want not valid", got)
+	}
+
+	w := call(router, http.MethodPost, "/api/session", `{"name": "Ann"}`, "")
+	decode(t, w, &got)
+	if got["valid"] != true || got["name"] != "Ann" || got["room_id"] != s.defaultRoomID {
+		t.Fatalf("sign in: %v", got)
+	}
+	var sessionID string
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "session_id" {
+			sessionID = c.Value
+		}
+	}
+	if sessionID == "" {
+		t.Fatal("signing in set no session cookie")
+	}
+
+	got = nil
+	decode(t, call(router, http.MethodGet, "/api/session", "", sessionID), &got)
+	if got["valid"] != true || got["name"] != "Ann" || got["client_id"] == "" {
+		t.Errorf("signed in: %v", got)
+	}
+}
+
+func TestSessionSignInErrors(t *testing.T) {
+	s := newTestServer(t)
+	router := NewRouter(s, nil)
+	if err := s.leaderboard.ClaimName("Bea", "4821"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"name": `, http.StatusBadRequest},
+		{`{"name": ""}`, http.StatusBadRequest},
+		{`{"name": "Bea"}`, http.StatusUnauthorized},
+		{`{"name": "Bea", "pin": "1111"}`, http.StatusUnauthorized},
+	} {
+		w := call(router, http.MethodPost, "/api/session", tc.body, "")
+		if w.Code != tc.want {
+			t.Errorf("sign in %s: %d %s, want %d", tc.body, w.Code, w.Body, tc.want)
+		}
+		if len(w.Result().Cookies()) != 0 {
+			t.Errorf("sign in %s set a cookie", tc.body)
+		}
+	}
+	var got map[string]interface{}
+	decode(t, call(router, http.MethodPost, "/api/session", `{"name": "Bea", "pin": "4821"}`, ""), &got)
+	if got["name"] != "Bea" {
+		t.Errorf("sign in with the PIN: %v", got)
+	}
+}
+
+func TestLobbiesEndpoint(t *testing.T) {
+	s := newTestServer(t)
+	router := NewRouter(s, nil)
+	room := newTestRoom(t, s)
+
+	var lobbies []LobbyInfo
+	decode(t, call(router, http.MethodGet, "/api/lobbies", "", ""), &lobbies)
+	found := false
+	for _, l := range lobbies {
+		if l.ID == room.id {
+			found = true
+			if l.Name != "Test room" || l.Status != string(StatusWaiting) || l.MaxPlayers != 4 {
+				t.Errorf("listed %+v", l)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("lobbies %+v don't list the new room", lobbies)
+	}
+
+	lobbies = nil
+	decode(t, call(router, http.MethodGet, "/api/lobbies?status=playing", "", ""), &lobbies)
+	for _, l := range lobbies {
+		if l.ID == room.id {
+			t.Error("status=playing listed a waiting room")
+		}
+	}
+
+	for _, query := range []string{"status=over", "has_password=maybe", "hide_full=2", "sort=age"} {
+		if w := call(router, http.MethodGet, "/api/lobbies?"+query, "", ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: %d, want 400", query, w.Code)
+		}
+	}
+	if w := call(router, http.MethodPost, "/api/lobbies", "", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/lobbies: %d, want 405", w.Code)
+	}
+}
+
+func TestLobbiesCreateEndpoint(t *testing.T) {
+	s := newTestServer(t)
+	router := NewRouter(s, nil)
+	sessionID := s.sessionManager.NewSession("Ann", "client-ann", s.defaultRoomID)
+
+	for _, tc := range []struct {
+		method, body, session string
+		want                  int
+	}{
+		{http.MethodGet, "", sessionID, http.StatusMethodNotAllowed},
+		{http.MethodPost, `{"name": "Ann's wagon"}`, "", http.StatusUnauthorized},
+		{http.MethodPost, `{"name": `, sessionID, http.StatusBadRequest},
+		{http.MethodPost, `{"name": "` + strings.Repeat("x", maxRoomNameLen+1) + `"}`, sessionID, http.StatusBadRequest},
+		{http.MethodPost, `{"name": "Ann's wagon", "max_players": 99}`, sessionID, http.StatusBadRequest},
+		{http.MethodPost, `{"name": "Ann's wagon", "room_type": "continuous"}`, sessionID, http.StatusBadRequest},
+		{http.MethodPost, `{"name": "Ann's wagon", "fort_interval": -1}`, sessionID, http.StatusBadRequest},
+		{http.MethodPost, `{"name": "Ann's wagon", "occupation": "astronaut"}`, sessionID, http.StatusBadRequest},
+	} {
+		if w := call(router, tc.method, "/api/lobbies/create", tc.body, tc.session); w.Code != tc.want {
+			t.Errorf("%s %s: %d %s, want %d", tc.method, tc.body, w.Code, w.Body, tc.want)
+		}
+	}
+
+	var created struct{ ID, Name string }
+	decode(t, call(router, http.MethodPost, "/api/lobbies/create", `{"name": "Ann's wagon", "max_players": 3}`, sessionID), &created)
+	room := s.GetRoom(created.ID)
+	if room == nil || created.Name != "Ann's wagon" {
+		t.Fatalf("created %+v, room %v", created, room)
+	}
+	if room.maxPlayers != 3 || room.roomType != RoomTypeScheduled {
+		t.Errorf("room: %d seats, %s", room.maxPlayers, room.roomType)
+	}
+	// The creator's next websocket lands in it
+	if sess, _ := s.sessionManager.GetSessionByID(sessionID); sess.RoomID != room.id {
+		t.Errorf("session room = %s, want %s", sess.RoomID, room.id)
+	}
+}
+
+func TestLeaderboardEndpoint(t *testing.T) {
+	s := newTestServer(t)
+	router := NewRouter(s, nil)
+	s.leaderboard.AddEntry("client-ann", "Ann", true, 2040, 30, 900, 4, "continuous", "")
+	s.leaderboard.AddEntry("client-bea", "Bea", false, 700, 12, 200, 0, "party", "")
+
+	var both map[string][]LeaderboardEntry
+	decode(t, call(router, http.MethodGet, "/api/leaderboard", "", ""), &both)
+	if len(both["continuous"]) != 1 || both["continuous"][0].PlayerName != "Ann" {
+		t.Errorf("continuous = %+v, want Ann", both["continuous"])
+	}
+	if len(both["party"]) != 1 || both["party"][0].PlayerName != "Bea" {
+		t.Errorf("party = %+v, want Bea", both["party"])
+	}
+	if strings.Contains(call(router, http.MethodGet, "/api/leaderboard", "", "").Body.String(), "client-ann") {
+		t.Error("the leaderboard shows client IDs")
+	}
+
+	var party []LeaderboardEntry
+	decode(t, call(router, http.MethodGet, "/api/leaderboard?mode=party&period=week", "", ""), &party)
+	if len(party) != 1 || party[0].PlayerName != "Bea" {
+		t.Errorf("party this week = %+v, want Bea", party)
+	}
+
+	if w := call(router, http.MethodGet, "/api/leaderboard?period=decade", "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("period=decade: %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Save files. A save is written to a temp file and renamed over the old one,
+// so a crash mid-write leaves the old save whole; the old one is kept as
+// <file>.bak first. A save that won't parse is moved aside as
+// <name>.corrupt-<time> and the backup is loaded instead.
+
+// saveFileMu keeps two saves of the same file from rotating at once.
+var saveFileMu sync.Mutex
+
+// writeSaveFile replaces path with data, keeping the previous version as
+// path.bak.
+func writeSaveFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	saveFileMu.Lock()
+	defer saveFileMu.Unlock()
+	if _, err := os.Stat(path); err == nil {
+		if err := os.Rename(path, path+".bak"); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readSaveFile reads the save at path and decodes it with parse. If the save
+// is missing or won't parse, the backup is tried; a save that won't parse is
+// moved aside first. It returns os.ErrNotExist if there is neither.
+func readSaveFile(path string, parse func([]byte) error) error {
+	corrupt := false
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err = parse(data); err == nil {
+			return nil
+		}
+		corrupt = true
+		aside := strings.TrimSuffix(path, filepath.Ext(path)) + ".corrupt-" + time.Now().Format("20060102-150405")
+		log.Printf("!!! %s is corrupt (%v); moving it to %s and loading the backup", path, err, aside)
+		if err := os.Rename(path, aside); err != nil {
+			log.Printf("!!! Couldn't move %s aside: %v", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	data, err = os.ReadFile(path + ".bak")
+	if err != nil {
+		if os.IsNotExist(err) {
+			if corrupt {
+				log.Printf("!!! There is no backup of %s; starting over", path)
+			}
+			return os.ErrNotExist
+		}
+		return err
+	}
+	if err := parse(data); err != nil {
+		return fmt.Errorf("backup %s.bak is corrupt too: %v", path, err)
+	}
+	log.Printf("!!! Loaded %s.bak; progress since it was saved is lost", path)
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readString reads a save with readSaveFile, returning what it decoded.
+func readString(path string) (string, error) {
+	var got string
+	err := readSaveFile(path, func(data []byte) error {
+		return json.Unmarshal(data, &got)
+	})
+	return got, err
+}
+
+// corruptFiles returns the saves in dir that were moved aside as corrupt.
+func corruptFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "*.corrupt-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestWriteSaveFileKeepsBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "save.json")
+	for _, data := range []string{`"first"`, `"second"`} {
+		if err := writeSaveFile(path, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != `"second"` {
+		t.Errorf("save = %s, want the second", data)
+	}
+	if data, _ := os.ReadFile(path + ".bak"); string(data) != `"first"` {
+		t.Errorf("backup = %s, want the first", data)
+	}
+	if leftover, _ := filepath.Glob(path + ".tmp-*"); len(leftover) != 0 {
+		t.Errorf("temp files left behind: %v", leftover)
+	}
+}
+
+func TestTruncatedSaveLoadsBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "save.json")
+	for _, data := range []string{`"first"`, `"second"`} {
+		if err := writeSaveFile(path, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(`"sec`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readString(path)
+	if err != nil || got != "first" {
+		t.Fatalf("readSaveFile = %q, %v; want the backup", got, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("the truncated save was left in place")
+	}
+	aside := corruptFiles(t, dir)
+	if len(aside) != 1 {
+		t.Fatalf("moved aside: %v, want the truncated save", aside)
+	}
+	if data, _ := os.ReadFile(aside[0]); string(data) != `"sec` {
+		t.Errorf("moved aside %s, want the truncated save", data)
+	}
+}
+
+func TestMissingSaveLoadsBackup(t *testing.T) {
+	// A crash between moving the save to .bak and renaming the new one in
+	path := filepath.Join(t.TempDir(), "save.json")
+	if err := os.WriteFile(path+".bak", []byte(`"first"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := readString(path); err != nil || got != "first" {
+		t.Fatalf("readSaveFile = %q, %v; want the backup", got, err)
+	}
+}
+
+func TestTruncatedSaveWithoutBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "save.json")
+	if err := os.WriteFile(path, []byte(`{"loot_sites": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readString(path); !os.IsNotExist(err) {
+		t.Fatalf("readSaveFile error = %v, want os.ErrNotExist", err)
+	}
+	if aside := corruptFiles(t, dir); len(aside) != 1 {
+		t.Errorf("moved aside: %v, want the truncated save", aside)
+	}
+}
+
+func TestCorruptBackupIsAnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "save.json")
+	if err := os.WriteFile(path, []byte(`"sec`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".bak", []byte(`"fir`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readString(path); err == nil || os.IsNotExist(err) {
+		t.Fatalf("readSaveFile error = %v, want the backup's parse error", err)
+	}
+	// The backup is all that is left, so it stays where it is
+	if _, err := os.Stat(path + ".bak"); err != nil {
+		t.Errorf("backup: %v", err)
+	}
+}
+
+func TestTruncatedGameStateLoadsBackup(t *testing.T) {
+	s := newTestServer(t)
+	room := newPersistentRoom(PersistentRoomConfig{ID: "trail-test"}, s.clock)
+	path := s.gameStateFilePath(room)
+
+	saved := PersistedContinuousState{
+		SchemaVersion: continuousSchemaVersion,
+		PlayerGames: map[string]PersistedGameState{
+			"client-ann": {PlayerName: "Ann", TurnNumber: 7, Mileage: 420, Food: 300},
+		},
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".bak", data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s.loadGameState(room)
+	g := room.playerGames["client-ann"]
+	if g == nil {
+		t.Fatal("Ann's game wasn't loaded from the backup")
+	}
+	if g.TurnNumber != 7 || g.Mileage != 420 {
+		t.Errorf("Ann's game: turn %d, %.0f miles; want turn 7, 420 miles", g.TurnNumber, g.Mileage)
+	}
+	if room.status != StatusPlaying {
+		t.Errorf("status = %s, want playing", room.status)
+	}
+}
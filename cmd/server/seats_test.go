@@ -0,0 +1,138 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDeadPlayersSeatsReopenOnReset(t *testing.T) {
+	s := newTestServer(t)
+	room := newTestRoom(t, s)
+	for _, c := range []*Client{{ID: "client-ann", Name: "Ann"}, {ID: "client-bea", Name: "Bea"},
+		{ID: "client-cy", Name: "Cy"}, {ID: "client-di", Name: "Di"}} {
+		s.AddClient(c, room.id)
+	}
+	room.mu.Lock()
+	room.ownerID = "client-ann"
+	room.mu.Unlock()
+	if err := s.StartGame(room.id, "client-ann", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cy and Di die on the trail and log out
+	room.mu.Lock()
+	for _, p := range room.game.Players {
+		if p.ID == "client-cy" || p.ID == "client-di" {
+			p.Alive = false
+		}
+	}
+	room.mu.Unlock()
+	s.LogoutClient("client-cy", "", room.id)
+	s.LogoutClient("client-di", "", room.id)
+
+	room.mu.Lock()
+	if room.CanJoin(SeatHuman, "client-eve") {
+		t.Error("a newcomer took a dead player's seat mid-game")
+	}
+	if seats := room.Seats(); seats.Locked != 2 || seats.Open != 0 || room.maxPlayers != 4 {
+		t.Errorf("mid-game: %+v with %d max, want 2 locked of 4", seats, room.maxPlayers)
+	}
+	room.game.GameOver = true
+	room.mu.Unlock()
+
+	if !s.ResetGame(room.id) {
+		t.Fatal("the finished game didn't reset")
+	}
+	// The room is back to four seats, however many times it lost players
+	s.AddClient(&Client{ID: "client-eve", Name: "Eve"}, room.id)
+	s.AddClient(&Client{ID: "client-flo", Name: "Flo"}, room.id)
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	if seats := room.Seats(); seats.Locked != 0 || seats.Humans != 4 || room.maxPlayers != 4 {
+		t.Errorf("after the reset: %+v with %d max, want 4 players of 4", seats, room.maxPlayers)
+	}
+	if room.CanJoin(SeatHuman, "client-gus") {
+		t.Error("a fifth player joined a room of four")
+	}
+}
+
+func TestCanJoin(t *testing.T) {
+	clock := newFakeClock()
+	room := NewGameRoom("r1", "Room", RoomTypeScheduled, clock)
+	room.maxPlayers = 3
+	room.clients["client-ann"] = &Client{ID: "client-ann", Name: "Ann"}
+	room.clients["client-spy"] = &Client{ID: "client-spy", Name: "Spy", Spectator: true}
+	room.reserveSeat("client-bea", clock.Now().Add(time.Minute))
+	room.reserveSeat("client-old", clock.Now().Add(-time.Minute))
+	room.botSeats = 1
+
+	// Ann and Bea's held seat take two of three; spectators and an
+	// expired hold take none, and bots only if they take seats
+	if seats := room.Seats(); seats.Humans != 1 || seats.Reserved != 1 || seats.Spectators != 1 || seats.Open != 1 {
+		t.Fatalf("seats = %+v, want 1 human, 1 reserved, 1 spectator, 1 open", seats)
+	}
+	for _, tc := range []struct {
+		role     SeatRole
+		clientID string
+		want     bool
+	}{
+		{SeatHuman, "client-cy", true},
+		{SeatBot, "", true},
+		{SeatSpectator, "client-di", true},
+	} {
+		if got := room.CanJoin(tc.role, tc.clientID); got != tc.want {
+			t.Errorf("with a seat open, CanJoin(%s, %q) = %v", tc.role, tc.clientID, got)
+		}
+	}
+
+	room.botsTakeSeats = true
+	if seats := room.Seats(); seats.Open != 0 {
+		t.Fatalf("with the bot seated, open = %d, want 0", seats.Open)
+	}
+	for _, tc := range []struct {
+		role     SeatRole
+		clientID string
+		want     bool
+	}{
+		{SeatHuman, "client-cy", false},
+		{SeatBot, "", false},
+		{SeatSpectator, "client-di", true},
+		{SeatHuman, "client-ann", true}, // a second tab
+		{SeatHuman, "client-bea", true}, // back within the grace period
+		{SeatHuman, "client-old", false},
+	} {
+		if got := room.CanJoin(tc.role, tc.clientID); got != tc.want {
+			t.Errorf("full, CanJoin(%s, %q) = %v, want %v", tc.role, tc.clientID, got, tc.want)
+		}
+	}
+
+	room.maxPlayers = 0
+	if !room.CanJoin(SeatHuman, "client-cy") || !room.CanJoin(SeatBot, "") || room.Seats().Open != -1 {
+		t.Error("an unlimited room turned someone away")
+	}
+}
+
+func TestFullRoomTakesSpectatorsOnly(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	for _, name := range []string{"Ann", "Bea", "Cy", "Di"} {
+		ts.dial(t, "name="+name+"&room="+room.id)
+	}
+
+	resp, err := http.Get(ts.url + "/ws?v=2&preflight=1&name=Eve&room=" + room.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("a fifth player joining a room of four: %d, want 409", resp.StatusCode)
+	}
+
+	spy := ts.dial(t, "name=Spy&spectate=1&room="+room.id)
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if c := room.clients[spy.clientID]; c == nil || !c.Spectator {
+		t.Errorf("the spectator wasn't let in to watch: %+v", c)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// NPCSeedConfig controls the flavor loot sites and graves placed on the open
+// trail the first time a server starts with no saved state.
+type NPCSeedConfig struct {
+	Sites  int // abandoned NPC wagons with supplies
+	Graves int // NPC graves with an epitaph and nothing to scavenge
+
+	// Upper bounds for the decayed supplies left in each NPC wagon.
+	MaxFood     float64
+	MaxBullets  float64
+	MaxClothing float64
+	MaxMisc     float64
+	MaxCash     float64
+}
+
+// defaultNPCSeed is a handful of modest sites spread along the trail.
+var defaultNPCSeed = NPCSeedConfig{
+	Sites:       4,
+	Graves:      3,
+	MaxFood:     40,
+	MaxBullets:  20,
+	MaxClothing: 10,
+	MaxMisc:     5,
+	MaxCash:     30,
+}
+
+var npcPartyNames = []string{
+	"the Donner-Whitfield party",
+	"the Donnelly-Crane party",
+	"the Reed & Graves company",
+	"the Breen family",
+	"the Murphy-Keseberg train",
+	"the Eddy brothers",
+	"the Hastings Cutoff party",
+}
+
+var npcEpitaphs = []string{
+	"Took the shortcut.",
+	"Died of dysentery.",
+	"Drowned fording the river.",
+	"Froze in the pass.",
+	"Bitten by a snake.",
+	"Gone ahead to Oregon.",
+}
+
+// seedNPCLootSites places NPC loot sites and graves on a persistent room's
+// trail. It does nothing if saved state was loaded or any site already
+// exists, so the seed is only ever generated once per save file.
+func (s *Server) seedNPCLootSites(room *GameRoom, cfg NPCSeedConfig) {
+	if room.stateLoaded {
+		return
+	}
+
+	room.mu.Lock()
+	if len(room.game.LootSites) > 0 || len(room.playerGames) > 0 {
+		room.mu.Unlock()
+		return
+	}
+	room.game.LootSites = generateNPCLootSites(cfg, room.game.Rand)
+	count := len(room.game.LootSites)
+	room.mu.Unlock()
+
+	if count > 0 {
+		s.saveGameState(room)
+		log.Printf("Seeded %d NPC loot sites in %s", count, room.id)
+	}
+}
+
+// generateNPCLootSites builds the NPC sites for cfg, spreading them evenly
+// along the trail with a little jitter.
+func generateNPCLootSites(cfg NPCSeedConfig, rng *rand.Rand) []game.LootSite {
+	total := cfg.Sites + cfg.Graves
+	if total <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	spacing := float64(game.TrailLength) / float64(total+1)
+	sites := make([]game.LootSite, 0, total)
+	for i := 0; i < total; i++ {
+		mileage := spacing*float64(i+1) + (rng.Float64()-0.5)*spacing/2
+		site := game.LootSite{
+			ID:            fmt.Sprintf("loot-npc-%d-%d", i, now.Unix()),
+			Mileage:       float64(int(mileage)),
+			PlayerName:    npcPartyNames[rng.Intn(len(npcPartyNames))],
+			DateCreated:   now,
+			LastDecayedAt: now,
+			IsNPC:         true,
+		}
+		// Interleave graves among the wagons rather than bunching them at the end
+		if i%2 == 1 && cfg.Graves > 0 || cfg.Sites == 0 {
+			site.Epitaph = npcEpitaphs[rng.Intn(len(npcEpitaphs))]
+			cfg.Graves--
+		} else {
+			site.Food = float64(int(rng.Float64() * cfg.MaxFood))
+			site.Bullets = float64(int(rng.Float64() * cfg.MaxBullets))
+			site.Clothing = float64(int(rng.Float64() * cfg.MaxClothing))
+			site.MiscSupplies = float64(int(rng.Float64() * cfg.MaxMisc))
+			site.Cash = float64(int(rng.Float64() * cfg.MaxCash))
+			cfg.Sites--
+		}
+		sites = append(sites, site)
+	}
+	return sites
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"online-trail/pkg/game"
+)
+
+func TestNPCSitesSpreadAlongTheTrail(t *testing.T) {
+	cfg := defaultNPCSeed
+	sites := generateNPCLootSites(cfg, rand.New(rand.NewSource(1)))
+	if len(sites) != cfg.Sites+cfg.Graves {
+		t.Fatalf("%d sites, want %d", len(sites), cfg.Sites+cfg.Graves)
+	}
+	graves, last := 0, 0.0
+	for _, site := range sites {
+		if !site.IsNPC || site.Mileage <= last || site.Mileage >= game.TrailLength {
+			t.Errorf("site %+v isn't an NPC site further along the trail than %.0f", site, last)
+		}
+		last = site.Mileage
+		if site.Epitaph != "" {
+			graves++
+			if site.Food+site.Bullets+site.Clothing+site.MiscSupplies+site.Cash != 0 {
+				t.Errorf("grave %s holds supplies: %+v", site.ID, site)
+			}
+			continue
+		}
+		if site.Food > cfg.MaxFood || site.Bullets > cfg.MaxBullets || site.Clothing > cfg.MaxClothing ||
+			site.MiscSupplies > cfg.MaxMisc || site.Cash > cfg.MaxCash {
+			t.Errorf("wagon %s holds more than the seed allows: %+v", site.ID, site)
+		}
+	}
+	if graves != cfg.Graves {
+		t.Errorf("%d graves, want %d", graves, cfg.Graves)
+	}
+
+	if sites := generateNPCLootSites(NPCSeedConfig{}, rand.New(rand.NewSource(1))); len(sites) != 0 {
+		t.Errorf("an empty seed made %d sites", len(sites))
+	}
+}
+
+func TestFreshTrailIsSeededOnce(t *testing.T) {
+	cfg := testConfig(t)
+	s := startServer(t, cfg)
+	room := s.GetRoom(s.defaultRoomID)
+	s.seedNPCLootSites(room, s.npcSeed)
+	seeded := append([]game.LootSite(nil), room.game.LootSites...)
+	if len(seeded) != s.npcSeed.Sites+s.npcSeed.Graves {
+		t.Fatalf("a fresh trail got %d sites, want %d", len(seeded), s.npcSeed.Sites+s.npcSeed.Graves)
+	}
+	s.seedNPCLootSites(room, s.npcSeed)
+	if len(room.game.LootSites) != len(seeded) {
+		t.Errorf("seeding again made %d sites", len(room.game.LootSites))
+	}
+
+	// After a restart the saved sites are loaded, not seeded anew, even once
+	// every one of them has been looted away
+	s = startServer(t, cfg)
+	room = s.GetRoom(s.defaultRoomID)
+	s.seedNPCLootSites(room, s.npcSeed)
+	if len(room.game.LootSites) != len(seeded) || room.game.LootSites[0].ID != seeded[0].ID {
+		t.Fatalf("after a restart the trail has %+v, want the saved %+v", room.game.LootSites, seeded)
+	}
+	room.game.LootSites = nil
+	s.saveGameState(room)
+	s = startServer(t, cfg)
+	room = s.GetRoom(s.defaultRoomID)
+	s.seedNPCLootSites(room, s.npcSeed)
+	if len(room.game.LootSites) != 0 {
+		t.Errorf("a saved trail with no sites left was seeded again with %d", len(room.game.LootSites))
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// testConfig is the default configuration, saving into a temporary
+// directory.
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg := defaultConfig()
+	cfg.DataPath = t.TempDir()
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+	return &cfg
+}
+
+// startServer creates a server for cfg. Background saves are waited for
+// when the test ends, before its data directory is removed.
+func startServer(t *testing.T, cfg *Config) *Server {
+	t.Helper()
+	s := NewServer(cfg)
+	t.Cleanup(s.saves.Wait)
+	return s
+}
+
+// newTestServer returns a server with the default configuration, saving
+// into a temporary directory, and no hub.
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	return startServer(t, testConfig(t))
+}
+
+// newTestRoom creates a party room owned by a fresh session.
+func newTestRoom(t *testing.T, s *Server) *GameRoom {
+	t.Helper()
+	room, err := s.CreateRoom("Test room", "", &Session{ID: "creator"}, RoomTypeScheduled, 4, 0, false, RoomRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return room
+}
+
+// testSite is a server on a local port, with its hub running.
+type testSite struct {
+	*Server
+	hub *Hub
+	url string
+}
+
+func newTestSite(t *testing.T) *testSite {
+	t.Helper()
+	cfg := testConfig(t)
+	s := startServer(t, cfg)
+	hub := NewHub(s, cfg)
+	s.hub = hub
+	go hub.Run()
+	srv := httptest.NewServer(NewRouter(s, hub))
+	t.Cleanup(srv.Close)
+	return &testSite{Server: s, hub: hub, url: srv.URL}
+}
+
+// testConn is a websocket to a testSite.
+type testConn struct {
+	*websocket.Conn
+	t        *testing.T
+	clientID string
+}
+
+// dial joins over /ws with the query, such as "name=Ann&room=r1", and waits
+// for the server to say who the client is.
+func (ts *testSite) dial(t *testing.T, query string) *testConn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(ts.url, "http") + "/ws?v=2&" + query
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("dial %s: %v (status %d)", query, err, status)
+	}
+	t.Cleanup(func() { conn.Close() })
+	c := &testConn{Conn: conn, t: t}
+	c.clientID, _ = c.await("your_id")["client_id"].(string)
+	return c
+}
+
+func (c *testConn) send(msg map[string]interface{}) {
+	c.t.Helper()
+	if err := c.WriteJSON(msg); err != nil {
+		c.t.Fatalf("send %v: %v", msg["type"], err)
+	}
+}
+
+// await reads messages until one of the given type arrives.
+func (c *testConn) await(kind string) map[string]interface{} {
+	c.t.Helper()
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg map[string]interface{}
+		if err := c.ReadJSON(&msg); err != nil {
+			c.t.Fatalf("waiting for %s: %v", kind, err)
+		}
+		if msg["type"] == kind {
+			return msg
+		}
+	}
+}
+
+// sync returns once the server has handled everything sent so far.
+func (c *testConn) sync() {
+	c.t.Helper()
+	c.send(map[string]interface{}{"type": "ping", "t": 1})
+	c.await("pong")
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"sync"
 	"time"
 )
@@ -19,24 +22,50 @@ type Session struct {
 
 type SessionManager struct {
 	sessions map[string]*Session
+	// Sessions saved with a room, by hashSessionID, waiting for their
+	// cookie to come back after a restart
+	restored map[string]*restoredSession
+	clock    Clock
 	mu       sync.RWMutex
 }
 
-func NewSessionManager() *SessionManager {
+// restoredSession is a saved session whose ID is known only by its hash.
+type restoredSession struct {
+	Session
+	hash string
+}
+
+// sessionTTL is how long a session is kept once its player has gone, as
+// long as the cookie that carries it.
+const sessionTTL = 30 * 24 * time.Hour
+
+// sessionSweepInterval is how often sessions past sessionTTL are swept.
+const sessionSweepInterval = time.Hour
+
+func NewSessionManager(clock Clock) *SessionManager {
 	return &SessionManager{
 		sessions: make(map[string]*Session),
+		restored: make(map[string]*restoredSession),
+		clock:    clock,
 	}
 }
 
+// hashSessionID is the form a session ID is saved in.
+func hashSessionID(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:])
+}
+
 func (sm *SessionManager) CreateSession(name string, clientID string, roomID string) string {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
+	now := sm.clock.Now()
 	// Check if session already exists (by checking all sessions for matching ClientID)
 	for _, s := range sm.sessions {
 		if s.ClientID == clientID {
 			// Restore existing session
-			s.LastSeen = time.Now()
+			s.LastSeen = now
 			s.Alive = true
 			s.RoomID = roomID
 			return s.ID
@@ -48,7 +77,7 @@ func (sm *SessionManager) CreateSession(name string, clientID string, roomID str
 		if s.Name == name && s.Alive {
 			// Update existing session
 			s.ClientID = clientID
-			s.LastSeen = time.Now()
+			s.LastSeen = now
 			s.Alive = true
 			s.RoomID = roomID
 			return s.ID
@@ -62,13 +91,89 @@ func (sm *SessionManager) CreateSession(name string, clientID string, roomID str
 		Name:      name,
 		ClientID:  clientID,
 		RoomID:    roomID,
-		CreatedAt: time.Now(),
-		LastSeen:  time.Now(),
+		CreatedAt: now,
+		LastSeen:  now,
 		Alive:     true,
 	}
 	return sessionID
 }
 
+// NewSession always starts a fresh session, never handing back one that
+// matches by name, for signing in over HTTP where nothing proves the caller
+// is the player already using that name.
+func (sm *SessionManager) NewSession(name, clientID, roomID string) string {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sessionID := GenerateSecureID()
+	now := sm.clock.Now()
+	sm.sessions[sessionID] = &Session{
+		ID:        sessionID,
+		Name:      name,
+		ClientID:  clientID,
+		RoomID:    roomID,
+		CreatedAt: now,
+		LastSeen:  now,
+		Alive:     true,
+	}
+	return sessionID
+}
+
+// RestoreSession brings back a session saved with a room by the hash of its
+// ID, so its cookie still resumes the player after a restart.
+func (sm *SessionManager) RestoreSession(hash, name, clientID, roomID string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if _, ok := sm.restored[hash]; ok {
+		return
+	}
+	now := sm.clock.Now()
+	sm.restored[hash] = &restoredSession{
+		Session: Session{
+			Name:      name,
+			ClientID:  clientID,
+			RoomID:    roomID,
+			CreatedAt: now,
+			LastSeen:  now,
+			Alive:     true,
+		},
+		hash: hash,
+	}
+}
+
+// claimRestored turns a restored session back into a live one when its
+// cookie shows up.
+func (sm *SessionManager) claimRestored(sessionID string) (*Session, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if s, ok := sm.sessions[sessionID]; ok {
+		return s, s.Alive
+	}
+	hash := hashSessionID(sessionID)
+	r, ok := sm.restored[hash]
+	if !ok {
+		return nil, false
+	}
+	delete(sm.restored, hash)
+	s := r.Session
+	s.ID = sessionID
+	sm.sessions[sessionID] = &s
+	return &s, true
+}
+
+// PendingRestores returns the restored sessions in roomID whose cookie
+// hasn't come back yet.
+func (sm *SessionManager) PendingRestores(roomID string) []*restoredSession {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	var pending []*restoredSession
+	for _, r := range sm.restored {
+		if r.RoomID == roomID {
+			pending = append(pending, r)
+		}
+	}
+	return pending
+}
+
 func (sm *SessionManager) GetSession(sessionID string) *Session {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
@@ -80,7 +185,7 @@ func (sm *SessionManager) UpdateClient(sessionID, clientID string) {
 	defer sm.mu.Unlock()
 	if s, ok := sm.sessions[sessionID]; ok {
 		s.ClientID = clientID
-		s.LastSeen = time.Now()
+		s.LastSeen = sm.clock.Now()
 	}
 }
 
@@ -92,16 +197,57 @@ func (sm *SessionManager) UpdateRoomID(sessionID, roomID string) {
 	}
 }
 
+// ClearRoom forgets roomID on every session in it, for when the room is
+// removed; those sessions resume into the default room instead. Returns the
+// number of sessions cleared.
+func (sm *SessionManager) ClearRoom(roomID string) int {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	count := 0
+	for _, s := range sm.sessions {
+		if s.RoomID == roomID {
+			s.RoomID = ""
+			count++
+		}
+	}
+	return count
+}
+
 func (sm *SessionManager) RemoveClient(clientID string) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
+	now := sm.clock.Now()
 	for _, sess := range sm.sessions {
 		if sess.ClientID == clientID {
 			sess.Alive = false
+			sess.LastSeen = now
 		}
 	}
 }
 
+// Sweep forgets the sessions whose player has been gone for sessionTTL and
+// the restored ones whose cookie never came back in that time. Returns the
+// number of sessions removed.
+func (sm *SessionManager) Sweep() int {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	now := sm.clock.Now()
+	count := 0
+	for id, s := range sm.sessions {
+		if !s.Alive && now.Sub(s.LastSeen) > sessionTTL {
+			delete(sm.sessions, id)
+			count++
+		}
+	}
+	for hash, r := range sm.restored {
+		if now.Sub(r.LastSeen) > sessionTTL {
+			delete(sm.restored, hash)
+			count++
+		}
+	}
+	return count
+}
+
 func (sm *SessionManager) GetActiveSessions() []*Session {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
@@ -117,9 +263,12 @@ func (sm *SessionManager) GetActiveSessions() []*Session {
 
 func (sm *SessionManager) GetSessionByID(sessionID string) (*Session, bool) {
 	sm.mu.RLock()
-	defer sm.mu.RUnlock()
 	s, ok := sm.sessions[sessionID]
-	if !ok || !s.Alive {
+	sm.mu.RUnlock()
+	if !ok {
+		return sm.claimRestored(sessionID)
+	}
+	if !s.Alive {
 		return nil, false
 	}
 	return s, true
@@ -130,11 +279,53 @@ func (sm *SessionManager) InvalidateSession(sessionID string) {
 	defer sm.mu.Unlock()
 	if s, ok := sm.sessions[sessionID]; ok {
 		s.Alive = false
+		s.LastSeen = sm.clock.Now()
 	}
 }
 
+// SignPayload returns the HMAC-SHA256 of payload under key, hex-encoded, for
+// data handed to a player that must come back unaltered.
+func SignPayload(key, payload []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidSignature reports whether sig is payload's SignPayload under key.
+func ValidSignature(key, payload []byte, sig string) bool {
+	want, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
 func GenerateSecureID() string {
 	b := make([]byte, 32)
 	rand.Read(b)
 	return base64.URLEncoding.EncodeToString(b)
 }
+
+// DeleteSessionsFor removes every session belonging to a client ID, live or
+// still waiting to be resumed since a restart. Returns the number of
+// sessions removed.
+func (sm *SessionManager) DeleteSessionsFor(clientID string) int {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	count := 0
+	for id, s := range sm.sessions {
+		if s.ClientID == clientID {
+			delete(sm.sessions, id)
+			count++
+		}
+	}
+	for hash, r := range sm.restored {
+		if r.ClientID == clientID {
+			delete(sm.restored, hash)
+			count++
+		}
+	}
+	return count
+}
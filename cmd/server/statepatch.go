@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// State patches. A client that connects with ?patch=1 is sent a full state
+// first and then only what changed, as
+// {"type":"state_patch","seq":N,"patch":{...}}. A top-level key in the
+// patch replaces the old value, or removes it if null; player_states is
+// patched one level down the same way, player by player. Every state
+// message to the client carries the next seq, so one lost to a full send
+// buffer shows up as a gap; the client then asks for "state_full" and is
+// sent a full state again. A full state also goes out every fullStateEvery
+// messages, in case a patch was applied wrong.
+const fullStateEvery = 30
+
+// stateView is a state split into its top-level keys, and player_states
+// into players, as raw JSON for comparing.
+type stateView struct {
+	keys    map[string]json.RawMessage
+	players map[string]json.RawMessage
+}
+
+// splitState splits a state for diffing.
+func splitState(stateJSON []byte) (*stateView, error) {
+	view := &stateView{}
+	if err := json.Unmarshal(stateJSON, &view.keys); err != nil {
+		return nil, err
+	}
+	if raw, ok := view.keys["player_states"]; ok {
+		if err := json.Unmarshal(raw, &view.players); err != nil {
+			return nil, err
+		}
+	}
+	return view, nil
+}
+
+// diffState returns the patch from old to new, empty if nothing changed.
+func diffState(old, new *stateView) map[string]interface{} {
+	patch := make(map[string]interface{})
+	for key, raw := range new.keys {
+		if key == "player_states" && old.players != nil && new.players != nil {
+			if players := diffRaw(old.players, new.players); len(players) > 0 {
+				patch[key] = players
+			}
+			continue
+		}
+		if !bytes.Equal(old.keys[key], raw) {
+			patch[key] = raw
+		}
+	}
+	for key := range old.keys {
+		if _, ok := new.keys[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	return patch
+}
+
+// diffRaw returns the entries of new that differ from old, with nil for
+// those that are gone.
+func diffRaw(old, new map[string]json.RawMessage) map[string]interface{} {
+	patch := make(map[string]interface{})
+	for key, raw := range new {
+		if !bytes.Equal(old[key], raw) {
+			patch[key] = raw
+		}
+	}
+	for key := range old {
+		if _, ok := new[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	return patch
+}
+
+// stateMessage builds a full state message: state_gz if gz holds the state
+// gzipped, state otherwise. seq is left out when zero, for clients that
+// don't take patches.
+func stateMessage(stateJSON []byte, gz string, seq int) []byte {
+	msg := map[string]interface{}{
+		"type": "state",
+		"data": json.RawMessage(stateJSON),
+	}
+	if gz != "" {
+		msg = map[string]interface{}{
+			"type":     "state_gz",
+			"data_b64": gz,
+		}
+	}
+	if seq > 0 {
+		msg["seq"] = seq
+	}
+	msgJSON, err := json.Marshal(msg)
+	if err != nil {
+		return nil
+	}
+	return msgJSON
+}
+
+// patchTracker remembers what a patching client was last sent.
+type patchTracker struct {
+	mu        sync.Mutex
+	last      *stateView
+	seq       int
+	sinceFull int
+}
+
+// next returns the message that brings the client up to view: a patch, or
+// a full state when the client has none to patch or is due one. It returns
+// nil if nothing changed.
+func (t *patchTracker) next(view *stateView, stateJSON []byte, gz string) []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.last != nil && t.sinceFull < fullStateEvery-1 {
+		patch := diffState(t.last, view)
+		if len(patch) == 0 {
+			return nil
+		}
+		msgJSON, err := json.Marshal(map[string]interface{}{
+			"type":  "state_patch",
+			"seq":   t.seq + 1,
+			"patch": patch,
+		})
+		if err != nil {
+			return nil
+		}
+		t.seq++
+		t.sinceFull++
+		t.last = view
+		return msgJSON
+	}
+
+	msgJSON := stateMessage(stateJSON, gz, t.seq+1)
+	if msgJSON == nil {
+		return nil
+	}
+	t.seq++
+	t.sinceFull = 0
+	t.last = view
+	return msgJSON
+}
+
+// resync forgets what the client was sent, so it gets a full state next.
+func (t *patchTracker) resync() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// patchClient rebuilds states from a tracker's messages the way the web
+// page does.
+type patchClient struct {
+	t     *testing.T
+	state map[string]interface{}
+	seq   int
+}
+
+// apply applies a state or state_patch message, checking its seq follows
+// on from the last.
+func (c *patchClient) apply(msgJSON []byte) {
+	c.t.Helper()
+	var msg struct {
+		Type  string                 `json:"type"`
+		Seq   int                    `json:"seq"`
+		Data  map[string]interface{} `json:"data"`
+		Patch map[string]interface{} `json:"patch"`
+	}
+	if err := json.Unmarshal(msgJSON, &msg); err != nil {
+		c.t.Fatal(err)
+	}
+	if msg.Seq != c.seq+1 {
+		c.t.Fatalf("seq %d after %d", msg.Seq, c.seq)
+	}
+	c.seq = msg.Seq
+	switch msg.Type {
+	case "state":
+		c.state = msg.Data
+	case "state_patch":
+		if c.state == nil {
+			c.t.Fatal("a patch before any full state")
+		}
+		patchInto(c.state, msg.Patch, true)
+	default:
+		c.t.Fatalf("message type %s", msg.Type)
+	}
+}
+
+func patchInto(state, patch map[string]interface{}, top bool) {
+	for key, value := range patch {
+		switch {
+		case value == nil:
+			delete(state, key)
+		case top && key == "player_states":
+			players, _ := state[key].(map[string]interface{})
+			if players == nil {
+				players = make(map[string]interface{})
+				state[key] = players
+			}
+			patchInto(players, value.(map[string]interface{}), false)
+		default:
+			state[key] = value
+		}
+	}
+}
+
+// send hands the tracker the next state and returns its message.
+func send(t *testing.T, tracker *patchTracker, state map[string]interface{}) []byte {
+	t.Helper()
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	view, err := splitState(stateJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tracker.next(view, stateJSON, "")
+}
+
+// sameState compares states as the client sees them.
+func sameState(t *testing.T, got, want map[string]interface{}) bool {
+	t.Helper()
+	var a, b interface{}
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	json.Unmarshal(gotJSON, &a)
+	json.Unmarshal(wantJSON, &b)
+	return reflect.DeepEqual(a, b)
+}
+
+func TestPatchesRebuildTheState(t *testing.T) {
+	tracker := &patchTracker{}
+	client := &patchClient{t: t}
+	states := []map[string]interface{}{
+		{"turn_number": 1, "food": 100, "player_states": map[string]interface{}{
+			"client-ann": map[string]interface{}{"mileage": 0},
+		}},
+		// A key changes, another appears, and a player joins
+		{"turn_number": 2, "food": 100, "hunt_word": "BANG", "player_states": map[string]interface{}{
+			"client-ann": map[string]interface{}{"mileage": 0},
+			"client-bea": map[string]interface{}{"mileage": 0},
+		}},
+		// A key goes, and one player moves while the other leaves
+		{"turn_number": 2, "food": 80, "player_states": map[string]interface{}{
+			"client-bea": map[string]interface{}{"mileage": 90},
+		}},
+		// player_states goes altogether
+		{"turn_number": 3, "food": 80},
+	}
+	for i, state := range states {
+		msg := send(t, tracker, state)
+		if msg == nil {
+			t.Fatalf("state %d sent nothing", i)
+		}
+		client.apply(msg)
+		if !sameState(t, client.state, state) {
+			t.Fatalf("after state %d the client has %v, want %v", i, client.state, state)
+		}
+	}
+
+	if msg := send(t, tracker, states[len(states)-1]); msg != nil {
+		t.Errorf("an unchanged state sent %s", msg)
+	}
+}
+
+func TestPatchOnlyCarriesWhatChanged(t *testing.T) {
+	tracker := &patchTracker{}
+	send(t, tracker, map[string]interface{}{"turn_number": 1, "food": 100, "player_states": map[string]interface{}{
+		"client-ann": map[string]interface{}{"mileage": 0},
+		"client-bea": map[string]interface{}{"mileage": 0},
+	}})
+	msg := send(t, tracker, map[string]interface{}{"turn_number": 1, "food": 90, "player_states": map[string]interface{}{
+		"client-ann": map[string]interface{}{"mileage": 0},
+		"client-bea": map[string]interface{}{"mileage": 85},
+	}})
+	var got struct {
+		Type  string
+		Patch map[string]interface{}
+	}
+	if err := json.Unmarshal(msg, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"food":          float64(90),
+		"player_states": map[string]interface{}{"client-bea": map[string]interface{}{"mileage": float64(85)}},
+	}
+	if got.Type != "state_patch" || !reflect.DeepEqual(got.Patch, want) {
+		t.Errorf("sent %s, want a patch of %v", msg, want)
+	}
+}
+
+func TestFullStatesGoOutRegularly(t *testing.T) {
+	tracker := &patchTracker{}
+	client := &patchClient{t: t}
+	for i := 0; i < 2*fullStateEvery+1; i++ {
+		msg := send(t, tracker, map[string]interface{}{"turn_number": i})
+		client.apply(msg)
+		var kind struct{ Type string }
+		json.Unmarshal(msg, &kind)
+		if want := i%fullStateEvery == 0; (kind.Type == "state") != want {
+			t.Errorf("message %d is a %s", i+1, kind.Type)
+		}
+	}
+}
+
+func TestResyncSendsAFullState(t *testing.T) {
+	tracker := &patchTracker{}
+	client := &patchClient{t: t}
+	client.apply(send(t, tracker, map[string]interface{}{"turn_number": 1}))
+	client.apply(send(t, tracker, map[string]interface{}{"turn_number": 2}))
+
+	tracker.resync()
+	state := map[string]interface{}{"turn_number": 2}
+	msg := send(t, tracker, state)
+	if msg == nil {
+		t.Fatal("a resync of an unchanged state sent nothing")
+	}
+	var kind struct{ Type string }
+	json.Unmarshal(msg, &kind)
+	if kind.Type != "state" {
+		t.Errorf("after a resync the client got a %s, want a full state", kind.Type)
+	}
+	client.apply(msg)
+	if !sameState(t, client.state, state) {
+		t.Errorf("the client has %v, want %v", client.state, state)
+	}
+}
+
+func TestPatchingClientOverTheWire(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	ann := ts.dial(t, "name=Ann&patch=1&room="+room.id)
+	first := ann.await("state")
+	if first["seq"] != float64(1) {
+		t.Fatalf("first state seq = %v, want 1", first["seq"])
+	}
+
+	ts.dial(t, "name=Bea&room="+room.id)
+	patch := ann.await("state_patch")
+	changes, _ := patch["patch"].(map[string]interface{})
+	if _, ok := changes["players"]; !ok || patch["seq"] != float64(2) {
+		t.Errorf("Bea joining sent Ann %v, want a patch of players with seq 2", patch)
+	}
+	if _, ok := changes["room_name"]; ok {
+		t.Error("the patch carries the unchanged room name")
+	}
+
+	ann.send(map[string]interface{}{"type": "state_full"})
+	full := ann.await("state")
+	if data, _ := full["data"].(map[string]interface{}); data["room_name"] != "Test room" {
+		t.Errorf("state_full sent %v, want the whole state", full)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"online-trail/static"
+)
+
+// staticHandler serves the web client: the copy embedded in the binary, or
+// the files in dir if one is given, for working on the page without a
+// rebuild. Paths can't reach outside either, and directories aren't listed.
+func staticHandler(dir string) http.Handler {
+	var files fs.FS = static.Files
+	if dir != "" {
+		files = os.DirFS(dir)
+		log.Printf("Serving the web client from %s", dir)
+	}
+	fileServer := http.FileServer(http.FS(files))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" && strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/" || r.URL.Path == "/index.html" {
+			// The page must match the server it talks to
+			w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+			w.Header().Set("Pragma", "no-cache")
+		} else {
+			w.Header().Set("Cache-Control", "public, max-age=3600")
+		}
+		fileServer.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStaticServesTheEmbeddedPage(t *testing.T) {
+	ts := newTestSite(t)
+	resp, err := http.Get(ts.url + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "<html") {
+		t.Fatalf("/: %d, %d bytes", resp.StatusCode, len(body))
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q, want text/html", ct)
+	}
+	if cc := resp.Header.Get("Cache-Control"); !strings.Contains(cc, "no-cache") {
+		t.Errorf("the page is served with Cache-Control %q, want no-cache", cc)
+	}
+}
+
+func TestStaticServesNothingElse(t *testing.T) {
+	ts := newTestSite(t)
+	for _, path := range []string{
+		"/go.mod",
+		"/embed.go",
+		// Tests run in cmd/server, so these are next to the working
+		// directory
+		"/main.go",
+		"/testdata/saves/rooms_v1.json",
+		"/data/",
+		"/data/game_state.json",
+		"/cmd/server/main.go",
+		// The mux cleans this to /cmd/server/main.go and redirects there
+		"/../cmd/server/main.go",
+		"/static/",
+	} {
+		resp, err := http.Get(ts.url + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: %d, want 404", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestStaticDirStaysInsideTheDirectory(t *testing.T) {
+	root := t.TempDir()
+	site := filepath.Join(root, "site")
+	if err := os.Mkdir(site, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{
+		filepath.Join(site, "index.html"): "<html>working copy</html>",
+		filepath.Join(site, "app.css"):    "body {}",
+		filepath.Join(root, "secret.txt"): "not for the web",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := staticHandler(site)
+
+	for _, tc := range []struct {
+		path  string
+		want  int
+		cache string
+	}{
+		{"/", http.StatusOK, "no-cache"},
+		{"/app.css", http.StatusOK, "max-age=3600"},
+		{"/../secret.txt", http.StatusNotFound, ""},
+	} {
+		// Straight to the handler, so the mux doesn't clean the path first
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.URL.Path = tc.path
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tc.want {
+			t.Errorf("%s: %d, want %d", tc.path, w.Code, tc.want)
+		}
+		if strings.Contains(w.Body.String(), "not for the web") {
+			t.Errorf("%s served a file outside the directory", tc.path)
+		}
+		if tc.cache != "" && !strings.Contains(w.Header().Get("Cache-Control"), tc.cache) {
+			t.Errorf("%s: Cache-Control %q, want %s", tc.path, w.Header().Get("Cache-Control"), tc.cache)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// tlsSettings is how the server serves HTTPS: from a certificate it is
+// given, from one fetched for autocertDomain from Let's Encrypt, or not at
+// all when neither is set.
+type tlsSettings struct {
+	certFile, keyFile string
+	autocertDomain    string
+	httpsPort         string
+}
+
+func (t tlsSettings) enabled() bool {
+	return t.certFile != "" || t.autocertDomain != ""
+}
+
+func (t tlsSettings) validate() error {
+	if (t.certFile == "") != (t.keyFile == "") {
+		return errors.New("TLS_CERT and TLS_KEY must be set together")
+	}
+	if t.certFile != "" && t.autocertDomain != "" {
+		return errors.New("use either TLS_CERT/TLS_KEY or AUTOCERT_DOMAIN, not both")
+	}
+	return nil
+}
+
+// newHTTPServer is an http.Server with the timeouts every listener uses.
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  120 * time.Second,
+	}
+}
+
+// serveHTTP starts serving handler and returns the servers it started, for
+// shutting down. Without TLS, handler is served on httpPort. With it,
+// handler is served on httpsPort and httpPort redirects there; under
+// autocert it also answers Let's Encrypt's challenges, with the
+// certificates cached under dataPath.
+func serveHTTP(handler http.Handler, httpPort string, t tlsSettings, dataPath string) []*http.Server {
+	if !t.enabled() {
+		srv := newHTTPServer(":"+httpPort, handler)
+		go serve(srv, srv.ListenAndServe)
+		log.Printf("HTTP server listening on :%s", httpPort)
+		return []*http.Server{srv}
+	}
+
+	redirect := redirectToHTTPS(t.httpsPort)
+	secure := newHTTPServer(":"+t.httpsPort, handler)
+	if t.autocertDomain != "" {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(t.autocertDomain),
+			Cache:      autocert.DirCache(filepath.Join(dataPath, "autocert")),
+		}
+		secure.TLSConfig = m.TLSConfig()
+		redirect = m.HTTPHandler(redirect)
+		log.Printf("Fetching certificates for %s automatically", t.autocertDomain)
+	} else {
+		secure.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	plain := newHTTPServer(":"+httpPort, redirect)
+
+	go serve(secure, func() error { return secure.ListenAndServeTLS(t.certFile, t.keyFile) })
+	go serve(plain, plain.ListenAndServe)
+	log.Printf("HTTPS server listening on :%s, redirecting from :%s", t.httpsPort, httpPort)
+	return []*http.Server{secure, plain}
+}
+
+// serve runs a listener until it is shut down.
+func serve(srv *http.Server, listen func() error) {
+	if err := listen(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+// redirectToHTTPS sends a plain HTTP request to the same page over HTTPS.
+func redirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// requestIsSecure reports whether the request came over HTTPS, directly
+// or through a proxy that terminated it, so cookies can be marked Secure.
+func requestIsSecure(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
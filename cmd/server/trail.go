@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"online-trail/pkg/game"
+)
+
+// TrailInfo is everything the web UI needs to draw the trail map: the
+// landmarks and, for a continuous room, where everyone is.
+type TrailInfo struct {
+	Length    int             `json:"length"`
+	Landmarks []game.Landmark `json:"landmarks"`
+	RoomID    string          `json:"room_id,omitempty"`
+	Players   []TrailPosition `json:"players"`
+	LootSites []TrailLootSite `json:"loot_sites"`
+}
+
+// TrailPosition is a live player's place on the trail.
+type TrailPosition struct {
+	Name    string  `json:"name"`
+	Mileage float64 `json:"mileage"`
+}
+
+// TrailLootSite is an abandoned wagon or grave on the map.
+type TrailLootSite struct {
+	ID      string  `json:"id"`
+	Mileage float64 `json:"mileage"`
+	Looted  bool    `json:"looted"`
+	Grave   bool    `json:"grave,omitempty"`
+}
+
+// TrailInfo builds the map for a room. Rooms that aren't continuous get the
+// landmarks only. It reports false if there is no such room.
+func (s *Server) TrailInfo(roomID string) (TrailInfo, bool) {
+	info := TrailInfo{
+		Length:    game.TrailLength,
+		Landmarks: game.Landmarks(),
+		Players:   []TrailPosition{},
+		LootSites: []TrailLootSite{},
+	}
+	if roomID == "" {
+		return info, true
+	}
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return TrailInfo{}, false
+	}
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if room.closed {
+		return TrailInfo{}, false
+	}
+	info.RoomID = room.id
+	if room.roomType != RoomTypeContinuous {
+		return info, true
+	}
+
+	for _, c := range room.clients {
+		if c.Spectator {
+			continue
+		}
+		g, p := s.getPlayerGame(room, c.ID)
+		if g == nil || p == nil || !p.Alive {
+			continue
+		}
+		info.Players = append(info.Players, TrailPosition{Name: c.Name, Mileage: g.Mileage})
+	}
+	sort.Slice(info.Players, func(i, j int) bool {
+		return info.Players[i].Mileage > info.Players[j].Mileage
+	})
+	for _, site := range room.game.LootSites {
+		info.LootSites = append(info.LootSites, TrailLootSite{
+			ID:      site.ID,
+			Mileage: site.Mileage,
+			Looted:  site.IsLooted,
+			Grave:   site.Epitaph != "",
+		})
+	}
+	return info, true
+}
+
+// handleTrail serves GET /api/trail. The room query parameter picks the
+// room; it defaults to the server's default room.
+func (s *Server) handleTrail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	roomID := r.URL.Query().Get("room")
+	if roomID == "" {
+		roomID = s.defaultRoomID
+	}
+	info, ok := s.TrailInfo(roomID)
+	if !ok {
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(info)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// maxTrailNewsPerHour is how much news one client's wagon may make in an
+// hour, so restarting over and over can't flood the room.
+const maxTrailNewsPerHour = 5
+
+// queueTrailNews holds the news a continuous player's wagon made for the
+// next BroadcastTrailNewsTo, as far as their hourly allowance goes. News
+// over the allowance is dropped, not saved for later.
+// NOTE: caller must hold room.mu.
+func (s *Server) queueTrailNews(room *GameRoom, clientID string, g *game.GameState, p *game.Player) {
+	if room.roomType != RoomTypeContinuous {
+		return
+	}
+	news := g.TrailNews(p)
+	if len(news) == 0 {
+		return
+	}
+
+	now := s.clock.Now()
+	recent := room.newsSent[clientID][:0]
+	for _, at := range room.newsSent[clientID] {
+		if now.Sub(at) < time.Hour {
+			recent = append(recent, at)
+		}
+	}
+	for _, text := range news {
+		if len(recent) >= maxTrailNewsPerHour {
+			break
+		}
+		room.trailNews = append(room.trailNews, text)
+		recent = append(recent, now)
+	}
+	room.newsSent[clientID] = recent
+}
+
+// TakeTrailNews returns the trail news waiting in the room and clears it.
+func (s *Server) TakeTrailNews(roomID string) []string {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return nil
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	news := room.trailNews
+	room.trailNews = nil
+	return news
+}
+
+// BroadcastTrailNewsTo tells the room the trail news its wagons made.
+func (h *Hub) BroadcastTrailNewsTo(roomID string) {
+	for _, text := range h.server.TakeTrailNews(roomID) {
+		h.BroadcastEventTo(roomID, "System", "trail_news", text)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"log"
+
+	"online-trail/pkg/game"
+)
+
+// LeaveNote carves a note at the client's place on the trail for the other
+// wagons in a continuous room to find.
+func (s *Server) LeaveNote(clientID, roomID, text string) (game.TrailNote, error) {
+	room := s.GetRoom(roomID)
+	if room == nil {
+		return game.TrailNote{}, errors.New("room not found")
+	}
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.roomType != RoomTypeContinuous {
+		return game.TrailNote{}, errors.New("notes can only be left in continuous rooms")
+	}
+	playerGame, player := s.getPlayerGame(room, clientID)
+	if playerGame == nil || player == nil {
+		return game.TrailNote{}, errors.New("you don't have a wagon on the trail")
+	}
+	if !player.Alive {
+		return game.TrailNote{}, errors.New("your party has perished")
+	}
+	name := player.Name
+	if c, ok := room.clients[clientID]; ok {
+		name = c.Name
+	}
+	note, err := room.game.LeaveNote(clientID, name, text, playerGame.Mileage, s.clock.Now())
+	if err != nil {
+		return game.TrailNote{}, err
+	}
+	s.saveGameStateLocked(room)
+	log.Printf("Player %s left a note at mile %.0f in room %s", name, note.Mileage, roomID)
+	return note, nil
+}
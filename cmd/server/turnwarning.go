@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// turnWarningLead is how long before a turn times out the player on the
+// clock is warned. The deadline is in every state broadcast too, but a
+// player who missed one, or is mid-thought, gets a nudge of their own.
+const turnWarningLead = 5 * time.Second
+
+// startTurnWarning arms the warning for a turn that times out after d.
+// NOTE: caller must hold room.mu.
+func (s *Server) startTurnWarning(room *GameRoom, playerID string, d time.Duration) {
+	s.stopTurnWarning(room)
+	if d <= turnWarningLead {
+		return
+	}
+	deadline := room.turnDeadline
+	room.warnTimer = s.clock.AfterFunc(d-turnWarningLead, func() {
+		s.sendTurnWarning(room, playerID, deadline)
+	})
+}
+
+// stopTurnWarning disarms the turn warning, if armed.
+// NOTE: caller must hold room.mu.
+func (s *Server) stopTurnWarning(room *GameRoom) {
+	if room.warnTimer != nil {
+		room.warnTimer.Stop()
+		room.warnTimer = nil
+	}
+}
+
+// sendTurnWarning tells playerID their turn is about to time out, if it is
+// still their turn on the same clock.
+func (s *Server) sendTurnWarning(room *GameRoom, playerID string, deadline time.Time) {
+	room.mu.Lock()
+	current := room.game.GetCurrentPlayer()
+	live := !room.closed && !room.paused && room.turnDeadline.Equal(deadline) &&
+		current != nil && current.ID == playerID
+	if live {
+		room.warnTimer = nil
+	}
+	room.mu.Unlock()
+	if !live || s.hub == nil {
+		return
+	}
+
+	left := deadline.Sub(s.clock.Now()).Round(time.Second)
+	msgJSON, err := json.Marshal(map[string]interface{}{
+		"type":         "turn_warning",
+		"seconds_left": int(left.Seconds()),
+		"deadline":     deadline.UnixMilli(),
+		"message":      fmt.Sprintf("%d seconds left!", int(left.Seconds())),
+	})
+	if err == nil {
+		s.hub.SendToClient(playerID, msgJSON)
+	}
+}
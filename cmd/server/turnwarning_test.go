@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlayerOnTheClockIsWarned(t *testing.T) {
+	ts := newTestSite(t)
+	clock := newFakeClock()
+	ts.clock = clock
+	start := clock.Now()
+	room := newTestRoom(t, ts.Server)
+	ann := ts.dial(t, "name=Ann&room="+room.id)
+	bea := ts.dial(t, "name=Bea&room="+room.id)
+	if err := ts.StartGame(room.id, ann.clientID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(ts.turnTime - turnWarningLead)
+	warning := ann.await("turn_warning")
+	if warning["seconds_left"] != float64(5) || warning["message"] != "5 seconds left!" {
+		t.Errorf("warning = %v, want 5 seconds left", warning)
+	}
+	if want := start.Add(ts.turnTime).UnixMilli(); warning["deadline"] != float64(want) {
+		t.Errorf("deadline = %v, want %d", warning["deadline"], want)
+	}
+
+	// Ann runs out of time, and Bea's turn gets a warning of its own
+	clock.Advance(turnWarningLead)
+	clock.Advance(ts.turnTime - turnWarningLead - time.Second)
+	room.mu.RLock()
+	armed := room.warnTimer != nil
+	room.mu.RUnlock()
+	if !armed {
+		t.Fatal("Bea's turn has no warning armed")
+	}
+	clock.Advance(time.Second)
+	warning = bea.await("turn_warning")
+	if want := start.Add(2 * ts.turnTime).UnixMilli(); warning["deadline"] != float64(want) {
+		t.Errorf("Bea's deadline = %v, want %d", warning["deadline"], want)
+	}
+}
+
+func TestTurnWarningIsDisarmedWithTheClock(t *testing.T) {
+	s, clock := newFakeClockServer(t)
+	room := startPartyGame(t, s)
+
+	room.mu.Lock()
+	if room.warnTimer == nil {
+		room.mu.Unlock()
+		t.Fatal("the turn started without a warning armed")
+	}
+	s.CancelTurnTimer(room)
+	disarmed := room.warnTimer == nil
+	room.mu.Unlock()
+	if !disarmed {
+		t.Error("cancelling the turn clock left its warning armed")
+	}
+
+	// Nothing re-arms it while no turn clock runs
+	clock.Advance(s.turnTime)
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	if room.warnTimer != nil {
+		t.Error("a warning was armed with no turn clock running")
+	}
+}
@@ -4,24 +4,59 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"online-trail/pkg/game"
 )
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool { return true },
+	CheckOrigin:       checkOrigin,
+	EnableCompression: true,
 }
 
 type Hub struct {
 	server     *Server
+	clock      Clock
 	clients    map[*websocket.Conn]*wsClient
 	register   chan *wsClient
 	unregister chan *websocket.Conn
-	mu         sync.RWMutex
+	// Latest connection of each client in each room. A reconnect can
+	// register before the old socket's close is noticed, and only the
+	// latest connection's close may remove the client from the room.
+	active map[connKey]*wsClient
+	// Latest connection of each client in any room
+	byID map[string]*wsClient
+	mu   sync.RWMutex
+
+	// Rooms with a state broadcast waiting to be sent, and the rooms a
+	// state is being sent to
+	pendingState map[string]*time.Timer
+	stateSends   map[string]*stateSend
+	pendingMu    sync.Mutex
+
+	// Lobby pages watching for room list changes
+	lobbyWatchers map[*wsClient]*lobbyWatch
+	lobbyDirty    chan struct{}
+	watchMu       sync.Mutex
+
+	// Largest message a client may send, in bytes
+	maxMessageBytes int64
+
+	// When the size of a large state was last logged
+	sizeLoggedAt time.Time
+	sizeLogMu    sync.Mutex
+}
+
+// connKey identifies a client's seat in one room.
+type connKey struct {
+	clientID string
+	roomID   string
 }
 
 type wsClient struct {
@@ -33,14 +68,76 @@ type wsClient struct {
 	sessionID  string
 	roomID     string
 	resumed    bool
+	spectator  bool
+	remoteIP   string
+
+	// Names of players whose chat this client has muted, and whether a
+	// player accepts whispers sent to spectators
+	mutedNames        map[string]bool
+	whisperSpectators bool
+	muteMu            sync.RWMutex
+
+	// request is the type of the message readPump is handling, named in
+	// the errors sent back
+	request string
+
+	// latency is the round trips the client's pings reported
+	latency latencyWindow
+
+	// schema is the protocol major version the client's states are built in
+	schema int
+
+	// flushOnClose leaves closing the connection to writePump, after the
+	// messages still queued, when readPump stops
+	flushOnClose bool
+
+	// registered is closed once the hub has taken the client in, so its
+	// replies have somewhere to go
+	registered chan struct{}
+
+	// gzipState is set for a client that asked for large states as
+	// state_gz rather than relying on permessage-deflate
+	gzipState bool
+
+	// patches tracks the states sent to a client that asked for patches
+	patches *patchTracker
+}
+
+// hasMuted reports whether this client muted chat from the named player.
+func (c *wsClient) hasMuted(name string) bool {
+	c.muteMu.RLock()
+	defer c.muteMu.RUnlock()
+	return c.mutedNames[strings.ToLower(name)]
 }
 
-func NewHub(server *Server) *Hub {
+// setMuted mutes or unmutes chat from the named player for this client.
+func (c *wsClient) setMuted(name string, muted bool) {
+	c.muteMu.Lock()
+	defer c.muteMu.Unlock()
+	if muted {
+		c.mutedNames[strings.ToLower(name)] = true
+	} else {
+		delete(c.mutedNames, strings.ToLower(name))
+	}
+}
+
+func NewHub(server *Server, cfg *Config) *Hub {
 	return &Hub{
 		server:     server,
+		clock:      server.clock,
 		clients:    make(map[*websocket.Conn]*wsClient),
 		register:   make(chan *wsClient),
 		unregister: make(chan *websocket.Conn),
+		active:     make(map[connKey]*wsClient),
+		byID:       make(map[string]*wsClient),
+
+		pendingState: make(map[string]*time.Timer),
+		stateSends:   make(map[string]*stateSend),
+
+		lobbyWatchers: make(map[*wsClient]*lobbyWatch),
+		lobbyDirty:    make(chan struct{}, 1),
+
+		maxMessageBytes: cfg.MaxMessageBytes,
 	}
 }
 
@@ -50,19 +147,32 @@ func (h *Hub) Run() {
 		case client := <-h.register:
 			h.mu.Lock()
 			h.clients[client.conn] = client
+			h.active[connKey{client.clientID, client.roomID}] = client
+			h.byID[client.clientID] = client
 			h.mu.Unlock()
 
-			h.server.AddClient(&Client{
+			joined := &Client{
 				ID:        client.clientID,
 				Name:      client.playerName,
 				SessionID: client.sessionID,
-			}, client.roomID)
+				Spectator: client.spectator,
+			}
+			h.server.AddClient(joined, client.roomID)
+			// AddClient may suffix the name if it collided with someone
+			// who joined at the same moment. The client is already in
+			// h.clients, where whispers and broadcasts read its name.
+			if joined.Name != client.playerName {
+				h.mu.Lock()
+				client.playerName = joined.Name
+				h.mu.Unlock()
+			}
 
-			// Set owner for new scheduled rooms if unset
+			// Set owner for new party and race rooms if unset, or if their
+			// owner is long gone
 			room := h.server.GetRoom(client.roomID)
 			if room != nil {
 				room.mu.Lock()
-				if room.roomType == RoomTypeScheduled && room.ownerID == "" {
+				if room.hasLobby() && room.ownerGone() {
 					room.ownerID = client.clientID
 				}
 				room.mu.Unlock()
@@ -70,19 +180,31 @@ func (h *Hub) Run() {
 
 			// Send client their ID first
 			idMsg := map[string]interface{}{
-				"type":      "your_id",
-				"client_id": client.clientID,
-				"resumed":   client.resumed,
-				"name":      client.playerName,
-				"room_id":   client.roomID,
+				"type":             "your_id",
+				"client_id":        client.clientID,
+				"resumed":          client.resumed,
+				"name":             client.playerName,
+				"room_id":          client.roomID,
+				"protocol_version": protocolVersion,
 			}
 			idJSON, err := json.Marshal(idMsg)
 			if err == nil {
 				client.send <- idJSON
 			}
+			// Anything that happened while they were away
+			h.flushNotices(client)
+			if client.resumed {
+				h.sendHistory(client)
+			}
+
+			if !client.spectator && h.server.StartCountdownIfFull(client.roomID) {
+				h.BroadcastEventTo(client.roomID, "System", "countdown",
+					fmt.Sprintf("The wagon train is full! Departing in %d seconds...", int(autoStartCountdown.Seconds())))
+			}
 
 			// Broadcast updated state to clients in the same room
 			h.BroadcastStateTo(client.roomID)
+			close(client.registered)
 
 		case conn := <-h.unregister:
 			h.mu.Lock()
@@ -90,8 +212,23 @@ func (h *Hub) Run() {
 				roomID := client.roomID
 				delete(h.clients, conn)
 				close(client.send)
-				h.server.RemoveClient(client.clientID, roomID)
+				// A reconnect may have registered before the old socket
+				// closed; then the client is still here on the new one
+				held := false
+				key := connKey{client.clientID, roomID}
+				if h.active[key] == client {
+					delete(h.active, key)
+					held = h.server.RemoveClient(client.clientID, roomID)
+				}
+				if h.byID[client.clientID] == client {
+					h.reindexClient(client.clientID)
+				}
 				h.mu.Unlock()
+				if held {
+					h.BroadcastEventTo(roomID, "System", "disconnect",
+						fmt.Sprintf("%s lost their connection. Waiting %d seconds for them to return...",
+							client.playerName, int(reconnectGrace.Seconds())))
+				}
 				h.BroadcastStateTo(roomID)
 				h.server.CleanupRoomIfEmpty(roomID)
 			} else {
@@ -101,71 +238,237 @@ func (h *Hub) Run() {
 	}
 }
 
+// reindexClient points byID at another of the client's connections after
+// the latest one closed, or removes the entry if there is none.
+// NOTE: caller must hold h.mu.
+func (h *Hub) reindexClient(clientID string) {
+	delete(h.byID, clientID)
+	for key, client := range h.active {
+		if key.clientID == clientID {
+			h.byID[clientID] = client
+			return
+		}
+	}
+}
+
 // sendToRoom sends a JSON message to all clients in the given room.
 func (h *Hub) sendToRoom(roomID string, msgJSON []byte) {
+	h.sendToRoomExcept(roomID, msgJSON, nil)
+}
+
+// sendToRoomExcept sends a JSON message to the clients in the given room,
+// skipping any for which skip returns true.
+func (h *Hub) sendToRoomExcept(roomID string, msgJSON []byte, skip func(*wsClient) bool) {
+	// Hold the read lock while sending: unregister closes send under the
+	// write lock, so no client here can have a closed channel
 	h.mu.RLock()
-	// Collect clients to send to
-	clients := make([]*wsClient, 0)
+	defer h.mu.RUnlock()
 	for _, client := range h.clients {
-		if client.roomID == roomID {
-			clients = append(clients, client)
+		if client.roomID == roomID && (skip == nil || !skip(client)) {
+			client.queue(msgJSON)
 		}
 	}
-	h.mu.RUnlock()
+}
 
-	// Track clients that need to be removed
-	var toRemove []*wsClient
-	for _, client := range clients {
+// queue hands a message to the client's writePump without blocking. When
+// the buffer is full the oldest queued message is dropped to make room, so a
+// slow client falls behind instead of being cut off mid-broadcast; one that
+// stops reading altogether hits the write deadline and unregisters as usual.
+// NOTE: caller must hold h.mu (read or write), which keeps send open.
+func (c *wsClient) queue(msgJSON []byte) {
+	for {
 		select {
-		case client.send <- msgJSON:
+		case c.send <- msgJSON:
+			return
 		default:
-			close(client.send)
-			toRemove = append(toRemove, client)
 		}
-	}
-
-	// Remove dead clients with write lock
-	if len(toRemove) > 0 {
-		h.mu.Lock()
-		for _, client := range toRemove {
-			delete(h.clients, client.conn)
+		select {
+		case <-c.send:
+		default:
 		}
-		h.mu.Unlock()
 	}
 }
 
 // SendToClient sends a message to a specific client by their clientID.
 func (h *Hub) SendToClient(clientID string, msgJSON []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if client, ok := h.byID[clientID]; ok {
+		client.queue(msgJSON)
+	}
+}
+
+// DisconnectClient forcibly closes every connection a client has open (used
+// after kick). Not only the latest: an older one that hasn't noticed a
+// reconnect yet would otherwise stay open and keep being sent to.
+func (h *Hub) DisconnectClient(clientID string) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 	for _, client := range h.clients {
 		if client.clientID == clientID {
-			select {
-			case client.send <- msgJSON:
-			default:
-			}
-			return
+			client.conn.Close()
 		}
 	}
 }
 
-// DisconnectClient forcibly closes a client's connection (used after kick).
-func (h *Hub) DisconnectClient(clientID string) {
+// DisconnectClientInRoom closes a client's connections only if they are
+// attached to the given room, leaving connections to other rooms alone.
+func (h *Hub) DisconnectClientInRoom(clientID, roomID string) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 	for _, client := range h.clients {
-		if client.clientID == clientID {
+		if client.clientID == clientID && client.roomID == roomID {
 			client.conn.Close()
-			return
 		}
 	}
 }
 
+// stateCoalesceDelay is how long a state broadcast waits so that a burst of
+// changes to a room goes out as one.
+const stateCoalesceDelay = 75 * time.Millisecond
+
+// BroadcastStateTo schedules a state broadcast to the room. Requests within
+// stateCoalesceDelay of each other share one broadcast, built when it is
+// sent, so it reflects every change so far. Events and chat are sent right
+// away and therefore always arrive before the state that follows them.
 func (h *Hub) BroadcastStateTo(roomID string) {
-	state := h.server.GetState(roomID)
+	h.pendingMu.Lock()
+	defer h.pendingMu.Unlock()
+	if _, ok := h.pendingState[roomID]; ok {
+		return
+	}
+	h.pendingState[roomID] = time.AfterFunc(stateCoalesceDelay, func() {
+		h.pendingMu.Lock()
+		delete(h.pendingState, roomID)
+		h.pendingMu.Unlock()
+		h.sendState(roomID)
+	})
+}
+
+// FlushStateTo sends the room's state now, replacing any pending broadcast.
+// It is for changes players are waiting on, like a new turn.
+func (h *Hub) FlushStateTo(roomID string) {
+	h.pendingMu.Lock()
+	if t, ok := h.pendingState[roomID]; ok {
+		t.Stop()
+		delete(h.pendingState, roomID)
+	}
+	h.pendingMu.Unlock()
+	h.sendState(roomID)
+}
+
+// stateSend serializes the state sends to one room, so that a state built
+// before another is never queued after it. Patches in particular only apply
+// on top of the state before them.
+type stateSend struct {
+	mu    sync.Mutex
+	users int // sends holding or waiting on mu
+}
+
+// lockStateSend waits for any other state send to the room to finish and
+// returns the func that lets the next one go.
+func (h *Hub) lockStateSend(roomID string) func() {
+	h.pendingMu.Lock()
+	send, ok := h.stateSends[roomID]
+	if !ok {
+		send = &stateSend{}
+		h.stateSends[roomID] = send
+	}
+	send.users++
+	h.pendingMu.Unlock()
+
+	send.mu.Lock()
+	return func() {
+		send.mu.Unlock()
+		h.pendingMu.Lock()
+		if send.users--; send.users == 0 {
+			delete(h.stateSends, roomID)
+		}
+		h.pendingMu.Unlock()
+	}
+}
+
+// sendState builds and sends the room's state, once in each schema its
+// clients speak. A large state goes to ?gzip=1 clients as state_gz, and
+// ?patch=1 clients get only what changed since their last one. Sends to a
+// room go one at a time, each built and queued before the next is built.
+func (h *Hub) sendState(roomID string) {
+	defer h.lockStateSend(roomID)()
+
+	// Anything worth a state broadcast may change the room's lobby entry
+	h.LobbyChanged()
+	for schema, use := range h.roomSchemas(roomID) {
+		stateJSON, err := json.Marshal(h.server.GetState(roomID, schema))
+		if err != nil {
+			continue
+		}
+		msgJSON := stateMessage(stateJSON, "", 0)
+		if msgJSON == nil {
+			continue
+		}
+		gz, gzJSON := "", []byte(nil)
+		if use.gzip && len(msgJSON) >= compressMinBytes {
+			gz = gzipState(stateJSON)
+			gzJSON = stateMessage(stateJSON, gz, 0)
+		}
+		h.logStateSize(roomID, msgJSON, stateJSON, gzJSON)
+		var view *stateView
+		if use.patch {
+			view, _ = splitState(stateJSON)
+		}
+
+		// Hold the read lock while sending, as sendToRoomExcept does
+		h.mu.RLock()
+		for _, client := range h.clients {
+			if client.roomID != roomID || client.schema != schema {
+				continue
+			}
+			switch {
+			case client.patches != nil && view != nil:
+				if patchJSON := client.patches.next(view, stateJSON, gz); patchJSON != nil {
+					client.queue(patchJSON)
+				}
+			case client.gzipState && gzJSON != nil:
+				client.queue(gzJSON)
+			default:
+				client.queue(msgJSON)
+			}
+		}
+		h.mu.RUnlock()
+	}
+}
+
+// schemaUse is how the clients speaking one schema in a room take their
+// states.
+type schemaUse struct {
+	gzip, patch bool
+}
+
+// roomSchemas lists the schemas the clients in a room speak, and whether
+// any of each schema's clients asked for gzipped states or patches.
+func (h *Hub) roomSchemas(roomID string) map[int]schemaUse {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	schemas := make(map[int]schemaUse)
+	for _, client := range h.clients {
+		if client.roomID == roomID {
+			use := schemas[client.schema]
+			use.gzip = use.gzip || client.gzipState
+			use.patch = use.patch || client.patches != nil
+			schemas[client.schema] = use
+		}
+	}
+	return schemas
+}
+
+func (h *Hub) BroadcastEventTo(roomID string, playerName, action, result string) {
 	msg := map[string]interface{}{
-		"type": "state",
-		"data": state,
+		"type": "event",
+		"data": map[string]interface{}{
+			"player": playerName,
+			"action": action,
+			"result": result,
+		},
 	}
 	msgJSON, err := json.Marshal(msg)
 	if err != nil {
@@ -174,13 +477,20 @@ func (h *Hub) BroadcastStateTo(roomID string) {
 	h.sendToRoom(roomID, msgJSON)
 }
 
-func (h *Hub) BroadcastEventTo(roomID string, playerName, action, result string) {
+// BroadcastResultTo sends an action's result to the room: the rendered text
+// as "result", as BroadcastEventTo does, plus the typed events behind it.
+func (h *Hub) BroadcastResultTo(roomID string, playerName, action string, result *game.TurnResult) {
+	events := []game.ResultEvent{}
+	if result != nil && result.Events != nil {
+		events = result.Events
+	}
 	msg := map[string]interface{}{
 		"type": "event",
 		"data": map[string]interface{}{
 			"player": playerName,
 			"action": action,
-			"result": result,
+			"result": result.String(),
+			"events": events,
 		},
 	}
 	msgJSON, err := json.Marshal(msg)
@@ -202,7 +512,107 @@ func (h *Hub) BroadcastChatTo(roomID string, playerName, message string) {
 	if err != nil {
 		return
 	}
-	h.sendToRoom(roomID, msgJSON)
+	h.sendToRoomExcept(roomID, msgJSON, func(c *wsClient) bool { return c.hasMuted(playerName) })
+}
+
+// SendChatToClient sends a private system chat line to a single client.
+func (h *Hub) SendChatToClient(clientID, message string) {
+	msg := map[string]interface{}{
+		"type": "chat",
+		"data": map[string]interface{}{
+			"player":  "System",
+			"message": message,
+			"private": true,
+		},
+	}
+	msgJSON, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	h.SendToClient(clientID, msgJSON)
+}
+
+// BroadcastEmoteTo sends an emote-styled chat line to everyone in the room.
+func (h *Hub) BroadcastEmoteTo(roomID string, playerName, message string) {
+	msg := map[string]interface{}{
+		"type": "chat",
+		"data": map[string]interface{}{
+			"player":  playerName,
+			"message": message,
+			"emote":   true,
+		},
+	}
+	msgJSON, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	h.sendToRoomExcept(roomID, msgJSON, func(c *wsClient) bool { return c.hasMuted(playerName) })
+}
+
+// Whisper routes a private chat line from sender to the client in the same
+// room whose client ID or name matches target, echoing a copy back to the
+// sender. Spectators may whisper players, but players may only whisper
+// spectators after opting in with "whisper_spectators".
+func (h *Hub) Whisper(sender *wsClient, target, message string) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	// A client ID resolves directly; names need a scan
+	recipient := h.active[connKey{target, sender.roomID}]
+	elsewhere := false
+	for _, client := range h.clients {
+		if recipient != nil {
+			break
+		}
+		if client.clientID != target && !strings.EqualFold(client.playerName, target) {
+			continue
+		}
+		if client.roomID == sender.roomID {
+			recipient = client
+		} else {
+			elsewhere = true
+		}
+	}
+
+	switch {
+	case recipient == nil && elsewhere:
+		return fmt.Errorf("%s is in a different room", target)
+	case recipient == nil:
+		return fmt.Errorf("%s isn't connected", target)
+	case recipient == sender:
+		return fmt.Errorf("you can't whisper to yourself")
+	case recipient.spectator && !sender.spectator && !sender.acceptsSpectatorWhispers():
+		return fmt.Errorf("%s is spectating; send whisper_spectators to whisper spectators", recipient.playerName)
+	}
+
+	data := map[string]interface{}{
+		"player":  sender.playerName,
+		"to":      recipient.playerName,
+		"message": message,
+		"whisper": true,
+	}
+	msgJSON, err := json.Marshal(map[string]interface{}{"type": "chat", "data": data})
+	if err != nil {
+		return err
+	}
+	if !recipient.hasMuted(sender.playerName) {
+		select {
+		case recipient.send <- msgJSON:
+		default:
+		}
+	}
+	select {
+	case sender.send <- msgJSON:
+	default:
+	}
+	return nil
+}
+
+// acceptsSpectatorWhispers reports whether this player opted in to
+// whispering spectators.
+func (c *wsClient) acceptsSpectatorWhispers() bool {
+	c.muteMu.RLock()
+	defer c.muteMu.RUnlock()
+	return c.whisperSpectators
 }
 
 func validatePlayerName(name string) (string, error) {
@@ -224,6 +634,16 @@ func validatePlayerName(name string) (string, error) {
 }
 
 func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("lobby") == "1" {
+		serveLobbyWatcher(hub, w, r)
+		return
+	}
+
+	schema, ok := checkProtocol(w, r)
+	if !ok {
+		return
+	}
+
 	playerName := r.URL.Query().Get("name")
 	if playerName == "" {
 		playerName = "Player"
@@ -242,7 +662,7 @@ func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 
 	var sessionID string
 	var resumed bool
-	clientID := fmt.Sprintf("player-%d", time.Now().UnixNano())
+	clientID := game.NewPlayerID()
 
 	// Check for existing session via cookie
 	cookie, err := r.Cookie("session_id")
@@ -254,17 +674,37 @@ func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 			if sess.ClientID != "" {
 				clientID = sess.ClientID
 			}
-			if sess.RoomID != "" {
-				roomID = sess.RoomID
+			// The session's room wins; if it was cleaned up, the player
+			// goes back to their shard of the open trail, or the default
+			// room, rather than being turned away
+			roomID = sess.RoomID
+			if hub.server.GetRoom(roomID) == nil {
+				roomID = hub.server.landingRoom(clientID)
 			}
 			resumed = true
 			log.Printf("Session resumed for %s in room %s", playerName, roomID)
 		}
 	}
 
-	// Default to continuous if no room specified
+	// Registered names need their PIN unless the session cookie already
+	// proved who this is. The PIN comes in a header, never the URL, which
+	// ends up in logs.
+	pinChecked := false
+	if !resumed && hub.server.leaderboard.IsNameClaimed(playerName) {
+		if err := hub.server.leaderboard.CheckNamePIN(playerName, r.Header.Get(pinHeader), requestIP(r)); err != nil {
+			http.Error(w, "The name "+playerName+" is registered: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+		pinChecked = true
+	}
+
+	// No room asked for: the default room, or the emptiest shard
 	if roomID == "" {
-		roomID = "continuous"
+		roomID = hub.server.landingRoom(clientID)
+	}
+	if roomID == "" {
+		http.Error(w, "No room specified", http.StatusBadRequest)
+		return
 	}
 
 	// Validate room exists
@@ -275,30 +715,66 @@ func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check password (skip for resumed sessions)
-	if !resumed && room.password != "" && password != room.password {
+	if !resumed && room.password != nil && !room.password.matches(password) {
 		http.Error(w, "Wrong password", http.StatusForbidden)
 		return
 	}
 
 	// Check if player died and is banned from rejoining
 	if hub.server.IsPlayerBanned(roomID, playerName) {
-		http.Error(w, "Your party perished in this game. Wait for the game to reset before rejoining.", http.StatusForbidden)
+		rejectJoin(w, r, http.StatusForbidden, errBanned, "Your party perished in this game. Wait for the game to reset before rejoining.")
 		return
 	}
 
-	// Check max players
-	if room.maxPlayers > 0 {
-		room.mu.RLock()
-		count := len(room.clients)
-		room.mu.RUnlock()
-		if count >= room.maxPlayers && !resumed {
-			http.Error(w, "Room is full", http.StatusConflict)
+	// Check if the lobby owner kicked this player
+	if hub.server.IsClientKicked(roomID, sessionID, playerName) {
+		rejectJoin(w, r, http.StatusForbidden, errBanned, "You were removed from this game by the lobby owner.")
+		return
+	}
+
+	// Check capacity (spectators never take a seat)
+	role := SeatHuman
+	if r.URL.Query().Get("spectate") == "1" {
+		role = SeatSpectator
+	}
+	room.mu.RLock()
+	canJoin := room.CanJoin(role, clientID)
+	rejoining := room.hasSeat(clientID) || room.playerGames[clientID] != nil
+	if room.roomType == RoomTypeScheduled {
+		for _, p := range room.game.Players {
+			if p.Name == playerName {
+				rejoining = true
+				break
+			}
+		}
+	}
+	nameTaken := room.nameInUse(playerName, clientID)
+	room.mu.RUnlock()
+	if !canJoin {
+		rejectJoin(w, r, http.StatusConflict, errRoomFull, "Room is full")
+		return
+	}
+	if nameTaken {
+		http.Error(w, "Someone named "+playerName+" is already in this room", http.StatusConflict)
+		return
+	}
+
+	// Limit how many games one player can hold at once (reconnects always allowed)
+	if role == SeatHuman && !rejoining {
+		if err := hub.server.CheckGameCap(Identity{ClientID: clientID, SessionID: sessionID}, room.roomType); err != nil {
+			http.Error(w, "Too many games: "+err.Error(), http.StatusConflict)
 			return
 		}
 	}
 
-	// Preflight check — return OK without upgrading
+	// Preflight check — return OK without upgrading. A browser can't send
+	// headers with a websocket, so a preflight that proved the PIN starts
+	// the session here, and the websocket resumes it by its cookie.
 	if r.URL.Query().Get("preflight") == "1" {
+		if pinChecked {
+			sessionID = hub.server.sessionManager.CreateSession(playerName, clientID, roomID)
+			http.SetCookie(w, sessionCookie(r, sessionID))
+		}
 		w.WriteHeader(http.StatusOK)
 		return
 	}
@@ -311,16 +787,8 @@ func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Pass cookie via Upgrade's responseHeader
-	sessionCookie := &http.Cookie{
-		Name:     "session_id",
-		Value:    sessionID,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   false,
-		MaxAge:   86400 * 30,
-	}
 	upgradeHeaders := http.Header{}
-	upgradeHeaders.Add("Set-Cookie", sessionCookie.String())
+	upgradeHeaders.Add("Set-Cookie", sessionCookie(r, sessionID).String())
 
 	conn, err := upgrader.Upgrade(w, r, upgradeHeaders)
 	if err != nil {
@@ -337,6 +805,15 @@ func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 		sessionID:  sessionID,
 		roomID:     roomID,
 		resumed:    resumed,
+		spectator:  role == SeatSpectator,
+		remoteIP:   requestIP(r),
+		mutedNames: make(map[string]bool),
+		schema:     schema,
+		registered: make(chan struct{}),
+		gzipState:  r.URL.Query().Get("gzip") == "1",
+	}
+	if r.URL.Query().Get("patch") == "1" {
+		client.patches = &patchTracker{}
 	}
 
 	hub.register <- client
@@ -351,18 +828,26 @@ func (c *wsClient) readPump() {
 			log.Printf("WebSocket readPump recovered from panic: %v", r)
 		}
 		c.hub.unregister <- c.conn
-		c.conn.Close()
+		if !c.flushOnClose {
+			c.conn.Close()
+		}
 	}()
 
-	c.conn.SetReadLimit(1024)
+	<-c.registered
 	c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 	c.conn.SetPongHandler(func(string) error {
 		c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 		return nil
 	})
 
+	limiter := newRateLimiter()
+
 	for {
-		_, message, err := c.conn.ReadMessage()
+		message, err := c.readMessage()
+		if err == errOversized {
+			c.refuseOversized()
+			break
+		}
 		if err != nil {
 			break
 		}
@@ -376,6 +861,19 @@ func (c *wsClient) readPump() {
 		if !ok {
 			continue
 		}
+		c.request = msgType
+		if !automaticMessages[msgType] {
+			c.hub.server.MarkActive(c.roomID, c.clientID)
+		}
+
+		if allowed, disconnect := limiter.Allow(msgType, time.Now()); !allowed {
+			if disconnect {
+				log.Printf("Closing connection for %s: too many rate limit violations", c.clientID)
+				return
+			}
+			c.sendError(errRateLimited, "Slow down! You're sending messages too fast.")
+			continue
+		}
 
 		roomID := c.roomID
 
@@ -385,24 +883,224 @@ func (c *wsClient) readPump() {
 			if !ok {
 				break
 			}
+			if action == "disable_hints" {
+				c.hub.server.leaderboard.DisableHints(c.playerName)
+				c.hub.SendChatToClient(c.clientID, "Trail tips turned off.")
+				break
+			}
+			if c.needsConfirmation(roomID, msg, action) {
+				break
+			}
+			if action == "start" && !c.chooseOccupation(roomID, msg) {
+				break
+			}
 			log.Printf("DEBUG WS: received action=%s from clientID=%s", action, c.clientID)
+			turnBefore := c.hub.server.TurnMarker(roomID)
 			result := c.hub.server.HandleAction(c.clientID, roomID, action)
 			log.Printf("DEBUG WS: action result: %q", result)
+			if action == "start" {
+				c.choosePeaceful(roomID, msg)
+			}
 
-			c.hub.BroadcastEventTo(roomID, c.playerName, action, result)
-			c.hub.BroadcastStateTo(roomID)
+			c.sendResult(roomID, action, result)
+			c.hub.BroadcastTrailNewsTo(roomID)
+			c.hub.BroadcastGameOverTo(roomID)
+			if c.hub.server.TurnMarker(roomID) != turnBefore {
+				c.hub.FlushStateTo(roomID)
+			} else {
+				c.hub.BroadcastStateTo(roomID)
+			}
+
+		case "ping":
+			c.handlePing(roomID, msg)
+
+		case "state_full":
+			if c.patches != nil {
+				c.patches.resync()
+				c.hub.BroadcastStateTo(roomID)
+			}
 
 		case "chat":
 			message, ok := msg["message"].(string)
 			if !ok {
 				break
 			}
-			if len(message) > 200 {
-				message = message[:200]
+			message = c.clipChat(message)
+			if strings.HasPrefix(message, "/") {
+				c.handleChatCommand(roomID, message)
+			} else if message != "" {
+				if text, ok := c.prepareChat(roomID, message); ok {
+					c.hub.BroadcastChatTo(roomID, c.playerName, text)
+				}
+			}
+
+		case "whisper":
+			message, ok := msg["message"].(string)
+			if !ok || strings.TrimSpace(message) == "" {
+				break
+			}
+			target, _ := msg["target_id"].(string)
+			if target == "" {
+				target, _ = msg["target"].(string)
+			}
+			c.whisper(roomID, strings.TrimSpace(target), message)
+
+		case "whisper_spectators":
+			enabled, _ := msg["enabled"].(bool)
+			c.muteMu.Lock()
+			c.whisperSpectators = enabled
+			c.muteMu.Unlock()
+			if enabled {
+				c.hub.SendChatToClient(c.clientID, "You can now whisper spectators.")
+			} else {
+				c.hub.SendChatToClient(c.clientID, "You will no longer whisper spectators.")
+			}
+
+		case "mute", "unmute":
+			name, ok := msg["player"].(string)
+			if !ok || strings.TrimSpace(name) == "" {
+				break
+			}
+			c.setMuted(strings.TrimSpace(name), msgType == "mute")
+			if msgType == "mute" {
+				c.hub.SendChatToClient(c.clientID, fmt.Sprintf("You will no longer see chat from %s.", name))
+			} else {
+				c.hub.SendChatToClient(c.clientID, fmt.Sprintf("You can see chat from %s again.", name))
+			}
+
+		case "leave_room":
+			leaveID, ok := msg["room_id"].(string)
+			if !ok || leaveID == "" {
+				break
+			}
+			if leaveID == roomID {
+				c.sendError(game.ReasonInvalid, "Use logout to leave the room you're in.")
+				break
+			}
+			removed := c.hub.server.LeaveRoom(Identity{ClientID: c.clientID, SessionID: c.sessionID}, leaveID)
+			if len(removed) == 0 {
+				c.sendError(game.ReasonInvalid, "You aren't playing in that room.")
+				break
+			}
+			for _, id := range removed {
+				c.hub.DisconnectClientInRoom(id, leaveID)
+			}
+			c.hub.BroadcastStateTo(leaveID)
+			c.hub.SendChatToClient(c.clientID, fmt.Sprintf("You left room %s.", leaveID))
+
+		case "room_mute":
+			targetID, ok := msg["target_id"].(string)
+			if !ok || targetID == "" {
+				break
+			}
+			duration := defaultRoomMute
+			if secs, ok := msg["duration"].(float64); ok && secs > 0 {
+				// Clamp before converting; a huge count would overflow
+				// into a negative duration
+				duration = time.Duration(math.Min(secs, maxRoomMute.Seconds())) * time.Second
+			}
+			targetName, ok := c.hub.server.RoomMute(roomID, c.clientID, targetID, duration)
+			if !ok {
+				c.sendError(errNotOwner, "Only the lobby owner can mute players.")
+				break
+			}
+			c.hub.BroadcastEventTo(roomID, "System", "mute",
+				fmt.Sprintf("%s has been muted by the lobby owner for %s.", targetName, formatMuteDuration(duration)))
+
+		case "room_settings":
+			modeName, ok := msg["chat_mode"].(string)
+			if !ok {
+				break
+			}
+			mode, ok := parseChatMode(modeName)
+			if !ok {
+				c.sendError(game.ReasonInvalid, "Chat mode must be open, filtered or disabled.")
+				break
+			}
+			if err := c.hub.server.SetChatMode(roomID, c.clientID, mode); err != nil {
+				c.sendError(errorCode(err), fmt.Sprintf("Settings not changed: %v.", err))
+				break
+			}
+			c.hub.BroadcastEventTo(roomID, "System", "settings", fmt.Sprintf("The lobby owner set chat to %s.", mode))
+			c.hub.BroadcastStateTo(roomID)
+
+		case "register_name":
+			pin, _ := msg["pin"].(string)
+			newPIN, _ := msg["new_pin"].(string)
+			c.registerName(pin, newPIN)
+
+		case "unban":
+			name, ok := msg["player"].(string)
+			if !ok || strings.TrimSpace(name) == "" {
+				break
+			}
+			if !c.hub.server.UnbanPlayer(roomID, c.clientID, strings.TrimSpace(name)) {
+				c.sendError(errNotOwner, "Only the lobby owner can unban players, and only players who were kicked.")
+				break
+			}
+			c.hub.SendChatToClient(c.clientID, fmt.Sprintf("%s may rejoin the game.", name))
+
+		case "list_bans":
+			bans, ok := c.hub.server.ListBans(roomID, c.clientID)
+			if !ok {
+				c.sendError(errNotOwner, "Only the lobby owner can see kicked players.")
+				break
+			}
+			bansMsg, err := json.Marshal(map[string]interface{}{
+				"type": "bans",
+				"bans": bans,
+			})
+			if err == nil {
+				c.hub.SendToClient(c.clientID, bansMsg)
 			}
-			if message != "" {
-				c.hub.BroadcastChatTo(roomID, c.playerName, message)
+
+		case "ready", "unready":
+			if !c.chooseOccupation(roomID, msg) {
+				break
+			}
+			if err := c.hub.server.SetReady(roomID, c.clientID, msgType == "ready"); err != nil {
+				c.sendError(errorCode(err), fmt.Sprintf("Can't %s: %v.", msgType, err))
+				break
 			}
+			c.hub.BroadcastStateTo(roomID)
+
+		case "start":
+			if !c.chooseOccupation(roomID, msg) {
+				break
+			}
+			force, _ := msg["force"].(bool)
+			if err := c.hub.server.StartGame(roomID, c.clientID, force); err != nil {
+				c.sendError(errorCode(err), fmt.Sprintf("Can't start: %v.", err))
+				break
+			}
+			c.hub.BroadcastEventTo(roomID, "System", "start", "The lobby owner started the game. The wagon train departs!")
+			c.hub.FlushStateTo(roomID)
+
+		case "cancel_countdown":
+			if err := c.hub.server.CancelCountdown(roomID, c.clientID); err != nil {
+				c.sendError(errorCode(err), fmt.Sprintf("Can't cancel: %v.", err))
+				break
+			}
+			c.hub.BroadcastEventTo(roomID, "System", "countdown", "The lobby owner called off the departure countdown.")
+			c.hub.BroadcastStateTo(roomID)
+
+		case "pause", "resume":
+			var err error
+			if msgType == "pause" {
+				err = c.hub.server.PauseRoom(roomID, c.clientID)
+			} else {
+				err = c.hub.server.ResumeRoom(roomID, c.clientID)
+			}
+			if err != nil {
+				c.sendError(errorCode(err), fmt.Sprintf("Can't %s: %v.", msgType, err))
+				break
+			}
+			if msgType == "pause" {
+				c.hub.BroadcastEventTo(roomID, "System", "pause", "The lobby owner paused the game.")
+			} else {
+				c.hub.BroadcastEventTo(roomID, "System", "resume", "The lobby owner resumed the game. Back on the trail!")
+			}
+			c.hub.BroadcastStateTo(roomID)
 
 		case "logout":
 			c.hub.server.LogoutClient(c.clientID, c.sessionID, roomID)
@@ -412,7 +1110,7 @@ func (c *wsClient) readPump() {
 
 		case "fort_enter":
 			result := c.hub.server.HandleFortEnter(c.clientID, roomID)
-			c.hub.BroadcastEventTo(roomID, c.playerName, "fort", result)
+			c.sendResult(roomID, "fort", result)
 			c.hub.BroadcastStateTo(roomID)
 
 		case "fort_buy":
@@ -424,9 +1122,13 @@ func (c *wsClient) readPump() {
 			if !ok {
 				break
 			}
+			if err := game.CheckFortQty(qtyFloat); err != nil {
+				c.sendError(errorCode(err), fmt.Sprintf("Can't buy: %v.", err))
+				break
+			}
 			qty := int(qtyFloat)
 			result := c.hub.server.HandleFortBuy(c.clientID, roomID, item, qty)
-			c.hub.BroadcastEventTo(roomID, c.playerName, "fort", result)
+			c.sendResult(roomID, "fort", result)
 			c.hub.BroadcastStateTo(roomID)
 
 		case "fort_sell":
@@ -438,14 +1140,119 @@ func (c *wsClient) readPump() {
 			if !ok {
 				break
 			}
+			if err := game.CheckFortQty(qtyFloat); err != nil {
+				c.sendError(errorCode(err), fmt.Sprintf("Can't sell: %v.", err))
+				break
+			}
 			qty := int(qtyFloat)
 			result := c.hub.server.HandleFortSell(c.clientID, roomID, item, qty)
-			c.hub.BroadcastEventTo(roomID, c.playerName, "fort", result)
+			c.sendResult(roomID, "fort", result)
+			c.hub.BroadcastStateTo(roomID)
+
+		case "fort_heal":
+			memberFloat, ok := msg["member"].(float64)
+			if !ok || memberFloat != float64(int(memberFloat)) {
+				c.sendError(game.ReasonInvalid, "Can't heal: pick a party member.")
+				break
+			}
+			result := c.hub.server.HandleFortHeal(c.clientID, roomID, int(memberFloat))
+			c.sendResult(roomID, "fort", result)
+			c.hub.BroadcastStateTo(roomID)
+
+		case "fort_buy_max":
+			item, ok := msg["item"].(string)
+			if !ok {
+				break
+			}
+			result := c.hub.server.HandleFortBuyMax(c.clientID, roomID, item)
+			c.sendResult(roomID, "fort", result)
+			c.hub.BroadcastStateTo(roomID)
+
+		case "fort_basket":
+			orders, err := parseFortBasket(msg["items"])
+			if err != nil {
+				c.sendError(errorCode(err), fmt.Sprintf("Can't buy: %v.", err))
+				break
+			}
+			result := c.hub.server.HandleFortBasket(c.clientID, roomID, orders)
+			c.sendResult(roomID, "fort", result)
 			c.hub.BroadcastStateTo(roomID)
 
 		case "fort_leave":
+			if c.needsConfirmation(roomID, msg, game.MoveFortLeave) {
+				break
+			}
 			result := c.hub.server.HandleFortLeave(c.clientID, roomID)
-			c.hub.BroadcastEventTo(roomID, c.playerName, "fort", result)
+			c.sendResult(roomID, "fort", result)
+			c.hub.BroadcastTrailNewsTo(roomID)
+			c.hub.BroadcastGameOverTo(roomID)
+			c.hub.BroadcastStateTo(roomID)
+
+		case "set_pace":
+			pace, ok := msg["pace"].(string)
+			if !ok {
+				break
+			}
+			result := c.hub.server.SetPace(c.clientID, roomID, pace)
+			c.sendResult(roomID, "pace", result)
+			c.hub.BroadcastStateTo(roomID)
+
+		case "leave_note":
+			text, ok := msg["text"].(string)
+			if !ok || strings.TrimSpace(text) == "" {
+				break
+			}
+			text, ok = c.prepareChat(roomID, text)
+			if !ok {
+				break
+			}
+			note, err := c.hub.server.LeaveNote(c.clientID, roomID, text)
+			if err != nil {
+				c.sendError(errorCode(err), fmt.Sprintf("Can't leave a note: %v.", err))
+				break
+			}
+			c.hub.SendChatToClient(c.clientID, fmt.Sprintf("You carved your note at mile %.0f.", note.Mileage))
+			c.hub.BroadcastStateTo(roomID)
+
+		case "set_peaceful":
+			if c.choosePeaceful(roomID, msg) {
+				c.hub.BroadcastStateTo(roomID)
+			}
+
+		case "raid":
+			target, ok := msg["target_id"].(string)
+			if !ok {
+				break
+			}
+			deadline, err := c.hub.server.StartRaid(roomID, c.clientID, target)
+			if err != nil {
+				c.sendError(errorCode(err), fmt.Sprintf("Can't raid: %v.", err))
+				break
+			}
+			if raidMsg, err := json.Marshal(map[string]interface{}{
+				"type":     "raid",
+				"attacker": c.playerName,
+				"deadline": deadline.UnixMilli(),
+				"tactics":  []game.RaidTactic{game.RaidFight, game.RaidFlee, game.RaidPay},
+			}); err == nil {
+				c.hub.SendToClient(target, raidMsg)
+			}
+			c.hub.BroadcastEventTo(roomID, c.playerName, "raid",
+				fmt.Sprintf("%s's raiders ride out after a wagon nearby!\n", c.playerName))
+			c.hub.BroadcastStateTo(roomID)
+
+		case "raid_answer":
+			name, _ := msg["tactic"].(string)
+			tactic, err := game.ParseRaidTactic(name)
+			var result *game.TurnResult
+			if err == nil {
+				result, err = c.hub.server.AnswerRaid(roomID, c.clientID, tactic)
+			}
+			if err != nil {
+				c.sendError(errorCode(err), fmt.Sprintf("Can't answer the raid: %v.", err))
+				break
+			}
+			c.sendResult(roomID, "raid", result)
 			c.hub.BroadcastStateTo(roomID)
 
 		case "loot_claim":
@@ -454,12 +1261,12 @@ func (c *wsClient) readPump() {
 				break
 			}
 			result := c.hub.server.HandleLootClaim(c.clientID, roomID, lootSiteID)
-			c.hub.BroadcastEventTo(roomID, c.playerName, "loot", result)
+			c.sendResult(roomID, "loot", result)
 			c.hub.BroadcastStateTo(roomID)
 
 		case "reset":
 			if c.hub.server.ResetGame(roomID) {
-				c.hub.BroadcastEventTo(roomID, "System", "reset", "A new journey begins! The wagon train is restocked and ready.")
+				c.hub.BroadcastEventTo(roomID, "System", "reset", "The wagon train is restocked for a new journey.")
 				c.hub.BroadcastStateTo(roomID)
 			}
 
@@ -468,9 +1275,19 @@ func (c *wsClient) readPump() {
 			if !ok {
 				break
 			}
+			word, _ := msg["word"].(string)
 			reactionTimeMs := int(timeFloat)
-			result := c.hub.server.HandleHuntShoot(c.clientID, roomID, reactionTimeMs)
-			c.hub.BroadcastEventTo(roomID, c.playerName, "hunt", result)
+			result := c.hub.server.HandleHuntShoot(c.clientID, roomID, word, reactionTimeMs)
+			c.sendResult(roomID, "hunt", result)
+			c.hub.BroadcastTrailNewsTo(roomID)
+			c.hub.BroadcastGameOverTo(roomID)
+			c.hub.BroadcastStateTo(roomID)
+
+		case "hunt_cancel":
+			result := c.hub.server.HandleHuntCancel(c.clientID, roomID)
+			c.sendResult(roomID, "hunt", result)
+			c.hub.BroadcastTrailNewsTo(roomID)
+			c.hub.BroadcastGameOverTo(roomID)
 			c.hub.BroadcastStateTo(roomID)
 
 		case "rider_tactic":
@@ -480,7 +1297,9 @@ func (c *wsClient) readPump() {
 			}
 			tactic := int(tacticFloat)
 			result := c.hub.server.HandleRiderTactic(c.clientID, roomID, tactic)
-			c.hub.BroadcastEventTo(roomID, c.playerName, "continue", result)
+			c.sendResult(roomID, "continue", result)
+			c.hub.BroadcastTrailNewsTo(roomID)
+			c.hub.BroadcastGameOverTo(roomID)
 			c.hub.BroadcastStateTo(roomID)
 
 		case "kick":
@@ -499,15 +1318,165 @@ func (c *wsClient) readPump() {
 
 			if c.hub.server.KickClient(roomID, c.clientID, targetID) {
 				// Disconnect the kicked client
-				c.hub.DisconnectClient(targetID)
+				c.hub.DisconnectClientInRoom(targetID, roomID)
 				c.hub.BroadcastStateTo(roomID)
 			}
+
+		case "transfer_ownership":
+			targetID, _ := msg["target_id"].(string)
+			name, err := c.hub.server.TransferOwnership(roomID, c.clientID, targetID)
+			if err != nil {
+				c.sendError(errorCode(err), fmt.Sprintf("Can't hand over the room: %v.", err))
+				break
+			}
+			c.hub.BroadcastEventTo(roomID, "System", "owner", fmt.Sprintf("%s is now the lobby owner.", name))
+			c.hub.BroadcastStateTo(roomID)
+
+		case "set_co_owner":
+			targetID, _ := msg["target_id"].(string)
+			coOwner, _ := msg["co_owner"].(bool)
+			name, err := c.hub.server.SetCoOwner(roomID, c.clientID, targetID, coOwner)
+			if err != nil {
+				c.sendError(errorCode(err), fmt.Sprintf("Can't change co-owners: %v.", err))
+				break
+			}
+			text := fmt.Sprintf("%s is now a co-owner.", name)
+			if !coOwner {
+				text = fmt.Sprintf("%s is no longer a co-owner.", name)
+			}
+			c.hub.BroadcastEventTo(roomID, "System", "owner", text)
+			c.hub.BroadcastStateTo(roomID)
 		}
+
+		c.sendHints(roomID)
+	}
+}
+
+// whisper applies the chat length limit and room mute to a whisper before
+// handing it to the hub.
+func (c *wsClient) whisper(roomID, target, message string) {
+	if target == "" {
+		c.sendError(game.ReasonInvalid, "Who do you want to whisper to?")
+		return
+	}
+	message, ok := c.prepareChat(roomID, c.clipChat(message))
+	if !ok {
+		return
+	}
+	if err := c.hub.Whisper(c, target, message); err != nil {
+		c.sendError(errorCode(err), fmt.Sprintf("Whisper not sent: %v.", err))
+	}
+}
+
+// chooseOccupation applies the occupation a start or ready message carries,
+// if any. It reports false, after sending the client the error, when the
+// occupation was refused.
+func (c *wsClient) chooseOccupation(roomID string, msg map[string]interface{}) bool {
+	name, ok := msg["occupation"].(string)
+	if !ok {
+		return true
 	}
+	occupation, err := game.ParseOccupation(name)
+	if err == nil {
+		err = c.hub.server.SetOccupation(roomID, c.clientID, occupation)
+	}
+	if err != nil {
+		c.sendError(errorCode(err), fmt.Sprintf("Can't choose occupation: %v.", err))
+		return false
+	}
+	return true
+}
+
+// choosePeaceful applies the raid opt-out a start or set_peaceful message
+// carries, if any. It reports false, after sending the client the error,
+// when it was refused.
+func (c *wsClient) choosePeaceful(roomID string, msg map[string]interface{}) bool {
+	peaceful, ok := msg["peaceful"].(bool)
+	if !ok {
+		return true
+	}
+	if err := c.hub.server.SetPeaceful(roomID, c.clientID, peaceful); err != nil {
+		c.sendError(errorCode(err), fmt.Sprintf("Can't choose a peaceful run: %v.", err))
+		return false
+	}
+	return true
+}
+
+// needsConfirmation checks whether a move would doom the party. If so, and
+// the message wasn't resent with confirm=true, the client is sent the
+// warning with requires_confirmation set and the move is held back.
+func (c *wsClient) needsConfirmation(roomID string, msg map[string]interface{}, move string) bool {
+	if move == "continue" {
+		move = game.MoveTravel
+	}
+	if confirmed, _ := msg["confirm"].(bool); confirmed {
+		return false
+	}
+	warning := c.hub.server.FatalMoveWarning(c.clientID, roomID, move)
+	if warning == "" {
+		return false
+	}
+	msgJSON, err := json.Marshal(map[string]interface{}{
+		"type":                  "warning",
+		"message":               warning,
+		"requires_confirmation": true,
+		"request":               msg,
+	})
+	if err == nil {
+		c.hub.SendToClient(c.clientID, msgJSON)
+	}
+	return true
+}
+
+// checkRoomMuted reports whether the owner muted this client in the room,
+// sending them an error if so.
+func (c *wsClient) checkRoomMuted(roomID string) bool {
+	until, muted := c.hub.server.RoomMutedUntil(roomID, c.clientID)
+	if !muted {
+		return false
+	}
+	c.sendError(errMuted, fmt.Sprintf("You have been muted by the lobby owner for another %s.",
+		formatMuteDuration(until.Sub(c.hub.server.clock.Now()))))
+	return true
+}
+
+// registerName claims this client's name with pin, or changes the PIN of
+// an already claimed name when newPIN is given.
+func (c *wsClient) registerName(pin, newPIN string) {
+	var err error
+	if newPIN != "" {
+		err = c.hub.server.leaderboard.ChangeNamePIN(c.playerName, pin, newPIN, c.remoteIP)
+	} else {
+		err = c.hub.server.leaderboard.ClaimName(c.playerName, pin)
+	}
+	if err != nil {
+		c.sendError(errorCode(err), fmt.Sprintf("Name not registered: %v.", err))
+		return
+	}
+	if newPIN != "" {
+		c.hub.SendChatToClient(c.clientID, "Your PIN has been changed.")
+		return
+	}
+	c.hub.SendChatToClient(c.clientID,
+		fmt.Sprintf("The name %s is now yours. You'll need your PIN to join from a new device.", c.playerName))
+}
+
+// prepareChat checks that this client may chat in the room and applies the
+// room's chat mode, returning the text to send.
+func (c *wsClient) prepareChat(roomID, message string) (string, bool) {
+	if c.checkRoomMuted(roomID) {
+		return "", false
+	}
+	text, err := c.hub.server.ChatText(roomID, message)
+	if err != nil {
+		c.sendError(errorCode(err), fmt.Sprintf("Message not sent: %v.", err))
+		return "", false
+	}
+	return text, true
 }
 
 func (c *wsClient) writePump() {
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := c.hub.clock.NewTicker(30 * time.Second)
 	defer func() {
 		ticker.Stop()
 		if r := recover(); r != nil {
@@ -525,11 +1494,12 @@ func (c *wsClient) writePump() {
 				return
 			}
 
+			c.conn.EnableWriteCompression(len(message) >= compressMinBytes)
 			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
 				return
 			}
 
-		case <-ticker.C:
+		case <-ticker.C():
 			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
@@ -538,12 +1508,28 @@ func (c *wsClient) writePump() {
 	}
 }
 
-func serveStatic(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path == "/" || r.URL.Path == "/index.html" {
-		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
-		w.Header().Set("Pragma", "no-cache")
-		http.ServeFile(w, r, "static/index.html")
-		return
+// parseFortBasket reads a fort_basket message's items: a list of
+// {"item": ..., "qty": ...} objects.
+func parseFortBasket(raw interface{}) ([]game.FortOrder, error) {
+	list, ok := raw.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("the basket is empty")
+	}
+	if len(list) > game.MaxFortTrades {
+		return nil, fmt.Errorf("a basket holds at most %d lines", game.MaxFortTrades)
+	}
+	orders := make([]game.FortOrder, 0, len(list))
+	for _, line := range list {
+		entry, _ := line.(map[string]interface{})
+		item, _ := entry["item"].(string)
+		qty, ok := entry["qty"].(float64)
+		if item == "" || !ok {
+			return nil, fmt.Errorf("each basket line needs an item and a qty")
+		}
+		if err := game.CheckFortQty(qty); err != nil {
+			return nil, fmt.Errorf("%s: %v", item, err)
+		}
+		orders = append(orders, game.FortOrder{Item: item, Qty: int(qty)})
 	}
-	http.ServeFile(w, r, r.URL.Path[1:])
+	return orders, nil
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// serverConn returns the server end of a fresh websocket, for a wsClient
+// registered with a hub by hand.
+func serverConn(t *testing.T) *websocket.Conn {
+	t.Helper()
+	conns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(srv.Close)
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return <-conns
+}
+
+// A name suffixed on register is written while whispers read it; run with
+// -race.
+func TestRenameOnRegisterIsLocked(t *testing.T) {
+	ts := newTestSite(t)
+	room := newTestRoom(t, ts.Server)
+	ts.AddClient(&Client{ID: "client-first", Name: "Ann"}, room.id)
+	sender := ts.dial(t, "name=Bea&room="+room.id)
+	ts.hub.mu.RLock()
+	bea := ts.hub.byID[sender.clientID]
+	ts.hub.mu.RUnlock()
+
+	late := &wsClient{
+		hub:        ts.hub,
+		conn:       serverConn(t),
+		send:       make(chan []byte, 256),
+		clientID:   "client-second",
+		playerName: "Ann",
+		roomID:     room.id,
+		mutedNames: make(map[string]bool),
+		registered: make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-late.registered:
+				return
+			default:
+				ts.hub.Whisper(bea, "Ann", "psst")
+			}
+		}
+	}()
+	ts.hub.register <- late
+	<-late.registered
+	wg.Wait()
+
+	ts.hub.mu.RLock()
+	name := late.playerName
+	ts.hub.mu.RUnlock()
+	if name == "Ann" {
+		t.Errorf("second Ann kept the name; want it suffixed")
+	}
+}
+
+func TestFatalMovesWaitForConfirmation(t *testing.T) {
+	ts := newTestSite(t)
+	room := ts.GetRoom(ts.defaultRoomID)
+	ann := ts.dial(t, "name=Ann&room="+room.id)
+	starve := func() {
+		room.mu.Lock()
+		defer room.mu.Unlock()
+		g := room.playerGames[ann.clientID]
+		g.Food, g.Cash, g.FortAvailable = 5, 0, false
+	}
+	mileage := func() float64 {
+		room.mu.RLock()
+		defer room.mu.RUnlock()
+		return room.playerGames[ann.clientID].Mileage
+	}
+	// next reads until the move is answered, with a warning or its result
+	next := func() string {
+		t.Helper()
+		ann.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var msg map[string]interface{}
+			if err := ann.ReadJSON(&msg); err != nil {
+				t.Fatalf("waiting for the action's answer: %v", err)
+			}
+			data, _ := msg["data"].(map[string]interface{})
+			switch {
+			case msg["type"] == "warning":
+				return "warning"
+			case msg["type"] == "event" && data["action"] == "continue":
+				return "event"
+			}
+		}
+	}
+
+	starve()
+	ann.send(map[string]interface{}{"type": "action", "action": "continue"})
+	if got := next(); got != "warning" || mileage() != 0 {
+		t.Fatalf("travelling on starving got %s at mile %.0f, want a warning and no travel", got, mileage())
+	}
+	ann.send(map[string]interface{}{"type": "action", "action": "continue", "confirm": true})
+	if got := next(); got != "event" || mileage() == 0 {
+		t.Fatalf("confirmed, travelling on got %s at mile %.0f, want the wagon moved", got, mileage())
+	}
+
+	// A room that turns the hand-holding off doesn't ask
+	room.mu.Lock()
+	room.rules.NoHandHolding = true
+	room.mu.Unlock()
+	starve()
+	before := mileage()
+	ann.send(map[string]interface{}{"type": "action", "action": "continue"})
+	if got := next(); got != "event" || mileage() == before {
+		t.Errorf("without hand-holding, travelling on got %s at mile %.0f, want the wagon moved", got, mileage())
+	}
+}
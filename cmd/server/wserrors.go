@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"online-trail/pkg/game"
+)
+
+// Error codes on the websocket's "error" messages, beside the game's own
+// refusal reasons (game.Reason*). Clients switch on the code; the message
+// is for showing.
+const (
+	errRoomFull    = "room_full"
+	errBanned      = "banned"
+	errPaused      = "paused"
+	errNotOwner    = "not_owner"
+	errMuted       = "muted"
+	errRateLimited = "rate_limited"
+)
+
+// codedError is an error that knows the code it is sent to the client with.
+type codedError struct {
+	code    string
+	message string
+}
+
+func (e *codedError) Error() string { return e.message }
+
+// ownerOnly is the error for something only the lobby owner may do.
+func ownerOnly(message string) error {
+	return &codedError{code: errNotOwner, message: message}
+}
+
+// wrongPhase is the error for something the room isn't ready for, or is
+// past.
+func wrongPhase(message string) error {
+	return &codedError{code: game.ReasonInvalidPhase, message: message}
+}
+
+// errorCode is the code err is sent with: its own if it has one, and
+// invalid_request otherwise.
+func errorCode(err error) string {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return game.ReasonInvalid
+}
+
+// errorMessage builds an "error" message: the code, the type of the request
+// that failed and the text to show.
+func errorMessage(code, request, message string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"type":    "error",
+		"code":    code,
+		"request": request,
+		"message": message,
+	})
+}
+
+// sendError sends an error to this client only, naming the request
+// readPump is handling.
+func (c *wsClient) sendError(code, message string) {
+	msgJSON, err := errorMessage(code, c.request, message)
+	if err != nil {
+		return
+	}
+	c.hub.SendToClient(c.clientID, msgJSON)
+}
+
+// sendResult sends an action's result: to the room as an event if it went
+// ahead, and to this client alone as an error if it was refused.
+func (c *wsClient) sendResult(roomID, action string, result *game.TurnResult) {
+	if refusal := result.Refusal(); refusal != nil {
+		code := refusal.Reason
+		if code == "" {
+			code = game.ReasonInvalid
+		}
+		c.sendError(code, strings.TrimSpace(refusal.Message))
+		return
+	}
+	c.hub.BroadcastResultTo(roomID, c.playerName, action, result)
+}
+
+// rejectJoin turns away a player joining a room with an "error" message.
+func rejectJoin(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	msgJSON, _ := errorMessage(code, "join", message)
+	refuseSocket(w, r, status, code, message, msgJSON)
+}
+
+// refuseSocket turns away a websocket handshake. A preflight check or plain
+// HTTP request gets the message as an HTTP error; a websocket is upgraded
+// just long enough to be sent msgJSON, since a browser can't read the body
+// of a refused handshake.
+func refuseSocket(w http.ResponseWriter, r *http.Request, status int, code, message string, msgJSON []byte) {
+	if r.URL.Query().Get("preflight") == "1" || !websocket.IsWebSocketUpgrade(r) {
+		http.Error(w, message, status)
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if msgJSON != nil {
+		conn.WriteMessage(websocket.TextMessage, msgJSON)
+	}
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, code))
+}
@@ -1,73 +1,188 @@
 package game
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 )
 
-func (g *GameState) ProcessTurn(p *Player, action string) string {
+func (g *GameState) ProcessTurn(p *Player, action string) *TurnResult {
 	if p == nil {
-		return "Error: Player not found.\n"
+		return Refuse(ReasonInvalid, "Error: Player not found.\n")
 	}
 	if !p.Alive {
-		return "Your party has perished. You are spectating.\n"
+		return NewResult(ResultSpectating, "Your party has perished. You are spectating.\n")
 	}
 
-	result := &strings.Builder{}
+	// CPU players make their own choices
+	if p.Type == PlayerTypeCPU {
+		return g.PlayCPUTurn(p)
+	}
+
+	result := &TurnResult{}
 
 	g.TurnPhase = PhaseMainMenu
 
 	switch action {
 	case "hunt":
-		if g.Bullets >= 50 {
-			if p.Type == PlayerTypeCPU {
-				// CPU auto-resolves hunting
-				result.WriteString(g.HandleHunting(p))
-			} else {
-				// Interactive: set phase and return prompt
-				g.TurnPhase = PhaseHunting
-				g.HuntWord = g.GetShootingPrompt()
-				g.Bullets -= 50
-				result.WriteString("Get ready to shoot...\n")
-				return result.String() // Return early — waiting for hunt_shoot
-			}
+		if g.Bullets >= huntBulletCost {
+			// Interactive: set phase and return prompt. The bullets are
+			// spent when the shot is taken.
+			g.TurnPhase = PhaseHunting
+			g.IssueHuntPrompt()
+			result.Add(ResultHuntReady, "Get ready to shoot...\n", nil)
+			return result // Return early — waiting for hunt_shoot
 		} else {
-			result.WriteString("Not enough bullets to hunt!\n")
-			result.WriteString(g.ContinueTravel(p))
+			result.Add(ResultNoBullets, "Not enough bullets to hunt!\n", nil)
+			result.Append(g.ContinueTravel(p))
 		}
 	default:
-		result.WriteString(g.ContinueTravel(p))
+		result.Append(g.ContinueTravel(p))
 	}
 
-	return result.String()
+	return result
 }
 
+// FortItem is one line of the trading post's price list. Capacity is the
+// most of the item a standard wagon can carry; see Capacities.
 type FortItem struct {
-	Price float64 `json:"price"`
-	Qty   float64 `json:"qty"`
-	Label string  `json:"label"`
+	Price    float64 `json:"price"`
+	Qty      float64 `json:"qty"`
+	Label    string  `json:"label"`
+	Capacity float64 `json:"capacity"`
+	MaxQty   int     `json:"max_qty"`
 }
 
+// Fort trading limits: the most bundles in one order and the most buys and
+// sells in one visit.
+const (
+	MaxFortQty    = 100
+	MaxFortTrades = 20
+)
+
 func GetFortPrices() map[string]FortItem {
 	return map[string]FortItem{
-		"food":     {Price: 10, Qty: 25, Label: "Food Pack (25 lbs)"},
-		"bullets":  {Price: 5, Qty: 50, Label: "Ammo Box (50 rounds)"},
-		"clothing": {Price: 5, Qty: 5, Label: "Clothing (5 sets)"},
-		"misc":     {Price: 5, Qty: 5, Label: "Supply Kit (5 kits)"},
+		"food":     {Price: 10, Qty: 25, Label: "Food Pack (25 lbs)", Capacity: FoodCapacity, MaxQty: MaxFortQty},
+		"bullets":  {Price: 5, Qty: 50, Label: "Ammo Box (50 rounds)", Capacity: BulletCapacity, MaxQty: MaxFortQty},
+		"clothing": {Price: 5, Qty: 5, Label: "Clothing (5 sets)", Capacity: ClothingCapacity, MaxQty: MaxFortQty},
+		"misc":     {Price: 5, Qty: 5, Label: "Supply Kit (5 kits)", Capacity: MiscCapacity, MaxQty: MaxFortQty},
+		"oxen":     {Price: 40, Qty: 20, Label: "Ox (+20 team strength)", Capacity: OxenMaxStrength, MaxQty: MaxFortQty},
 	}
 }
 
-func (g *GameState) HandleFort(p *Player) string {
-	result := &strings.Builder{}
+// CheckFortQty validates the number of bundles in a fort order as sent by a
+// client.
+func CheckFortQty(qty float64) error {
+	switch {
+	case qty != math.Trunc(qty):
+		return errors.New("quantity must be a whole number")
+	case qty < 1:
+		return errors.New("quantity must be at least 1")
+	case qty > MaxFortQty:
+		return fmt.Errorf("quantity must be at most %d", MaxFortQty)
+	}
+	return nil
+}
+
+// EnterFort stops the wagon at the trading post and opens a new visit.
+func (g *GameState) EnterFort() {
+	g.TurnPhase = PhaseFort
+	g.FortTrades = 0
+}
+
+// fortStock returns a pointer to the wagon's supply of a trade item.
+func (g *GameState) fortStock(item string) *float64 {
+	switch item {
+	case "food":
+		return &g.Food
+	case "bullets":
+		return &g.Bullets
+	case "clothing":
+		return &g.Clothing
+	case "misc":
+		return &g.MiscSupplies
+	case "oxen":
+		return &g.OxenCost
+	}
+	return nil
+}
+
+// checkFortTrade reports why a buy or sell of qty bundles can't go ahead,
+// or "" if it can.
+func (g *GameState) checkFortTrade(qty int) string {
+	if err := CheckFortQty(float64(qty)); err != nil {
+		return fmt.Sprintf("Invalid order: %v.\n", err)
+	}
+	return g.checkFortTradeLimit(1)
+}
+
+// checkFortTradeLimit reports whether n more trades would go over the
+// visit's limit.
+func (g *GameState) checkFortTradeLimit(n int) string {
+	if g.FortTrades+n > MaxFortTrades {
+		return fmt.Sprintf("The trader won't deal any more this visit (limit %d trades).\n", MaxFortTrades)
+	}
+	return ""
+}
+
+// fortPurchase is a priced order for trade goods that hasn't been paid for.
+// left is the bundles ordered that didn't fit in the wagon.
+type fortPurchase struct {
+	item   string
+	cost   float64
+	gained float64
+	left   int
+}
+
+// priceFortBuy prices qty bundles of an item, cut down to what fits in the
+// wagon. Cash is left to the caller.
+func (g *GameState) priceFortBuy(item string, qty int) (fortPurchase, string) {
+	fi, ok := GetFortPrices()[item]
+	if !ok {
+		return fortPurchase{}, "Unknown item.\n"
+	}
+	fits := min(qty, int(g.roomFor(item)/fi.Qty))
+	if fits < 1 {
+		return fortPurchase{}, fmt.Sprintf("The wagon can carry at most %.0f %s! You have %.0f\n", g.capacity(item), item, *g.fortStock(item))
+	}
+	return fortPurchase{item: item, cost: fi.Price * float64(fits), gained: fi.Qty * float64(fits), left: qty - fits}, ""
+}
+
+// applyFortBuy pays for a priced purchase and loads it into the wagon.
+func (g *GameState) applyFortBuy(p fortPurchase) *TurnResult {
+	g.Cash -= p.cost
+	g.contribution(g.actingPlayerID(nil)).FortSpending += p.cost
+	*g.fortStock(p.item) += p.gained
+	g.FortTrades++
+
+	g.ClampResources()
+	msg := fmt.Sprintf("Bought %s for $%.0f\n", fortGoods(p.item, p.gained), p.cost)
+	if p.left > 0 {
+		msg += fmt.Sprintf("The wagon is full; the other %d of your order stay at the fort.\n", p.left)
+	}
+	return NewResultWith(ResultFortBuy, msg,
+		map[string]float64{"qty": p.gained, "cost": p.cost, "left": float64(p.left)})
+}
+
+func (g *GameState) HandleFort(p *Player) *TurnResult {
+	return g.visitFort(p, p.Type == PlayerTypeCPU)
+}
+
+// visitFort detours to the fort. With auto set, supplies are bought the way
+// a CPU player would; otherwise the game waits in PhaseFort for the player.
+func (g *GameState) visitFort(p *Player, auto bool) *TurnResult {
+	result := &TurnResult{}
 
 	g.Mileage -= 45
 	g.ClampResources()
 
-	if p.Type == PlayerTypeCPU {
-		result.WriteString("AT THE FORT - Prices are 50% higher\n")
+	if auto {
+		cashBefore := g.Cash
+		defer func() { g.contribution(p.ID).FortSpending += cashBefore - g.Cash }()
 		if g.Food < 100 && g.Cash >= 10 {
-			bundles := int(g.Cash * 0.3 / 10)
+			bundles := min(int(g.Cash*0.3/10), int(g.roomFor("food")/25))
 			if bundles > 0 {
 				cost := float64(bundles) * 10
 				g.Food += float64(bundles) * 25
@@ -75,15 +190,22 @@ func (g *GameState) HandleFort(p *Player) string {
 			}
 		}
 		if g.Bullets < 200 && g.Cash >= 5 {
-			bundles := int(g.Cash * 0.2 / 5)
+			bundles := min(int(g.Cash*0.2/5), int(g.roomFor("bullets")/50))
 			if bundles > 0 {
 				cost := float64(bundles) * 5
 				g.Bullets += float64(bundles) * 50
 				g.Cash -= cost
 			}
 		}
+		if g.OxenCritical() && g.Cash >= 40 {
+			oxen := int(math.Min(g.Cash/40, (OxenMaxStrength-g.OxenCost)/20))
+			if oxen > 0 {
+				g.OxenCost += float64(oxen) * 20
+				g.Cash -= float64(oxen) * 40
+			}
+		}
 		if g.Clothing < 30 && g.Cash >= 5 {
-			bundles := int(g.Cash * 0.15 / 5)
+			bundles := min(int(g.Cash*0.15/5), int(g.roomFor("clothing")/5))
 			if bundles > 0 {
 				cost := float64(bundles) * 5
 				g.Clothing += float64(bundles) * 5
@@ -91,64 +213,58 @@ func (g *GameState) HandleFort(p *Player) string {
 			}
 		}
 		g.ClampResources()
-		result.WriteString("CPU purchased supplies at the fort.\n")
+		result.Add(ResultFortAuto, "AT THE FORT - Prices are 50% higher\nCPU purchased supplies at the fort.\n",
+			map[string]float64{"spent": cashBefore - g.Cash})
 	} else {
-		g.TurnPhase = PhaseFort
-		result.WriteString("You arrived at the fort. Browse the trading post!\n")
+		g.EnterFort()
+		result.Add(ResultFortArrive, "You arrived at the fort. Browse the trading post!\n", nil)
 	}
 
-	return result.String()
+	return result
 }
 
-func (g *GameState) HandleFortBuy(item string, qty int) string {
+func (g *GameState) HandleFortBuy(item string, qty int) *TurnResult {
 	if g.TurnPhase != PhaseFort {
-		return "You're not at a fort!\n"
+		return Refuse(ReasonInvalidPhase, "You're not at a fort!\n")
 	}
-	if qty <= 0 {
-		return "Invalid quantity.\n"
+	if _, ok := GetFortUpgrades()[item]; ok {
+		return g.buyUpgrade(item)
 	}
-
-	prices := GetFortPrices()
-	fi, ok := prices[item]
-	if !ok {
-		return "Unknown item.\n"
+	if item == ForecastItem {
+		return g.buyForecast()
 	}
-
-	cost := fi.Price * float64(qty)
-	if cost > g.Cash {
-		return fmt.Sprintf("Not enough cash! Need $%.0f but only have $%.0f\n", cost, g.Cash)
+	if msg := g.checkFortTrade(qty); msg != "" {
+		return Refuse(ReasonInvalid, msg)
 	}
-
-	g.Cash -= cost
-	gained := fi.Qty * float64(qty)
-
-	switch item {
-	case "food":
-		g.Food += gained
-	case "bullets":
-		g.Bullets += gained
-	case "clothing":
-		g.Clothing += gained
-	case "misc":
-		g.MiscSupplies += gained
+	if _, ok := GetFortParts()[item]; ok {
+		return g.buyPart(item, qty)
 	}
 
-	g.ClampResources()
-	return fmt.Sprintf("Bought %.0f %s for $%.0f\n", gained, item, cost)
+	purchase, msg := g.priceFortBuy(item, qty)
+	if msg != "" {
+		return Refuse(ReasonInvalid, msg)
+	}
+	if purchase.cost > g.Cash {
+		return Refuse(ReasonInsufficientCash, fmt.Sprintf("Not enough cash! Need $%.0f but only have $%.0f\n", purchase.cost, g.Cash))
+	}
+	return g.applyFortBuy(purchase)
 }
 
-func (g *GameState) HandleFortSell(item string, qty int) string {
+func (g *GameState) HandleFortSell(item string, qty int) *TurnResult {
 	if g.TurnPhase != PhaseFort {
-		return "You're not at a fort!\n"
+		return Refuse(ReasonInvalidPhase, "You're not at a fort!\n")
 	}
-	if qty <= 0 {
-		return "Invalid quantity.\n"
+	if msg := g.checkFortTrade(qty); msg != "" {
+		return Refuse(ReasonInvalid, msg)
+	}
+	if _, ok := GetFortParts()[item]; ok {
+		return g.sellPart(item, qty)
 	}
 
 	prices := GetFortPrices()
 	fi, ok := prices[item]
 	if !ok {
-		return "Unknown item.\n"
+		return Refuse(ReasonInvalid, "Unknown item.\n")
 	}
 
 	// Sell at 50% of buy price
@@ -160,157 +276,302 @@ func (g *GameState) HandleFortSell(item string, qty int) string {
 	switch item {
 	case "food":
 		if g.Food < amount {
-			return fmt.Sprintf("Not enough food to sell! Have %.0f, need %.0f\n", g.Food, amount)
+			return Refuse(ReasonInsufficientSupplies, fmt.Sprintf("Not enough food to sell! Have %.0f, need %.0f\n", g.Food, amount))
 		}
 		g.Food -= amount
 	case "bullets":
 		if g.Bullets < amount {
-			return fmt.Sprintf("Not enough bullets to sell! Have %.0f, need %.0f\n", g.Bullets, amount)
+			return Refuse(ReasonInsufficientSupplies, fmt.Sprintf("Not enough bullets to sell! Have %.0f, need %.0f\n", g.Bullets, amount))
 		}
 		g.Bullets -= amount
 	case "clothing":
 		if g.Clothing < amount {
-			return fmt.Sprintf("Not enough clothing to sell! Have %.0f, need %.0f\n", g.Clothing, amount)
+			return Refuse(ReasonInsufficientSupplies, fmt.Sprintf("Not enough clothing to sell! Have %.0f, need %.0f\n", g.Clothing, amount))
 		}
 		g.Clothing -= amount
 	case "misc":
 		if g.MiscSupplies < amount {
-			return fmt.Sprintf("Not enough supplies to sell! Have %.0f, need %.0f\n", g.MiscSupplies, amount)
+			return Refuse(ReasonInsufficientSupplies, fmt.Sprintf("Not enough supplies to sell! Have %.0f, need %.0f\n", g.MiscSupplies, amount))
 		}
 		g.MiscSupplies -= amount
+	case "oxen":
+		if g.OxenCost < amount {
+			return Refuse(ReasonInsufficientSupplies, fmt.Sprintf("Not enough oxen to sell! Team strength %.0f, need %.0f\n", g.OxenCost, amount))
+		}
+		g.OxenCost -= amount
 	}
 
 	g.Cash += earnings
+	g.FortTrades++
 	g.ClampResources()
-	return fmt.Sprintf("Sold %.0f %s for $%.0f\n", amount, item, earnings)
+	result := &TurnResult{}
+	result.Add(ResultFortSell, fmt.Sprintf("Sold %s for $%.0f\n", fortGoods(item, amount), earnings),
+		map[string]float64{"qty": amount, "earnings": earnings})
+	return result
 }
 
-func (g *GameState) HandleFortLeave() string {
+func (g *GameState) HandleFortLeave() *TurnResult {
 	g.TurnPhase = PhaseMainMenu
-	return "You leave the fort and continue on the trail.\n"
+	return NewResult(ResultFortLeave, "You leave the fort and continue on the trail.\n")
 }
 
-func (g *GameState) ContinueTravel(p *Player) string {
-	result := &strings.Builder{}
+func (g *GameState) ContinueTravel(p *Player) *TurnResult {
+	return g.continueTravel(p, p.Type == PlayerTypeCPU)
+}
+
+// continueTravel plays the travel part of a turn. With auto set, eating and
+// rider choices are made the way a CPU player would instead of pausing in
+// PhaseRiders for the player.
+func (g *GameState) continueTravel(p *Player, auto bool) *TurnResult {
+	result := g.rollWeather()
 
 	// Starvation deals HP damage instead of instant death
-	if g.Food < 13 {
-		result.WriteString("FOOD IS CRITICALLY LOW! Your party is starving!\n")
+	if g.Food < LowFoodThreshold {
+		result.Add(ResultStarving, "FOOD IS CRITICALLY LOW! Your party is starving!\n", nil)
 		// Deal 20 HP damage to all alive members
 		for i := range p.Party {
 			if p.Party[i].Alive {
-				result.WriteString(g.DamagePartyMember(p, i, 20))
+				result.Text(g.DamagePartyMember(p, i, 20, "starvation"))
 			}
 		}
 		if !p.Alive {
-			return result.String()
+			return result
 		}
 	}
 
 	eatingLevel := 2
-	if p.Type == PlayerTypeCPU {
-		if g.Food > 200 {
-			eatingLevel = 3
-		} else if g.Food > 100 {
-			eatingLevel = 2
-		} else {
-			eatingLevel = 1
-		}
+	if auto {
+		eatingLevel = g.cpuEatingLevel()
 	}
 
-	foodConsumed := 8 + 5*eatingLevel
-	g.Food -= float64(foodConsumed)
+	g.Food -= foodConsumption(eatingLevel)
 
 	// Adjusted travel: ~80-95 miles/turn for 4500 mile trail
 	baseTravel := 80.0 + (g.OxenCost-220)/5 + g.Rand.Float64()*15
+	if g.Season() == SeasonWinter {
+		baseTravel *= winterTravelFactor
+	}
+	baseTravel *= paceEffects[g.CurrentPace()].travel
+	if g.OxenCritical() {
+		baseTravel = oxenCrawlMiles + g.Rand.Float64()*10
+		result.Add(ResultOxenCritical, "Your oxen can barely pull the wagon! You crawl along the trail.\n",
+			map[string]float64{"strength": g.OxenCost})
+	}
 	g.Mileage += baseTravel
 
-	result.WriteString(fmt.Sprintf("\nYou traveled %.0f miles this week.\n", baseTravel))
+	result.Add(ResultTravel, fmt.Sprintf("\nYou traveled %.0f miles this week.\n", baseTravel),
+		map[string]float64{"miles": baseTravel})
+	result.Text(g.paceWear(p))
+	if !p.Alive {
+		return result
+	}
 
-	result.WriteString(g.HandleRiverCrossing(p))
+	result.Text(g.HandleRiverCrossing(p))
 
 	// Check for riders — interactive for humans, auto for CPU
 	if baseTravel > 1 && !g.GameOver && p.Alive {
 		if g.CheckRiders() {
-			if p.Type == PlayerTypeHuman {
+			if !auto {
 				// Store state and pause for player choice
 				g.TurnPhase = PhaseRiders
 				g.PendingEatingLevel = eatingLevel
+				params := map[string]float64{"count": float64(g.PendingRiderCount)}
 				if g.PendingRiderHostile {
-					result.WriteString(fmt.Sprintf("\nRIDERS AHEAD! %d hostile riders approaching!\n", g.PendingRiderCount))
+					params["hostile"] = 1
+					result.Add(ResultRiders, fmt.Sprintf("\nRIDERS AHEAD! %d hostile riders approaching!\n", g.PendingRiderCount), params)
 				} else {
-					result.WriteString(fmt.Sprintf("\nRIDERS AHEAD. %d riders, they don't look hostile.\n", g.PendingRiderCount))
+					params["hostile"] = 0
+					result.Add(ResultRiders, fmt.Sprintf("\nRIDERS AHEAD. %d riders, they don't look hostile.\n", g.PendingRiderCount), params)
 				}
-				return result.String() // Pause — waiting for rider_tactic
+				return result // Pause — waiting for rider_tactic
 			}
 			// CPU auto-resolves
 			tactic := g.cpuChooseTactic(g.PendingRiderHostile)
-			result.WriteString(g.ResolveRiderTactic(p, tactic))
+			result.Text(g.ResolveRiderTactic(p, tactic))
 		}
 	}
 
 	if !g.GameOver && p.Alive {
-		result.WriteString(g.FinishTurn(p, eatingLevel))
+		result.Append(g.FinishTurn(p, eatingLevel))
 	}
 
-	return result.String()
+	return result
+}
+
+// CPU hunting thresholds: hunt instead of travelling when food runs low and
+// there are bullets to spare.
+const (
+	cpuHuntFoodBelow    = 60
+	cpuHuntBulletsAbove = 100
+)
+
+// cpuEatingLevel is how well a CPU player feeds the party: better the more
+// food there is.
+func (g *GameState) cpuEatingLevel() int {
+	if g.Food > 200 {
+		return 3
+	} else if g.Food > 100 {
+		return 2
+	}
+	return 1
+}
+
+// PlayCPUTurn plays one complete, non-interactive turn for p the way a CPU
+// player would: visiting the fort if one is available, deciding whether to
+// hunt, resolving riders and the end-of-turn upkeep. It works for human
+// players too and never leaves the game in an interactive phase.
+func (g *GameState) PlayCPUTurn(p *Player) *TurnResult {
+	if p == nil {
+		return Refuse(ReasonInvalid, "Error: Player not found.\n")
+	}
+	if !p.Alive {
+		return NewResult(ResultSpectating, "Your party has perished. You are spectating.\n")
+	}
+	result := &TurnResult{}
+
+	g.TurnPhase = PhaseMainMenu
+
+	if g.FortAvailable {
+		result.Append(g.visitFort(p, true))
+		g.FortAvailable = false
+	}
+
+	if g.Food < cpuHuntFoodBelow && g.Bullets > cpuHuntBulletsAbove {
+		result.Text(g.hunt(p, g.cpuShootingTime(p)))
+		if !g.GameOver && p.Alive {
+			result.Append(g.FinishTurn(p, g.cpuEatingLevel()))
+		}
+	} else {
+		result.Append(g.continueTravel(p, true))
+	}
+
+	g.TurnPhase = PhaseMainMenu
+	return result
 }
 
 // FinishTurn completes the rest of a turn after riders are resolved.
-func (g *GameState) FinishTurn(p *Player, eatingLevel int) string {
-	result := &strings.Builder{}
+func (g *GameState) FinishTurn(p *Player, eatingLevel int) *TurnResult {
+	result := &TurnResult{}
 
 	if !g.GameOver && p.Alive {
-		result.WriteString(g.HandleRandomEvent(p))
+		result.Text(g.HandleRandomEvent(p))
 	}
 
 	if !g.GameOver && p.Alive && g.Mileage > float64(MountainThreshold) {
-		result.WriteString(g.HandleMountains(p))
+		result.Text(g.HandleMountains(p))
 	}
 
 	g.HandleEatingResult(p, eatingLevel)
 
 	g.ClampResources()
+	result.Append(g.checkLandmarks())
 
 	if !g.GameOver && g.Mileage >= float64(TrailLength) {
 		g.HandleFinalTurn(p)
 	}
 
-	return result.String()
+	return result
 }
 
-// HandleHuntShoot resolves a hunting attempt based on player reaction time.
-func (g *GameState) HandleHuntShoot(p *Player, reactionTimeMs int) string {
-	if p == nil {
-		return "Error: Player not found.\n"
+// Hunt timing. The word is shown a random delay after the prompt is issued
+// and the shot is timed on the server from then, less an allowance for the
+// round trip to the client: huntLatencyMs, or the client's measured round
+// trip up to huntMaxLatencyMs if that is longer.
+const (
+	huntMinDelayMs    = 1000
+	huntMaxDelayMs    = 3000
+	huntLatencyMs     = 250
+	huntMaxLatencyMs  = 600
+	huntMinReactionMs = 150
+)
+
+// HuntMissMs is the reaction time of a shot that can't hit: a wrong word,
+// or a shot before the word was shown.
+const HuntMissMs = 9999
+
+// HuntTimeLimit is how long a hunter has from the prompt to shoot; a hunt
+// still open after that is scored as a miss.
+const HuntTimeLimit = 10 * time.Second
+
+// IssueHuntPrompt picks the word to shoot on and starts the hunt clock.
+func (g *GameState) IssueHuntPrompt() {
+	g.HuntWord = g.GetShootingPrompt()
+	g.HuntDelayMs = huntMinDelayMs + g.Rand.Intn(huntMaxDelayMs-huntMinDelayMs)
+	g.HuntStartedAt = time.Now()
+}
+
+// ShownHuntWord returns the hunt's word once its delay has passed at now,
+// and "" before. Until then only the server knows it, so nobody can aim a
+// shot at the exact moment it appears.
+func (g *GameState) ShownHuntWord(now time.Time) string {
+	if now.Before(g.HuntWordAt()) {
+		return ""
 	}
-	result := &strings.Builder{}
+	return g.HuntWord
+}
 
-	// Calculate accuracy from reaction time
-	var accuracy float64
-	switch {
-	case reactionTimeMs < 300:
-		accuracy = 0
-	case reactionTimeMs < 600:
-		accuracy = 1 + float64(reactionTimeMs-300)/300.0
-	case reactionTimeMs < 1000:
-		accuracy = 3 + float64(reactionTimeMs-600)/250.0
-	case reactionTimeMs < 2000:
-		accuracy = 6 + float64(reactionTimeMs-1000)/500.0
-	default:
-		accuracy = 9
+// HuntWordAt is when the hunt's word is shown.
+func (g *GameState) HuntWordAt() time.Time {
+	return g.HuntStartedAt.Add(time.Duration(g.HuntDelayMs) * time.Millisecond)
+}
+
+// huntReactionTime times a shot from when the word was shown. The client's
+// own measurement, less half its round trip, can only make the shot slower,
+// never faster. A wrong word or a shot before the word appeared scores as a
+// miss.
+func (g *GameState) huntReactionTime(word string, reportedMs, latencyMs int, now time.Time) int {
+	if g.HuntWord == "" || !strings.EqualFold(strings.TrimSpace(word), g.HuntWord) {
+		return HuntMissMs
 	}
+	elapsed := int(now.Sub(g.HuntStartedAt).Milliseconds()) - g.HuntDelayMs
+	if elapsed < 0 {
+		return HuntMissMs
+	}
+	latencyMs = min(max(latencyMs, 0), huntMaxLatencyMs)
+	reaction := elapsed - max(latencyMs, huntLatencyMs)
+	if reported := reportedMs - latencyMs/2; reported > reaction {
+		reaction = reported
+	}
+	if reaction < huntMinReactionMs {
+		reaction = huntMinReactionMs
+	}
+	if reaction > HuntMissMs {
+		reaction = HuntMissMs
+	}
+	return reaction
+}
+
+// HandleHuntShoot resolves a hunting attempt. The player sends back the word
+// they were shown and their own reaction time; the server times the shot,
+// allowing for the player's round trip, latencyMs (0 if unknown).
+func (g *GameState) HandleHuntShoot(p *Player, word string, reportedMs, latencyMs int) *TurnResult {
+	if p == nil {
+		return Refuse(ReasonInvalid, "Error: Player not found.\n")
+	}
+	result := &TurnResult{}
+
+	// The ante for the hunt, paid now that the shot is taken
+	g.Bullets -= huntBulletCost
+
+	reactionTimeMs := g.huntReactionTime(word, reportedMs, latencyMs, time.Now())
+	g.HuntWord = ""
+	accuracy := huntAccuracy(reactionTimeMs, g.HasRifleScope)
 
 	if accuracy <= 2 {
 		foodGained := 52 + g.Rand.Float64()*6
 		g.Food += foodGained
-		result.WriteString(fmt.Sprintf("RIGHT BETWEEN THE EYES! You got a big one!\nFull bellies tonight! (+%.0f food)\n", foodGained))
+		g.contribution(p.ID).FoodHunted += foodGained
+		result.Add(ResultHuntHit, fmt.Sprintf("RIGHT BETWEEN THE EYES! You got a big one!\nFull bellies tonight! (+%.0f food)\n", foodGained),
+			map[string]float64{"food": foodGained, "big": 1, "reaction_ms": float64(reactionTimeMs)})
 	} else if g.Rand.Float64()*100 < 13*accuracy {
-		result.WriteString("You missed - and your dinner got away...\n")
+		result.Add(ResultHuntMiss, "You missed - and your dinner got away...\n",
+			map[string]float64{"reaction_ms": float64(reactionTimeMs)})
 	} else {
 		foodGained := 48 - 2*accuracy
 		g.Food += foodGained
-		result.WriteString(fmt.Sprintf("Nice shot! Right on target! Good eatin' tonight! (+%.0f food)\n", foodGained))
+		g.contribution(p.ID).FoodHunted += foodGained
+		result.Add(ResultHuntHit, fmt.Sprintf("Nice shot! Right on target! Good eatin' tonight! (+%.0f food)\n", foodGained),
+			map[string]float64{"food": foodGained, "big": 0, "reaction_ms": float64(reactionTimeMs)})
 	}
 
 	g.Bullets -= 10 + 3*accuracy
@@ -321,9 +582,11 @@ func (g *GameState) HandleHuntShoot(p *Player, reactionTimeMs int) string {
 	// Hunting adds reduced travel distance
 	huntTravel := 45 + g.Rand.Float64()*20
 	g.Mileage += huntTravel
-	result.WriteString(fmt.Sprintf("You traveled %.0f miles while hunting.\n", huntTravel))
+	result.Add(ResultHuntTravel, fmt.Sprintf("You traveled %.0f miles while hunting.\n", huntTravel),
+		map[string]float64{"miles": huntTravel})
 
 	g.ClampResources()
+	result.Append(g.checkLandmarks())
 	g.TurnPhase = PhaseMainMenu
 
 	// Check win condition
@@ -331,25 +594,83 @@ func (g *GameState) HandleHuntShoot(p *Player, reactionTimeMs int) string {
 		g.HandleFinalTurn(p)
 	}
 
-	return result.String()
+	return result
+}
+
+// CancelHunt puts the rifle away before the shot. No bullets are spent and
+// the turn goes on as travel.
+func (g *GameState) CancelHunt(p *Player) *TurnResult {
+	if p == nil {
+		return Refuse(ReasonInvalid, "Error: Player not found.\n")
+	}
+	g.HuntWord = ""
+	g.TurnPhase = PhaseMainMenu
+	result := NewResult(ResultHuntCancel, "You put the rifle away and push on.\n")
+	result.Append(g.ContinueTravel(p))
+	return result
+}
+
+// TimeOutHunt ends a hunt the player never shot in, as a miss.
+func (g *GameState) TimeOutHunt(p *Player) *TurnResult {
+	if p == nil {
+		return Refuse(ReasonInvalid, "Error: Player not found.\n")
+	}
+	result := NewResult(ResultHuntTimeout, "You waited too long to shoot.\n")
+	result.Append(g.HandleHuntShoot(p, "", HuntMissMs, 0))
+	return result
+}
+
+// huntBucket is one segment of the reaction-time accuracy curve: reaction
+// times in [startMs, endMs) score base + (t-startMs)*slope.
+type huntBucket struct {
+	startMs, endMs int
+	base, slope    float64
+}
+
+var huntBuckets = []huntBucket{
+	{0, 300, 0, 0},
+	{300, 600, 1, 1 / 300.0},
+	{600, 1000, 3, 1 / 250.0},
+	{1000, 2000, 6, 1 / 500.0},
+	{2000, 2000, 9, 0},
+}
+
+// huntAccuracy maps a reaction time to a hunting accuracy (lower is better).
+// A rifle scope scores the shot one bucket better, at the same relative
+// position within that bucket.
+func huntAccuracy(reactionTimeMs int, scoped bool) float64 {
+	idx := len(huntBuckets) - 1
+	frac := 1.0
+	for i, b := range huntBuckets[:idx] {
+		if reactionTimeMs < b.endMs {
+			idx = i
+			frac = float64(reactionTimeMs-b.startMs) / float64(b.endMs-b.startMs)
+			break
+		}
+	}
+	if scoped && idx > 0 {
+		idx--
+	}
+	b := huntBuckets[idx]
+	return b.base + frac*float64(b.endMs-b.startMs)*b.slope
 }
 
 // HandleRiderTactic resolves a rider encounter with the player's chosen tactic,
 // then finishes the rest of the turn.
-func (g *GameState) HandleRiderTactic(p *Player, tactic int) string {
+func (g *GameState) HandleRiderTactic(p *Player, tactic int) *TurnResult {
 	if p == nil {
-		return "Error: Player not found.\n"
+		return Refuse(ReasonInvalid, "Error: Player not found.\n")
 	}
-	result := &strings.Builder{}
+	result := &TurnResult{}
 
-	result.WriteString(g.ResolveRiderTactic(p, tactic))
+	result.Text(g.ResolveRiderTactic(p, tactic))
 
 	if !g.GameOver && p.Alive {
-		result.WriteString(g.FinishTurn(p, g.PendingEatingLevel))
+		result.Append(g.FinishTurn(p, g.PendingEatingLevel))
 	}
 
 	g.TurnPhase = PhaseMainMenu
-	return result.String()
+	return result
 }
 
 func (g *GameState) HandleEatingResult(p *Player, eatingLevel int) string {
@@ -384,10 +705,13 @@ func (g *GameState) HandleMountains(p *Player) string {
 		}
 	}
 
-	// Blizzard in final stretch (3800-4500)
-	if g.Mileage > 3800 && g.Mileage < float64(TrailLength) {
-		if g.Rand.Float64() < 0.3 {
+	// Blizzards strike the final stretch (3800-4500) depending on the
+	// season, and anywhere in the mountains in winter
+	season := g.Season()
+	if g.Mileage < float64(TrailLength) && (g.Mileage > 3800 || season == SeasonWinter) {
+		if g.Rand.Float64() < blizzardChance[season] {
 			result.WriteString("BLIZZARD IN MOUNTAIN PASS - Time and supplies lost\n")
+			g.weatheredBlizzard = true
 			g.Food -= 25
 			g.MiscSupplies -= 10
 			g.Bullets -= 30
@@ -415,13 +739,13 @@ func (g *GameState) HandleFinalTurn(p *Player) string {
 	result.WriteString(fmt.Sprintf("Arrival: %s\n", arrivalDate))
 
 	// Show party survivors
-	survivors := 0
+	survivors := g.CountSurvivors(p)
+	result.WriteString(fmt.Sprintf("\nSurvivors: %d of %d party members\n", survivors, len(p.Party)))
 	for _, m := range p.Party {
-		if m.Alive {
-			survivors++
+		if !m.Alive {
+			result.WriteString(fmt.Sprintf("  %s - died of %s on turn %d\n", m.Name, m.DiedOf, m.DiedOnTurn))
 		}
 	}
-	result.WriteString(fmt.Sprintf("\nSurvivors: %d of %d party members\n", survivors, len(p.Party)))
 
 	result.WriteString("\nFINAL INVENTORY:\n")
 	result.WriteString(fmt.Sprintf("  Food: %.0f\n", g.Food))
@@ -429,6 +753,7 @@ func (g *GameState) HandleFinalTurn(p *Player) string {
 	result.WriteString(fmt.Sprintf("  Clothing: %.0f\n", g.Clothing))
 	result.WriteString(fmt.Sprintf("  Misc Supplies: %.0f\n", g.MiscSupplies))
 	result.WriteString(fmt.Sprintf("  Cash: $%.2f\n", g.Cash))
+	result.WriteString(fmt.Sprintf("\nFINAL SCORE: %d\n", g.ComputeScore(p)))
 
 	result.WriteString("\nPRESIDENT JAMES K. POLK SENDS YOU HIS\n")
 	result.WriteString("HEARTIEST CONGRATULATIONS\n")
@@ -443,17 +768,8 @@ func (g *GameState) HandleFinalTurn(p *Player) string {
 }
 
 func (g *GameState) calculateArrivalDate() string {
-	days := g.TurnNumber * 7
-	month := "JULY"
-	day := 1
-
-	baseDate := time.Date(1847, time.March, 29, 0, 0, 0, 0, time.UTC)
-	arrival := baseDate.AddDate(0, 0, days)
-
-	month = arrival.Month().String()
-	day = arrival.Day()
-
-	return fmt.Sprintf("%s %d, 1847", month, day)
+	arrival := g.CurrentDate()
+	return fmt.Sprintf("%s %d, %d", arrival.Month(), arrival.Day(), arrival.Year())
 }
 
 func (g *GameState) formatStatus() string {
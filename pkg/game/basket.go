@@ -0,0 +1,87 @@
+package game
+
+import (
+	"fmt"
+	"math"
+)
+
+// FortOrder is one line of a fort basket: a trade item and a number of
+// bundles.
+type FortOrder struct {
+	Item string `json:"item"`
+	Qty  int    `json:"qty"`
+}
+
+// HandleFortBuyMax buys as many bundles of an item as the cash on hand,
+// the order limit and the wagon's room allow.
+func (g *GameState) HandleFortBuyMax(item string) *TurnResult {
+	if g.TurnPhase != PhaseFort {
+		return Refuse(ReasonInvalidPhase, "You're not at a fort!\n")
+	}
+	fi, ok := GetFortPrices()[item]
+	if !ok {
+		return Refuse(ReasonInvalid, "Unknown item.\n")
+	}
+	qty := int(math.Min(float64(MaxFortQty), math.Min(
+		math.Floor(g.Cash/fi.Price),
+		math.Floor(g.roomFor(item)/fi.Qty))))
+	if qty < 1 {
+		// Nothing fits; let a single bundle report which limit got in the way
+		qty = 1
+	}
+	return g.HandleFortBuy(item, qty)
+}
+
+// HandleFortBasket buys several trade goods at once. The whole basket is
+// checked before anything is bought: if any line is refused or the total is
+// more than the cash on hand, nothing changes.
+func (g *GameState) HandleFortBasket(orders []FortOrder) *TurnResult {
+	if g.TurnPhase != PhaseFort {
+		return Refuse(ReasonInvalidPhase, "You're not at a fort!\n")
+	}
+	if len(orders) == 0 {
+		return Refuse(ReasonInvalid, "Your basket is empty.\n")
+	}
+
+	// Merge repeated items so the limits apply to the total of each
+	var merged []FortOrder
+	index := make(map[string]int)
+	for _, o := range orders {
+		if i, ok := index[o.Item]; ok {
+			merged[i].Qty += o.Qty
+			continue
+		}
+		index[o.Item] = len(merged)
+		merged = append(merged, o)
+	}
+	if msg := g.checkFortTradeLimit(len(merged)); msg != "" {
+		return Refuse(ReasonInvalid, msg)
+	}
+
+	purchases := make([]fortPurchase, 0, len(merged))
+	total := 0.0
+	for _, o := range merged {
+		if err := CheckFortQty(float64(o.Qty)); err != nil {
+			return Refuse(ReasonInvalid, fmt.Sprintf("Basket refused, %s: %v.\n", o.Item, err))
+		}
+		p, msg := g.priceFortBuy(o.Item, o.Qty)
+		if msg != "" {
+			return Refuse(ReasonInvalid, fmt.Sprintf("Basket refused, %s: %s", o.Item, msg))
+		}
+		purchases = append(purchases, p)
+		total += p.cost
+	}
+	if total > g.Cash {
+		return RefuseWith(ReasonInsufficientCash,
+			fmt.Sprintf("Your basket costs $%.0f but you only have $%.0f ($%.0f short). Nothing was bought.\n", total, g.Cash, total-g.Cash),
+			map[string]float64{"cost": total, "cash": g.Cash, "shortfall": total - g.Cash})
+	}
+
+	result := &TurnResult{}
+	for _, p := range purchases {
+		result.Append(g.applyFortBuy(p))
+	}
+	result.Add(ResultFortBasket, fmt.Sprintf("Basket total: $%.0f for %d items\n", total, len(purchases)),
+		map[string]float64{"cost": total, "items": float64(len(purchases))})
+	return result
+}
@@ -0,0 +1,99 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// How much of each kind of goods a standard wagon can carry.
+const (
+	FoodCapacity     = 2000
+	BulletCapacity   = 5000
+	ClothingCapacity = 200
+	MiscCapacity     = 200
+)
+
+// UpgradeWagonBed is the fort upgrade that makes room for more goods, and
+// wagonBedFactor how much more.
+const (
+	UpgradeWagonBed = "wagon_bed"
+	wagonBedFactor  = 1.5
+)
+
+// Capacities returns how much of each kind of goods the wagon can carry,
+// keyed like GetFortPrices.
+func (g *GameState) Capacities() map[string]float64 {
+	caps := map[string]float64{
+		"food":     FoodCapacity,
+		"bullets":  BulletCapacity,
+		"clothing": ClothingCapacity,
+		"misc":     MiscCapacity,
+	}
+	if g.HasWagonBed {
+		for item := range caps {
+			caps[item] *= wagonBedFactor
+		}
+	}
+	caps["oxen"] = OxenMaxStrength
+	return caps
+}
+
+// capacity is the most of a trade item the wagon can carry.
+func (g *GameState) capacity(item string) float64 {
+	return g.Capacities()[item]
+}
+
+// roomFor is how much more of a trade item fits in the wagon.
+func (g *GameState) roomFor(item string) float64 {
+	return math.Max(0, g.capacity(item)-*g.fortStock(item))
+}
+
+// stow loads up to amount of a trade item into the wagon and returns what
+// didn't fit.
+func (g *GameState) stow(item string, amount float64) float64 {
+	kept := math.Min(amount, g.roomFor(item))
+	*g.fortStock(item) += kept
+	return amount - kept
+}
+
+// ClaimLoot takes what fits from an abandoned wagon: its supplies, cash,
+// spare parts and, if it had one, its extended wagon bed. Whatever doesn't
+// fit stays at the site; the returned text says what was left behind, and
+// is "" once the site is empty.
+func (g *GameState) ClaimLoot(site *LootSite) string {
+	if site.WagonBed && !g.HasWagonBed {
+		g.HasWagonBed = true
+		site.WagonBed = false
+	}
+	site.Food = g.stow("food", site.Food)
+	site.Bullets = g.stow("bullets", site.Bullets)
+	site.Clothing = g.stow("clothing", site.Clothing)
+	site.MiscSupplies = g.stow("misc", site.MiscSupplies)
+	g.Cash += site.Cash
+	site.Cash = 0
+	g.AddParts(site.Parts)
+	site.Parts = nil
+
+	left := make([]string, 0, 4)
+	if site.Food > 0 {
+		left = append(left, fmt.Sprintf("%.0f lbs of food", site.Food))
+	}
+	if site.Bullets > 0 {
+		left = append(left, fmt.Sprintf("%.0f bullets", site.Bullets))
+	}
+	if site.Clothing > 0 {
+		left = append(left, fmt.Sprintf("%.0f sets of clothing", site.Clothing))
+	}
+	if site.MiscSupplies > 0 {
+		left = append(left, fmt.Sprintf("%.0f supply kits", site.MiscSupplies))
+	}
+	if len(left) == 0 {
+		return ""
+	}
+	last := left[len(left)-1]
+	if len(left) > 1 {
+		last = strings.Join(left[:len(left)-1], ", ") + " and " + last
+	}
+	return fmt.Sprintf("Your wagon is full! You had to leave behind %s.\n", last)
+}
@@ -0,0 +1,92 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Contribution tallies what happened to the shared wagon on one player's
+// turns in party mode.
+type Contribution struct {
+	Turns        int     `json:"turns"`
+	Miles        float64 `json:"miles"`
+	FoodHunted   float64 `json:"food_hunted"`
+	FortSpending float64 `json:"fort_spending"`
+	DamageTaken  int     `json:"damage_taken"`
+}
+
+// contribution returns the accumulator for a player, creating it if needed.
+// With no player to credit, a scratch accumulator is returned.
+func (g *GameState) contribution(playerID string) *Contribution {
+	if playerID == "" {
+		return &Contribution{}
+	}
+	if g.Contributions == nil {
+		g.Contributions = make(map[string]*Contribution)
+	}
+	c, ok := g.Contributions[playerID]
+	if !ok {
+		c = &Contribution{}
+		g.Contributions[playerID] = c
+	}
+	return c
+}
+
+// actingPlayerID is the player whose turn it is, falling back to p when no
+// turn is in progress.
+func (g *GameState) actingPlayerID(p *Player) string {
+	if cp := g.GetCurrentPlayer(); cp != nil {
+		return cp.ID
+	}
+	if p != nil {
+		return p.ID
+	}
+	return ""
+}
+
+// ContributionFor returns a copy of a player's contribution so far.
+func (g *GameState) ContributionFor(playerID string) Contribution {
+	if c, ok := g.Contributions[playerID]; ok {
+		return *c
+	}
+	return Contribution{}
+}
+
+// creditTurn credits the miles covered since the turn began to the player
+// whose turn is ending.
+func (g *GameState) creditTurn(p *Player) {
+	if p == nil {
+		return
+	}
+	c := g.contribution(p.ID)
+	c.Turns++
+	c.Miles += g.Mileage - g.TurnStartMileage
+	g.TurnStartMileage = g.Mileage
+}
+
+// SettleTurn credits the turn in progress when the game ends before the
+// next turn begins.
+func (g *GameState) SettleTurn() {
+	g.creditTurn(g.GetCurrentPlayer())
+}
+
+// ContributionSummary describes each player's share of the journey, most
+// miles first, for the game over screen.
+func (g *GameState) ContributionSummary() string {
+	if len(g.Contributions) == 0 {
+		return ""
+	}
+	result := &strings.Builder{}
+	result.WriteString("\nWHO PULLED THEIR WEIGHT:\n")
+	players := append([]*Player(nil), g.Players...)
+	sort.SliceStable(players, func(i, j int) bool {
+		return g.ContributionFor(players[i].ID).Miles > g.ContributionFor(players[j].ID).Miles
+	})
+	for _, p := range players {
+		c := g.ContributionFor(p.ID)
+		result.WriteString(fmt.Sprintf("  %s: %.0f miles over %d turns, %.0f lbs hunted, $%.0f spent at forts, %d damage taken\n",
+			p.Name, c.Miles, c.Turns, c.FoodHunted, c.FortSpending, c.DamageTaken))
+	}
+	return result.String()
+}
@@ -0,0 +1,101 @@
+package game
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTurnsAreCreditedToWhoeverPlayedThem(t *testing.T) {
+	g := tableOf("Ann", "Ann", "Bea")
+	ann, _ := g.playerByID("Ann")
+
+	g.Mileage += 120
+	g.NextTurn()
+	// Bea's turn: the shared wagon rolls on, and Ann's son is hurt
+	g.Mileage += 45
+	g.DamagePartyMember(ann, 2, 30, "a snakebite")
+	g.NextTurn()
+	g.Mileage += 80
+	g.NextTurn()
+
+	if c := g.ContributionFor("Ann"); c.Turns != 2 || c.Miles != 200 || c.DamageTaken != 0 {
+		t.Errorf("Ann = %+v, want 200 miles over 2 turns and no damage", c)
+	}
+	if c := g.ContributionFor("Bea"); c.Turns != 1 || c.Miles != 45 || c.DamageTaken != 30 {
+		t.Errorf("Bea = %+v, want 45 miles over 1 turn and the 30 damage on it", c)
+	}
+}
+
+func TestDamageIsCountedUpToTheHealthLost(t *testing.T) {
+	g := tableOf("Bea", "Ann", "Bea")
+	ann, _ := g.playerByID("Ann")
+	health := ann.Party[1].Health
+	g.DamagePartyMember(ann, 1, health+50, "dysentery")
+	g.DamagePartyMember(ann, 1, 10, "dysentery")
+	if c := g.ContributionFor("Bea"); c.DamageTaken != health {
+		t.Errorf("damage taken = %d, want the %d health Ann's wife had", c.DamageTaken, health)
+	}
+}
+
+func TestSettleTurnCreditsTheLastTurn(t *testing.T) {
+	g := tableOf("Ann", "Ann", "Bea")
+	g.Mileage += 100
+	g.NextTurn()
+	g.Mileage += 60
+	// The game ends on Bea's turn
+	g.SettleTurn()
+	if c := g.ContributionFor("Bea"); c.Turns != 1 || c.Miles != 60 {
+		t.Errorf("Bea = %+v, want the 60 miles of the turn the game ended on", c)
+	}
+	if c := g.ContributionFor("Ann"); c.Turns != 1 || c.Miles != 100 {
+		t.Errorf("Ann = %+v, want 100 miles over 1 turn", c)
+	}
+}
+
+func TestContributionsSurviveASave(t *testing.T) {
+	g := tableOf("Ann", "Ann", "Bea")
+	g.Mileage += 100
+	g.NextTurn()
+	g.contribution("Bea").FoodHunted += 75
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded GameState
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if c := loaded.ContributionFor("Ann"); c.Turns != 1 || c.Miles != 100 {
+		t.Errorf("Ann after a save = %+v", c)
+	}
+	if c := loaded.ContributionFor("Bea"); c.FoodHunted != 75 {
+		t.Errorf("Bea after a save = %+v", c)
+	}
+}
+
+func TestContributionSummaryListsMostMilesFirst(t *testing.T) {
+	g := tableOf("Ann", "Ann", "Bea", "Cy")
+	if got := g.ContributionSummary(); got != "" {
+		t.Errorf("summary before anyone played = %q", got)
+	}
+
+	for _, miles := range []float64{20, 90, 50} {
+		g.Mileage += miles
+		g.NextTurn()
+	}
+	got := g.ContributionSummary()
+	if !strings.HasPrefix(got, "\nWHO PULLED THEIR WEIGHT:\n") {
+		t.Fatalf("summary = %q", got)
+	}
+	lines := strings.Split(strings.TrimSpace(got), "\n")[1:]
+	if len(lines) != 3 {
+		t.Fatalf("summary lists %d players:\n%s", len(lines), got)
+	}
+	for i, want := range []string{"Bea: 90 miles over 1 turns", "Cy: 50 miles", "Ann: 20 miles"} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("line %d = %q, want %q", i+1, lines[i], want)
+		}
+	}
+}
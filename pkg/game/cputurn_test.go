@@ -0,0 +1,67 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCPUTurnNeverWaitsForInput(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		g := NewGameStateWithSeed(seed)
+		g.Food, g.Bullets, g.Clothing, g.MiscSupplies, g.Cash, g.OxenCost = 100, 300, 20, 10, 700, 220
+		p := g.AddPlayer("CPU", PlayerTypeCPU)
+		for turn := 0; turn < 60 && !g.GameOver && p.Alive; turn++ {
+			// Low on food now and then, so the CPU hunts as well as travels,
+			// and forts along the way
+			if turn%7 == 3 {
+				g.Food = cpuHuntFoodBelow - 1
+			}
+			if turn%5 == 2 {
+				g.FortAvailable = true
+			}
+			action := "continue"
+			if turn%2 == 1 {
+				action = "hunt"
+			}
+			g.ProcessTurn(p, action)
+			switch g.TurnPhase {
+			case PhaseHunting, PhaseRiders, PhaseFort:
+				t.Fatalf("seed %d, turn %d: a CPU turn left the game in %s", seed, turn, g.TurnPhase)
+			}
+			g.NextTurn()
+		}
+	}
+}
+
+func TestCPUHuntFinishesTheTurn(t *testing.T) {
+	g := NewGameStateWithSeed(1)
+	p := g.AddPlayer("CPU", PlayerTypeCPU)
+	g.Food, g.Bullets, g.Mileage = cpuHuntFoodBelow-1, 500, MountainThreshold+100
+
+	result := g.PlayCPUTurn(p)
+	if got := result.String(); !strings.Contains(got, "MOUNTAINS") {
+		t.Errorf("a CPU hunt in the mountains skipped the rest of the turn:\n%s", got)
+	}
+	if g.Bullets >= 500 {
+		t.Error("the CPU traveled instead of hunting")
+	}
+}
+
+func TestCPUTurnVisitsTheFort(t *testing.T) {
+	g := NewGameStateWithSeed(1)
+	p := g.AddPlayer("CPU", PlayerTypeCPU)
+	g.Food, g.Bullets, g.Cash, g.OxenCost = 50, 50, 300, 220
+	g.FortAvailable = true
+
+	result := g.PlayCPUTurn(p)
+	if g.FortAvailable || g.TurnPhase != PhaseMainMenu {
+		t.Errorf("after the turn: fort available %v, phase %s", g.FortAvailable, g.TurnPhase)
+	}
+	found := false
+	for _, e := range result.Events {
+		found = found || e.Code == ResultFortAuto
+	}
+	if !found || g.Cash >= 300 {
+		t.Errorf("the CPU bought nothing at the fort: $%.0f left", g.Cash)
+	}
+}
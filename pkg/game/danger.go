@@ -0,0 +1,55 @@
+package game
+
+import "fmt"
+
+// LowFoodThreshold is the food level below which the party starts starving.
+const LowFoodThreshold = 13
+
+// huntBulletCost is the bullets a hunt takes up front.
+const huntBulletCost = 50
+
+// lastBulletsFoodBelow is the food level under which spending the last
+// bullets on a hunt is treated as fatal.
+const lastBulletsFoodBelow = 20
+
+// Moves checked by FatalMoveWarning.
+const (
+	MoveTravel    = "travel"
+	MoveHunt      = "hunt"
+	MoveFortLeave = "fort_leave"
+)
+
+// foodConsumption is the food a party eats in one turn at an eating level.
+func foodConsumption(eatingLevel int) float64 {
+	return float64(8 + 5*eatingLevel)
+}
+
+// FatalMoveWarning returns a warning if the move would all but certainly doom
+// the party, or "" if it's safe enough to make without confirmation:
+//   - travelling or leaving the fort with less than a turn's food left, no
+//     cash for more and no fort ahead
+//   - hunting away the last bullets while food is nearly gone
+func (g *GameState) FatalMoveWarning(move string) string {
+	perTurn := foodConsumption(2)
+	broke := g.Cash < GetFortPrices()["food"].Price
+
+	switch move {
+	case MoveTravel:
+		if g.Food-perTurn < perTurn && broke && !g.FortAvailable {
+			return fmt.Sprintf("You have %.0f lbs of food, no money and no fort ahead. "+
+				"Travelling on will leave your party starving.", g.Food)
+		}
+	case MoveFortLeave:
+		if g.Food < perTurn && broke {
+			return fmt.Sprintf("You're leaving the fort with only %.0f lbs of food and no money. "+
+				"Your party will starve on the trail.", g.Food)
+		}
+	case MoveHunt:
+		if g.Bullets >= huntBulletCost && g.Bullets-huntBulletCost < huntBulletCost &&
+			g.Food < lastBulletsFoodBelow {
+			return fmt.Sprintf("This hunt will use up your last bullets with only %.0f lbs of food left. "+
+				"If it fails you'll have no way to hunt again.", g.Food)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package game
+
+import "testing"
+
+func TestFatalMoveWarning(t *testing.T) {
+	foodPrice := GetFortPrices()["food"].Price
+	perTurn := foodConsumption(2)
+	tests := []struct {
+		name string
+		move string
+		set  func(g *GameState)
+		warn bool
+	}{
+		// Travelling on with under two turns' food
+		{"travel starving and broke", MoveTravel, func(g *GameState) { g.Food, g.Cash = 2*perTurn-1, 0 }, true},
+		{"travel with two turns' food", MoveTravel, func(g *GameState) { g.Food, g.Cash = 2*perTurn, 0 }, false},
+		{"travel with money for food", MoveTravel, func(g *GameState) { g.Food, g.Cash = 5, foodPrice }, false},
+		{"travel with a fort ahead", MoveTravel, func(g *GameState) { g.Food, g.Cash, g.FortAvailable = 5, 0, true }, false},
+
+		// Leaving the fort without a turn's food
+		{"leave the fort starving and broke", MoveFortLeave, func(g *GameState) { g.Food, g.Cash = perTurn-1, 0 }, true},
+		{"leave the fort with a turn's food", MoveFortLeave, func(g *GameState) { g.Food, g.Cash = perTurn, 0 }, false},
+		{"leave the fort with money for food", MoveFortLeave, func(g *GameState) { g.Food, g.Cash = 5, foodPrice }, false},
+
+		// Hunting away the last bullets with food nearly gone
+		{"last hunt while starving", MoveHunt, func(g *GameState) { g.Bullets, g.Food = huntBulletCost, lastBulletsFoodBelow-1 }, true},
+		{"last hunt with food left", MoveHunt, func(g *GameState) { g.Bullets, g.Food = huntBulletCost, lastBulletsFoodBelow }, false},
+		{"hunt with bullets to spare", MoveHunt, func(g *GameState) { g.Bullets, g.Food = 2*huntBulletCost, 5 }, false},
+		{"no bullets to hunt with", MoveHunt, func(g *GameState) { g.Bullets, g.Food = huntBulletCost-1, 5 }, false},
+
+		{"other moves", "rest", func(g *GameState) { g.Food, g.Cash, g.Bullets = 0, 0, huntBulletCost }, false},
+	}
+	for _, tt := range tests {
+		g := NewGameState()
+		g.Food, g.Cash, g.Bullets = 100, 100, 100
+		tt.set(g)
+		if got := g.FatalMoveWarning(tt.move); (got != "") != tt.warn {
+			t.Errorf("%s: warning %q, want one: %v", tt.name, got, tt.warn)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package game
+
+import (
+	"fmt"
+	"math"
+)
+
+// MaxHealth is a party member's HP at full health.
+const MaxHealth = 100
+
+// doctorHPPerDollar is how many HP the fort doctor restores for each dollar.
+const doctorHPPerDollar = 2
+
+// HealCost is what the fort doctor charges to bring a party member back to
+// full health. Dead and healthy members cost nothing.
+func HealCost(m PartyMember) float64 {
+	if !m.Alive || m.Health >= MaxHealth {
+		return 0
+	}
+	return math.Ceil(float64(MaxHealth-m.Health) / doctorHPPerDollar)
+}
+
+// HealCosts lists the doctor's price for each of p's party members, in
+// party order.
+func (g *GameState) HealCosts(p *Player) []float64 {
+	if p == nil {
+		return nil
+	}
+	costs := make([]float64, len(p.Party))
+	for i, m := range p.Party {
+		costs[i] = HealCost(m)
+	}
+	return costs
+}
+
+// HandleFortHeal has the fort doctor restore one of p's party members to
+// full health.
+func (g *GameState) HandleFortHeal(p *Player, memberIdx int) *TurnResult {
+	if g.TurnPhase != PhaseFort {
+		return Refuse(ReasonInvalidPhase, "You're not at a fort!\n")
+	}
+	if p == nil {
+		return Refuse(ReasonInvalid, "Error: Player not found.\n")
+	}
+	if memberIdx < 0 || memberIdx >= len(p.Party) {
+		return Refuse(ReasonInvalid, "No such party member.\n")
+	}
+	m := &p.Party[memberIdx]
+	if !m.Alive {
+		return Refuse(ReasonInvalid, fmt.Sprintf("The doctor can't help %s now.\n", m.Name))
+	}
+	cost := HealCost(*m)
+	if cost == 0 {
+		return Refuse(ReasonInvalid, fmt.Sprintf("The doctor finds nothing wrong with %s.\n", m.Name))
+	}
+	if cost > g.Cash {
+		return Refuse(ReasonInsufficientCash, fmt.Sprintf("Not enough cash! Need $%.0f but only have $%.0f\n", cost, g.Cash))
+	}
+
+	g.Cash -= cost
+	g.contribution(g.actingPlayerID(p)).FortSpending += cost
+	healed := MaxHealth - m.Health
+	m.Health = MaxHealth
+	m.Injured = false
+	return NewResultWith(ResultFortHeal, fmt.Sprintf("The doctor patched up %s for $%.0f (+%d HP)\n", m.Name, cost, healed),
+		map[string]float64{"member": float64(memberIdx), "hp": float64(healed), "cost": cost})
+}
@@ -17,7 +17,7 @@ func (g *GameState) HandleRiverCrossing(p *Player) string {
 			g.Clothing -= 20
 			g.Mileage -= g.Rand.Float64()*20 + 20
 			g.ClampResources()
-			result.WriteString(g.DamageRandomMember(p, 5))
+			result.WriteString(g.DamageRandomMember(p, 5, "drowning in the Kansas River"))
 		} else {
 			result.WriteString("You crossed safely.\n")
 		}
@@ -30,7 +30,7 @@ func (g *GameState) HandleRiverCrossing(p *Player) string {
 			g.MiscSupplies -= 10
 			g.Mileage -= g.Rand.Float64()*30 + 25
 			g.ClampResources()
-			result.WriteString(g.DamageRandomMember(p, 10))
+			result.WriteString(g.DamageRandomMember(p, 10, "drowning in the Green River"))
 		} else {
 			result.WriteString("Safe crossing.\n")
 		}
@@ -43,7 +43,7 @@ func (g *GameState) HandleRiverCrossing(p *Player) string {
 			g.Bullets -= 30
 			g.Mileage -= g.Rand.Float64()*25 + 20
 			g.ClampResources()
-			result.WriteString(g.DamageRandomMember(p, 15))
+			result.WriteString(g.DamageRandomMember(p, 15, "drowning in the Snake River"))
 		} else {
 			result.WriteString("Careful crossing - you made it!\n")
 		}
@@ -56,7 +56,7 @@ func (g *GameState) HandleRiverCrossing(p *Player) string {
 			g.Clothing -= 30
 			g.Mileage -= g.Rand.Float64()*40 + 30
 			g.ClampResources()
-			result.WriteString(g.DamageRandomMember(p, 20))
+			result.WriteString(g.DamageRandomMember(p, 20, "drowning in the Columbia River"))
 		} else {
 			result.WriteString("You made it across!\n")
 		}
@@ -66,27 +66,36 @@ func (g *GameState) HandleRiverCrossing(p *Player) string {
 }
 
 func (g *GameState) HandleHunting(p *Player) string {
+	return g.hunt(p, g.getShootingTime(p))
+}
+
+// hunt resolves a hunt with the given shooting time.
+func (g *GameState) hunt(p *Player, shootTime float64) string {
 	result := &strings.Builder{}
 
-	if g.Bullets < 50 {
+	if g.Bullets < huntBulletCost {
 		result.WriteString("Not enough bullets to hunt!\n")
 		return result.String()
 	}
 
-	g.Bullets -= 50
+	g.Bullets -= huntBulletCost
 
-	shootTime := g.getShootingTime(p)
 	accuracy := g.calculateAccuracy(shootTime, p.ShootingRank)
+	if g.HasRifleScope && accuracy >= 1 {
+		accuracy--
+	}
 
 	if accuracy <= 1 {
 		foodGained := 52 + g.Rand.Float64()*6
 		g.Food += foodGained
+		g.contribution(p.ID).FoodHunted += foodGained
 		result.WriteString(fmt.Sprintf("RIGHT BETWEEN THE EYES! You got a big one!\nFull bellies tonight! (+%.0f food)\n", foodGained))
 	} else if g.Rand.Float64()*100 < 13*float64(accuracy) {
 		result.WriteString("You missed - and your dinner got away...\n")
 	} else {
 		foodGained := 48 - 2*float64(accuracy)
 		g.Food += foodGained
+		g.contribution(p.ID).FoodHunted += foodGained
 		result.WriteString(fmt.Sprintf("Nice shot! Right on target! Good eatin' tonight! (+%.0f food)\n", foodGained))
 	}
 
@@ -101,6 +110,7 @@ func (g *GameState) HandleHunting(p *Player) string {
 	result.WriteString(fmt.Sprintf("You traveled %.0f miles while hunting.\n", huntTravel))
 
 	g.ClampResources()
+	result.WriteString(g.checkLandmarks().String())
 
 	if g.Mileage >= float64(TrailLength) {
 		g.HandleFinalTurn(p)
@@ -121,6 +131,10 @@ func (g *GameState) HandleIllness(p *Player, eatingLevel int) string {
 	case 3:
 		illnessChance = 0.25
 	}
+	if g.Season() == SeasonWinter && g.Clothing < winterClothingNeeded {
+		illnessChance += winterIllnessIncrease
+	}
+	illnessChance += paceEffects[g.CurrentPace()].illness
 
 	if g.Rand.Float64() < illnessChance {
 		severity := g.Rand.Float64()
@@ -128,23 +142,23 @@ func (g *GameState) HandleIllness(p *Player, eatingLevel int) string {
 			result.WriteString("MILD ILLNESS - Medicine used\n")
 			g.Mileage -= 5
 			g.MiscSupplies -= 2
-			result.WriteString(g.DamageRandomMember(p, 10))
+			result.WriteString(g.DamageRandomMember(p, 10, "a mild illness"))
 		} else if severity < 0.66 {
 			result.WriteString("BAD ILLNESS - Medicine used\n")
 			g.Mileage -= 5
 			g.MiscSupplies -= 5
-			result.WriteString(g.DamageRandomMember(p, 20))
+			result.WriteString(g.DamageRandomMember(p, 20, "a bad illness"))
 		} else {
 			result.WriteString("SERIOUS ILLNESS - Must stop for medical attention\n")
 			g.MiscSupplies -= 10
 			result.WriteString(fmt.Sprintf("Doctor's bill is $20\n"))
 			g.Cash -= 20
-			result.WriteString(g.DamageRandomMember(p, 30))
+			result.WriteString(g.DamageRandomMember(p, 30, "a serious illness"))
 		}
 
 		if g.MiscSupplies < 0 && !g.GameOver {
 			result.WriteString("You ran out of medical supplies!\n")
-			result.WriteString(g.DamageRandomMember(p, 40))
+			result.WriteString(g.DamageRandomMember(p, 40, "illness without medicine"))
 		}
 	}
 
@@ -181,10 +195,11 @@ func (g *GameState) ResolveRiderTactic(p *Player, tactic int) string {
 			g.Bullets -= 50
 			g.OxenCost -= 40
 			result.WriteString("You fled from the riders!\n")
+			result.WriteString(g.oxenReport())
 			// Running has a chance of taking damage
 			if g.Rand.Float64() < 0.3 {
 				result.WriteString("They got some shots off as you fled!\n")
-				result.WriteString(g.DamageRandomMember(p, 15))
+				result.WriteString(g.DamageRandomMember(p, 15, "gunshot wounds while fleeing riders"))
 			}
 		case 2: // Attack
 			shootTime := g.getShootingTime(p)
@@ -193,14 +208,15 @@ func (g *GameState) ResolveRiderTactic(p *Player, tactic int) string {
 
 			if accuracy <= 1 {
 				result.WriteString("NICE SHOOTING - You drove them off!\n")
+				g.droveOffRiders = true
 			} else if accuracy > 4 {
 				result.WriteString("LOUSY SHOT - You got knifed!\n")
 				result.WriteString("You have to see the doctor.\n")
 				g.Cash -= 20
-				result.WriteString(g.DamageRandomMember(p, 25))
+				result.WriteString(g.DamageRandomMember(p, 25, "a knife wound from riders"))
 			} else {
 				result.WriteString("Kinda slow with your Colt .45\n")
-				result.WriteString(g.DamageRandomMember(p, 15))
+				result.WriteString(g.DamageRandomMember(p, 15, "wounds from a rider attack"))
 			}
 		case 3: // Continue
 			if g.Rand.Float64() > 0.8 {
@@ -211,7 +227,7 @@ func (g *GameState) ResolveRiderTactic(p *Player, tactic int) string {
 			g.Bullets -= 50
 			g.MiscSupplies -= 15
 			result.WriteString("They attacked and you defended.\n")
-			result.WriteString(g.DamageRandomMember(p, 20))
+			result.WriteString(g.DamageRandomMember(p, 20, "wounds from a rider attack"))
 		case 4: // Circle Wagons
 			shootTime := g.getShootingTime(p)
 			accuracy := g.calculateAccuracy(shootTime, p.ShootingRank)
@@ -219,13 +235,14 @@ func (g *GameState) ResolveRiderTactic(p *Player, tactic int) string {
 			g.Mileage -= 25
 			if accuracy <= 1 {
 				result.WriteString("NICE SHOOTING - You drove them off!\n")
+				g.droveOffRiders = true
 			} else if accuracy > 4 {
 				result.WriteString("LOUSY SHOT - You got knifed!\n")
 				g.Cash -= 20
-				result.WriteString(g.DamageRandomMember(p, 30))
+				result.WriteString(g.DamageRandomMember(p, 30, "a knife wound from riders"))
 			} else {
 				result.WriteString("KINDA SLOW - They got some licks in\n")
-				result.WriteString(g.DamageRandomMember(p, 15))
+				result.WriteString(g.DamageRandomMember(p, 15, "wounds from a rider attack"))
 			}
 		}
 	} else {
@@ -234,11 +251,12 @@ func (g *GameState) ResolveRiderTactic(p *Player, tactic int) string {
 			g.Mileage += 15
 			g.OxenCost -= 10
 			result.WriteString("You ran from friendly riders. Wasted energy.\n")
+			result.WriteString(g.oxenReport())
 		case 2:
 			g.Mileage -= 5
 			g.Bullets -= 50
 			result.WriteString("You attacked friendly riders! They fought back.\n")
-			result.WriteString(g.DamageRandomMember(p, 20))
+			result.WriteString(g.DamageRandomMember(p, 20, "wounds from a fight with friendly riders"))
 		case 3:
 			result.WriteString("They passed by peacefully. Nothing happened.\n")
 		case 4:
@@ -249,7 +267,7 @@ func (g *GameState) ResolveRiderTactic(p *Player, tactic int) string {
 
 	if g.Bullets < 0 {
 		result.WriteString("You ran out of bullets in the fight!\n")
-		result.WriteString(g.DamageRandomMember(p, 50))
+		result.WriteString(g.DamageRandomMember(p, 50, "running out of bullets in a fight"))
 	}
 
 	g.ClampResources()
@@ -296,12 +314,17 @@ func (g *GameState) cpuChooseTactic(hostile bool) int {
 
 func (g *GameState) getShootingTime(p *Player) float64 {
 	if p.Type == PlayerTypeCPU {
-		baseTime := 0.5 + g.Rand.Float64()*1.5
-		return baseTime - float64(p.ShootingRank-1)*0.15
+		return g.cpuShootingTime(p)
 	}
 	return 0
 }
 
+// cpuShootingTime simulates a CPU player's reaction time for p's shooting rank.
+func (g *GameState) cpuShootingTime(p *Player) float64 {
+	baseTime := 0.5 + g.Rand.Float64()*1.5
+	return baseTime - float64(p.ShootingRank-1)*0.15
+}
+
 func (g *GameState) calculateAccuracy(shootTime float64, shootingRank int) float64 {
 	baseTime := shootTime * 3600
 	accuracy := baseTime - float64(shootingRank-1)
@@ -357,16 +380,10 @@ func (g *GameState) HandleRandomEvent(p *Player) string {
 	return result.String()
 }
 
-func (g *GameState) eventWagonBreakdown(p *Player) string {
-	g.Mileage -= 15 + g.Rand.Float64()*5
-	g.MiscSupplies -= 8
-	return "WAGON BREAKS DOWN - Lose time and supplies fixing it\n"
-}
-
 func (g *GameState) eventOxInjury(p *Player) string {
 	g.Mileage -= 25
 	g.OxenCost -= 20
-	return "OX INJURES LEG - Slows you down rest of trip\n"
+	return "OX INJURES LEG - Slows you down rest of trip\n" + g.oxenReport()
 }
 
 func (g *GameState) eventDaughterBrokenArm(p *Player) string {
@@ -375,7 +392,7 @@ func (g *GameState) eventDaughterBrokenArm(p *Player) string {
 	g.MiscSupplies -= 2 + g.Rand.Float64()*3
 	// Damage daughter (index 3) specifically
 	if len(p.Party) > 3 && p.Party[3].Alive {
-		result += g.DamagePartyMember(p, 3, 10)
+		result += g.DamagePartyMember(p, 3, 10, "a broken arm")
 	}
 	return result
 }
@@ -390,15 +407,18 @@ func (g *GameState) eventSonGetsLost(p *Player) string {
 	g.Mileage -= 10
 	// Damage son (index 2) specifically
 	if len(p.Party) > 2 && p.Party[2].Alive {
-		result += g.DamagePartyMember(p, 2, 8)
+		result += g.DamagePartyMember(p, 2, 8, "exposure while lost")
 	}
 	return result
 }
 
 func (g *GameState) eventUnsafeWater(p *Player) string {
+	if g.HasWaterBarrel {
+		return "DRY STRETCH - Your water barrel keeps the party supplied\n"
+	}
 	g.Mileage -= 10 + g.Rand.Float64()*10
 	result := "UNSAFE WATER - Lose time looking for clean spring\n"
-	result += g.DamageRandomMember(p, 8)
+	result += g.DamageRandomMember(p, 8, "unsafe water")
 	return result
 }
 
@@ -408,7 +428,7 @@ func (g *GameState) eventHeavyRains(p *Player) string {
 			return "COLD WEATHER - You have enough clothing to keep you warm\n"
 		}
 		result := "COLD WEATHER - You don't have enough clothing! Risk of illness.\n"
-		result += g.DamageRandomMember(p, 12)
+		result += g.DamageRandomMember(p, 12, "cold weather")
 		return result
 	}
 	g.Food -= 10
@@ -428,8 +448,8 @@ func (g *GameState) eventBandits(p *Player) string {
 		g.Cash -= 20
 		g.OxenCost -= 20
 		g.MiscSupplies -= 5
-		result := "BANDITS ATTACK - You ran out of bullets! They took cash and an ox!\n"
-		result += g.DamageRandomMember(p, 30)
+		result := "BANDITS ATTACK - You ran out of bullets! They took cash and an ox!\n" + g.oxenReport()
+		result += g.DamageRandomMember(p, 30, "a bandit attack")
 		return result
 	}
 
@@ -452,8 +472,8 @@ func (g *GameState) eventBandits(p *Player) string {
 
 	g.OxenCost -= 20
 	g.MiscSupplies -= 5
-	result := "BANDITS ATTACK - You got shot in the leg! Better have a doc look at it.\n"
-	result += g.DamageRandomMember(p, 20)
+	result := "BANDITS ATTACK - You got shot in the leg! Better have a doc look at it.\n" + g.oxenReport()
+	result += g.DamageRandomMember(p, 20, "a bandit attack")
 	return result
 }
 
@@ -464,7 +484,7 @@ func (g *GameState) eventFireInWagon(p *Player) string {
 	g.Mileage -= 15
 	result := "FIRE IN WAGON - Food and supplies damaged\n"
 	if g.Rand.Float64() < 0.3 {
-		result += g.DamageRandomMember(p, 15)
+		result += g.DamageRandomMember(p, 15, "burns from a wagon fire")
 	}
 	return result
 }
@@ -480,11 +500,11 @@ func (g *GameState) eventSnakeBite(p *Player) string {
 	result := "SNAKE BITE! "
 	if g.MiscSupplies < 0 {
 		result += "No medicine available!\n"
-		result += g.DamageRandomMember(p, 40)
+		result += g.DamageRandomMember(p, 40, "an untreated snake bite")
 		return result
 	}
 	result += "You killed a poisonous snake after it bit you\n"
-	result += g.DamageRandomMember(p, 25)
+	result += g.DamageRandomMember(p, 25, "a snake bite")
 	return result
 }
 
@@ -501,7 +521,7 @@ func (g *GameState) eventWildAnimals(p *Player) string {
 
 	if g.Bullets < 40 {
 		result := "WILD ANIMALS ATTACK - You were too low on bullets! The wolves overpowered you.\n"
-		result += g.DamageRandomMember(p, 35)
+		result += g.DamageRandomMember(p, 35, "a wild animal attack")
 		return result
 	}
 
@@ -513,7 +533,7 @@ func (g *GameState) eventWildAnimals(p *Player) string {
 		return "NICE SHOOTIN' PARTNER - They didn't get much\n"
 	}
 	result := "SLOW ON THE DRAW - They got at your food and clothes\n"
-	result += g.DamageRandomMember(p, 15)
+	result += g.DamageRandomMember(p, 15, "a wild animal attack")
 	return result
 }
 
@@ -523,14 +543,14 @@ func (g *GameState) eventHailStorm(p *Player) string {
 	g.MiscSupplies -= 4 + g.Rand.Float64()*3
 	result := "HAIL STORM - Supplies damaged\n"
 	if g.Rand.Float64() < 0.2 {
-		result += g.DamageRandomMember(p, 10)
+		result += g.DamageRandomMember(p, 10, "a hail storm")
 	}
 	return result
 }
 
 func (g *GameState) eventBadFood(p *Player) string {
 	result := "You got sick from something you ate.\n"
-	result += g.DamageRandomMember(p, 12)
+	result += g.DamageRandomMember(p, 12, "bad food")
 	return result
 }
 
@@ -0,0 +1,58 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHuntReactionTime(t *testing.T) {
+	start := time.Date(1848, 4, 1, 12, 0, 0, 0, time.UTC)
+	g := NewGameStateWithSeed(1)
+	g.HuntWord = "BANG"
+	g.HuntStartedAt = start
+	g.HuntDelayMs = 2000
+	shown := start.Add(2 * time.Second)
+
+	tests := []struct {
+		name       string
+		word       string
+		reportedMs int
+		latencyMs  int
+		at         time.Time
+		want       int
+	}{
+		{"before the word", "BANG", 100, 0, shown.Add(-time.Millisecond), HuntMissMs},
+		{"wrong word", "BLAM", 300, 0, shown.Add(500 * time.Millisecond), HuntMissMs},
+		{"no word", "", 300, 0, shown.Add(500 * time.Millisecond), HuntMissMs},
+		{"word any case", "bang ", 0, 0, shown.Add(900 * time.Millisecond), 900 - huntLatencyMs},
+		{"fast shot is clamped", "BANG", 0, 0, shown.Add(200 * time.Millisecond), huntMinReactionMs},
+		{"right on the delay is clamped", "BANG", 0, 0, shown, huntMinReactionMs},
+		{"slow shot", "BANG", 0, 0, shown.Add(3 * time.Second), 3000 - huntLatencyMs},
+		{"slower report wins", "BANG", 1500, 0, shown.Add(time.Second), 1500},
+		{"measured latency", "BANG", 0, 400, shown.Add(time.Second), 600},
+		{"latency capped", "BANG", 0, 5000, shown.Add(time.Second), 1000 - huntMaxLatencyMs},
+		{"very slow shot", "BANG", 0, 0, shown.Add(time.Minute), HuntMissMs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.huntReactionTime(tt.word, tt.reportedMs, tt.latencyMs, tt.at); got != tt.want {
+				t.Errorf("huntReactionTime = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShownHuntWordWaitsForDelay(t *testing.T) {
+	g := NewGameStateWithSeed(1)
+	g.IssueHuntPrompt()
+	if g.HuntWord == "" || g.HuntDelayMs < huntMinDelayMs || g.HuntDelayMs >= huntMaxDelayMs {
+		t.Fatalf("prompt = %q after %d ms", g.HuntWord, g.HuntDelayMs)
+	}
+	due := g.HuntWordAt()
+	if got := g.ShownHuntWord(due.Add(-time.Millisecond)); got != "" {
+		t.Errorf("word shown %q before its delay", got)
+	}
+	if got := g.ShownHuntWord(due); got != g.HuntWord {
+		t.Errorf("ShownHuntWord at the delay = %q, want %q", got, g.HuntWord)
+	}
+}
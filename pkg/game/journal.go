@@ -0,0 +1,53 @@
+package game
+
+import "time"
+
+// JournalEntry is one event in a game's journal: what an action produced,
+// when and on which turn, so a finished game can be gone back over.
+type JournalEntry struct {
+	Time    time.Time          `json:"time"`
+	Turn    int                `json:"turn"`
+	Mileage float64            `json:"mileage"`
+	Player  string             `json:"player,omitempty"`
+	Action  string             `json:"action"`
+	Code    string             `json:"code"`
+	Params  map[string]float64 `json:"params,omitempty"`
+	Message string             `json:"message"`
+}
+
+// MaxJournalEntries is how many entries a game's journal keeps; older ones
+// are dropped.
+const MaxJournalEntries = 500
+
+// Record appends the events of an action's result to the journal, trimming
+// it to MaxJournalEntries.
+func (g *GameState) Record(player, action string, result *TurnResult, now time.Time) {
+	if result == nil {
+		return
+	}
+	for _, e := range result.Events {
+		g.EventLog = append(g.EventLog, JournalEntry{
+			Time:    now,
+			Turn:    g.TurnNumber,
+			Mileage: g.Mileage,
+			Player:  player,
+			Action:  action,
+			Code:    e.Code,
+			Params:  e.Params,
+			Message: e.Message,
+		})
+	}
+	if over := len(g.EventLog) - MaxJournalEntries; over > 0 {
+		g.EventLog = append([]JournalEntry(nil), g.EventLog[over:]...)
+	}
+}
+
+// RecentJournal returns a copy of the last n journal entries, or all of
+// them if n is not positive.
+func (g *GameState) RecentJournal(n int) []JournalEntry {
+	from := 0
+	if n > 0 && len(g.EventLog) > n {
+		from = len(g.EventLog) - n
+	}
+	return append([]JournalEntry{}, g.EventLog[from:]...)
+}
@@ -0,0 +1,68 @@
+package game
+
+import "fmt"
+
+// Landmark is a named place along the trail.
+type Landmark struct {
+	Name    string `json:"name"`
+	Mileage int    `json:"mileage"`
+}
+
+// landmarks are the places announced along the way, in trail order.
+var landmarks = []Landmark{
+	{"Fort Kearney", 300},
+	{"Chimney Rock", 750},
+	{"Fort Laramie", 1000},
+	{"Independence Rock", 1400},
+	{"South Pass", 1800},
+	{"Fort Bridger", 2100},
+	{"Soda Springs", 2450},
+	{"Fort Hall", 2800},
+	{"Snake River", 3200},
+	{"Fort Boise", 3500},
+	{"Blue Mountains", 3800},
+	{"The Dalles", 4200},
+}
+
+// Landmarks returns every landmark in trail order.
+func Landmarks() []Landmark {
+	return append([]Landmark(nil), landmarks...)
+}
+
+// LandmarkProgress is the next landmark ahead and how far off it is.
+type LandmarkProgress struct {
+	Name     string  `json:"name"`
+	Distance float64 `json:"distance"`
+}
+
+// NextLandmark returns the next landmark not yet reached, or nil once the
+// wagon is past them all.
+func (g *GameState) NextLandmark() *LandmarkProgress {
+	if g.LandmarksReached >= len(landmarks) {
+		return nil
+	}
+	lm := landmarks[g.LandmarksReached]
+	return &LandmarkProgress{Name: lm.Name, Distance: max(0, float64(lm.Mileage)-g.Mileage)}
+}
+
+// MarkLandmarksPassed counts every landmark behind the wagon as reached
+// without announcing it, for games saved before landmarks were tracked.
+func (g *GameState) MarkLandmarksPassed() {
+	for g.LandmarksReached < len(landmarks) && g.Mileage >= float64(landmarks[g.LandmarksReached].Mileage) {
+		g.LandmarksReached++
+	}
+}
+
+// checkLandmarks announces each landmark the wagon has reached since the
+// last check. Each is announced once per game, even if the wagon is later
+// pushed back behind it.
+func (g *GameState) checkLandmarks() *TurnResult {
+	result := &TurnResult{}
+	for g.LandmarksReached < len(landmarks) && g.Mileage >= float64(landmarks[g.LandmarksReached].Mileage) {
+		lm := landmarks[g.LandmarksReached]
+		result.Add(ResultLandmark, fmt.Sprintf("\nYou have reached %s!\n", lm.Name),
+			map[string]float64{"mileage": float64(lm.Mileage), "index": float64(g.LandmarksReached)})
+		g.LandmarksReached++
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+package game
+
+import (
+	"math"
+	"time"
+)
+
+// LootDecayPeriod is the time over which an abandoned wagon's supplies decay
+// by one step of the rates below.
+const LootDecayPeriod = 24 * time.Hour
+
+// Share of each supply left after one LootDecayPeriod. Cash doesn't decay.
+const (
+	lootFoodDecay     = 0.90 // rot
+	lootBulletsDecay  = 0.95 // damp
+	lootClothingDecay = 0.97 // weather wear
+	lootMiscDecay     = 0.95
+	lootOxenDecay     = 0.98 // wagon part decay
+)
+
+// Decay ages an unlooted site's supplies for the time since it last
+// decayed, however long that was, and moves its decay clock to now. A site
+// saved before the clock was kept starts it now, without decaying.
+func (l *LootSite) Decay(now time.Time) {
+	if l.IsLooted {
+		return
+	}
+	if l.LastDecayedAt.IsZero() {
+		l.LastDecayedAt = now
+		return
+	}
+	periods := float64(now.Sub(l.LastDecayedAt)) / float64(LootDecayPeriod)
+	if periods <= 0 {
+		return
+	}
+	l.Food *= math.Pow(lootFoodDecay, periods)
+	l.Bullets *= math.Pow(lootBulletsDecay, periods)
+	l.Clothing *= math.Pow(lootClothingDecay, periods)
+	l.MiscSupplies *= math.Pow(lootMiscDecay, periods)
+	l.OxenCost *= math.Pow(lootOxenDecay, periods)
+	l.LastDecayedAt = now
+}
@@ -0,0 +1,50 @@
+package game
+
+import "fmt"
+
+// FinalStretchMileage is where the last push to Online City begins.
+const FinalStretchMileage = 3800
+
+// trailMilestones are the mileages whose passing is news to the other
+// players on the open trail: every 500 miles, and the final stretch.
+var trailMilestones = []int{500, 1000, 1500, 2000, 2500, 3000, 3500, FinalStretchMileage, 4000}
+
+// MarkMilestonesPassed counts every milestone behind the wagon as reached
+// without announcing it, for games saved before milestones were tracked.
+func (g *GameState) MarkMilestonesPassed() {
+	for g.MilestonesReached < len(trailMilestones) && g.Mileage >= float64(trailMilestones[g.MilestonesReached]) {
+		g.MilestonesReached++
+	}
+}
+
+// TrailNews returns what the other players should hear about the wagon
+// since the last call: the furthest milestone it has reached, a blizzard it
+// came through and hostile riders it drove off. Like landmarks, each
+// milestone is news once per journey. A party that perished makes no news.
+func (g *GameState) TrailNews(p *Player) []string {
+	passed := 0
+	for g.MilestonesReached < len(trailMilestones) && g.Mileage >= float64(trailMilestones[g.MilestonesReached]) {
+		passed = trailMilestones[g.MilestonesReached]
+		g.MilestonesReached++
+	}
+	blizzard, riders := g.weatheredBlizzard, g.droveOffRiders
+	g.weatheredBlizzard, g.droveOffRiders = false, false
+	if p == nil || !p.Alive {
+		return nil
+	}
+
+	var news []string
+	switch {
+	case passed == FinalStretchMileage:
+		news = append(news, fmt.Sprintf("%s has reached the final stretch, %d miles from Online City.", p.Name, TrailLength-FinalStretchMileage))
+	case passed > 0:
+		news = append(news, fmt.Sprintf("%s's wagon has passed mile %d.", p.Name, passed))
+	}
+	if blizzard {
+		news = append(news, fmt.Sprintf("%s's party came through a blizzard in the mountain pass.", p.Name))
+	}
+	if riders {
+		news = append(news, fmt.Sprintf("%s drove off a band of hostile riders.", p.Name))
+	}
+	return news
+}
@@ -0,0 +1,101 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TrailNote is a message a player carved at a spot on the trail for those
+// coming after them.
+type TrailNote struct {
+	ID        string    `json:"id"`
+	AuthorID  string    `json:"author_id"`
+	Author    string    `json:"author"`
+	Text      string    `json:"text"`
+	Mileage   float64   `json:"mileage"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Trail note limits: the longest note, how long one lasts, how many each
+// player may have standing and how far away a note can be read.
+const (
+	MaxNoteLength     = 120
+	NoteLifetime      = 7 * 24 * time.Hour
+	maxNotesPerPlayer = 3
+	NoteRange         = 100
+)
+
+// LeaveNote carves a note at mileage. A player's oldest note is worn away
+// once they have more than maxNotesPerPlayer.
+func (g *GameState) LeaveNote(authorID, author, text string, mileage float64, now time.Time) (TrailNote, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "":
+		return TrailNote{}, errors.New("the note is empty")
+	case utf8.RuneCountInString(text) > MaxNoteLength:
+		return TrailNote{}, fmt.Errorf("notes are at most %d characters", MaxNoteLength)
+	}
+	g.PruneNotes(now)
+
+	note := TrailNote{
+		ID:        fmt.Sprintf("note-%s-%d", authorID, now.UnixNano()),
+		AuthorID:  authorID,
+		Author:    author,
+		Text:      text,
+		Mileage:   mileage,
+		CreatedAt: now,
+	}
+	g.TrailNotes = append(g.TrailNotes, note)
+
+	mine := 0
+	for i := len(g.TrailNotes) - 1; i >= 0; i-- {
+		if g.TrailNotes[i].AuthorID != authorID {
+			continue
+		}
+		if mine++; mine > maxNotesPerPlayer {
+			g.TrailNotes = append(g.TrailNotes[:i], g.TrailNotes[i+1:]...)
+		}
+	}
+	return note, nil
+}
+
+// PruneNotes drops notes older than NoteLifetime and reports how many went.
+func (g *GameState) PruneNotes(now time.Time) int {
+	kept := g.TrailNotes[:0]
+	for _, n := range g.TrailNotes {
+		if now.Sub(n.CreatedAt) < NoteLifetime {
+			kept = append(kept, n)
+		}
+	}
+	dropped := len(g.TrailNotes) - len(kept)
+	g.TrailNotes = kept
+	return dropped
+}
+
+// NotesNear returns the standing notes within NoteRange miles of mileage.
+func (g *GameState) NotesNear(mileage float64, now time.Time) []TrailNote {
+	near := make([]TrailNote, 0)
+	for _, n := range g.TrailNotes {
+		if now.Sub(n.CreatedAt) < NoteLifetime && n.Mileage >= mileage-NoteRange && n.Mileage <= mileage+NoteRange {
+			near = append(near, n)
+		}
+	}
+	return near
+}
+
+// PassNotes reports the notes by other players that a wagon went past
+// travelling from one mileage to another.
+func (g *GameState) PassNotes(readerID string, from, to float64, now time.Time) *TurnResult {
+	result := &TurnResult{}
+	for _, n := range g.TrailNotes {
+		if n.AuthorID == readerID || now.Sub(n.CreatedAt) >= NoteLifetime || n.Mileage <= from || n.Mileage > to {
+			continue
+		}
+		result.Add(ResultTrailNote, fmt.Sprintf("You pass a signpost. %s carved: \"%s\"\n", n.Author, n.Text),
+			map[string]float64{"mileage": n.Mileage})
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Occupation is what a player did back east. It sets how much cash the
+// party starts with and how much their score is worth: the less money, the
+// bigger the multiplier.
+type Occupation string
+
+const (
+	OccupationBanker    Occupation = "banker"
+	OccupationCarpenter Occupation = "carpenter"
+	OccupationFarmer    Occupation = "farmer"
+)
+
+// DefaultStartingCash is the purse of a party that chose no occupation.
+const DefaultStartingCash = 700
+
+// occupationPreset is an occupation's starting cash and score multiplier.
+type occupationPreset struct {
+	cash       float64
+	multiplier int
+}
+
+var occupationPresets = map[Occupation]occupationPreset{
+	OccupationBanker:    {cash: 1100, multiplier: 1},
+	OccupationCarpenter: {cash: 800, multiplier: 2},
+	OccupationFarmer:    {cash: 500, multiplier: 3},
+}
+
+// ParseOccupation validates an occupation sent by a client. An empty one
+// means no occupation.
+func ParseOccupation(s string) (Occupation, error) {
+	o := Occupation(strings.ToLower(strings.TrimSpace(s)))
+	if o == "" {
+		return "", nil
+	}
+	if _, ok := occupationPresets[o]; !ok {
+		return "", fmt.Errorf("occupation must be banker, carpenter or farmer, not %q", s)
+	}
+	return o, nil
+}
+
+// StartingCash is the cash a party of this occupation sets out with.
+func (o Occupation) StartingCash() float64 {
+	if preset, ok := occupationPresets[o]; ok {
+		return preset.cash
+	}
+	return DefaultStartingCash
+}
+
+// ScoreMultiplier is what the occupation multiplies the final score by.
+func (o Occupation) ScoreMultiplier() int {
+	if preset, ok := occupationPresets[o]; ok {
+		return preset.multiplier
+	}
+	return 1
+}
+
+// PartyStartingCash is the shared wagon's purse: the average of what each
+// player's occupation brings.
+func PartyStartingCash(players []*Player) float64 {
+	if len(players) == 0 {
+		return DefaultStartingCash
+	}
+	total := 0.0
+	for _, p := range players {
+		total += p.Occupation.StartingCash()
+	}
+	return total / float64(len(players))
+}
@@ -0,0 +1,35 @@
+package game
+
+import "fmt"
+
+// Oxen team strength (OxenCost). Below OxenCriticalLevel the team can
+// barely pull the wagon and travel slows to a crawl.
+const (
+	OxenMaxStrength   = 300
+	OxenCriticalLevel = 100
+	oxenCrawlMiles    = 15
+)
+
+// OxenCritical reports whether the team is too weak to travel properly.
+func (g *GameState) OxenCritical() bool {
+	return g.OxenCost < OxenCriticalLevel
+}
+
+// fortGoods describes an amount of a trade item for a receipt. Oxen are
+// counted as animals rather than team strength.
+func fortGoods(item string, amount float64) string {
+	if item == "oxen" {
+		return fmt.Sprintf("%.0f oxen (%.0f team strength)", amount/GetFortPrices()["oxen"].Qty, amount)
+	}
+	return fmt.Sprintf("%.0f %s", amount, item)
+}
+
+// oxenReport tells the player how much pull their team has left after
+// losing an ox.
+func (g *GameState) oxenReport() string {
+	g.ClampResources()
+	if g.OxenCritical() {
+		return fmt.Sprintf("Your oxen are down to %.0f strength - buy more at the next fort or you'll crawl!\n", g.OxenCost)
+	}
+	return fmt.Sprintf("Your oxen have %.0f of %d strength left.\n", g.OxenCost, OxenMaxStrength)
+}
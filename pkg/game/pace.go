@@ -0,0 +1,84 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pace is how hard the party pushes each week on the trail.
+type Pace string
+
+const (
+	PaceResting   Pace = "resting"
+	PaceSteady    Pace = "steady"
+	PaceStrenuous Pace = "strenuous"
+	PaceGrueling  Pace = "grueling"
+)
+
+// paceEffect is what a pace does to a week of travel: a travel multiplier,
+// the fatigue (or, when negative, rest) each party member may take, and a
+// change to the illness chance.
+type paceEffect struct {
+	travel  float64
+	fatigue int
+	illness float64
+}
+
+var paceEffects = map[Pace]paceEffect{
+	PaceResting:   {travel: 0.75, fatigue: -5, illness: -0.15},
+	PaceSteady:    {travel: 1},
+	PaceStrenuous: {travel: 1.1, illness: 0.05},
+	PaceGrueling:  {travel: 1.25, fatigue: 4, illness: 0.15},
+}
+
+// fatigueChance is the chance each living party member feels the pace in a
+// week.
+const fatigueChance = 0.5
+
+// Paces lists the paces from slowest to fastest.
+func Paces() []Pace {
+	return []Pace{PaceResting, PaceSteady, PaceStrenuous, PaceGrueling}
+}
+
+// CurrentPace is the pace the party travels at, steady unless one was set.
+func (g *GameState) CurrentPace() Pace {
+	if _, ok := paceEffects[g.Pace]; ok {
+		return g.Pace
+	}
+	return PaceSteady
+}
+
+// SetPace changes how hard the party travels from the next week on.
+func (g *GameState) SetPace(pace string) *TurnResult {
+	next := Pace(strings.ToLower(strings.TrimSpace(pace)))
+	if _, ok := paceEffects[next]; !ok {
+		return Refuse(ReasonInvalid, fmt.Sprintf("Unknown pace %q. Choose resting, steady, strenuous or grueling.\n", pace))
+	}
+	g.Pace = next
+	return NewResult(ResultPace, fmt.Sprintf("The party will travel at a %s pace.\n", next))
+}
+
+// paceWear deals the week's fatigue from a hard pace, or lets the party
+// recover a little at a resting one.
+func (g *GameState) paceWear(p *Player) string {
+	effect := paceEffects[g.CurrentPace()]
+	if effect.fatigue == 0 || p == nil {
+		return ""
+	}
+	result := &strings.Builder{}
+	for i := range p.Party {
+		m := &p.Party[i]
+		if !m.Alive || g.Rand.Float64() >= fatigueChance {
+			continue
+		}
+		if effect.fatigue > 0 {
+			result.WriteString(g.DamagePartyMember(p, i, effect.fatigue, "exhaustion"))
+			continue
+		}
+		if m.Health < MaxHealth {
+			m.Health = min(MaxHealth, m.Health-effect.fatigue)
+			result.WriteString(fmt.Sprintf("%s rested and feels better. (HP: %d)\n", m.Name, m.Health))
+		}
+	}
+	return result.String()
+}
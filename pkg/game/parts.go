@@ -0,0 +1,122 @@
+package game
+
+import "fmt"
+
+// Spare wagon parts carried in GameState.Parts.
+const (
+	PartWheel  = "wheel"
+	PartAxle   = "axle"
+	PartTongue = "tongue"
+)
+
+// wagonParts lists the parts a breakdown can take out.
+var wagonParts = []string{PartWheel, PartAxle, PartTongue}
+
+// maxSpareParts is how many of each part the wagon has room for.
+const maxSpareParts = 3
+
+// sparePartPrefix prefixes a part in the fort catalog, keeping "spare_axle"
+// apart from the reinforced axle upgrade.
+const sparePartPrefix = "spare_"
+
+// GetFortParts returns the spare parts the fort sells, keyed by catalog item.
+func GetFortParts() map[string]FortItem {
+	return map[string]FortItem{
+		sparePartPrefix + PartWheel:  {Price: 20, Qty: 1, Label: "Spare Wheel", Capacity: maxSpareParts, MaxQty: maxSpareParts},
+		sparePartPrefix + PartAxle:   {Price: 25, Qty: 1, Label: "Spare Axle", Capacity: maxSpareParts, MaxQty: maxSpareParts},
+		sparePartPrefix + PartTongue: {Price: 15, Qty: 1, Label: "Spare Tongue", Capacity: maxSpareParts, MaxQty: maxSpareParts},
+	}
+}
+
+// AddParts adds spare parts to the wagon, up to what it has room for.
+func (g *GameState) AddParts(parts map[string]int) {
+	for part, n := range parts {
+		if n <= 0 {
+			continue
+		}
+		if g.Parts == nil {
+			g.Parts = make(map[string]int)
+		}
+		g.Parts[part] = min(g.Parts[part]+n, maxSpareParts)
+	}
+}
+
+// CopyParts returns a copy of a parts inventory, or nil if it is empty.
+func CopyParts(parts map[string]int) map[string]int {
+	var out map[string]int
+	for part, n := range parts {
+		if n > 0 {
+			if out == nil {
+				out = make(map[string]int)
+			}
+			out[part] = n
+		}
+	}
+	return out
+}
+
+// spareParts describes n spare parts of one kind, e.g. "2 spare wheels".
+func spareParts(n int, part string) string {
+	if n == 1 {
+		return "1 spare " + part
+	}
+	return fmt.Sprintf("%d spare %ss", n, part)
+}
+
+// buyPart buys spare parts at the fort.
+func (g *GameState) buyPart(item string, qty int) *TurnResult {
+	fi := GetFortParts()[item]
+	part := item[len(sparePartPrefix):]
+	cost := fi.Price * float64(qty)
+	if cost > g.Cash {
+		return Refuse(ReasonInsufficientCash, fmt.Sprintf("Not enough cash! Need $%.0f but only have $%.0f\n", cost, g.Cash))
+	}
+	if have := g.Parts[part]; have+qty > maxSpareParts {
+		return Refuse(ReasonInvalid, fmt.Sprintf("The wagon can carry at most %d spare %ss! You have %d\n", maxSpareParts, part, have))
+	}
+
+	g.Cash -= cost
+	g.contribution(g.actingPlayerID(nil)).FortSpending += cost
+	g.AddParts(map[string]int{part: qty})
+	g.FortTrades++
+	return NewResultWith(ResultFortBuy, fmt.Sprintf("Bought %s for $%.0f\n", spareParts(qty, part), cost),
+		map[string]float64{"qty": float64(qty), "cost": cost})
+}
+
+// sellPart sells spare parts back to the fort at half price.
+func (g *GameState) sellPart(item string, qty int) *TurnResult {
+	fi := GetFortParts()[item]
+	part := item[len(sparePartPrefix):]
+	if have := g.Parts[part]; have < qty {
+		return Refuse(ReasonInsufficientSupplies, fmt.Sprintf("Not enough spare %ss to sell! Have %d, need %d\n", part, have, qty))
+	}
+
+	earnings := fi.Price * 0.5 * float64(qty)
+	g.Parts[part] -= qty
+	g.Cash += earnings
+	g.FortTrades++
+	return NewResultWith(ResultFortSell, fmt.Sprintf("Sold %s for $%.0f\n", spareParts(qty, part), earnings),
+		map[string]float64{"qty": float64(qty), "earnings": earnings})
+}
+
+// eventWagonBreakdown breaks a random part. A spare gets the wagon rolling
+// again quickly; without one the party loses days jury-rigging a fix and
+// may get hurt doing it.
+func (g *GameState) eventWagonBreakdown(p *Player) string {
+	if g.HasStrongAxle && g.Rand.Float64() < axleBreakdownSave {
+		return "ROUGH TRAIL - Your reinforced axle held up\n"
+	}
+	part := wagonParts[g.Rand.Intn(len(wagonParts))]
+	if g.Parts[part] > 0 {
+		g.Parts[part]--
+		g.Mileage -= 5 + g.Rand.Float64()*5
+		return fmt.Sprintf("WAGON BREAKS DOWN - A broken %s! You fit your spare and are soon on your way (%d left)\n", part, g.Parts[part])
+	}
+	g.Mileage -= 40 + g.Rand.Float64()*20
+	g.MiscSupplies -= 8
+	result := fmt.Sprintf("WAGON BREAKS DOWN - A broken %s and no spare! You lose days jury-rigging a fix\n", part)
+	if g.Rand.Float64() < 0.4 {
+		result += g.DamageRandomMember(p, 10, "an accident while repairing the wagon")
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RaidTactic is how the defender of a raid answers it.
+type RaidTactic string
+
+const (
+	RaidFight RaidTactic = "fight"
+	RaidFlee  RaidTactic = "flee"
+	RaidPay   RaidTactic = "pay"
+)
+
+// Raid rules: how close the wagons must be, how long the defender has to
+// answer, how long both wagons are left alone afterwards, the share of
+// goods the winner takes, the smaller toll a defender who pays hands over,
+// the chance a fleeing wagon gets away, the loser's wounds and the bullets
+// a volley takes.
+const (
+	RaidRange        = 50
+	RaidResponseTime = 60 * time.Second
+	RaidCooldown     = 30 * time.Minute
+	raidShare        = 0.2
+	raidToll         = 0.1
+	raidFleeChance   = 0.6
+	raidDamage       = 20
+	raidBulletCost   = 80
+)
+
+// raidGoods are the trade items a raid plunders besides cash, with how
+// each reads in a raid report.
+var raidGoods = []struct{ item, name string }{
+	{"food", "lbs of food"},
+	{"bullets", "bullets"},
+	{"clothing", "sets of clothing"},
+	{"misc", "supply kits"},
+}
+
+// ParseRaidTactic validates a tactic sent by a defender.
+func ParseRaidTactic(s string) (RaidTactic, error) {
+	t := RaidTactic(strings.ToLower(strings.TrimSpace(s)))
+	switch t {
+	case RaidFight, RaidFlee, RaidPay:
+		return t, nil
+	}
+	return "", fmt.Errorf("answer with fight, flee or pay, not %q", s)
+}
+
+// ResolveRaid settles a raid by ap, driving atk, on dp, driving def. An
+// empty tactic means the defender never answered and was taken by surprise.
+// Fights are shot out like a rider attack: each side fires, the better shot
+// wins and the defender wins a tie. The loser's party takes damage and the
+// winner takes a share of their cash and goods.
+func ResolveRaid(atk *GameState, ap *Player, def *GameState, dp *Player, tactic RaidTactic) *TurnResult {
+	result := &TurnResult{}
+	attackerWon := true
+
+	switch tactic {
+	case RaidFight:
+		atkAcc, defAcc := atk.raidShot(), def.raidShot()
+		attackerWon = atkAcc < defAcc
+		result.Text(fmt.Sprintf("%s fought back against %s!\n", dp.Name, ap.Name))
+	case RaidFlee:
+		def.Mileage += 20
+		def.OxenCost -= 40
+		if def.Rand.Float64() < raidFleeChance {
+			result.Add(ResultRaid, fmt.Sprintf("%s whipped the oxen and outran %s's raiders.\n", dp.Name, ap.Name),
+				map[string]float64{"attacker_won": 0})
+			def.ClampResources()
+			return result
+		}
+		result.Text(fmt.Sprintf("%s tried to flee, but %s's raiders ran the wagon down!\n", dp.Name, ap.Name))
+	case RaidPay:
+		took := plunder(def, atk, raidToll)
+		result.Add(ResultRaid, fmt.Sprintf("%s paid %s off with %s.\n", dp.Name, ap.Name, took),
+			map[string]float64{"attacker_won": 1})
+		return result
+	default:
+		result.Text(fmt.Sprintf("%s's raiders caught %s's wagon by surprise!\n", ap.Name, dp.Name))
+	}
+
+	winner, wp, loser, lp := atk, ap, def, dp
+	if !attackerWon {
+		winner, wp, loser, lp = def, dp, atk, ap
+	}
+	params := map[string]float64{"attacker_won": 0}
+	if attackerWon {
+		params["attacker_won"] = 1
+	}
+	took := plunder(loser, winner, raidShare)
+	result.Add(ResultRaid, fmt.Sprintf("%s won the fight and took %s from %s.\n", wp.Name, took, lp.Name), params)
+	if hurt := loser.DamageRandomMember(lp, raidDamage, "wounds from a raid"); hurt != "" {
+		result.Text(fmt.Sprintf("%s's party: %s", lp.Name, hurt))
+	}
+	return result
+}
+
+// raidShot fires one volley in a raid, like a shot at riders, and returns
+// its accuracy (lower is better). A wagon short of bullets can't shoot back.
+func (g *GameState) raidShot() float64 {
+	if g.Bullets < raidBulletCost {
+		g.Bullets = 0
+		return huntAccuracy(HuntMissMs, false)
+	}
+	accuracy := huntAccuracy(g.Rand.Intn(2000), g.HasRifleScope)
+	g.Bullets -= accuracy*40 + raidBulletCost
+	g.ClampResources()
+	return accuracy
+}
+
+// plunder moves share of from's cash and goods into to's wagon, leaving
+// whatever doesn't fit with from, and describes what changed hands.
+func plunder(from, to *GameState, share float64) string {
+	cash := from.Cash * share
+	from.Cash -= cash
+	to.Cash += cash
+	took := []string{fmt.Sprintf("$%.0f", cash)}
+	for _, goods := range raidGoods {
+		stock := from.fortStock(goods.item)
+		amount := *stock * share
+		kept := amount - to.stow(goods.item, amount)
+		*stock -= kept
+		if kept >= 1 {
+			took = append(took, fmt.Sprintf("%.0f %s", kept, goods.name))
+		}
+	}
+	from.ClampResources()
+	to.ClampResources()
+	last := took[len(took)-1]
+	if len(took) > 1 {
+		last = strings.Join(took[:len(took)-1], ", ") + " and " + last
+	}
+	return last
+}
@@ -0,0 +1,129 @@
+package game
+
+import "strings"
+
+// Result event codes. Clients that format results themselves switch on
+// these; anything else can show the default message.
+const (
+	ResultError        = "error"         // the action was refused
+	ResultSpectating   = "spectating"    // the player's party has perished
+	ResultText         = "text"          // narrative not broken down further
+	ResultWeather      = "weather"       // the week's date, season and weather
+	ResultTravel       = "travel"        // params: miles
+	ResultPace         = "pace"          // the pace changed; pace in the message
+	ResultStarving     = "starving"      // food ran critically low
+	ResultOxenCritical = "oxen_critical" // params: strength
+	ResultRiders       = "riders"        // params: count, hostile (0 or 1)
+	ResultRaid         = "raid"          // params: attacker_won (0 or 1); who took what in the message
+	ResultLandmark     = "landmark"      // params: mileage, index; name in the message
+	ResultTrailNote    = "trail_note"    // params: mileage; author and text in the message
+	ResultHuntReady    = "hunt_ready"    // waiting for the shot
+	ResultNoBullets    = "no_bullets"    // too few bullets to hunt
+	ResultHuntHit      = "hunt_hit"      // params: food, big (0 or 1), reaction_ms
+	ResultHuntMiss     = "hunt_miss"     // params: reaction_ms
+	ResultHuntTravel   = "hunt_travel"   // params: miles
+	ResultHuntCancel   = "hunt_cancel"   // the hunter put the rifle away
+	ResultHuntTimeout  = "hunt_timeout"  // no shot in time; scored as a miss
+	ResultFortArrive   = "fort_arrive"   // the wagon is at the trading post
+	ResultFortAuto     = "fort_auto"     // params: spent
+	ResultFortBuy      = "fort_buy"      // params: qty, cost, left; item in the message
+	ResultFortSell     = "fort_sell"     // params: qty, earnings
+	ResultFortBasket   = "fort_basket"   // params: cost, items
+	ResultUpgrade      = "upgrade"       // params: cost
+	ResultForecast     = "forecast"      // params: cost
+	ResultFortHeal     = "fort_heal"     // params: member, hp, cost
+	ResultFortLeave    = "fort_leave"    // back on the trail
+)
+
+// Reasons an action was refused, carried on its ResultError event so
+// clients can tell why without reading the message.
+const (
+	ReasonNotYourTurn          = "not_your_turn"
+	ReasonNotOwner             = "not_owner"             // only the wagon's owner may do it
+	ReasonInvalidPhase         = "invalid_phase"         // not now: not at a fort, not hunting, ...
+	ReasonInsufficientCash     = "insufficient_cash"     // can't afford it
+	ReasonInsufficientSupplies = "insufficient_supplies" // not enough of the goods to sell
+	ReasonInvalid              = "invalid_request"       // anything else wrong with the request
+)
+
+// ResultEvent is one thing that happened during an action: a code, the
+// numbers behind it and the default English message. A ResultError event
+// also says why the action was refused.
+type ResultEvent struct {
+	Code    string             `json:"code"`
+	Params  map[string]float64 `json:"params,omitempty"`
+	Message string             `json:"message"`
+	Reason  string             `json:"reason,omitempty"`
+}
+
+// TurnResult is everything an action produced, in order. String renders it
+// the way results were always shown.
+type TurnResult struct {
+	Events []ResultEvent `json:"events"`
+}
+
+// NewResult returns a result holding a single event.
+func NewResult(code, message string) *TurnResult {
+	r := &TurnResult{}
+	r.Add(code, message, nil)
+	return r
+}
+
+// NewResultWith returns a result holding a single event with params.
+func NewResultWith(code, message string, params map[string]float64) *TurnResult {
+	r := &TurnResult{}
+	r.Add(code, message, params)
+	return r
+}
+
+// Refuse returns a result refusing an action for reason.
+func Refuse(reason, message string) *TurnResult {
+	return RefuseWith(reason, message, nil)
+}
+
+// RefuseWith returns a result refusing an action for reason, with params.
+func RefuseWith(reason, message string, params map[string]float64) *TurnResult {
+	r := NewResultWith(ResultError, message, params)
+	r.Events[0].Reason = reason
+	return r
+}
+
+// Refusal returns the event refusing the action, or nil if it went ahead.
+func (r *TurnResult) Refusal() *ResultEvent {
+	if r == nil || len(r.Events) == 0 || r.Events[0].Code != ResultError {
+		return nil
+	}
+	return &r.Events[0]
+}
+
+// Add appends an event.
+func (r *TurnResult) Add(code, message string, params map[string]float64) {
+	r.Events = append(r.Events, ResultEvent{Code: code, Params: params, Message: message})
+}
+
+// Text appends narrative from the helpers that still build plain strings.
+// Empty text is dropped.
+func (r *TurnResult) Text(message string) {
+	if message != "" {
+		r.Add(ResultText, message, nil)
+	}
+}
+
+// Append adds another result's events after this one's.
+func (r *TurnResult) Append(other *TurnResult) {
+	if other != nil {
+		r.Events = append(r.Events, other.Events...)
+	}
+}
+
+// String renders the default messages in order.
+func (r *TurnResult) String() string {
+	if r == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, e := range r.Events {
+		b.WriteString(e.Message)
+	}
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package game
+
+import "math/rand"
+
+// drawSource is a game's random source. It counts its draws, so a saved
+// game can pick its sequence up where it left off instead of replaying it
+// from the seed.
+type drawSource struct {
+	src   rand.Source64
+	draws uint64
+}
+
+// newDrawSource returns seed's source with its first draws already taken.
+func newDrawSource(seed int64, draws uint64) *drawSource {
+	s := &drawSource{src: rand.NewSource(seed).(rand.Source64)}
+	for s.draws < draws {
+		s.Uint64()
+	}
+	return s
+}
+
+func (s *drawSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *drawSource) Uint64() uint64 {
+	s.draws++
+	return s.src.Uint64()
+}
+
+func (s *drawSource) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.draws = 0
+}
+
+// Draws returns how many values the game has drawn from its seed.
+func (g *GameState) Draws() uint64 {
+	if g.source == nil {
+		return 0
+	}
+	return g.source.draws
+}
+
+// ResumeDraws moves the game's random sequence to just after its first
+// draws values, as a saved game left it.
+func (g *GameState) ResumeDraws(draws uint64) {
+	g.source = newDrawSource(g.Seed, draws)
+	g.Rand = rand.New(g.source)
+}
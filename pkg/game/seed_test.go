@@ -0,0 +1,99 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// seededTrip plays turns of steady travel from a fresh outfit, answering
+// riders by running, and returns what each turn produced.
+func seededTrip(g *GameState, turns int) []string {
+	g.Food, g.Bullets, g.Clothing, g.MiscSupplies, g.Cash, g.OxenCost = 100, 50, 20, 10, 700, 220
+	p := g.AddPlayer("Tester", PlayerTypeHuman)
+	log := make([]string, 0, turns)
+	for i := 0; i < turns && !g.GameOver; i++ {
+		result := g.ProcessTurn(p, "continue")
+		if g.TurnPhase == PhaseRiders {
+			result.Append(g.HandleRiderTactic(p, 3))
+		}
+		codes := make([]string, 0, len(result.Events))
+		for _, e := range result.Events {
+			codes = append(codes, e.Code)
+		}
+		log = append(log, fmt.Sprintf("%s @%.0f", strings.Join(codes, ","), g.Mileage))
+		g.NextTurn()
+	}
+	return log
+}
+
+func TestSeededTripIsRepeatable(t *testing.T) {
+	tests := []struct {
+		seed int64
+		want []string
+	}{
+		{1, []string{
+			"weather,travel,text @89",
+			"weather,travel,text @158",
+			"weather,travel,text @241",
+			"weather,starving,text,text,text,text,text,travel,text,landmark @313",
+			"weather,starving,text,text,text,text,text,travel,text @344",
+		}},
+		{42, []string{
+			"weather,travel,text @66",
+			"weather,travel,text @141",
+			"weather,travel,text @236",
+			"weather,travel,text,landmark @317",
+			"weather,travel,text @406",
+		}},
+		{1848, []string{
+			"weather,travel,text @83",
+			"weather,travel,text @155",
+			"weather,travel,text @242",
+			"weather,travel,text,landmark @321",
+			"weather,travel,text @400",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.seed), func(t *testing.T) {
+			got := seededTrip(NewGameStateWithSeed(tt.seed), len(tt.want))
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got turns\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestResumeDrawsContinuesSequence(t *testing.T) {
+	for _, draws := range []int{0, 1, 17, 250} {
+		t.Run(fmt.Sprint(draws), func(t *testing.T) {
+			played := NewGameStateWithSeed(7)
+			for i := 0; i < draws; i++ {
+				played.Rand.Float64()
+			}
+			if played.Draws() != uint64(draws) {
+				t.Fatalf("Draws() = %d, want %d", played.Draws(), draws)
+			}
+
+			restored := NewGameStateWithSeed(7)
+			restored.ResumeDraws(played.Draws())
+			for i := 0; i < 10; i++ {
+				if got, want := restored.Rand.Int63(), played.Rand.Int63(); got != want {
+					t.Fatalf("draw %d after resuming: got %d, want %d", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSeedStaysOffTheWire(t *testing.T) {
+	g := NewGameStateWithSeed(918273645)
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "918273645") {
+		t.Errorf("marshalled game state contains its seed: %s", data)
+	}
+}
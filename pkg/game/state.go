@@ -1,6 +1,8 @@
 package game
 
 import (
+	crand "crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"math/rand"
 	"time"
@@ -14,73 +16,124 @@ const (
 )
 
 type PartyMember struct {
-	Name    string
-	Alive   bool
-	Health  int
-	Injured bool
+	Name       string `json:"name"`
+	Alive      bool   `json:"alive"`
+	Health     int    `json:"health"`
+	Injured    bool   `json:"injured"`
+	DiedOf     string `json:"died_of,omitempty"`
+	DiedOnTurn int    `json:"died_on_turn,omitempty"`
 }
 
 type Player struct {
-	ID           string
-	Name         string
-	Type         PlayerType
-	Party        []PartyMember
-	InputChan    chan string
-	OutputChan   chan string
-	Connected    bool
-	ShootingRank int
-	Alive        bool
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Type         PlayerType    `json:"type"`
+	Party        []PartyMember `json:"party"`
+	InputChan    chan string   `json:"-"`
+	OutputChan   chan string   `json:"-"`
+	Connected    bool          `json:"connected"`
+	ShootingRank int           `json:"shooting_rank"`
+	Alive        bool          `json:"alive"`
+	Occupation   Occupation    `json:"occupation,omitempty"`
+	Peaceful     bool          `json:"peaceful,omitempty"` // opted out of raids on the open trail
 }
 
+// GameState is the whole state of one game. It marshals to JSON for the TCP
+// protocol; the random source and player channels are left out.
 type GameState struct {
-	Players          []*Player
-	CurrentPlayerIdx int
-	TurnNumber       int
-	Week             int
-	Day              int
-	Mileage          float64
-	Food             float64
-	Bullets          float64
-	Clothing         float64
-	MiscSupplies     float64
-	Cash             float64
-	OxenCost         float64
-	DistanceTraveled int
-	TurnPhase        TurnPhase
-	EventLog         []string
-	GameOver         bool
-	Win              bool
-	FinalDate        string
-	Rand             *rand.Rand
+	Players          []*Player  `json:"players"`
+	CurrentPlayerIdx int        `json:"current_player_idx"`
+	TurnOrder        []string   `json:"turn_order,omitempty"` // player IDs; see turnorder.go
+	TurnNumber       int        `json:"turn_number"`
+	Week             int        `json:"week"`
+	Day              int        `json:"day"`
+	Weather          string     `json:"weather"`
+	Pace             Pace       `json:"pace"`
+	Mileage          float64    `json:"mileage"`
+	Food             float64    `json:"food"`
+	Bullets          float64    `json:"bullets"`
+	Clothing         float64    `json:"clothing"`
+	MiscSupplies     float64    `json:"misc_supplies"`
+	Cash             float64    `json:"cash"`
+	OxenCost         float64    `json:"oxen_cost"`
+	DistanceTraveled int        `json:"distance_traveled"`
+	LandmarksReached int        `json:"landmarks_reached"`
+	TurnPhase        TurnPhase  `json:"turn_phase"`
+	GameOver         bool       `json:"game_over"`
+	Win              bool       `json:"win"`
+	FinalDate        string     `json:"final_date"`
+	Seed             int64      `json:"-"` // secret: it predicts every draw
+	Rand             *rand.Rand `json:"-"`
+	source           *drawSource
+
+	// Journal of what happened, oldest first; see Record
+	EventLog []JournalEntry `json:"event_log"`
 
 	// Interactive phase fields
-	PendingRiderHostile bool
-	PendingEatingLevel  int
-	PendingRiderCount   int
-	HuntWord            string
-
-	// Fort availability
-	FortAvailable bool
-
-	// Loot sites (abandoned wagons from dead players) - for 24/7 mode
-	LootSites []LootSite
+	PendingRiderHostile bool   `json:"pending_rider_hostile"`
+	PendingEatingLevel  int    `json:"pending_eating_level"`
+	PendingRiderCount   int    `json:"pending_rider_count"`
+	HuntWord            string `json:"hunt_word"`
+
+	// When the hunt prompt was issued and how long after that the word is
+	// shown, so the server can time the shot itself
+	HuntStartedAt time.Time `json:"hunt_started_at"`
+	HuntDelayMs   int       `json:"hunt_delay_ms"`
+
+	// Fort availability and buys and sells made on the current visit
+	FortAvailable bool `json:"fort_available"`
+	FortTrades    int  `json:"fort_trades"`
+
+	// Wagon upgrades (one-time purchases at forts past UpgradeMinMileage)
+	HasStrongAxle  bool `json:"has_strong_axle"`
+	HasWaterBarrel bool `json:"has_water_barrel"`
+	HasRifleScope  bool `json:"has_rifle_scope"`
+	HasWagonBed    bool `json:"has_wagon_bed"`
+
+	// Spare parts on hand, keyed by Part*
+	Parts map[string]int `json:"parts"`
+
+	// Loot sites (abandoned wagons from dead players) and notes carved by
+	// players - for 24/7 mode
+	LootSites  []LootSite  `json:"loot_sites"`
+	TrailNotes []TrailNote `json:"trail_notes,omitempty"`
+
+	// Per-player tallies for the shared wagon, keyed by player ID, and the
+	// mileage when the current turn began
+	Contributions    map[string]*Contribution `json:"contributions,omitempty"`
+	TurnStartMileage float64                  `json:"turn_start_mileage"`
+
+	// Trail milestones already announced to the room, and what the last
+	// action gave the wagon to tell; see TrailNews
+	MilestonesReached int `json:"milestones_reached"`
+	weatheredBlizzard bool
+	droveOffRiders    bool
 }
 
 // LootSite represents an abandoned wagon from a dead player
 type LootSite struct {
-	ID           string    `json:"id"`
-	Mileage      float64   `json:"mileage"`
-	PlayerName   string    `json:"player_name"`
-	Food         float64   `json:"food"`
-	Bullets      float64   `json:"bullets"`
-	Clothing     float64   `json:"clothing"`
-	MiscSupplies float64   `json:"misc_supplies"`
-	Cash         float64   `json:"cash"`
-	OxenCost     float64   `json:"oxen_cost"`
-	DateCreated  time.Time `json:"date_created"`
-	IsLooted     bool      `json:"is_looted"`
-	LootedBy     string    `json:"looted_by"`
-	LootedAt     time.Time `json:"looted_at"`
+	ID           string         `json:"id"`
+	Mileage      float64        `json:"mileage"`
+	PlayerName   string         `json:"player_name"`
+	Food         float64        `json:"food"`
+	Bullets      float64        `json:"bullets"`
+	Clothing     float64        `json:"clothing"`
+	MiscSupplies float64        `json:"misc_supplies"`
+	Cash         float64        `json:"cash"`
+	OxenCost     float64        `json:"oxen_cost"`
+	Parts        map[string]int `json:"parts,omitempty"`
+	WagonBed     bool           `json:"wagon_bed,omitempty"`
+	DateCreated  time.Time      `json:"date_created"`
+	// LastDecayedAt is when the supplies last decayed; see Decay
+	LastDecayedAt time.Time `json:"last_decayed_at,omitempty"`
+	IsLooted      bool      `json:"is_looted"`
+	LootedBy      string    `json:"looted_by"`
+	LootedAt      time.Time `json:"looted_at"`
+
+	// NPC sites are flavor seeded on a fresh server; they never belonged to
+	// a real player. Graves are NPC sites with an epitaph and no supplies.
+	IsNPC   bool   `json:"is_npc,omitempty"`
+	Epitaph string `json:"epitaph,omitempty"`
 }
 
 type TurnPhase string
@@ -110,7 +163,15 @@ type Event struct {
 
 var shootingWords = []string{"BANG", "BLAM", "POW", "WHAM"}
 
+// NewGameState returns a game seeded from the clock.
 func NewGameState() *GameState {
+	return NewGameStateWithSeed(time.Now().UnixNano())
+}
+
+// NewGameStateWithSeed returns a game whose random draws all come from seed,
+// so the same seed and the same choices replay the same journey.
+func NewGameStateWithSeed(seed int64) *GameState {
+	source := newDrawSource(seed, 0)
 	return &GameState{
 		Players:          make([]*Player, 0),
 		CurrentPlayerIdx: 0,
@@ -126,11 +187,16 @@ func NewGameState() *GameState {
 		OxenCost:         0,
 		DistanceTraveled: 0,
 		TurnPhase:        PhaseStart,
-		EventLog:         make([]string, 0),
+		Pace:             PaceSteady,
+		EventLog:         make([]JournalEntry, 0),
 		GameOver:         false,
 		Win:              false,
-		Rand:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		Seed:             seed,
+		Rand:             rand.New(source),
+		source:           source,
 		LootSites:        make([]LootSite, 0),
+		Contributions:    make(map[string]*Contribution),
+		Parts:            make(map[string]int),
 	}
 }
 
@@ -169,46 +235,15 @@ func (g *GameState) NextTurn() {
 		return
 	}
 
+	g.creditTurn(g.GetCurrentPlayer())
+
 	g.TurnNumber++
 	if g.TurnNumber%4 == 0 {
 		g.Week++
 	}
 
-	// Single alive player — ensure index points to them
-	if len(humans) == 1 {
-		for idx, p := range g.Players {
-			if p == humans[0] {
-				g.CurrentPlayerIdx = idx
-				break
-			}
-		}
-		return
-	}
-
-	currentHuman := g.GetCurrentPlayer()
-	found := false
-	for i, h := range humans {
-		if h == currentHuman {
-			nextIdx := (i + 1) % len(humans)
-			for idx, p := range g.Players {
-				if p == humans[nextIdx] {
-					g.CurrentPlayerIdx = idx
-					found = true
-					break
-				}
-			}
-			break
-		}
-	}
-	if !found {
-		// Fallback: pick the first alive human
-		for idx, p := range g.Players {
-			if p.Type == PlayerTypeHuman && p.Alive {
-				g.CurrentPlayerIdx = idx
-				break
-			}
-		}
-	}
+	g.syncTurnOrder()
+	g.setCurrentPlayer(g.nextInOrder(g.turnPosition(g.TurnOrder)))
 }
 
 func (g *GameState) AddPlayer(name string, pType PlayerType) *Player {
@@ -218,13 +253,13 @@ func (g *GameState) AddPlayer(name string, pType PlayerType) *Player {
 		party[i] = PartyMember{
 			Name:    names[i],
 			Alive:   true,
-			Health:  100,
+			Health:  MaxHealth,
 			Injured: false,
 		}
 	}
 
 	player := &Player{
-		ID:           generateID(),
+		ID:           NewPlayerID(),
 		Name:         name,
 		Type:         pType,
 		Party:        party,
@@ -239,16 +274,29 @@ func (g *GameState) AddPlayer(name string, pType PlayerType) *Player {
 	return player
 }
 
-func generateID() string {
-	return fmt.Sprintf("player-%d", time.Now().UnixNano())
+// GenerateShortID returns a random 16-character hex string, for IDs that are
+// shown to other players and so aren't secrets, but must never collide.
+func GenerateShortID() string {
+	b := make([]byte, 8)
+	crand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewPlayerID returns a random player ID. Servers usually replace it with
+// their own client ID.
+func NewPlayerID() string {
+	return "player-" + GenerateShortID()
 }
 
 func (g *GameState) ResetGame() {
 	g.Players = make([]*Player, 0)
 	g.CurrentPlayerIdx = 0
+	g.TurnOrder = nil
 	g.TurnNumber = 0
 	g.Week = 1
 	g.Day = 1
+	g.Weather = ""
+	g.Pace = PaceSteady
 	g.Mileage = 0
 	g.Food = 0
 	g.Bullets = 0
@@ -257,8 +305,10 @@ func (g *GameState) ResetGame() {
 	g.Cash = 0
 	g.OxenCost = 0
 	g.DistanceTraveled = 0
+	g.LandmarksReached = 0
+	g.MilestonesReached = 0
 	g.TurnPhase = PhaseStart
-	g.EventLog = make([]string, 0)
+	g.EventLog = make([]JournalEntry, 0)
 	g.GameOver = false
 	g.Win = false
 	g.FinalDate = ""
@@ -266,8 +316,19 @@ func (g *GameState) ResetGame() {
 	g.PendingEatingLevel = 0
 	g.PendingRiderCount = 0
 	g.HuntWord = ""
+	g.HuntStartedAt = time.Time{}
+	g.HuntDelayMs = 0
 	g.FortAvailable = false
+	g.FortTrades = 0
+	g.HasStrongAxle = false
+	g.HasWaterBarrel = false
+	g.HasRifleScope = false
+	g.HasWagonBed = false
+	g.Parts = make(map[string]int)
 	g.LootSites = make([]LootSite, 0)
+	g.TrailNotes = nil
+	g.Contributions = make(map[string]*Contribution)
+	g.TurnStartMileage = 0
 }
 
 // TrailLength is the total trail distance in miles.
@@ -278,10 +339,12 @@ const MountainThreshold = 2500
 
 // GetPartyHealth returns party health info for the current player.
 type PartyHealthInfo struct {
-	Name    string `json:"name"`
-	Health  int    `json:"health"`
-	Alive   bool   `json:"alive"`
-	Injured bool   `json:"injured"`
+	Name       string `json:"name"`
+	Health     int    `json:"health"`
+	Alive      bool   `json:"alive"`
+	Injured    bool   `json:"injured"`
+	DiedOf     string `json:"died_of,omitempty"`
+	DiedOnTurn int    `json:"died_on_turn,omitempty"`
 }
 
 func (g *GameState) GetPartyHealth(p *Player) []PartyHealthInfo {
@@ -291,17 +354,20 @@ func (g *GameState) GetPartyHealth(p *Player) []PartyHealthInfo {
 	info := make([]PartyHealthInfo, len(p.Party))
 	for i, m := range p.Party {
 		info[i] = PartyHealthInfo{
-			Name:    m.Name,
-			Health:  m.Health,
-			Alive:   m.Alive,
-			Injured: m.Injured,
+			Name:       m.Name,
+			Health:     m.Health,
+			Alive:      m.Alive,
+			Injured:    m.Injured,
+			DiedOf:     m.DiedOf,
+			DiedOnTurn: m.DiedOnTurn,
 		}
 	}
 	return info
 }
 
 // DamagePartyMember reduces HP of a specific party member and checks for death.
-func (g *GameState) DamagePartyMember(p *Player, memberIdx int, amount int) string {
+// cause describes what dealt the damage and is recorded if the member dies.
+func (g *GameState) DamagePartyMember(p *Player, memberIdx int, amount int, cause string) string {
 	if p == nil || memberIdx < 0 || memberIdx >= len(p.Party) {
 		return ""
 	}
@@ -309,11 +375,16 @@ func (g *GameState) DamagePartyMember(p *Player, memberIdx int, amount int) stri
 	if !m.Alive {
 		return ""
 	}
+	if amount > 0 {
+		g.contribution(g.actingPlayerID(p)).DamageTaken += min(amount, m.Health)
+	}
 	m.Health -= amount
 	if m.Health <= 0 {
 		m.Health = 0
 		m.Alive = false
-		msg := fmt.Sprintf("%s has died!\n", m.Name)
+		m.DiedOf = cause
+		m.DiedOnTurn = g.TurnNumber
+		msg := fmt.Sprintf("%s has died of %s!\n", m.Name, cause)
 		if memberIdx == 0 {
 			p.Alive = false
 			msg += fmt.Sprintf("%s's party leader has fallen! They are out of the game.\n", p.Name)
@@ -337,7 +408,7 @@ func (g *GameState) CheckAllPlayersDead() bool {
 }
 
 // DamageRandomMember picks a random alive member and damages them.
-func (g *GameState) DamageRandomMember(p *Player, amount int) string {
+func (g *GameState) DamageRandomMember(p *Player, amount int, cause string) string {
 	if p == nil {
 		return ""
 	}
@@ -351,10 +422,11 @@ func (g *GameState) DamageRandomMember(p *Player, amount int) string {
 		return ""
 	}
 	idx := alive[g.Rand.Intn(len(alive))]
-	return g.DamagePartyMember(p, idx, amount)
+	return g.DamagePartyMember(p, idx, amount, cause)
 }
 
-// ClampResources ensures no resource goes below zero.
+// ClampResources ensures no resource goes below zero or past what the wagon
+// can carry.
 func (g *GameState) ClampResources() {
 	if g.Food < 0 {
 		g.Food = 0
@@ -377,4 +449,52 @@ func (g *GameState) ClampResources() {
 	if g.OxenCost < 0 {
 		g.OxenCost = 0
 	}
+	for item, max := range g.Capacities() {
+		if stock := g.fortStock(item); *stock > max {
+			*stock = max
+		}
+	}
+}
+
+// Score weights used by ComputeScore.
+const (
+	ScoreSurvivorBonus = 500
+	ScoreTurnPenalty   = 10
+)
+
+// CountSurvivors returns the number of living members in a player's party.
+func (g *GameState) CountSurvivors(p *Player) int {
+	if p == nil {
+		return 0
+	}
+	survivors := 0
+	for _, m := range p.Party {
+		if m.Alive {
+			survivors++
+		}
+	}
+	return survivors
+}
+
+// ComputeScore returns the classic end-of-game score for a player: remaining
+// cash plus supplies valued at fort prices, a bonus for each survivor, minus
+// a penalty for every turn spent on the trail, times the player's occupation
+// multiplier. The score never goes below zero.
+func (g *GameState) ComputeScore(p *Player) int {
+	if p == nil {
+		return 0
+	}
+	prices := GetFortPrices()
+	supplies := g.Food/prices["food"].Qty*prices["food"].Price +
+		g.Bullets/prices["bullets"].Qty*prices["bullets"].Price +
+		g.Clothing/prices["clothing"].Qty*prices["clothing"].Price +
+		g.MiscSupplies/prices["misc"].Qty*prices["misc"].Price
+
+	score := int(g.Cash+supplies) +
+		g.CountSurvivors(p)*ScoreSurvivorBonus -
+		g.TurnNumber*ScoreTurnPenalty
+	if score < 0 {
+		score = 0
+	}
+	return score * p.Occupation.ScoreMultiplier()
 }
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewPlayerIDsDontCollide(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := NewPlayerID()
+		if !strings.HasPrefix(id, "player-") || len(id) != len("player-")+16 {
+			t.Fatalf("NewPlayerID() = %q, want player- and 16 hex characters", id)
+		}
+		if seen[id] {
+			t.Fatalf("%s was handed out twice", id)
+		}
+		seen[id] = true
+	}
+	if a, b := NewGameState().AddPlayer("Ann", PlayerTypeHuman), NewGameState().AddPlayer("Ann", PlayerTypeHuman); a.ID == b.ID {
+		t.Errorf("two games gave their first player the same ID %s", a.ID)
+	}
+}
+
+func TestDeathRecordsItsCause(t *testing.T) {
+	g := NewGameStateWithSeed(1)
+	g.TurnNumber = 6
+	p := g.AddPlayer("Ann", PlayerTypeHuman)
+
+	g.DamagePartyMember(p, 2, 30, "a snake bite")
+	if m := p.Party[2]; !m.Alive || m.DiedOf != "" || m.DiedOnTurn != 0 {
+		t.Errorf("an injured member has a cause of death: %+v", m)
+	}
+	msg := g.DamagePartyMember(p, 2, MaxHealth, "cholera")
+	if m := p.Party[2]; m.Alive || m.DiedOf != "cholera" || m.DiedOnTurn != 6 {
+		t.Errorf("after dying: %+v, want cholera on turn 6", m)
+	}
+	if !strings.Contains(msg, "died of cholera") {
+		t.Errorf("death message = %q", msg)
+	}
+	// The dead can't die again of something else
+	g.TurnNumber = 7
+	g.DamagePartyMember(p, 2, MaxHealth, "drowning in the Snake River")
+	if m := p.Party[2]; m.DiedOf != "cholera" || m.DiedOnTurn != 6 {
+		t.Errorf("a second death rewrote the first: %+v", m)
+	}
+
+	data, err := json.Marshal(p.Party[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved PartyMember
+	if err := json.Unmarshal(data, &saved); err != nil || saved != p.Party[2] {
+		t.Errorf("saved as %s, read back %+v", data, saved)
+	}
+
+	g.Mileage = TrailLength
+	if final := g.HandleFinalTurn(p); !strings.Contains(final, "Son - died of cholera on turn 6") {
+		t.Errorf("the final tally doesn't name the dead:\n%s", final)
+	}
+}
+
+func TestGameStateRoundTripsThroughJSON(t *testing.T) {
+	g := NewGameStateWithSeed(1)
+	ann := g.AddPlayer("Ann", PlayerTypeHuman)
+	ann.InputChan = make(chan string)
+	ann.OutputChan = make(chan string)
+	g.AddPlayer("Bea", PlayerTypeHuman)
+	g.Mileage, g.Food, g.Bullets, g.Cash = 640, 212, 900, 35.5
+	g.TurnNumber, g.TurnPhase = 9, PhaseFort
+	g.DamagePartyMember(ann, 3, 25, "measles")
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("marshaling a game with player channels: %v", err)
+	}
+	for _, key := range []string{`"mileage":640`, `"turn_phase":"fort"`, `"misc_supplies":`, `"current_player_idx":`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("JSON has no %s:\n%s", key, data)
+		}
+	}
+	if strings.Contains(string(data), "Chan") {
+		t.Errorf("JSON has the player channels:\n%s", data)
+	}
+
+	var got GameState
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Mileage != 640 || got.Food != 212 || got.Bullets != 900 || got.Cash != 35.5 {
+		t.Errorf("supplies: %.0f miles, %.0f food, %.0f bullets, $%.2f", got.Mileage, got.Food, got.Bullets, got.Cash)
+	}
+	if got.TurnNumber != 9 || got.TurnPhase != PhaseFort {
+		t.Errorf("turn %d, phase %s; want turn 9 at the fort", got.TurnNumber, got.TurnPhase)
+	}
+	if len(got.Players) != 2 || got.Players[0].ID != ann.ID || got.Players[1].Name != "Bea" {
+		t.Fatalf("players = %+v", got.Players)
+	}
+	if h := got.Players[0].Party[3].Health; h != ann.Party[3].Health || !got.Players[0].Party[3].Injured {
+		t.Errorf("Ann's daughter: %d health, want %d and injured", h, ann.Party[3].Health)
+	}
+}
@@ -0,0 +1,52 @@
+package game
+
+// GameSummary is the structured end of a game for one wagon: how far it
+// got, what was left in it and how each party aboard fared.
+type GameSummary struct {
+	Win         bool               `json:"win"`
+	Mileage     float64            `json:"mileage"`
+	ArrivalDate string             `json:"arrival_date,omitempty"`
+	Turns       int                `json:"turns"`
+	Inventory   map[string]float64 `json:"inventory"`
+	Parties     []PartySummary     `json:"parties"`
+}
+
+// PartySummary is how one player's party ended the game. Placement is its
+// rank on the leaderboard, filled in by whoever records the run.
+type PartySummary struct {
+	PlayerID  string        `json:"player_id"`
+	Name      string        `json:"name"`
+	Score     int           `json:"score"`
+	Survivors int           `json:"survivors"`
+	Members   []PartyMember `json:"members"`
+	Placement int           `json:"placement,omitempty"`
+}
+
+// Summary sums up the game as it stands once it is over.
+func (g *GameState) Summary() GameSummary {
+	summary := GameSummary{
+		Win:         g.Win,
+		Mileage:     g.Mileage,
+		ArrivalDate: g.FinalDate,
+		Turns:       g.TurnNumber,
+		Inventory: map[string]float64{
+			"food":     g.Food,
+			"bullets":  g.Bullets,
+			"clothing": g.Clothing,
+			"misc":     g.MiscSupplies,
+			"cash":     g.Cash,
+			"oxen":     g.OxenCost,
+		},
+		Parties: make([]PartySummary, 0, len(g.Players)),
+	}
+	for _, p := range g.Players {
+		summary.Parties = append(summary.Parties, PartySummary{
+			PlayerID:  p.ID,
+			Name:      p.Name,
+			Score:     g.ComputeScore(p),
+			Survivors: g.CountSurvivors(p),
+			Members:   append([]PartyMember(nil), p.Party...),
+		})
+	}
+	return summary
+}
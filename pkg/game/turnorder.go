@@ -0,0 +1,141 @@
+package game
+
+// Turns in a shared game go round TurnOrder, a list of player IDs in the
+// order the players joined. Players leaving are spliced out of it, so
+// everyone else keeps their place. CurrentPlayerIdx still indexes Players;
+// it is only ever moved to the player TurnOrder says is next.
+
+// syncTurnOrder brings TurnOrder up to date with Players.
+func (g *GameState) syncTurnOrder() {
+	g.TurnOrder = g.currentTurnOrder()
+}
+
+// currentTurnOrder returns TurnOrder without the IDs of players no longer in
+// the game, and with players not yet in it added at the end, in Players
+// order.
+func (g *GameState) currentTurnOrder() []string {
+	inGame := make(map[string]bool, len(g.Players))
+	for _, p := range g.Players {
+		inGame[p.ID] = true
+	}
+	order := make([]string, 0, len(g.Players))
+	seen := make(map[string]bool, len(g.Players))
+	for _, id := range g.TurnOrder {
+		if inGame[id] && !seen[id] {
+			order = append(order, id)
+			seen[id] = true
+		}
+	}
+	for _, p := range g.Players {
+		if !seen[p.ID] {
+			order = append(order, p.ID)
+			seen[p.ID] = true
+		}
+	}
+	return order
+}
+
+// playerByID returns the player with the given ID and its index in Players.
+func (g *GameState) playerByID(id string) (*Player, int) {
+	for i, p := range g.Players {
+		if p.ID == id {
+			return p, i
+		}
+	}
+	return nil, -1
+}
+
+// takesTurns reports whether p still gets turns.
+func takesTurns(p *Player) bool {
+	return p != nil && p.Type == PlayerTypeHuman && p.Alive
+}
+
+// nextInOrder returns the first player after position pos of TurnOrder,
+// going round, who still takes turns. pos -1 starts from the top.
+func (g *GameState) nextInOrder(pos int) *Player {
+	n := len(g.TurnOrder)
+	for step := 1; step <= n; step++ {
+		if p, _ := g.playerByID(g.TurnOrder[(pos+step+n)%n]); takesTurns(p) {
+			return p
+		}
+	}
+	return nil
+}
+
+// turnPosition returns the position of the current player in order, or -1.
+func (g *GameState) turnPosition(order []string) int {
+	cp := g.GetCurrentPlayer()
+	if cp == nil {
+		return -1
+	}
+	for i, id := range order {
+		if id == cp.ID {
+			return i
+		}
+	}
+	return -1
+}
+
+// setCurrentPlayer points CurrentPlayerIdx at p.
+func (g *GameState) setCurrentPlayer(p *Player) {
+	if p == nil {
+		return
+	}
+	if _, idx := g.playerByID(p.ID); idx >= 0 {
+		g.CurrentPlayerIdx = idx
+	}
+}
+
+// ReplacePlayerID gives a player a new ID, keeping their place in the turn
+// order.
+func (g *GameState) ReplacePlayerID(p *Player, id string) {
+	for i, old := range g.TurnOrder {
+		if old == p.ID {
+			g.TurnOrder[i] = id
+		}
+	}
+	p.ID = id
+}
+
+// RemovePlayer takes a player out of the game and the turn order. If it was
+// their turn, it passes to whoever was after them; otherwise the current
+// player keeps it.
+func (g *GameState) RemovePlayer(id string) {
+	g.syncTurnOrder()
+	removed, idx := g.playerByID(id)
+	if removed == nil {
+		return
+	}
+	current := g.GetCurrentPlayer()
+	if current == removed {
+		if current = g.nextInOrder(g.turnPosition(g.TurnOrder)); current == removed {
+			current = nil
+		}
+	}
+
+	g.Players = append(g.Players[:idx], g.Players[idx+1:]...)
+	g.syncTurnOrder()
+	g.CurrentPlayerIdx = 0
+	if current == nil {
+		current = g.nextInOrder(-1)
+	}
+	g.setCurrentPlayer(current)
+}
+
+// UpcomingTurns returns the players still taking turns, in the order their
+// turns come, starting with the current player.
+func (g *GameState) UpcomingTurns() []*Player {
+	order := g.currentTurnOrder()
+	n := len(order)
+	start := g.turnPosition(order)
+	if start < 0 {
+		start = 0
+	}
+	upcoming := make([]*Player, 0, n)
+	for step := 0; step < n; step++ {
+		if p, _ := g.playerByID(order[(start+step)%n]); takesTurns(p) {
+			upcoming = append(upcoming, p)
+		}
+	}
+	return upcoming
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// tableOf returns a game with a party for each name, in join order, on the
+// given player's turn.
+func tableOf(current string, names ...string) *GameState {
+	g := NewGameStateWithSeed(1)
+	for _, name := range names {
+		g.AddPlayer(name, PlayerTypeHuman).ID = name
+	}
+	g.syncTurnOrder()
+	p, _ := g.playerByID(current)
+	g.setCurrentPlayer(p)
+	return g
+}
+
+// queue lists the upcoming turns' player IDs.
+func queue(g *GameState) string {
+	var ids []string
+	for _, p := range g.UpcomingTurns() {
+		ids = append(ids, p.ID)
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestRemovePlayerKeepsTheTurn(t *testing.T) {
+	for _, tc := range []struct {
+		name, current, remove, want string
+	}{
+		{"the previous player", "Bea", "Ann", "Bea Cy Di"},
+		{"the next player", "Bea", "Cy", "Bea Di Ann"},
+		{"a later player", "Bea", "Di", "Bea Cy Ann"},
+		{"the current player", "Bea", "Bea", "Cy Di Ann"},
+		{"the current player, last in order", "Di", "Di", "Ann Bea Cy"},
+		{"the current player, first in order", "Ann", "Ann", "Bea Cy Di"},
+		{"someone not playing", "Bea", "Eve", "Bea Cy Di Ann"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := tableOf(tc.current, "Ann", "Bea", "Cy", "Di")
+			g.RemovePlayer(tc.remove)
+			if got := queue(g); got != tc.want {
+				t.Errorf("turns = %s, want %s", got, tc.want)
+			}
+			if cp := g.GetCurrentPlayer(); cp == nil || cp.ID != strings.Fields(tc.want)[0] {
+				t.Errorf("current player = %+v, want %s", cp, strings.Fields(tc.want)[0])
+			}
+		})
+	}
+}
+
+func TestRemovePlayerSkipsTheDead(t *testing.T) {
+	g := tableOf("Bea", "Ann", "Bea", "Cy", "Di")
+	g.Players[2].Alive = false
+	g.RemovePlayer("Bea")
+	if cp := g.GetCurrentPlayer(); cp == nil || cp.ID != "Di" {
+		t.Errorf("current player = %+v, want Di, past the late Cy", cp)
+	}
+}
+
+func TestTurnsFollowJoinOrderAfterRemovals(t *testing.T) {
+	g := tableOf("Ann", "Ann", "Bea", "Cy", "Di")
+	g.RemovePlayer("Bea")
+	g.NextTurn()
+	if cp := g.GetCurrentPlayer(); cp == nil || cp.ID != "Cy" {
+		t.Fatalf("after Ann's turn it is %+v's, want Cy's", cp)
+	}
+	g.AddPlayer("Eve", PlayerTypeHuman).ID = "Eve"
+	g.RemovePlayer("Ann")
+	if got := queue(g); got != "Cy Di Eve" {
+		t.Errorf("turns = %s, want Cy Di Eve", got)
+	}
+
+	g.RemovePlayer("Cy")
+	g.RemovePlayer("Di")
+	g.RemovePlayer("Eve")
+	if cp := g.GetCurrentPlayer(); cp != nil {
+		t.Errorf("current player = %+v with nobody left", cp)
+	}
+}
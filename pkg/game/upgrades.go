@@ -0,0 +1,92 @@
+package game
+
+import "fmt"
+
+// UpgradeMinMileage is how far along the trail forts start selling wagon upgrades.
+const UpgradeMinMileage = 2000
+
+// Wagon upgrade item keys, accepted by HandleFortBuy.
+const (
+	UpgradeAxle   = "axle"
+	UpgradeBarrel = "water_barrel"
+	UpgradeScope  = "rifle_scope"
+)
+
+// axleBreakdownSave is the chance a strong axle prevents a wagon breakdown.
+const axleBreakdownSave = 0.5
+
+// GetFortUpgrades returns every wagon upgrade and its price. Upgrades are
+// one-time purchases, so Qty is always 1.
+func GetFortUpgrades() map[string]FortItem {
+	return map[string]FortItem{
+		UpgradeAxle:     {Price: 250, Qty: 1, Label: "Reinforced Axle (fewer breakdowns)"},
+		UpgradeBarrel:   {Price: 200, Qty: 1, Label: "Water Barrel (no more bad water)"},
+		UpgradeScope:    {Price: 300, Qty: 1, Label: "Rifle Scope (better hunting)"},
+		UpgradeWagonBed: {Price: 150, Qty: 1, Label: "Extended Wagon Bed (room for 50% more goods)"},
+	}
+}
+
+// HasUpgrade reports whether the wagon already has the given upgrade.
+func (g *GameState) HasUpgrade(item string) bool {
+	switch item {
+	case UpgradeAxle:
+		return g.HasStrongAxle
+	case UpgradeBarrel:
+		return g.HasWaterBarrel
+	case UpgradeScope:
+		return g.HasRifleScope
+	case UpgradeWagonBed:
+		return g.HasWagonBed
+	}
+	return false
+}
+
+// AvailableUpgrades returns the upgrades the fort can sell right now: none
+// before UpgradeMinMileage, and only the ones not yet owned after it.
+func (g *GameState) AvailableUpgrades() map[string]FortItem {
+	available := make(map[string]FortItem)
+	if g.Mileage < UpgradeMinMileage {
+		return available
+	}
+	for key, item := range GetFortUpgrades() {
+		if !g.HasUpgrade(key) {
+			available[key] = item
+		}
+	}
+	return available
+}
+
+// buyUpgrade purchases a one-time wagon upgrade at the fort.
+func (g *GameState) buyUpgrade(item string) *TurnResult {
+	if g.Mileage < UpgradeMinMileage {
+		return Refuse(ReasonInvalid, "This fort doesn't sell wagon upgrades.\n")
+	}
+	if g.HasUpgrade(item) {
+		return Refuse(ReasonInvalid, "Your wagon already has that upgrade.\n")
+	}
+	if msg := g.checkFortTradeLimit(1); msg != "" {
+		return Refuse(ReasonInvalid, msg)
+	}
+	fi := GetFortUpgrades()[item]
+	if fi.Price > g.Cash {
+		return Refuse(ReasonInsufficientCash, fmt.Sprintf("Not enough cash! Need $%.0f but only have $%.0f\n", fi.Price, g.Cash))
+	}
+
+	g.Cash -= fi.Price
+	g.contribution(g.actingPlayerID(nil)).FortSpending += fi.Price
+	switch item {
+	case UpgradeAxle:
+		g.HasStrongAxle = true
+	case UpgradeBarrel:
+		g.HasWaterBarrel = true
+	case UpgradeScope:
+		g.HasRifleScope = true
+	case UpgradeWagonBed:
+		g.HasWagonBed = true
+	}
+	g.FortTrades++
+	result := &TurnResult{}
+	result.Add(ResultUpgrade, fmt.Sprintf("Bought %s for $%.0f\n", fi.Label, fi.Price),
+		map[string]float64{"cost": fi.Price})
+	return result
+}
@@ -0,0 +1,111 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// fortAt returns a game stopped at a fort far enough along to sell
+// upgrades, on Ann's turn.
+func fortAt(cash float64) *GameState {
+	g := tableOf("Ann", "Ann")
+	g.Mileage, g.Cash = UpgradeMinMileage, cash
+	g.EnterFort()
+	return g
+}
+
+func TestBuyingAnUpgrade(t *testing.T) {
+	g := fortAt(1000)
+	if r := g.HandleFortBuy(UpgradeAxle, 1).Refusal(); r != nil {
+		t.Fatalf("buying the axle was refused: %+v", r)
+	}
+	price := GetFortUpgrades()[UpgradeAxle].Price
+	if !g.HasStrongAxle || g.Cash != 1000-price {
+		t.Errorf("axle %v, $%.0f left; want the axle for $%.0f", g.HasStrongAxle, g.Cash, price)
+	}
+	if g.FortTrades != 1 {
+		t.Errorf("fort trades = %d, want the upgrade counted", g.FortTrades)
+	}
+	if c := g.ContributionFor("Ann"); c.FortSpending != price {
+		t.Errorf("Ann's fort spending = $%.0f, want $%.0f", c.FortSpending, price)
+	}
+	if _, ok := g.AvailableUpgrades()[UpgradeAxle]; ok {
+		t.Error("the fort still sells an axle the wagon has")
+	}
+	if r := g.HandleFortBuy(UpgradeAxle, 1).Refusal(); r == nil || g.Cash != 1000-price {
+		t.Errorf("a second axle: %+v, $%.0f left", r, g.Cash)
+	}
+}
+
+func TestUpgradeRefusals(t *testing.T) {
+	early := fortAt(1000)
+	early.Mileage = UpgradeMinMileage - 1
+	if r := early.HandleFortBuy(UpgradeScope, 1).Refusal(); r == nil || early.HasRifleScope {
+		t.Errorf("a scope before mile %d: %+v", UpgradeMinMileage, r)
+	}
+
+	broke := fortAt(10)
+	if r := broke.HandleFortBuy(UpgradeScope, 1).Refusal(); r == nil || r.Reason != ReasonInsufficientCash {
+		t.Errorf("a scope without the cash: %+v, want %s", r, ReasonInsufficientCash)
+	}
+
+	busy := fortAt(1000)
+	busy.FortTrades = MaxFortTrades
+	if r := busy.HandleFortBuy(UpgradeScope, 1).Refusal(); r == nil || busy.HasRifleScope {
+		t.Errorf("a scope over the trade limit: %+v", r)
+	}
+}
+
+func TestStrongAxleSavesSomeBreakdowns(t *testing.T) {
+	held := 0
+	for seed := int64(0); seed < 200; seed++ {
+		g := NewGameStateWithSeed(seed)
+		p := g.AddPlayer("Ann", PlayerTypeHuman)
+		if strings.Contains(g.eventWagonBreakdown(p), "axle held up") {
+			t.Fatal("an axle held up on a wagon without one")
+		}
+		g.HasStrongAxle = true
+		if strings.Contains(g.eventWagonBreakdown(p), "axle held up") {
+			held++
+		}
+	}
+	if held < 60 || held > 140 {
+		t.Errorf("the axle saved %d of 200 breakdowns, want about half", held)
+	}
+}
+
+func TestWaterBarrelPreventsBadWater(t *testing.T) {
+	g := NewGameStateWithSeed(1)
+	p := g.AddPlayer("Ann", PlayerTypeHuman)
+	g.HasWaterBarrel, g.Mileage = true, 500
+	g.eventUnsafeWater(p)
+	if g.Mileage != 500 {
+		t.Errorf("the wagon lost %.0f miles to bad water with a barrel", 500-g.Mileage)
+	}
+	for _, m := range p.Party {
+		if m.Health != 100 {
+			t.Errorf("%s was hurt by bad water with a barrel", m.Name)
+		}
+	}
+}
+
+func TestRifleScopeSteadiesTheAim(t *testing.T) {
+	for _, ms := range []int{300, 800, 1500, 3000} {
+		if scoped, bare := huntAccuracy(ms, true), huntAccuracy(ms, false); scoped >= bare {
+			t.Errorf("a %dms shot: accuracy %.2f scoped, %.2f without; want the scope to help", ms, scoped, bare)
+		}
+	}
+
+	// The quick hunt a CPU takes gains at least as much with the scope
+	for seed := int64(0); seed < 50; seed++ {
+		bare, scoped := NewGameStateWithSeed(seed), NewGameStateWithSeed(seed)
+		scoped.HasRifleScope = true
+		for _, g := range []*GameState{bare, scoped} {
+			g.Bullets = 500
+			g.hunt(g.AddPlayer("Ann", PlayerTypeHuman), 0.002)
+		}
+		if scoped.Food < bare.Food {
+			t.Errorf("seed %d: %.0f food hunted with the scope, %.0f without", seed, scoped.Food, bare.Food)
+		}
+	}
+}
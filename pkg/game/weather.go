@@ -0,0 +1,110 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Season is the time of year on the trail.
+type Season string
+
+const (
+	SeasonSpring Season = "spring"
+	SeasonSummer Season = "summer"
+	SeasonFall   Season = "fall"
+	SeasonWinter Season = "winter"
+)
+
+// trailStartDate is the day the wagons leave. Each turn is a week on the
+// trail.
+var trailStartDate = time.Date(1847, time.March, 29, 0, 0, 0, 0, time.UTC)
+
+// Winter effects: travel slows, and a party without enough clothing is more
+// likely to fall ill.
+const (
+	winterTravelFactor    = 0.75
+	winterClothingNeeded  = 30
+	winterIllnessIncrease = 0.2
+)
+
+// blizzardChance is the chance of a blizzard in the high passes each turn,
+// by season. In winter blizzards can strike anywhere in the mountains.
+var blizzardChance = map[Season]float64{
+	SeasonSpring: 0.15,
+	SeasonSummer: 0.05,
+	SeasonFall:   0.3,
+	SeasonWinter: 0.5,
+}
+
+// seasonWeather are the kinds of weather each season rolls from.
+var seasonWeather = map[Season][]string{
+	SeasonSpring: {"mild", "rainy", "windy"},
+	SeasonSummer: {"clear", "hot", "dusty"},
+	SeasonFall:   {"cool", "clear", "windy"},
+	SeasonWinter: {"freezing", "snowing", "bitter cold"},
+}
+
+// ForecastItem is the fort_buy item for the trader's weather forecast.
+const (
+	ForecastItem  = "forecast"
+	ForecastPrice = 5
+)
+
+// CurrentDate is the calendar date on the trail.
+func (g *GameState) CurrentDate() time.Time {
+	return trailStartDate.AddDate(0, 0, g.TurnNumber*7)
+}
+
+// Season returns the season for the current date.
+func (g *GameState) Season() Season {
+	return seasonOf(g.CurrentDate())
+}
+
+func seasonOf(t time.Time) Season {
+	switch t.Month() {
+	case time.March, time.April, time.May:
+		return SeasonSpring
+	case time.June, time.July, time.August:
+		return SeasonSummer
+	case time.September, time.October, time.November:
+		return SeasonFall
+	}
+	return SeasonWinter
+}
+
+// rollWeather picks this week's weather for the season and reports it.
+func (g *GameState) rollWeather() *TurnResult {
+	season := g.Season()
+	kinds := seasonWeather[season]
+	g.Weather = kinds[g.Rand.Intn(len(kinds))]
+	msg := fmt.Sprintf("\n%s - %s, %s.\n", g.CurrentDate().Format("January 2, 2006"), season, g.Weather)
+	if season == SeasonWinter {
+		msg += "Winter slows the oxen.\n"
+	}
+	return NewResult(ResultWeather, msg)
+}
+
+// buyForecast sells the trader's outlook for the coming month.
+func (g *GameState) buyForecast() *TurnResult {
+	if ForecastPrice > g.Cash {
+		return Refuse(ReasonInsufficientCash, fmt.Sprintf("Not enough cash! Need $%d but only have $%.0f\n", ForecastPrice, g.Cash))
+	}
+	g.Cash -= ForecastPrice
+	g.contribution(g.actingPlayerID(nil)).FortSpending += ForecastPrice
+
+	now := g.Season()
+	msg := fmt.Sprintf("The trader squints at the sky: \"It's %s now.", now)
+	for weeks := 1; weeks <= 6; weeks++ {
+		next := seasonOf(g.CurrentDate().AddDate(0, 0, weeks*7))
+		if next != now {
+			msg += fmt.Sprintf(" %s comes in about %d weeks.", strings.ToUpper(string(next[:1]))+string(next[1:]), weeks)
+			break
+		}
+	}
+	if now == SeasonWinter || seasonOf(g.CurrentDate().AddDate(0, 0, 42)) == SeasonWinter {
+		msg += fmt.Sprintf(" Carry %d sets of clothing or more, and mind the passes.", winterClothingNeeded)
+	}
+	msg += "\"\n"
+	return NewResultWith(ResultForecast, msg, map[string]float64{"cost": ForecastPrice})
+}
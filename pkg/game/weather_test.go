@@ -0,0 +1,134 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSeasonFollowsTheCalendar(t *testing.T) {
+	g := NewGameStateWithSeed(1)
+	for _, tc := range []struct {
+		turn   int
+		date   string
+		season Season
+	}{
+		{0, "March 29, 1847", SeasonSpring},
+		{9, "May 31, 1847", SeasonSpring},
+		{10, "June 7, 1847", SeasonSummer},
+		{23, "September 6, 1847", SeasonFall},
+		{36, "December 6, 1847", SeasonWinter},
+		{49, "March 6, 1848", SeasonSpring},
+	} {
+		g.TurnNumber = tc.turn
+		if got := g.CurrentDate().Format("January 2, 2006"); got != tc.date {
+			t.Errorf("turn %d: date %s, want %s", tc.turn, got, tc.date)
+		}
+		if got := g.Season(); got != tc.season {
+			t.Errorf("turn %d: season %s, want %s", tc.turn, got, tc.season)
+		}
+	}
+}
+
+func TestWeatherIsRolledForTheSeason(t *testing.T) {
+	g := NewGameStateWithSeed(1)
+	for _, turn := range []int{0, 10, 23, 36} {
+		g.TurnNumber = turn
+		season := g.Season()
+		for i := 0; i < 20; i++ {
+			result := g.rollWeather()
+			if !strings.Contains(strings.Join(seasonWeather[season], " "), g.Weather) {
+				t.Fatalf("%s rolled %q", season, g.Weather)
+			}
+			if got := result.String(); !strings.Contains(got, string(season)+", "+g.Weather) {
+				t.Fatalf("weather report %q doesn't name the season and weather", got)
+			}
+		}
+	}
+}
+
+// travelMiles plays a travel turn on the given turn number and returns the
+// miles it reported, with everything but the date the same.
+func travelMiles(t *testing.T, turn int) float64 {
+	t.Helper()
+	g := NewGameStateWithSeed(7)
+	p := g.AddPlayer("Ann", PlayerTypeHuman)
+	g.Food, g.Clothing, g.OxenCost = 500, 100, 220
+	g.TurnNumber = turn
+	for _, e := range g.ContinueTravel(p).Events {
+		if e.Code == ResultTravel {
+			return e.Params["miles"]
+		}
+	}
+	t.Fatal("the turn reported no travel")
+	return 0
+}
+
+func TestWinterSlowsTravel(t *testing.T) {
+	summer, winter := travelMiles(t, 10), travelMiles(t, 36)
+	if want := summer * winterTravelFactor; winter < want-0.001 || winter > want+0.001 {
+		t.Errorf("winter travel = %.2f miles, want %.2f, %.0f%% of summer's %.2f",
+			winter, want, winterTravelFactor*100, summer)
+	}
+}
+
+// blizzards counts the blizzards over many mountain crossings on the given
+// turn at the given mileage.
+func blizzards(turn int, mileage float64) int {
+	count := 0
+	for seed := int64(0); seed < 500; seed++ {
+		g := NewGameStateWithSeed(seed)
+		p := g.AddPlayer("Ann", PlayerTypeHuman)
+		g.Clothing = 100
+		g.TurnNumber, g.Mileage = turn, mileage
+		if strings.Contains(g.HandleMountains(p), "BLIZZARD") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestBlizzardOddsFollowTheSeason(t *testing.T) {
+	if n := blizzards(10, 3000); n != 0 {
+		t.Errorf("%d summer blizzards below the high passes, want none", n)
+	}
+	if n := blizzards(36, 3000); n == 0 {
+		t.Error("no winter blizzards below the high passes")
+	}
+	summer, fall := blizzards(10, 4000), blizzards(23, 4000)
+	if summer == 0 || summer >= fall {
+		t.Errorf("%d summer and %d fall blizzards in the high passes, want fewer but some in summer", summer, fall)
+	}
+}
+
+func TestForecastIsSoldAtTheFort(t *testing.T) {
+	g := NewGameStateWithSeed(1)
+	g.TurnNumber = 30 // October 25: winter is 6 weeks off
+	g.Cash = 12
+
+	if got := g.HandleFortBuy(ForecastItem, 1).Refusal(); got == nil || got.Reason != ReasonInvalidPhase {
+		t.Errorf("a forecast away from a fort: %+v, want %s", got, ReasonInvalidPhase)
+	}
+
+	g.TurnPhase = PhaseFort
+	result := g.HandleFortBuy(ForecastItem, 1)
+	if r := result.Refusal(); r != nil {
+		t.Fatalf("the forecast was refused: %+v", r)
+	}
+	if g.Cash != 12-ForecastPrice {
+		t.Errorf("cash = $%.0f, want $%d", g.Cash, 12-ForecastPrice)
+	}
+	msg := result.String()
+	for _, want := range []string{"It's fall now.", "Winter comes in about 6 weeks.", "30 sets of clothing"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("forecast %q doesn't say %q", msg, want)
+		}
+	}
+
+	g.Cash = ForecastPrice - 1
+	if got := g.HandleFortBuy(ForecastItem, 1).Refusal(); got == nil || got.Reason != ReasonInsufficientCash {
+		t.Errorf("a forecast without the cash: %+v, want %s", got, ReasonInsufficientCash)
+	}
+	if g.Cash != ForecastPrice-1 {
+		t.Errorf("a refused forecast took cash: $%.0f left", g.Cash)
+	}
+}
@@ -0,0 +1,359 @@
+package network
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"online-trail/pkg/game"
+)
+
+// gameFortInterval is how often a fort turns up on the shared trail, in
+// turns, as on the web server.
+const gameFortInterval = 3
+
+// GameServer plays one shared wagon over the TCP protocol. The first player
+// to join owns the wagon, and it waits for them to send MsgStart; if they
+// leave, the next player to have joined owns it. Players take turns in the
+// order they joined; every move is answered with an MsgEvent holding the
+// result and a fresh MsgGameState for everyone, or an MsgError to the mover
+// alone if the move was refused.
+type GameServer struct {
+	*Server
+	gameMu  sync.Mutex
+	game    *game.GameState
+	ownerID string
+}
+
+// NewGameServer returns a game server with a new game waiting for its
+// first player.
+func NewGameServer() *GameServer {
+	gs := &GameServer{
+		Server: NewServer(),
+		game:   game.NewGameState(),
+	}
+	gs.handler = gs.handleConnection
+	return gs
+}
+
+// StartGameServer starts a game server listening on addr.
+func StartGameServer(addr string) (*GameServer, error) {
+	gs := NewGameServer()
+	if err := gs.listen(addr); err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
+
+// handleConnection seats a player, then plays their messages until they
+// disconnect.
+func (gs *GameServer) handleConnection(conn net.Conn) {
+	defer conn.Close()
+	decoder := json.NewDecoder(conn)
+
+	var joinMsg Message
+	if err := decoder.Decode(&joinMsg); err != nil || joinMsg.Type != MsgJoin {
+		return
+	}
+	var join JoinPayload
+	if err := json.Unmarshal(joinMsg.Payload, &join); err != nil || join.Name == "" {
+		SendMessage(conn, MsgError, ErrorPayload{Code: game.ReasonInvalid, Request: string(MsgJoin), Message: "join with a name"})
+		return
+	}
+
+	player := gs.seat(join.Name)
+	client := &Client{
+		Conn:     conn,
+		PlayerID: player.ID,
+		Name:     join.Name,
+		Input:    make(chan []byte, 10),
+		Output:   make(chan string, 100),
+	}
+	gs.startClient(client)
+	defer close(client.Output)
+	defer gs.leave(client)
+
+	gs.sendTo(client, MsgJoin, map[string]string{"id": player.ID})
+	gs.broadcast(MsgPlayerList, gs.GetPlayerList())
+	gs.broadcastOwner()
+	gs.broadcastState()
+
+	for {
+		msg, err := client.next(decoder)
+		if err != nil {
+			return
+		}
+		gs.handleMessage(client, msg)
+	}
+}
+
+// seat adds a party to the game. The first one to join owns the wagon.
+func (gs *GameServer) seat(name string) *game.Player {
+	gs.gameMu.Lock()
+	defer gs.gameMu.Unlock()
+	p := gs.game.AddPlayer(name, game.PlayerTypeHuman)
+	if gs.ownerID == "" {
+		gs.ownerID = p.ID
+	}
+	return p
+}
+
+// owner returns the wagon's owner and their index in Players.
+// NOTE: caller must hold gs.gameMu.
+func (gs *GameServer) owner() (*game.Player, int) {
+	for i, p := range gs.game.Players {
+		if p.ID == gs.ownerID {
+			return p, i
+		}
+	}
+	return nil, -1
+}
+
+// start sets off on the trail when the owner says so. The owner outfits the
+// wagon and takes the first turn.
+// NOTE: caller must hold gs.gameMu.
+func (gs *GameServer) start(client *Client) *game.TurnResult {
+	g := gs.game
+	switch {
+	case client.PlayerID != gs.ownerID:
+		return game.Refuse(game.ReasonNotOwner, "Only the wagon's owner can start the journey.\n")
+	case g.TurnPhase != game.PhaseStart:
+		return game.Refuse(game.ReasonInvalidPhase, "The journey has already begun.\n")
+	}
+	owner, idx := gs.owner()
+	g.OxenCost, g.Food, g.Bullets, g.Clothing, g.MiscSupplies, g.Cash = g.InitialPurchase(owner)
+	g.TurnNumber = 1
+	g.TurnPhase = game.PhaseMainMenu
+	g.CurrentPlayerIdx = idx
+	return game.NewResult(game.ResultText, "The journey begins! Head west on the Online Trail!\n")
+}
+
+// leave takes a disconnected player's party off the trail. If it was their
+// move, the turn passes to the next party.
+func (gs *GameServer) leave(client *Client) {
+	gs.RemoveClient(client.PlayerID)
+
+	gs.gameMu.Lock()
+	g := gs.game
+	if cp := g.GetCurrentPlayer(); cp != nil && cp.ID == client.PlayerID &&
+		g.TurnPhase != game.PhaseGameOver && g.TurnPhase != game.PhaseStart {
+		g.TurnPhase = game.PhaseMainMenu
+	}
+	g.RemovePlayer(client.PlayerID)
+	ownerLeft := gs.ownerID == client.PlayerID
+	if ownerLeft {
+		gs.ownerID = ""
+		if len(g.Players) > 0 {
+			gs.ownerID = g.Players[0].ID
+		}
+	}
+	if len(g.Players) == 0 {
+		// The last party has gone; the next to join starts a new trail
+		gs.game = game.NewGameState()
+	}
+	gs.gameMu.Unlock()
+
+	gs.broadcast(MsgPlayerList, gs.GetPlayerList())
+	if ownerLeft {
+		gs.broadcastOwner()
+	}
+	gs.broadcastState()
+}
+
+// handleMessage plays one message from a client.
+func (gs *GameServer) handleMessage(client *Client, msg Message) {
+	if msg.Type == MsgChat {
+		var chat ChatPayload
+		if json.Unmarshal(msg.Payload, &chat) == nil && chat.Message != "" {
+			gs.broadcast(MsgChat, ChatPayload{Sender: client.Name, Message: chat.Message})
+		}
+		return
+	}
+
+	gs.gameMu.Lock()
+	action, result := gs.play(client, msg)
+	hunting, wordAt := gs.game.TurnPhase == game.PhaseHunting, gs.game.HuntWordAt()
+	gs.gameMu.Unlock()
+	if result == nil {
+		return
+	}
+	if refusal := result.Refusal(); refusal != nil {
+		gs.sendTo(client, MsgError, ErrorPayload{
+			Code:    refusal.Reason,
+			Request: string(msg.Type),
+			Message: strings.TrimSpace(refusal.Message),
+		})
+		return
+	}
+
+	gs.broadcast(MsgEvent, EventPayload{Player: client.Name, Action: action, Result: result.String()})
+	gs.broadcastState()
+	if hunting {
+		// The state so far has left the word out; send it when it's due
+		time.AfterFunc(time.Until(wordAt), gs.broadcastState)
+	}
+}
+
+// play applies a move to the game and ends the turn once it is complete.
+// It returns nil for messages that aren't moves, and a refusal for moves
+// that can't be made.
+// NOTE: caller must hold gs.gameMu.
+func (gs *GameServer) play(client *Client, msg Message) (string, *game.TurnResult) {
+	g := gs.game
+	if msg.Type == MsgStart {
+		return "start", gs.start(client)
+	}
+	if g.TurnPhase == game.PhaseStart {
+		switch msg.Type {
+		case MsgAction, MsgHuntShoot, MsgFortBuy, MsgFortSell, MsgFortLeave, MsgRiderTactic:
+			return string(msg.Type), game.Refuse(game.ReasonInvalidPhase, "The wagon hasn't left yet; its owner starts the journey.\n")
+		}
+		return "", nil
+	}
+	p := g.GetCurrentPlayer()
+	if p == nil || p.ID != client.PlayerID {
+		switch msg.Type {
+		case MsgAction, MsgHuntShoot, MsgFortBuy, MsgFortSell, MsgFortLeave, MsgRiderTactic:
+			return string(msg.Type), game.Refuse(game.ReasonNotYourTurn, "It's not your turn.\n")
+		}
+		return "", nil
+	}
+
+	var action string
+	var result *game.TurnResult
+	switch msg.Type {
+	case MsgAction:
+		var payload ActionPayload
+		if json.Unmarshal(msg.Payload, &payload) != nil {
+			return "", nil
+		}
+		action = payload.Action
+		if action == "fort" {
+			if !g.FortAvailable || g.TurnPhase != game.PhaseMainMenu {
+				result = game.Refuse(game.ReasonInvalidPhase, "No fort is available at this location.\n")
+				break
+			}
+			result = g.HandleFort(p)
+			break
+		}
+		if g.TurnPhase != game.PhaseMainMenu {
+			result = game.Refuse(game.ReasonInvalidPhase, "Finish what you're doing first.\n")
+			break
+		}
+		result = g.ProcessTurn(p, action)
+
+	case MsgHuntShoot:
+		var payload HuntShootPayload
+		if json.Unmarshal(msg.Payload, &payload) != nil || g.TurnPhase != game.PhaseHunting {
+			return "", nil
+		}
+		action = "hunt"
+		result = g.HandleHuntShoot(p, payload.Word, payload.Time, 0)
+
+	case MsgRiderTactic:
+		var payload RiderTacticPayload
+		if json.Unmarshal(msg.Payload, &payload) != nil || g.TurnPhase != game.PhaseRiders {
+			return "", nil
+		}
+		if payload.Tactic < 1 || payload.Tactic > 4 {
+			payload.Tactic = 3
+		}
+		action = "continue"
+		result = g.HandleRiderTactic(p, payload.Tactic)
+
+	case MsgFortBuy, MsgFortSell:
+		var payload FortTradePayload
+		if json.Unmarshal(msg.Payload, &payload) != nil {
+			return "", nil
+		}
+		action = string(msg.Type)
+		if err := game.CheckFortQty(float64(payload.Qty)); err != nil {
+			result = game.Refuse(game.ReasonInvalid, "Can't trade: "+err.Error()+".\n")
+			break
+		}
+		if msg.Type == MsgFortBuy {
+			result = g.HandleFortBuy(payload.Item, payload.Qty)
+		} else {
+			result = g.HandleFortSell(payload.Item, payload.Qty)
+		}
+
+	case MsgFortLeave:
+		if g.TurnPhase != game.PhaseFort {
+			return "", nil
+		}
+		action = "fort_leave"
+		result = g.HandleFortLeave()
+		g.FortAvailable = false
+
+	default:
+		return "", nil
+	}
+
+	if result.Refusal() != nil {
+		return action, result
+	}
+	g.Record(p.Name, action, result, time.Now())
+	gs.endTurn()
+	return action, result
+}
+
+// endTurn passes the turn on once the current one is complete, and marks
+// the end of the game.
+// NOTE: caller must hold gs.gameMu.
+func (gs *GameServer) endTurn() {
+	g := gs.game
+	if g.TurnPhase != game.PhaseMainMenu {
+		return
+	}
+	if !g.GameOver {
+		g.NextTurn()
+		if g.TurnNumber%gameFortInterval == 0 {
+			g.FortAvailable = true
+		}
+	}
+	if g.GameOver {
+		g.TurnPhase = game.PhaseGameOver
+		log.Printf("TCP game over (win: %v) after %d turns", g.Win, g.TurnNumber)
+	}
+}
+
+// broadcastState sends everyone the game as it stands, with the hunt word
+// left out until it is due to be shown.
+func (gs *GameServer) broadcastState() {
+	gs.gameMu.Lock()
+	shown := *gs.game
+	shown.HuntWord = gs.game.ShownHuntWord(time.Now())
+	msg, err := EncodeMessage(MsgGameState, &shown)
+	gs.gameMu.Unlock()
+	if err == nil {
+		gs.Broadcast(msg)
+	}
+}
+
+// broadcastOwner tells everyone who owns the wagon.
+func (gs *GameServer) broadcastOwner() {
+	gs.gameMu.Lock()
+	owner := OwnerPayload{ID: gs.ownerID}
+	if p, _ := gs.owner(); p != nil {
+		owner.Name = p.Name
+	}
+	gs.gameMu.Unlock()
+	if owner.ID != "" {
+		gs.broadcast(MsgOwner, owner)
+	}
+}
+
+func (gs *GameServer) broadcast(msgType MessageType, payload interface{}) {
+	if msg, err := EncodeMessage(msgType, payload); err == nil {
+		gs.Broadcast(msg)
+	}
+}
+
+func (gs *GameServer) sendTo(client *Client, msgType MessageType, payload interface{}) {
+	if msg, err := EncodeMessage(msgType, payload); err == nil {
+		gs.SendTo(client.PlayerID, msg)
+	}
+}
@@ -0,0 +1,97 @@
+package network
+
+import (
+	"testing"
+
+	"online-trail/pkg/game"
+)
+
+// join seats a player as handleConnection does, without a connection.
+func join(gs *GameServer, name string) *Client {
+	p := gs.seat(name)
+	return &Client{PlayerID: p.ID, Name: name}
+}
+
+// play plays a message as the client and returns the result.
+func play(gs *GameServer, client *Client, msg Message) *game.TurnResult {
+	gs.gameMu.Lock()
+	defer gs.gameMu.Unlock()
+	_, result := gs.play(client, msg)
+	return result
+}
+
+// reason returns why a result was refused, or "" if it wasn't.
+func reason(result *game.TurnResult) string {
+	if result == nil || result.Refusal() == nil {
+		return ""
+	}
+	return result.Refusal().Reason
+}
+
+func TestFirstPlayerOwnsAndStartsTheWagon(t *testing.T) {
+	gs := NewGameServer()
+	ann := join(gs, "Ann")
+	bea := join(gs, "Bea")
+	if gs.ownerID != ann.PlayerID {
+		t.Fatalf("owner = %s, want Ann, the first to join", gs.ownerID)
+	}
+
+	if got := reason(play(gs, bea, Message{Type: MsgAction})); got != game.ReasonInvalidPhase {
+		t.Errorf("a move before the start: %q, want %q", got, game.ReasonInvalidPhase)
+	}
+	if got := reason(play(gs, bea, Message{Type: MsgStart})); got != game.ReasonNotOwner {
+		t.Errorf("Bea starting: %q, want %q", got, game.ReasonNotOwner)
+	}
+	if gs.game.TurnPhase != game.PhaseStart {
+		t.Fatalf("phase = %s after a refused start", gs.game.TurnPhase)
+	}
+
+	if got := reason(play(gs, ann, Message{Type: MsgStart})); got != "" {
+		t.Fatalf("Ann starting was refused: %q", got)
+	}
+	g := gs.game
+	if g.TurnPhase != game.PhaseMainMenu || g.TurnNumber != 1 {
+		t.Errorf("after the start: phase %s, turn %d", g.TurnPhase, g.TurnNumber)
+	}
+	if cp := g.GetCurrentPlayer(); cp == nil || cp.ID != ann.PlayerID {
+		t.Errorf("current player = %+v, want Ann", cp)
+	}
+	if g.Food <= 0 || g.Bullets <= 0 {
+		t.Errorf("the wagon wasn't outfitted: %.0f food, %.0f bullets", g.Food, g.Bullets)
+	}
+
+	if got := reason(play(gs, ann, Message{Type: MsgStart})); got != game.ReasonInvalidPhase {
+		t.Errorf("starting twice: %q, want %q", got, game.ReasonInvalidPhase)
+	}
+}
+
+func TestOwnershipPassesOnLeave(t *testing.T) {
+	gs := NewGameServer()
+	ann := join(gs, "Ann")
+	bea := join(gs, "Bea")
+	join(gs, "Cy")
+
+	gs.leave(ann)
+	if gs.ownerID != bea.PlayerID {
+		t.Fatalf("owner = %s, want Bea, the next to have joined", gs.ownerID)
+	}
+	if gs.game.TurnPhase != game.PhaseStart {
+		t.Errorf("phase = %s, want the wagon still waiting to start", gs.game.TurnPhase)
+	}
+	if got := reason(play(gs, bea, Message{Type: MsgStart})); got != "" {
+		t.Errorf("the new owner starting was refused: %q", got)
+	}
+}
+
+func TestEmptyServerHasNoOwner(t *testing.T) {
+	gs := NewGameServer()
+	ann := join(gs, "Ann")
+	gs.leave(ann)
+	if gs.ownerID != "" {
+		t.Fatalf("owner = %s with nobody seated", gs.ownerID)
+	}
+	bea := join(gs, "Bea")
+	if gs.ownerID != bea.PlayerID {
+		t.Errorf("owner = %s, want Bea, the first to join the new trail", gs.ownerID)
+	}
+}
@@ -2,10 +2,14 @@ package network
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"sync"
 	"time"
+
+	"online-trail/pkg/game"
 )
 
 type MessageType string
@@ -20,6 +24,29 @@ const (
 	MsgError      MessageType = "error"
 	MsgTurn       MessageType = "turn"
 	MsgStart      MessageType = "start"
+	MsgHuntShoot  MessageType = "hunt_shoot"
+	MsgHuntCancel MessageType = "hunt_cancel"
+	MsgFortBuy    MessageType = "fort_buy"
+	MsgFortSell   MessageType = "fort_sell"
+	MsgFortLeave  MessageType = "fort_leave"
+	MsgEvent      MessageType = "event"
+	MsgOwner      MessageType = "owner"
+
+	// Rider encounters
+	MsgRiderTactic MessageType = "rider_tactic"
+
+	// Keepalive
+	MsgPing MessageType = "ping"
+	MsgPong MessageType = "pong"
+)
+
+// Keepalive: the server pings each client every PingInterval and drops one
+// that misses MissedPongs pongs in a row. A write stuck for writeTimeout
+// drops the client too.
+const (
+	PingInterval = 30 * time.Second
+	MissedPongs  = 2
+	writeTimeout = 10 * time.Second
 )
 
 type Message struct {
@@ -39,10 +66,75 @@ type ActionPayload struct {
 	Value    string `json:"value"`
 }
 
+// HuntShootPayload is a shot at the hunt word: the word typed and the
+// reaction time the client measured.
+type HuntShootPayload struct {
+	PlayerID string `json:"player_id"`
+	Word     string `json:"word"`
+	Time     int    `json:"time"`
+}
+
+// FortTradePayload is a fort order: how many bundles of which item to buy
+// or sell.
+type FortTradePayload struct {
+	PlayerID string `json:"player_id"`
+	Item     string `json:"item"`
+	Qty      int    `json:"qty"`
+}
+
+// RiderTacticPayload is how the party meets riders: 1 run, 2 attack,
+// 3 continue, 4 circle the wagons.
+type RiderTacticPayload struct {
+	PlayerID string `json:"player_id"`
+	Tactic   int    `json:"tactic"`
+}
+
+// EventPayload is the outcome of a player's action, rendered as text.
+type EventPayload struct {
+	Player string `json:"player"`
+	Action string `json:"action"`
+	Result string `json:"result"`
+}
+
+// ErrorPayload is why the server refused something: a code clients can
+// switch on (one of the game.Reason* values, or ErrCodeRoomFull), the type
+// of the message refused and the text to show.
+type ErrorPayload struct {
+	Code    string `json:"code,omitempty"`
+	Request string `json:"request,omitempty"`
+	Message string `json:"message"`
+}
+
+// OwnerPayload names the player who owns the wagon, and so starts it.
+type OwnerPayload struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
 type ChatPayload struct {
+	Sender  string `json:"sender,omitempty"`
 	Message string `json:"message"`
 }
 
+// ChatCommand is a slash command the web server's chat understands.
+type ChatCommand struct {
+	Usage string
+	Help  string
+}
+
+// ChatCommands lists the web server's chat commands, for /help and the
+// client's usage text alike.
+var ChatCommands = []ChatCommand{
+	{"/status", "show your supplies (only you see this)"},
+	{"/players", "list everyone in the room"},
+	{"/me <action>", "describe what you're doing"},
+	{"/w <name> <message>", "whisper to one player"},
+	{"/roll [sides]", "roll a die (default 100 sides)"},
+	{"/hints off", "stop showing trail tips"},
+	{"/register <pin> [new pin]", "claim your name, or change its PIN"},
+	{"/help", "show this list"},
+}
+
 type GameStatePayload struct {
 	State     interface{} `json:"state"`
 	TurnIndex int         `json:"turn_index"`
@@ -60,6 +152,11 @@ type Client struct {
 	Name     string
 	Input    chan []byte
 	Output   chan string
+
+	// Keepalive, set by the server: how often WriteLoop pings and how long
+	// a read may wait for the next pong (0 = never)
+	pingInterval time.Duration
+	pongWait     time.Duration
 }
 
 type Server struct {
@@ -71,6 +168,19 @@ type Server struct {
 	addChan    chan *Client
 	removeChan chan string
 	broadcast  chan string
+
+	// Open connections, joined or not, the most allowed at once (0 = no
+	// limit) and the goroutines serving them, so Stop can close and wait
+	conns    map[net.Conn]struct{}
+	maxConns int
+	stopped  bool
+	wg       sync.WaitGroup
+
+	// handler serves each connection; HandleConnection if nil
+	handler func(net.Conn)
+
+	// pingInterval is how often clients are pinged (0 = never)
+	pingInterval time.Duration
 }
 
 func NewServer() *Server {
@@ -80,9 +190,80 @@ func NewServer() *Server {
 		addChan:    make(chan *Client),
 		removeChan: make(chan string),
 		broadcast:  make(chan string, 100),
+		conns:      make(map[net.Conn]struct{}),
+
+		pingInterval: PingInterval,
 	}
 }
 
+// ErrServerFull is sent, with ErrCodeRoomFull, to a connection turned away
+// because the server already has as many as SetMaxConnections allows.
+const (
+	ErrServerFull   = "server is full, try again later"
+	ErrCodeRoomFull = "room_full"
+)
+
+// SetMaxConnections caps how many connections the server serves at once.
+// Connections past the cap get an MsgError and are closed. 0 means no cap.
+func (s *Server) SetMaxConnections(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxConns = n
+}
+
+// SetPingInterval changes how often clients that join from now on are
+// pinged. 0 turns the keepalive off.
+func (s *Server) SetPingInterval(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pingInterval = d
+}
+
+// track registers a new connection, or reports false if the server is
+// stopped or full.
+func (s *Server) track(conn net.Conn) (ok bool, full bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return false, false
+	}
+	if s.maxConns > 0 && len(s.conns) >= s.maxConns {
+		return false, true
+	}
+	s.conns[conn] = struct{}{}
+	s.wg.Add(1)
+	return true, false
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, conn)
+	s.wg.Done()
+}
+
+// Stop closes the listener and every connection, then waits for the
+// goroutines serving them to return. It is safe to call more than once.
+func (s *Server) Stop() error {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return nil
+	}
+	s.stopped = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
 func (s *Server) AddClient(c *Client) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -93,6 +274,25 @@ func (s *Server) AddClient(c *Client) {
 	})
 }
 
+// startClient adds a joined client and starts writing to it. A client whose
+// writes fail is removed here, so a dead connection leaves the player list
+// even before its reader notices.
+func (s *Server) startClient(c *Client) {
+	s.mu.RLock()
+	c.pingInterval = s.pingInterval
+	s.mu.RUnlock()
+	if c.pingInterval > 0 {
+		c.pongWait = MissedPongs*c.pingInterval + c.pingInterval/2
+		c.Conn.SetReadDeadline(time.Now().Add(c.pongWait))
+	}
+
+	s.AddClient(c)
+	go func() {
+		c.WriteLoop()
+		s.RemoveClient(c.PlayerID)
+	}()
+}
+
 func (s *Server) RemoveClient(playerID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -137,26 +337,28 @@ func (s *Server) Broadcast(msg string) {
 	}
 }
 
+// SendTo queues msg for one client. The send happens under the lock, like
+// Broadcast's, so it can't race a removed client's Output being closed.
 func (s *Server) SendTo(playerID string, msg string) bool {
 	s.mu.RLock()
+	defer s.mu.RUnlock()
 	c, ok := s.clients[playerID]
-	s.mu.RUnlock()
-	if ok {
-		select {
-		case c.Output <- msg:
-			return true
-		default:
-			return false
-		}
+	if !ok {
+		return false
+	}
+	select {
+	case c.Output <- msg:
+		return true
+	default:
+		return false
 	}
-	return false
 }
 
 func (c *Client) ReadLoop() {
 	decoder := json.NewDecoder(c.Conn)
 	for {
-		var msg Message
-		if err := decoder.Decode(&msg); err != nil {
+		msg, err := c.next(decoder)
+		if err != nil {
 			break
 		}
 		c.Input <- msg.Payload
@@ -164,26 +366,91 @@ func (c *Client) ReadLoop() {
 	close(c.Input)
 }
 
+// next reads the next message from the peer, answering pings and taking
+// pongs as it goes. Each pong extends the read deadline, so a peer that
+// stops answering fails the read.
+func (c *Client) next(decoder *json.Decoder) (Message, error) {
+	for {
+		var msg Message
+		if err := decoder.Decode(&msg); err != nil {
+			return msg, err
+		}
+		switch msg.Type {
+		case MsgPing:
+			if pong, err := EncodeMessage(MsgPong, nil); err == nil {
+				select {
+				case c.Output <- pong:
+				default:
+				}
+			}
+		case MsgPong:
+			if c.pongWait > 0 {
+				c.Conn.SetReadDeadline(time.Now().Add(c.pongWait))
+			}
+		default:
+			return msg, nil
+		}
+	}
+}
+
+// WriteLoop writes the client's queued messages, each already encoded by
+// EncodeMessage, and pings the peer if the server asked for that. It returns
+// once Output is closed or a write fails.
 func (c *Client) WriteLoop() {
-	encoder := json.NewEncoder(c.Conn)
-	for msg := range c.Output {
-		if err := encoder.Encode(msg); err != nil {
-			break
+	var ping <-chan time.Time
+	if c.pingInterval > 0 {
+		ticker := time.NewTicker(c.pingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	for {
+		var msg string
+		select {
+		case m, ok := <-c.Output:
+			if !ok {
+				return
+			}
+			msg = m
+		case <-ping:
+			m, err := EncodeMessage(MsgPing, nil)
+			if err != nil {
+				continue
+			}
+			msg = m
+		}
+		c.Conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if _, err := io.WriteString(c.Conn, msg); err != nil {
+			return
 		}
 	}
 }
 
-func SendMessage(conn net.Conn, msgType MessageType, payload interface{}) error {
+// EncodeMessage renders a message as the line SendMessage would write, for
+// queueing on a client's Output.
+func EncodeMessage(msgType MessageType, payload interface{}) (string, error) {
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return err
+		return "", err
 	}
-	msg := Message{
+	data, err := json.Marshal(Message{
 		Type:    msgType,
 		Payload: payloadBytes,
 		Time:    time.Now(),
+	})
+	if err != nil {
+		return "", err
 	}
-	return json.NewEncoder(conn).Encode(msg)
+	return string(data) + "\n", nil
+}
+
+func SendMessage(conn net.Conn, msgType MessageType, payload interface{}) error {
+	msg, err := EncodeMessage(msgType, payload)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(conn, msg)
+	return err
 }
 
 func ReceiveMessage(conn net.Conn) (*Message, error) {
@@ -211,55 +478,88 @@ func HandleConnection(conn net.Conn, server *Server) {
 
 	client := &Client{
 		Conn:     conn,
-		PlayerID: generateID(),
+		PlayerID: game.NewPlayerID(),
 		Name:     payload.Name,
 		Input:    make(chan []byte, 10),
 		Output:   make(chan string, 100),
 	}
 
-	server.AddClient(client)
-
-	playerList := server.GetPlayerList()
-	SendMessage(conn, MsgPlayerList, playerList)
+	server.startClient(client)
+	if msg, err := EncodeMessage(MsgPlayerList, server.GetPlayerList()); err == nil {
+		server.SendTo(client.PlayerID, msg)
+	}
 
 	go client.ReadLoop()
-	go client.WriteLoop()
 
 	for {
 		select {
 		case input, ok := <-client.Input:
 			if !ok {
 				server.RemoveClient(client.PlayerID)
+				close(client.Output)
 				return
 			}
-			server.Broadcast(fmt.Sprintf("[%s]: %s", client.Name, input))
+			msg, err := EncodeMessage(MsgChat, ChatPayload{Sender: client.Name, Message: string(input)})
+			if err == nil {
+				server.Broadcast(msg)
+			}
 		}
 	}
 }
 
-func generateID() string {
-	return fmt.Sprintf("player-%d", time.Now().UnixNano())
-}
-
 func StartServer(addr string) (*Server, error) {
 	server := NewServer()
+	if err := server.listen(addr); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// listen starts accepting connections on addr.
+func (s *Server) listen(addr string) error {
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	server.listener = ln
+	s.mu.Lock()
+	s.listener = ln
+	s.mu.Unlock()
 
-	go func() {
-		for {
-			conn, err := ln.Accept()
-			if err != nil {
-				continue
-			}
-			go HandleConnection(conn, server)
+	go s.acceptLoop(ln)
+	return nil
+}
+
+// acceptLoop serves connections until the listener is closed.
+func (s *Server) acceptLoop(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			// Something like running out of file descriptors; give it
+			// a moment rather than spinning
+			time.Sleep(10 * time.Millisecond)
+			continue
 		}
-	}()
 
-	return server, nil
+		ok, full := s.track(conn)
+		if !ok {
+			if full {
+				SendMessage(conn, MsgError, ErrorPayload{Code: ErrCodeRoomFull, Request: string(MsgJoin), Message: ErrServerFull})
+			}
+			conn.Close()
+			continue
+		}
+		go func() {
+			defer s.untrack(conn)
+			if s.handler != nil {
+				s.handler(conn)
+			} else {
+				HandleConnection(conn, s)
+			}
+		}()
+	}
 }
 
 func DialServer(addr, playerName string) (*Client, error) {
@@ -0,0 +1,72 @@
+package network
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatDropsASilentPeer(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	pongWait := MissedPongs*interval + interval/2
+
+	s := NewServer()
+	s.SetPingInterval(interval)
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	go HandleConnection(conn, s)
+
+	if err := SendMessage(peer, MsgJoin, JoinPayload{Name: "Ann"}); err != nil {
+		t.Fatal(err)
+	}
+	// The peer keeps reading throughout, so only the missing pongs can
+	// end the connection
+	pings := make(chan struct{}, 100)
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		decoder := json.NewDecoder(peer)
+		for {
+			var msg Message
+			if err := decoder.Decode(&msg); err != nil {
+				return
+			}
+			if msg.Type == MsgPing {
+				pings <- struct{}{}
+			}
+		}
+	}()
+
+	// Answered pings keep the connection open well past the pong wait
+	var lastPong time.Time
+	for answering := time.Now().Add(3 * pongWait); time.Now().Before(answering); {
+		select {
+		case <-pings:
+			if err := SendMessage(peer, MsgPong, nil); err != nil {
+				t.Fatalf("the server hung up on a peer that answered: %v", err)
+			}
+			lastPong = time.Now()
+		case <-closed:
+			t.Fatal("the server hung up on a peer that answered its pings")
+		case <-time.After(time.Second):
+			t.Fatal("the server stopped pinging")
+		}
+	}
+	if len(s.GetPlayerList()) != 1 {
+		t.Fatalf("players = %+v, want Ann", s.GetPlayerList())
+	}
+
+	// Then the peer goes quiet
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the connection was still open long after the peer stopped answering")
+	}
+	if waited := time.Since(lastPong); waited < pongWait {
+		t.Errorf("the connection closed %v after the last pong, before the %v pong wait", waited, pongWait)
+	}
+	if players := s.GetPlayerList(); len(players) != 0 {
+		t.Errorf("the silent peer is still listed: %+v", players)
+	}
+}
@@ -0,0 +1,10 @@
+// Package static holds the web client, embedded in the server so it can be
+// served from wherever the binary runs.
+package static
+
+import "embed"
+
+// Files are the web client's files. New kinds of asset need a pattern here.
+//
+//go:embed *.html
+var Files embed.FS